@@ -0,0 +1,174 @@
+// Package apperr holds the kinds of errors the domain reports. each api turns a kind into a
+// status of its own, e.g. NotFound into 404 for the rest api and NOT_FOUND for the grpc api
+package apperr
+
+import (
+	"errors"
+)
+
+// Kind is the class of an error, which tells the caller whether and how to retry
+type Kind string
+
+const (
+	// KindInternal is a failure of the server, the zero kind of an error which has no other
+	KindInternal Kind = "internal"
+	// KindValidation is a request breaking the rules of its fields
+	KindValidation Kind = "validation"
+	// KindUnauthorized is a request without valid credentials
+	KindUnauthorized Kind = "unauthorized"
+	// KindForbidden is a request the caller is not allowed to make
+	KindForbidden Kind = "forbidden"
+	// KindNotFound is a request for a record which does not exist
+	KindNotFound Kind = "not-found"
+	// KindConflict is a change clashing with the stored data
+	KindConflict Kind = "conflict"
+	// KindUnprocessable is a well formed request which can not be carried out as it is,
+	// such as moving a category under its own descendant
+	KindUnprocessable Kind = "unprocessable"
+	// KindUnavailable is a dependency of the server being down, the request may be retried
+	KindUnavailable Kind = "unavailable"
+)
+
+// FieldError is the reason a field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error of a kind. errors of the domain which carry more than a message
+// implement the methods of Error themselves rather than embedding it
+type Error struct {
+	kind    Kind
+	code    string
+	message string
+	fields  []FieldError
+	details interface{}
+}
+
+// New returns an error of the kind. the code tells it apart from the others of its kind,
+// e.g. "brand_not_found", and is what clients match on rather than the message
+func New(kind Kind, code, message string) *Error {
+	return &Error{kind: kind, code: code, message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	err := New(KindValidation, code, message)
+	err.fields = fields
+
+	return err
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Unprocessable(code, message string) *Error {
+	return New(KindUnprocessable, code, message)
+}
+
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+// WithDetails returns a copy of the error carrying further details for the caller, e.g. the
+// results of a bulk request which was rolled back
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.details = details
+
+	return &copied
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+func (e *Error) Kind() Kind {
+	return e.kind
+}
+
+func (e *Error) Code() string {
+	return e.code
+}
+
+func (e *Error) Fields() []FieldError {
+	return e.fields
+}
+
+func (e *Error) Details() interface{} {
+	return e.details
+}
+
+// KindOf returns the kind of the first error in the chain of err which has one,
+// KindInternal when none has
+func KindOf(err error) Kind {
+	var kinded interface{ Kind() Kind }
+	if errors.As(err, &kinded) {
+		return kinded.Kind()
+	}
+
+	return KindInternal
+}
+
+// MessageOf returns the message of the first error in the chain of err which has a kind,
+// without the messages wrapping it or the cause it wraps, which may tell more than the
+// caller should learn. empty when none has a kind
+func MessageOf(err error) string {
+	var kinded interface {
+		error
+		Kind() Kind
+	}
+
+	if errors.As(err, &kinded) {
+		return kinded.Error()
+	}
+
+	return ""
+}
+
+// CodeOf returns the code of the first error in the chain of err which has one
+func CodeOf(err error) string {
+	var coded interface{ Code() string }
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+
+	return ""
+}
+
+// FieldsOf returns the fields the first error in the chain of err which has them was about
+func FieldsOf(err error) []FieldError {
+	var fielded interface{ Fields() []FieldError }
+	if errors.As(err, &fielded) {
+		return fielded.Fields()
+	}
+
+	return nil
+}
+
+// DetailsOf returns the details of the first error in the chain of err which has them
+func DetailsOf(err error) interface{} {
+	var detailed interface{ Details() interface{} }
+	if errors.As(err, &detailed) {
+		return detailed.Details()
+	}
+
+	return nil
+}
+
+// IsNotFound reports whether err is about a record which does not exist
+func IsNotFound(err error) bool {
+	return KindOf(err) == KindNotFound
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+func (c *Client) ListAuditLogs(ctx context.Context, filter AuditFilter) (*service.AuditResult, error) {
+	return getJSON[service.AuditResult](ctx, c, "/api/audit", filter.values())
+}
+
+// AuditLogIterator walks the audit log entries matching filter from filter.Page on, filter.Limit at a time
+func (c *Client) AuditLogIterator(filter AuditFilter) *Iterator[service.AuditEntry] {
+	return newIterator(filter.Page, func(ctx context.Context, page int64) ([]service.AuditEntry, bool, error) {
+		filter.Page = page
+
+		result, err := c.ListAuditLogs(ctx, filter)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Entries, hasMore(page, filter.Limit, result.Total), nil
+	})
+}
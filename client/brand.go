@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+func (c *Client) CreateBrand(ctx context.Context, req CreateBrandRequest) (*service.Brand, error) {
+	return sendJSON[service.Brand](ctx, c, http.MethodPost, "/api/brands", req)
+}
+
+// GetBrand returns the brand with the id, an error IsNotFound reports when there is none
+func (c *Client) GetBrand(ctx context.Context, id string) (*service.Brand, error) {
+	return getJSON[service.Brand](ctx, c, entityPath("brands", id), nil)
+}
+
+func (c *Client) ListBrands(ctx context.Context, params ListParams) (*service.BrandResult, error) {
+	return getJSON[service.BrandResult](ctx, c, "/api/brands", params.values())
+}
+
+// BrandIterator walks the brands from params.Page on, params.Limit at a time
+func (c *Client) BrandIterator(params ListParams) *Iterator[service.Brand] {
+	return newIterator(params.Page, func(ctx context.Context, page int64) ([]service.Brand, bool, error) {
+		params.Page = page
+
+		result, err := c.ListBrands(ctx, params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Brands, hasMore(page, params.Limit, result.Total), nil
+	})
+}
+
+func (c *Client) UpdateBrand(ctx context.Context, id string, req UpdateBrandRequest) (*service.Brand, error) {
+	return sendJSON[service.Brand](ctx, c, http.MethodPut, entityPath("brands", id), req)
+}
+
+func (c *Client) PatchBrand(ctx context.Context, id string, req PatchBrandRequest) (*service.Brand, error) {
+	return patchJSON[service.Brand](ctx, c, entityPath("brands", id), req)
+}
+
+// DeleteBrand soft deletes a brand and returns it
+func (c *Client) DeleteBrand(ctx context.Context, id string) (*service.Brand, error) {
+	return sendJSON[service.Brand](ctx, c, http.MethodDelete, entityPath("brands", id), nil)
+}
+
+func (c *Client) RestoreBrand(ctx context.Context, id string) (*service.Brand, error) {
+	return sendJSON[service.Brand](ctx, c, http.MethodPost, entityPath("brands", id, "restore"), nil)
+}
+
+func (c *Client) BulkBrands(ctx context.Context, req BulkRequest[CreateBrandRequest]) (*BulkResult[service.Brand], error) {
+	return bulk[service.Brand](ctx, c, "/api/brands/bulk", req)
+}
+
+// GetBrandPage returns the brand with its product counts per category and its top best
+// selling products, 10 of them when top is 0. an error IsNotFound reports when there is
+// no such brand
+func (c *Client) GetBrandPage(ctx context.Context, id string, top int) (*service.BrandPage, error) {
+	query := url.Values{}
+	if top > 0 {
+		query.Set("top", strconv.Itoa(top))
+	}
+
+	return getJSON[service.BrandPage](ctx, c, entityPath("brands", id, "page"), query)
+}
+
+// SetBrandLogo uploads a png, jpeg, webp or gif image of at most 2MB as the logo of the brand
+func (c *Client) SetBrandLogo(ctx context.Context, id, fileName string, file io.Reader) (*service.Brand, error) {
+	req, err := newUploadRequest(http.MethodPut, entityPath("brands", id, "logo"), nil, fileName, file)
+	if err != nil {
+		return nil, err
+	}
+
+	brand := new(service.Brand)
+	if err := c.call(ctx, req, brand); err != nil {
+		return nil, err
+	}
+
+	return brand, nil
+}
+
+func (c *Client) DeleteBrandLogo(ctx context.Context, id string) (*service.Brand, error) {
+	return sendJSON[service.Brand](ctx, c, http.MethodDelete, entityPath("brands", id, "logo"), nil)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+func (c *Client) CreateCategory(ctx context.Context, req CreateCategoryRequest) (*service.Category, error) {
+	return sendJSON[service.Category](ctx, c, http.MethodPost, "/api/categories", req)
+}
+
+// GetCategory returns the category with the id, an error IsNotFound reports when there is none
+func (c *Client) GetCategory(ctx context.Context, id string) (*service.Category, error) {
+	return getJSON[service.Category](ctx, c, entityPath("categories", id), nil)
+}
+
+func (c *Client) ListCategories(ctx context.Context, params ListParams) (*service.CategoryResult, error) {
+	return getJSON[service.CategoryResult](ctx, c, "/api/categories", params.values())
+}
+
+// CategoryIterator walks the categories from params.Page on, params.Limit at a time
+func (c *Client) CategoryIterator(params ListParams) *Iterator[service.Category] {
+	return newIterator(params.Page, func(ctx context.Context, page int64) ([]service.Category, bool, error) {
+		params.Page = page
+
+		result, err := c.ListCategories(ctx, params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Categories, hasMore(page, params.Limit, result.Total), nil
+	})
+}
+
+func (c *Client) UpdateCategory(ctx context.Context, id string, req UpdateCategoryRequest) (*service.Category, error) {
+	return sendJSON[service.Category](ctx, c, http.MethodPut, entityPath("categories", id), req)
+}
+
+func (c *Client) PatchCategory(ctx context.Context, id string, req PatchCategoryRequest) (*service.Category, error) {
+	return patchJSON[service.Category](ctx, c, entityPath("categories", id), req)
+}
+
+// DeleteCategory soft deletes a category and returns it
+func (c *Client) DeleteCategory(ctx context.Context, id string) (*service.Category, error) {
+	return sendJSON[service.Category](ctx, c, http.MethodDelete, entityPath("categories", id), nil)
+}
+
+func (c *Client) RestoreCategory(ctx context.Context, id string) (*service.Category, error) {
+	return sendJSON[service.Category](ctx, c, http.MethodPost, entityPath("categories", id, "restore"), nil)
+}
+
+func (c *Client) BulkCategories(ctx context.Context, req BulkRequest[CreateCategoryRequest]) (*BulkResult[service.Category], error) {
+	return bulk[service.Category](ctx, c, "/api/categories/bulk", req)
+}
+
+// GetCategoryTree returns the categories nested under their parents
+func (c *Client) GetCategoryTree(ctx context.Context) ([]*CategoryNode, error) {
+	tree, err := getJSON[[]*CategoryNode](ctx, c, "/api/categories/tree", nil)
+	if err != nil || tree == nil {
+		return nil, err
+	}
+
+	return *tree, nil
+}
+
+// GetCategorySubtree returns the category with its descendants down to depth levels below it,
+// all of them when depth is 0. an error IsNotFound reports when there is no such category
+func (c *Client) GetCategorySubtree(ctx context.Context, id string, depth int) (*service.CategoryNode, error) {
+	query := url.Values{}
+	setInt(query, "depth", int64(depth))
+
+	return getJSON[service.CategoryNode](ctx, c, entityPath("categories", id, "subtree"), query)
+}
+
+// GetCategoryAncestors returns the path from the root down to the parent of the category
+func (c *Client) GetCategoryAncestors(ctx context.Context, id string) ([]service.Category, error) {
+	ancestors, err := getJSON[[]service.Category](ctx, c, entityPath("categories", id, "ancestors"), nil)
+	if err != nil || ancestors == nil {
+		return nil, err
+	}
+
+	return *ancestors, nil
+}
+
+// MoveCategory puts a category, with the categories underneath it, under a new parent
+func (c *Client) MoveCategory(ctx context.Context, id string, req MoveCategoryRequest) (*service.Category, error) {
+	return sendJSON[service.Category](ctx, c, http.MethodPost, entityPath("categories", id, "move"), req)
+}
+
+// ReorderCategories sets the order of sibling categories and returns them in that order
+func (c *Client) ReorderCategories(ctx context.Context, req ReorderCategoriesRequest) ([]service.Category, error) {
+	ctgries, err := sendJSON[[]service.Category](ctx, c, http.MethodPost, "/api/categories/reorder", req)
+	if err != nil || ctgries == nil {
+		return nil, err
+	}
+
+	return *ctgries, nil
+}
@@ -0,0 +1,329 @@
+// Package client is a typed Go client for the rest api of the ecommerce server
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/logger"
+)
+
+// headers understood by the rest api
+const (
+	traceIDHeader = "X-Trace-ID"
+	actorHeader   = "X-Actor"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 2
+	defaultRetryWait  = 200 * time.Millisecond
+)
+
+// envelope is the body every json endpoint answers with
+type envelope struct {
+	Timestamp   int64           `json:"timestamp"`
+	Description string          `json:"description"`
+	Data        json.RawMessage `json:"data"`
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	retryWait  time.Duration
+	actor      string
+	token      string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the http client the requests are sent with
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a request failing with a 5xx or a network error
+// is retried and the wait before the first retry, which doubles on every attempt
+func WithRetries(maxRetries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryWait = wait
+	}
+}
+
+// WithAdminKey authenticates the requests of the catalog team, e.g. changing brands,
+// categories, suppliers and products, issuing supplier tokens or moderating reviews and
+// questions, with the admin api key of the server
+func WithAdminKey(key string) Option {
+	return func(c *Client) {
+		c.token = key
+	}
+}
+
+// WithActor sends the actor recorded in the audit log for the changes made with the client
+func WithActor(actor string) Option {
+	return func(c *Client) {
+		c.actor = actor
+	}
+}
+
+// New returns a client of the api served at baseURL, e.g. http://localhost:3000
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		retryWait:  defaultRetryWait,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// request describes a call of an endpoint
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+}
+
+func newRequest(method, path string, query url.Values) *request {
+	return &request{
+		method: method,
+		path:   path,
+		query:  query,
+	}
+}
+
+// withJSON sets the body of the request to v encoded as json
+func (r *request) withJSON(v interface{}) (*request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	r.body = body
+	r.contentType = "application/json"
+	return r, nil
+}
+
+// call sends the request and decodes the data of the envelope into out, which may be nil
+func (c *Client) call(ctx context.Context, req *request, out interface{}) error {
+	res, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+		return fmt.Errorf("cannot decode response of %s %s: %w", req.method, req.path, err)
+	}
+
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("cannot decode data of %s %s: %w", req.method, req.path, err)
+	}
+
+	return nil
+}
+
+// stream sends the request and copies the body of a successful response to w
+func (c *Client) stream(ctx context.Context, req *request, w io.Writer) error {
+	res, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+// send sends the request, retrying the idempotent ones on a 5xx or a network error,
+// and turns an unsuccessful response into an *Error. the caller closes the body
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, error) {
+	// every attempt carries the same trace id, so the logs tie the retries together
+	traceID := logger.GetTraceID(ctx)
+	if len(traceID) == 0 {
+		traceID = uuid.NewString()
+	}
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		res, err := c.do(ctx, req, traceID)
+
+		retry := attempt < c.maxRetries && isIdempotent(req.method) && ctx.Err() == nil &&
+			(err != nil || res.StatusCode >= http.StatusInternalServerError)
+		if !retry {
+			if err != nil {
+				return nil, err
+			}
+
+			if res.StatusCode >= http.StatusBadRequest {
+				defer res.Body.Close()
+				return nil, newError(res, traceID)
+			}
+
+			return res, nil
+		}
+
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		wait *= 2
+	}
+}
+
+func (c *Client) do(ctx context.Context, req *request, traceID string) (*http.Response, error) {
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set(traceIDHeader, traceID)
+
+	if len(req.contentType) > 0 {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+
+	if len(c.actor) > 0 {
+		httpReq.Header.Set(actorHeader, c.actor)
+	}
+
+	if len(c.token) > 0 {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return c.httpClient.Do(httpReq)
+}
+
+// isIdempotent reports whether a request may be sent again without repeating its effect
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// getJSON fetches the data of a json endpoint. an entity which does not exist is an
+// error IsNotFound reports
+func getJSON[T any](ctx context.Context, c *Client, path string, query url.Values) (*T, error) {
+	var out *T
+	if err := c.call(ctx, newRequest(http.MethodGet, path, query), &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// sendJSON sends body as json, or nothing when it is nil, and returns the data of the response
+func sendJSON[T any](ctx context.Context, c *Client, method, path string, body interface{}) (*T, error) {
+	req := newRequest(method, path, nil)
+	if body != nil {
+		var err error
+		if req, err = req.withJSON(body); err != nil {
+			return nil, err
+		}
+	}
+
+	var out *T
+	if err := c.call(ctx, req, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// patchJSON sends body as a json merge patch and returns the data of the response
+func patchJSON[T any](ctx context.Context, c *Client, path string, body interface{}) (*T, error) {
+	req, err := newRequest(http.MethodPatch, path, nil).withJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.contentType = mergePatchContentType
+
+	var out *T
+	if err := c.call(ctx, req, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// bulk sends a bulk request. an atomic request which was rolled back returns its
+// result along with the error, to tell which operations failed
+func bulk[T, D any](ctx context.Context, c *Client, path string, req BulkRequest[D]) (*BulkResult[T], error) {
+	result, err := sendJSON[BulkResult[T]](ctx, c, http.MethodPost, path, req)
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity {
+		result = new(BulkResult[T])
+		if json.Unmarshal(apiErr.Data, result) != nil {
+			result = nil
+		}
+	}
+
+	return result, err
+}
+
+// Health reports whether the server is up
+func (c *Client) Health(ctx context.Context) error {
+	res, err := c.send(ctx, newRequest(http.MethodGet, "/api/health", nil))
+	if err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
+
+func entityPath(collection, id string, parts ...string) string {
+	path := "/api/" + collection + "/" + url.PathEscape(id)
+	for _, part := range parts {
+		path += "/" + part
+	}
+
+	return path
+}
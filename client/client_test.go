@@ -0,0 +1,295 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/config"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/rest"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+const testAdminKey = "admin-key"
+
+// errFlaky is the failure of the server the fake service answers with, a 500
+var errFlaky = errors.New("database is restarting")
+
+// fakeService keeps the brands in memory and fails the next failures calls, recording the
+// trace id of every call. the methods the tests do not reach are left to the nil Service
+// it embeds
+type fakeService struct {
+	service.Service
+
+	mu       sync.Mutex
+	brands   []service.Brand
+	failures int
+	traceIDs []string
+}
+
+func (f *fakeService) Response(ctx context.Context, description string, data interface{}) *service.ResponseData {
+	return &service.ResponseData{
+		Timestamp:   util.GetCurrentTimestamp(),
+		Description: description,
+		Data:        data,
+	}
+}
+
+// called records a call, failing it while there are failures left
+func (f *fakeService) called(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.traceIDs = append(f.traceIDs, logger.GetTraceID(ctx))
+	if f.failures > 0 {
+		f.failures--
+		return errFlaky
+	}
+
+	return nil
+}
+
+func (f *fakeService) AddBrand(ctx context.Context, brand *service.Brand) (*service.Brand, error) {
+	if err := f.called(ctx); err != nil {
+		return nil, err
+	}
+
+	brand.ID = uuid.NewString()
+	f.brands = append(f.brands, *brand)
+
+	return brand, nil
+}
+
+func (f *fakeService) GetBrand(ctx context.Context, brandID string) (*service.Brand, error) {
+	if err := f.called(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, brand := range f.brands {
+		if brand.ID == brandID {
+			return &brand, nil
+		}
+	}
+
+	return nil, service.ErrBrandNotFound
+}
+
+func (f *fakeService) GetBrands(ctx context.Context, page, limit int64, includeDeleted bool) (*service.BrandResult, error) {
+	if err := f.called(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &service.BrandResult{Brands: []service.Brand{}, Total: int64(len(f.brands)), Page: page, Limit: limit}
+	for i := (page - 1) * limit; i < page*limit && i < result.Total; i++ {
+		result.Brands = append(result.Brands, f.brands[i])
+	}
+
+	return result, nil
+}
+
+// BulkProducts fails the operations on products named "taken", rolling the others back
+func (f *fakeService) BulkProducts(ctx context.Context, mode string, ops []service.BulkProductOp) (*service.BulkResult, error) {
+	result := &service.BulkResult{Mode: mode}
+	for i, op := range ops {
+		item := service.BulkItemResult{Index: i, Op: op.Op, Status: service.BulkStatusSkipped}
+		if op.Product.Name == "taken" {
+			item.Status = service.BulkStatusFailed
+			item.Error = service.ErrDuplicateProduct.Error()
+			result.Failed++
+		} else {
+			result.Skipped++
+		}
+
+		result.Items = append(result.Items, item)
+	}
+
+	return result, nil
+}
+
+// newTestClient serves the rest api of a fake service, returning a client of it which
+// retries without waiting
+func newTestClient(t *testing.T, svc *fakeService) *Client {
+	server, err := rest.NewServer(svc, &config.Application{
+		Env:         "test",
+		AuthSecret:  "secret",
+		AdminAPIKey: testAdminKey,
+		MediaDir:    t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	srv := httptest.NewServer(server.Handler())
+	t.Cleanup(srv.Close)
+
+	return New(srv.URL, WithAdminKey(testAdminKey), WithRetries(2, time.Millisecond))
+}
+
+func TestGetBrand(t *testing.T) {
+	brand := service.Brand{ID: uuid.NewString(), Name: "Acme", Status: service.StatusActive, Version: 3}
+	c := newTestClient(t, &fakeService{brands: []service.Brand{brand}})
+
+	got, err := c.GetBrand(context.Background(), brand.ID)
+	if err != nil {
+		t.Fatalf("GetBrand: %v", err)
+	}
+
+	if got.ID != brand.ID || got.Name != brand.Name || got.Version != brand.Version {
+		t.Errorf("GetBrand = %+v, want %+v", got, brand)
+	}
+
+	_, err = c.GetBrand(context.Background(), uuid.NewString())
+	if !IsNotFound(err) {
+		t.Fatalf("GetBrand of a missing brand = %v, want not found", err)
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != service.ErrBrandNotFound.Code() {
+		t.Errorf("error = %+v, want code %s", apiErr, service.ErrBrandNotFound.Code())
+	}
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	brand := service.Brand{ID: uuid.NewString(), Name: "Acme"}
+	svc := &fakeService{brands: []service.Brand{brand}, failures: 2}
+	c := newTestClient(t, svc)
+
+	if _, err := c.GetBrand(context.Background(), brand.ID); err != nil {
+		t.Fatalf("GetBrand: %v", err)
+	}
+
+	if len(svc.traceIDs) != 3 {
+		t.Errorf("the server was called %d times, want 3", len(svc.traceIDs))
+	}
+
+	// retries give up after the last one
+	svc.traceIDs, svc.failures = nil, 3
+	if _, err := c.GetBrand(context.Background(), brand.ID); !IsServerError(err) {
+		t.Errorf("GetBrand = %v, want a server error", err)
+	}
+
+	if len(svc.traceIDs) != 3 {
+		t.Errorf("the server was called %d times, want 3", len(svc.traceIDs))
+	}
+}
+
+func TestDoesNotRetryCreates(t *testing.T) {
+	svc := &fakeService{failures: 1}
+	c := newTestClient(t, svc)
+
+	if _, err := c.CreateBrand(context.Background(), CreateBrandRequest{Name: "Acme"}); !IsServerError(err) {
+		t.Errorf("CreateBrand = %v, want a server error", err)
+	}
+
+	if len(svc.traceIDs) != 1 {
+		t.Errorf("the server was called %d times, want once", len(svc.traceIDs))
+	}
+}
+
+func TestSendsTraceID(t *testing.T) {
+	svc := &fakeService{failures: 3}
+	c := newTestClient(t, svc)
+
+	ctx := logger.WithTraceID(context.Background(), "trace-1")
+
+	_, err := c.GetBrand(ctx, uuid.NewString())
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.TraceID != "trace-1" {
+		t.Errorf("error = %+v, want trace id trace-1", apiErr)
+	}
+
+	for _, traceID := range svc.traceIDs {
+		if traceID != "trace-1" {
+			t.Errorf("the server was called with trace ids %q, want trace-1 on every retry", svc.traceIDs)
+			break
+		}
+	}
+
+	// without one every retry still carries the same
+	svc.traceIDs, svc.failures = nil, 1
+	c.ListBrands(context.Background(), ListParams{Page: 1, Limit: 10})
+	if len(svc.traceIDs) != 2 || len(svc.traceIDs[0]) == 0 || svc.traceIDs[0] != svc.traceIDs[1] {
+		t.Errorf("the server was called with trace ids %q, want one for both attempts", svc.traceIDs)
+	}
+}
+
+func TestBrandIterator(t *testing.T) {
+	svc := &fakeService{}
+	for i := 0; i < 7; i++ {
+		svc.brands = append(svc.brands, service.Brand{ID: uuid.NewString(), Name: fmt.Sprintf("brand %d", i)})
+	}
+
+	c := newTestClient(t, svc)
+
+	brands, err := c.BrandIterator(ListParams{Limit: 3}).All(context.Background())
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+
+	if len(brands) != len(svc.brands) {
+		t.Fatalf("walked %d brands, want %d", len(brands), len(svc.brands))
+	}
+
+	for i, brand := range brands {
+		if brand.ID != svc.brands[i].ID {
+			t.Errorf("brand %d = %s, want %s", i, brand.ID, svc.brands[i].ID)
+		}
+	}
+
+	// 3 pages, and no request for a page past the last
+	if len(svc.traceIDs) != 3 {
+		t.Errorf("fetched %d pages, want 3", len(svc.traceIDs))
+	}
+
+	svc.failures = 3
+	it := c.BrandIterator(ListParams{Page: 2, Limit: 3})
+	if it.Next(context.Background()) || !IsServerError(it.Err()) {
+		t.Errorf("Err = %v, want a server error", it.Err())
+	}
+}
+
+func TestBulkRolledBack(t *testing.T) {
+	c := newTestClient(t, &fakeService{})
+
+	product := func(name string) *CreateProductRequest {
+		return &CreateProductRequest{
+			Name:          name,
+			Description:   "a phone",
+			BrandID:       uuid.NewString(),
+			CategoryID:    uuid.NewString(),
+			SupplierID:    uuid.NewString(),
+			UnitPrice:     100,
+			DiscountPrice: 10,
+			Tags:          []string{"phone"},
+			StockQuantity: 5,
+		}
+	}
+
+	result, err := c.BulkProducts(context.Background(), BulkRequest[CreateProductRequest]{
+		Operations: []BulkOp[CreateProductRequest]{
+			{Op: service.BulkOpCreate, Data: product("Phone X")},
+			{Op: service.BulkOpCreate, Data: product("taken")},
+		},
+	})
+
+	if statusOf(err) != http.StatusUnprocessableEntity {
+		t.Fatalf("BulkProducts = %v, want a 422", err)
+	}
+
+	if result == nil || result.Failed != 1 || result.Skipped != 1 || len(result.Items) != 2 {
+		t.Fatalf("result = %+v, want one failed and one skipped operation", result)
+	}
+
+	if item := result.Items[1]; item.Status != service.BulkStatusFailed || item.Error != service.ErrDuplicateProduct.Error() {
+		t.Errorf("item = %+v, want the duplicate name failed", item)
+	}
+}
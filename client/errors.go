@@ -0,0 +1,149 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxErrorBody caps how much of an error response is read
+const maxErrorBody = 1 << 20
+
+// Error is an unsuccessful response of the api, an RFC 7807 problem
+type Error struct {
+	StatusCode int
+	// Type is the type of the problem, e.g. "urn:problem-type:not-found"
+	Type string
+	// Description is the detail of the problem, e.g. "brand not found"
+	Description string
+	// Code tells the error apart from the others of its type, e.g. "brand_not_found"
+	Code string
+	// Fields are the fields of the request which were rejected and why
+	Fields []FieldError
+	// Data is the details of the error, e.g. the results of a rolled back bulk request
+	Data json.RawMessage
+	// TraceID is the trace id the request was sent with, to find it in the server logs
+	TraceID string
+}
+
+// FieldError is the reason a field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// problem is the body of an unsuccessful response
+type problem struct {
+	Type    string          `json:"type"`
+	Detail  string          `json:"detail"`
+	Code    string          `json:"code"`
+	TraceID string          `json:"trace_id"`
+	Errors  []FieldError    `json:"errors"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Data) == 0 || string(e.Data) == "null" {
+		return fmt.Sprintf("%d %s", e.StatusCode, e.Description)
+	}
+
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Description, e.Data)
+}
+
+func newError(res *http.Response, traceID string) *Error {
+	apiErr := &Error{
+		StatusCode:  res.StatusCode,
+		Description: http.StatusText(res.StatusCode),
+		TraceID:     traceID,
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+
+	var prob problem
+	if err := json.Unmarshal(body, &prob); err != nil || len(prob.Type) == 0 {
+		// not a problem, e.g. a proxy in between answering
+		apiErr.Data = json.RawMessage(mustMarshal(string(body)))
+		return apiErr
+	}
+
+	apiErr.Type = prob.Type
+	apiErr.Description = prob.Detail
+	apiErr.Code = prob.Code
+	apiErr.Fields = prob.Errors
+	apiErr.Data = prob.Data
+	if len(prob.TraceID) > 0 {
+		apiErr.TraceID = prob.TraceID
+	}
+
+	return apiErr
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+func statusOf(err error) int {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return 0
+	}
+
+	return apiErr.StatusCode
+}
+
+// IsNotFound reports whether err is a response for a missing entity
+func IsNotFound(err error) bool {
+	return statusOf(err) == http.StatusNotFound
+}
+
+// IsBadRequest reports whether err is a response for invalid parameters
+func IsBadRequest(err error) bool {
+	return statusOf(err) == http.StatusBadRequest
+}
+
+// IsUnauthorized reports whether err is a response for a missing or invalid supplier token or
+// admin api key
+func IsUnauthorized(err error) bool {
+	return statusOf(err) == http.StatusUnauthorized
+}
+
+// IsConflict reports whether err is a response for a change clashing with the stored data,
+// e.g. a stale version, an entity still referenced by others or a status change the stored
+// status does not allow
+func IsConflict(err error) bool {
+	status := statusOf(err)
+	return status == http.StatusConflict || status == http.StatusPreconditionFailed
+}
+
+// IsVersionConflict reports whether err is a response for an update based on a stale version
+func IsVersionConflict(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.StatusCode == http.StatusPreconditionFailed ||
+		(apiErr.StatusCode == http.StatusConflict && apiErr.Code == "version_conflict")
+}
+
+// FieldOf returns the first field the error is about, e.g. "name" for a brand name another
+// brand has or a field breaking its rules. empty when it is about none
+func FieldOf(err error) string {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || len(apiErr.Fields) == 0 {
+		return ""
+	}
+
+	return apiErr.Fields[0].Field
+}
+
+// IsServerError reports whether err is a response for a failure of the server
+func IsServerError(err error) bool {
+	return statusOf(err) >= http.StatusInternalServerError
+}
@@ -0,0 +1,17 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// GoogleFeedXML writes the google merchant feed of the products to w as rss xml
+func (c *Client) GoogleFeedXML(ctx context.Context, w io.Writer) error {
+	return c.stream(ctx, newRequest(http.MethodGet, "/api/feeds/google.xml", nil), w)
+}
+
+// GoogleFeedCSV writes the google merchant feed of the products to w as csv
+func (c *Client) GoogleFeedCSV(ctx context.Context, w io.Writer) error {
+	return c.stream(ctx, newRequest(http.MethodGet, "/api/feeds/google.csv", nil), w)
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLError is an error of a graphql operation
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// GraphQLErrors are the errors an operation answered with, the data of the
+// fields which did not fail is still decoded
+type GraphQLErrors []GraphQLError
+
+func (errs GraphQLErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Message
+		if len(err.Path) > 0 {
+			messages[i] = fmt.Sprintf("%v: %s", err.Path, err.Message)
+		}
+	}
+
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// GraphQL runs an operation and decodes its data into out, which may be nil
+func (c *Client) GraphQL(ctx context.Context, req GraphQLRequest, out interface{}) error {
+	httpReq, err := newRequest(http.MethodPost, "/api/graphql", nil).withJSON(req)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.send(ctx, httpReq)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return fmt.Errorf("cannot decode graphql response: %w", err)
+	}
+
+	if out != nil && len(body.Data) > 0 && string(body.Data) != "null" {
+		if err := json.Unmarshal(body.Data, out); err != nil {
+			return fmt.Errorf("cannot decode graphql data: %w", err)
+		}
+	}
+
+	if len(body.Errors) > 0 {
+		return body.Errors
+	}
+
+	return nil
+}
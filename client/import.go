@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// ImportProducts uploads a csv or xlsx file of products, the format is taken from
+// the extension of fileName. the rows are imported by a background job
+func (c *Client) ImportProducts(ctx context.Context, fileName string, file io.Reader) (*service.ImportJob, error) {
+	req, err := newImportRequest(fileName, file, false)
+	if err != nil {
+		return nil, err
+	}
+
+	job := new(service.ImportJob)
+	if err := c.call(ctx, req, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ValidateImport checks the rows of a file of products without importing them
+func (c *Client) ValidateImport(ctx context.Context, fileName string, file io.Reader) (*service.ImportReport, error) {
+	req, err := newImportRequest(fileName, file, true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := new(service.ImportReport)
+	if err := c.call(ctx, req, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (c *Client) GetImportJob(ctx context.Context, id string) (*service.ImportJob, error) {
+	return getJSON[service.ImportJob](ctx, c, entityPath("imports", id), nil)
+}
+
+// DownloadImportErrors writes the rows an import rejected to w as csv or xlsx, csv when format is empty
+func (c *Client) DownloadImportErrors(ctx context.Context, id, format string, w io.Writer) error {
+	query := url.Values{}
+	setString(query, "format", format)
+
+	return c.stream(ctx, newRequest(http.MethodGet, entityPath("imports", id, "errors"), query), w)
+}
+
+func newImportRequest(fileName string, file io.Reader, dryRun bool) (*request, error) {
+	var query url.Values
+	if dryRun {
+		query = url.Values{"dry_run": {"true"}}
+	}
+
+	return newUploadRequest(http.MethodPost, "/api/imports/products", query, fileName, file)
+}
+
+// newUploadRequest sends the file as the file field of a form. the upload is buffered,
+// so the request holds all of its body
+func newUploadRequest(method, path string, query url.Values, fileName string, file io.Reader) (*request, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req := newRequest(method, path, query)
+	req.body = body.Bytes()
+	req.contentType = writer.FormDataContentType()
+
+	return req, nil
+}
+
+func (c *Client) GetJob(ctx context.Context, id string) (*service.Job, error) {
+	return getJSON[service.Job](ctx, c, entityPath("jobs", id), nil)
+}
+
+// RequeueJob runs a dead job again
+func (c *Client) RequeueJob(ctx context.Context, id string) (*service.Job, error) {
+	return sendJSON[service.Job](ctx, c, http.MethodPost, entityPath("jobs", id, "requeue"), nil)
+}
@@ -0,0 +1,90 @@
+package client
+
+import "context"
+
+// pageFetcher fetches a page of a list and reports whether there are pages after it
+type pageFetcher[T any] func(ctx context.Context, page int64) (items []T, more bool, err error)
+
+// Iterator walks a paginated list page by page, fetching the next page when the
+// current one is used up:
+//
+//	it := c.BrandIterator(client.ListParams{Limit: 100})
+//	for it.Next(ctx) {
+//		brand := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type Iterator[T any] struct {
+	fetch pageFetcher[T]
+	page  int64
+	items []T
+	index int
+	done  bool
+	err   error
+}
+
+func newIterator[T any](startPage int64, fetch pageFetcher[T]) *Iterator[T] {
+	if startPage < 1 {
+		startPage = 1
+	}
+
+	return &Iterator[T]{
+		fetch: fetch,
+		page:  startPage,
+		index: -1,
+	}
+}
+
+// Next advances to the next item and reports whether there is one
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.index+1 < len(it.items) {
+		it.index++
+		return true
+	}
+
+	if it.done {
+		return false
+	}
+
+	items, more, err := it.fetch(ctx, it.page)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.page++
+	it.items = items
+	it.index = 0
+	it.done = !more || len(items) == 0
+
+	return len(items) > 0
+}
+
+// Item returns the current item
+func (it *Iterator[T]) Item() T {
+	return it.items[it.index]
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All walks the rest of the list and returns its items
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	var items []T
+	for it.Next(ctx) {
+		items = append(items, it.Item())
+	}
+
+	return items, it.Err()
+}
+
+// hasMore reports whether a list holds items after the given page
+func hasMore(page, limit, total int64) bool {
+	return page*limit < total
+}
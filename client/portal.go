@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// PortalClient calls the supplier portal as the supplier a token was issued for
+type PortalClient struct {
+	c *Client
+}
+
+// SupplierPortal returns a client of the supplier portal authenticated with token,
+// see CreateSupplierToken
+func (c *Client) SupplierPortal(token string) *PortalClient {
+	portal := *c
+	portal.token = token
+
+	return &PortalClient{c: &portal}
+}
+
+func portalPath(parts ...string) string {
+	path := "/api/supplier-portal"
+	for _, part := range parts {
+		path += "/" + part
+	}
+
+	return path
+}
+
+func (p *PortalClient) ListProducts(ctx context.Context, filter PortalProductFilter) (*service.ProductResult, error) {
+	return getJSON[service.ProductResult](ctx, p.c, portalPath("products"), filter.values())
+}
+
+// ProductIterator walks the products of the supplier matching filter from filter.Page on, filter.Limit at a time
+func (p *PortalClient) ProductIterator(filter PortalProductFilter) *Iterator[service.Product] {
+	return newIterator(filter.Page, func(ctx context.Context, page int64) ([]service.Product, bool, error) {
+		filter.Page = page
+
+		result, err := p.ListProducts(ctx, filter)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Products, hasMore(page, filter.Limit, result.Total), nil
+	})
+}
+
+func (p *PortalClient) CreateProduct(ctx context.Context, req CreatePortalProductRequest) (*service.Product, error) {
+	return sendJSON[service.Product](ctx, p.c, http.MethodPost, portalPath("products"), req)
+}
+
+func (p *PortalClient) GetProduct(ctx context.Context, id string) (*service.Product, error) {
+	return getJSON[service.Product](ctx, p.c, portalPath("products", url.PathEscape(id)), nil)
+}
+
+func (p *PortalClient) UpdateProduct(ctx context.Context, id string, req UpdatePortalProductRequest) (*service.Product, error) {
+	return sendJSON[service.Product](ctx, p.c, http.MethodPut, portalPath("products", url.PathEscape(id)), req)
+}
+
+func (p *PortalClient) ArchiveProduct(ctx context.Context, id string) error {
+	return p.c.call(ctx, newRequest(http.MethodPost, portalPath("products", url.PathEscape(id), "archive"), nil), nil)
+}
+
+// TransitionProduct moves a product to another lifecycle state, e.g. submits a draft for
+// review, only the catalog team approves it
+func (p *PortalClient) TransitionProduct(ctx context.Context, id string, req TransitionRequest) (*service.Product, error) {
+	return sendJSON[service.Product](ctx, p.c, http.MethodPost, portalPath("products", url.PathEscape(id), "transitions"), req)
+}
+
+func (p *PortalClient) AdjustStock(ctx context.Context, id string, req StockAdjustment) (*service.ProductStock, error) {
+	return sendJSON[service.ProductStock](ctx, p.c, http.MethodPost, portalPath("products", url.PathEscape(id), "stock"), req)
+}
+
+// GetSalesReport returns the units sold and the revenue of the products between from and
+// to, unix milliseconds which are unbounded when zero
+func (p *PortalClient) GetSalesReport(ctx context.Context, from, to int64) ([]service.SalesReportItem, error) {
+	query := url.Values{}
+	setInt(query, "from", from)
+	setInt(query, "to", to)
+
+	items, err := getJSON[[]service.SalesReportItem](ctx, p.c, portalPath("reports", "sales"), query)
+	if err != nil || items == nil {
+		return nil, err
+	}
+
+	return *items, nil
+}
+
+// GetLowStockReport returns the products with at most threshold items in stock,
+// the server default applies when threshold is negative
+func (p *PortalClient) GetLowStockReport(ctx context.Context, threshold int64) ([]service.LowStockItem, error) {
+	query := url.Values{}
+	if threshold >= 0 {
+		query.Set("threshold", strconv.FormatInt(threshold, 10))
+	}
+
+	items, err := getJSON[[]service.LowStockItem](ctx, p.c, portalPath("reports", "low-stock"), query)
+	if err != nil || items == nil {
+		return nil, err
+	}
+
+	return *items, nil
+}
+
+// ListQuestions returns the approved questions about a product of the supplier, e.g. the
+// unanswered ones. the statuses of the filter are ignored
+func (p *PortalClient) ListQuestions(ctx context.Context, productID string, filter QuestionFilter) (*service.QuestionResult, error) {
+	filter.ProductID = ""
+	filter.Statuses = nil
+
+	return getJSON[service.QuestionResult](ctx, p.c, portalPath("products", url.PathEscape(productID), "questions"), filter.values())
+}
+
+// AnswerQuestion answers an approved question about a product of the supplier in its name
+func (p *PortalClient) AnswerQuestion(ctx context.Context, productID, questionID string, req PortalAnswerRequest) (*service.Answer, error) {
+	return sendJSON[service.Answer](ctx, p.c, http.MethodPost, portalPath("products", url.PathEscape(productID), "questions", url.PathEscape(questionID), "answers"), req)
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+func (c *Client) CreateProduct(ctx context.Context, req CreateProductRequest) (*service.Product, error) {
+	return sendJSON[service.Product](ctx, c, http.MethodPost, "/api/products", req)
+}
+
+// GetProduct returns the product with the id, an error IsNotFound reports when there is
+// none or its schedule hides it, see PreviewProduct
+func (c *Client) GetProduct(ctx context.Context, id string) (*service.Product, error) {
+	return getJSON[service.Product](ctx, c, entityPath("products", id), nil)
+}
+
+// PreviewProduct returns the product with the id whatever its status and schedule, an error
+// IsNotFound reports when there is none. The client needs the admin api key, see WithAdminKey
+func (c *Client) PreviewProduct(ctx context.Context, id string) (*service.Product, error) {
+	return getJSON[service.Product](ctx, c, entityPath("products", id), url.Values{"preview": {"true"}})
+}
+
+// ListScheduledChanges returns the publishes and unpublishes scheduled between from and to,
+// unix milliseconds which are unbounded when zero, soonest first
+func (c *Client) ListScheduledChanges(ctx context.Context, from, to, page, limit int64) (*service.ScheduleResult, error) {
+	query := url.Values{}
+	setInt(query, "from", from)
+	setInt(query, "to", to)
+	query.Set("page", strconv.FormatInt(page, 10))
+	query.Set("limit", strconv.FormatInt(limit, 10))
+
+	return getJSON[service.ScheduleResult](ctx, c, "/api/products/schedule", query)
+}
+
+func (c *Client) ListProducts(ctx context.Context, filter ProductFilter) (*service.ProductResult, error) {
+	return getJSON[service.ProductResult](ctx, c, "/api/products", filter.values())
+}
+
+// ProductIterator walks the products matching filter from filter.Page on, filter.Limit at a time
+func (c *Client) ProductIterator(filter ProductFilter) *Iterator[service.Product] {
+	return newIterator(filter.Page, func(ctx context.Context, page int64) ([]service.Product, bool, error) {
+		filter.Page = page
+
+		result, err := c.ListProducts(ctx, filter)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Products, hasMore(page, filter.Limit, result.Total), nil
+	})
+}
+
+func (c *Client) UpdateProduct(ctx context.Context, id string, req UpdateProductRequest) (*service.Product, error) {
+	return sendJSON[service.Product](ctx, c, http.MethodPut, entityPath("products", id), req)
+}
+
+func (c *Client) PatchProduct(ctx context.Context, id string, req PatchProductRequest) (*service.Product, error) {
+	return patchJSON[service.Product](ctx, c, entityPath("products", id), req)
+}
+
+// DeleteProduct soft deletes a product and returns it
+func (c *Client) DeleteProduct(ctx context.Context, id string) (*service.Product, error) {
+	return sendJSON[service.Product](ctx, c, http.MethodDelete, entityPath("products", id), nil)
+}
+
+func (c *Client) RestoreProduct(ctx context.Context, id string) (*service.Product, error) {
+	return sendJSON[service.Product](ctx, c, http.MethodPost, entityPath("products", id, "restore"), nil)
+}
+
+func (c *Client) BulkProducts(ctx context.Context, req BulkRequest[CreateProductRequest]) (*BulkResult[service.Product], error) {
+	return bulk[service.Product](ctx, c, "/api/products/bulk", req)
+}
+
+// ExportProducts writes the products matching params to w in the format of params
+func (c *Client) ExportProducts(ctx context.Context, params ExportParams, w io.Writer) error {
+	return c.stream(ctx, newRequest(http.MethodGet, "/api/products/export", params.values()), w)
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+func questionPath(productID, questionID string, parts ...string) string {
+	return entityPath("products", productID, append([]string{"questions", url.PathEscape(questionID)}, parts...)...)
+}
+
+// AskQuestion asks a question about a product on behalf of a customer, the question is
+// pending until it is moderated. the client needs the admin api key, see WithAdminKey
+func (c *Client) AskQuestion(ctx context.Context, productID string, req AskQuestionRequest) (*service.Question, error) {
+	return sendJSON[service.Question](ctx, c, http.MethodPost, entityPath("products", productID, "questions"), req)
+}
+
+// ListProductQuestions returns the approved questions about a product along with their answers
+func (c *Client) ListProductQuestions(ctx context.Context, productID string, filter QuestionFilter) (*service.QuestionResult, error) {
+	filter.ProductID = ""
+	filter.Statuses = nil
+
+	return getJSON[service.QuestionResult](ctx, c, entityPath("products", productID, "questions"), filter.values())
+}
+
+// ListQuestions returns the questions in any status, e.g. the pending ones to moderate,
+// the client needs the admin api key, see WithAdminKey
+func (c *Client) ListQuestions(ctx context.Context, filter QuestionFilter) (*service.QuestionResult, error) {
+	return getJSON[service.QuestionResult](ctx, c, "/api/questions", filter.values())
+}
+
+// QuestionIterator walks the questions the filter selects, starting from its page
+func (c *Client) QuestionIterator(filter QuestionFilter) *Iterator[service.Question] {
+	return newIterator(filter.Page, func(ctx context.Context, page int64) ([]service.Question, bool, error) {
+		filter.Page = page
+
+		result, err := c.ListQuestions(ctx, filter)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Questions, hasMore(page, filter.Limit, result.Total), nil
+	})
+}
+
+// GetQuestion returns a question in any status along with its answers, the client needs the
+// admin api key, see WithAdminKey
+func (c *Client) GetQuestion(ctx context.Context, productID, questionID string) (*service.Question, error) {
+	return getJSON[service.Question](ctx, c, questionPath(productID, questionID), nil)
+}
+
+// ModerateQuestion approves or rejects a question, the client needs the admin api key, see
+// WithAdminKey
+func (c *Client) ModerateQuestion(ctx context.Context, productID, questionID string, req TransitionRequest) (*service.Question, error) {
+	return sendJSON[service.Question](ctx, c, http.MethodPost, questionPath(productID, questionID, "transitions"), req)
+}
+
+// ListQuestionTransitions returns the moderation history of a question, newest first,
+// the client needs the admin api key, see WithAdminKey
+func (c *Client) ListQuestionTransitions(ctx context.Context, productID, questionID string, page, limit int64) (*service.TransitionResult, error) {
+	query := url.Values{}
+	query.Set("page", strconv.FormatInt(page, 10))
+	query.Set("limit", strconv.FormatInt(limit, 10))
+
+	return getJSON[service.TransitionResult](ctx, c, questionPath(productID, questionID, "transitions"), query)
+}
+
+// UpvoteQuestion upvotes an approved question, once per voter, the client needs the admin
+// api key, see WithAdminKey
+func (c *Client) UpvoteQuestion(ctx context.Context, productID, questionID string, req UpvoteRequest) (*service.Question, error) {
+	return sendJSON[service.Question](ctx, c, http.MethodPost, questionPath(productID, questionID, "upvotes"), req)
+}
+
+// DeleteQuestion deletes a question along with its answers and upvotes, the client needs
+// the admin api key, see WithAdminKey
+func (c *Client) DeleteQuestion(ctx context.Context, productID, questionID string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, questionPath(productID, questionID), nil), nil)
+}
+
+// AnswerQuestion answers an approved question on behalf of the catalog team, the client
+// needs the admin api key, see WithAdminKey
+func (c *Client) AnswerQuestion(ctx context.Context, productID, questionID string, req AnswerQuestionRequest) (*service.Answer, error) {
+	return sendJSON[service.Answer](ctx, c, http.MethodPost, questionPath(productID, questionID, "answers"), req)
+}
+
+// UpvoteAnswer upvotes an answer, once per voter, the client needs the admin api key, see
+// WithAdminKey
+func (c *Client) UpvoteAnswer(ctx context.Context, productID, questionID, answerID string, req UpvoteRequest) (*service.Answer, error) {
+	return sendJSON[service.Answer](ctx, c, http.MethodPost, questionPath(productID, questionID, "answers", url.PathEscape(answerID), "upvotes"), req)
+}
+
+// DeleteAnswer deletes an answer along with its upvotes, the client needs the admin api
+// key, see WithAdminKey
+func (c *Client) DeleteAnswer(ctx context.Context, productID, questionID, answerID string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, questionPath(productID, questionID, "answers", url.PathEscape(answerID)), nil), nil)
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// CreateReview reviews a product on behalf of a customer, the review is pending until it is
+// moderated. the client needs the admin api key, see WithAdminKey
+func (c *Client) CreateReview(ctx context.Context, productID string, req CreateReviewRequest) (*service.Review, error) {
+	return sendJSON[service.Review](ctx, c, http.MethodPost, entityPath("products", productID, "reviews"), req)
+}
+
+// ListProductReviews returns the approved reviews of a product
+func (c *Client) ListProductReviews(ctx context.Context, productID string, filter ReviewFilter) (*service.ReviewResult, error) {
+	filter.ProductID = ""
+	filter.Statuses = nil
+
+	return getJSON[service.ReviewResult](ctx, c, entityPath("products", productID, "reviews"), filter.values())
+}
+
+// ListReviews returns the reviews in any status, e.g. the pending ones to moderate, the
+// client needs the admin api key, see WithAdminKey
+func (c *Client) ListReviews(ctx context.Context, filter ReviewFilter) (*service.ReviewResult, error) {
+	return getJSON[service.ReviewResult](ctx, c, "/api/reviews", filter.values())
+}
+
+// ReviewIterator walks the reviews the filter selects, starting from its page
+func (c *Client) ReviewIterator(filter ReviewFilter) *Iterator[service.Review] {
+	return newIterator(filter.Page, func(ctx context.Context, page int64) ([]service.Review, bool, error) {
+		filter.Page = page
+
+		result, err := c.ListReviews(ctx, filter)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Reviews, hasMore(page, filter.Limit, result.Total), nil
+	})
+}
+
+// GetReview returns a review in any status, the client needs the admin api key, see
+// WithAdminKey
+func (c *Client) GetReview(ctx context.Context, id string) (*service.Review, error) {
+	return getJSON[service.Review](ctx, c, entityPath("reviews", id), nil)
+}
+
+// ModerateReview approves or rejects a review, the client needs the admin api key, see
+// WithAdminKey
+func (c *Client) ModerateReview(ctx context.Context, id string, req TransitionRequest) (*service.Review, error) {
+	return sendJSON[service.Review](ctx, c, http.MethodPost, entityPath("reviews", id, "transitions"), req)
+}
+
+// VoteReview records whether the voter found an approved review helpful, the client needs
+// the admin api key, see WithAdminKey
+func (c *Client) VoteReview(ctx context.Context, id string, req ReviewVoteRequest) (*service.Review, error) {
+	return sendJSON[service.Review](ctx, c, http.MethodPost, entityPath("reviews", id, "votes"), req)
+}
+
+// DeleteReview deletes a review along with its votes unless it changed since the version,
+// the client needs the admin api key, see WithAdminKey
+func (c *Client) DeleteReview(ctx context.Context, id string, version int64) error {
+	query := url.Values{}
+	query.Set("version", strconv.FormatInt(version, 10))
+
+	return c.call(ctx, newRequest(http.MethodDelete, entityPath("reviews", id), query), nil)
+}
@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// ResolvePath returns the category or product of a storefront path such as /laptops/gaming,
+// an error IsNotFound reports when there is none. Redirect is set for an old path, Path
+// being the one to go to
+func (c *Client) ResolvePath(ctx context.Context, path string) (*service.PathResolution, error) {
+	query := url.Values{}
+	query.Set("path", path)
+
+	return getJSON[service.PathResolution](ctx, c, "/api/resolve", query)
+}
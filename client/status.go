@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// TransitionBrand changes the status of a brand, archiving it archives its products
+func (c *Client) TransitionBrand(ctx context.Context, id string, req TransitionRequest) (*service.Brand, error) {
+	return sendJSON[service.Brand](ctx, c, http.MethodPost, entityPath("brands", id, "transitions"), req)
+}
+
+// TransitionCategory changes the status of a category, along with the categories underneath it
+// when it is deactivated or archived
+func (c *Client) TransitionCategory(ctx context.Context, id string, req TransitionRequest) (*service.Category, error) {
+	return sendJSON[service.Category](ctx, c, http.MethodPost, entityPath("categories", id, "transitions"), req)
+}
+
+// TransitionSupplier changes the status of a supplier, archiving it archives its products
+func (c *Client) TransitionSupplier(ctx context.Context, id string, req TransitionRequest) (*service.Supplier, error) {
+	return sendJSON[service.Supplier](ctx, c, http.MethodPost, entityPath("suppliers", id, "transitions"), req)
+}
+
+// TransitionProduct moves a product to another lifecycle state
+func (c *Client) TransitionProduct(ctx context.Context, id string, req TransitionRequest) (*service.Product, error) {
+	return sendJSON[service.Product](ctx, c, http.MethodPost, entityPath("products", id, "transitions"), req)
+}
+
+// ListTransitions returns the status changes of a record of the collection, "brands",
+// "categories", "suppliers", "products" or "reviews", newest first
+func (c *Client) ListTransitions(ctx context.Context, collection, id string, page, limit int64) (*service.TransitionResult, error) {
+	query := url.Values{}
+	query.Set("page", strconv.FormatInt(page, 10))
+	query.Set("limit", strconv.FormatInt(limit, 10))
+
+	return getJSON[service.TransitionResult](ctx, c, entityPath(collection, id, "transitions"), query)
+}
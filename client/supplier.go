@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+func (c *Client) CreateSupplier(ctx context.Context, req CreateSupplierRequest) (*service.Supplier, error) {
+	return sendJSON[service.Supplier](ctx, c, http.MethodPost, "/api/suppliers", req)
+}
+
+// GetSupplier returns the supplier with the id, an error IsNotFound reports when there is none
+func (c *Client) GetSupplier(ctx context.Context, id string) (*service.Supplier, error) {
+	return getJSON[service.Supplier](ctx, c, entityPath("suppliers", id), nil)
+}
+
+func (c *Client) ListSuppliers(ctx context.Context, params ListParams) (*service.SupplierResult, error) {
+	return getJSON[service.SupplierResult](ctx, c, "/api/suppliers", params.values())
+}
+
+// SupplierIterator walks the suppliers from params.Page on, params.Limit at a time
+func (c *Client) SupplierIterator(params ListParams) *Iterator[service.Supplier] {
+	return newIterator(params.Page, func(ctx context.Context, page int64) ([]service.Supplier, bool, error) {
+		params.Page = page
+
+		result, err := c.ListSuppliers(ctx, params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Suppliers, hasMore(page, params.Limit, result.Total), nil
+	})
+}
+
+func (c *Client) UpdateSupplier(ctx context.Context, id string, req UpdateSupplierRequest) (*service.Supplier, error) {
+	return sendJSON[service.Supplier](ctx, c, http.MethodPut, entityPath("suppliers", id), req)
+}
+
+func (c *Client) PatchSupplier(ctx context.Context, id string, req PatchSupplierRequest) (*service.Supplier, error) {
+	return patchJSON[service.Supplier](ctx, c, entityPath("suppliers", id), req)
+}
+
+// DeleteSupplier soft deletes a supplier and returns it
+func (c *Client) DeleteSupplier(ctx context.Context, id string) (*service.Supplier, error) {
+	return sendJSON[service.Supplier](ctx, c, http.MethodDelete, entityPath("suppliers", id), nil)
+}
+
+func (c *Client) RestoreSupplier(ctx context.Context, id string) (*service.Supplier, error) {
+	return sendJSON[service.Supplier](ctx, c, http.MethodPost, entityPath("suppliers", id, "restore"), nil)
+}
+
+func (c *Client) BulkSuppliers(ctx context.Context, req BulkRequest[CreateSupplierRequest]) (*BulkResult[service.Supplier], error) {
+	return bulk[service.Supplier](ctx, c, "/api/suppliers/bulk", req)
+}
+
+// CreateSupplierToken issues the bearer token a supplier signs in to the supplier portal with,
+// the client needs the admin api key, see WithAdminKey
+func (c *Client) CreateSupplierToken(ctx context.Context, id string) (string, error) {
+	res, err := sendJSON[struct {
+		Token string `json:"token"`
+	}](ctx, c, http.MethodPost, entityPath("suppliers", id, "token"), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Token, nil
+}
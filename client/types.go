@@ -0,0 +1,665 @@
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// bulk modes
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+// bulk operations
+const (
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+)
+
+// ListParams pages through the brands, categories and suppliers
+type ListParams struct {
+	Page  int64
+	Limit int64
+	// IncludeDeleted lists the soft deleted records too, with the admin api key
+	IncludeDeleted bool
+}
+
+func (p ListParams) values() url.Values {
+	values := url.Values{}
+	values.Set("page", strconv.FormatInt(p.Page, 10))
+	values.Set("limit", strconv.FormatInt(p.Limit, 10))
+	if p.IncludeDeleted {
+		values.Set("include_deleted", "true")
+	}
+
+	return values
+}
+
+/////////////////////// slug requests //////////////////////
+
+// SEO is the seo metadata of a brand, category or product
+type SEO struct {
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	CanonicalURL string `json:"canonical_url,omitempty"`
+}
+
+// PatchSEO changes the seo fields which are set, an empty one is removed
+type PatchSEO struct {
+	Title        *string `json:"title,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	CanonicalURL *string `json:"canonical_url,omitempty"`
+}
+
+/////////////////////// brand requests //////////////////////
+
+// CreateBrandRequest creates a brand, its slug is made from the name when Slug is empty.
+// CountryOfOrigin is an ISO 3166-1 alpha-2 code such as "DE"
+type CreateBrandRequest struct {
+	Name            string `json:"name"`
+	Slug            string `json:"slug,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Website         string `json:"website,omitempty"`
+	CountryOfOrigin string `json:"country_of_origin,omitempty"`
+	Status          string `json:"status,omitempty"`
+	SEO             *SEO   `json:"seo,omitempty"`
+}
+
+// UpdateBrandRequest replaces a brand, the slug, the status, the seo metadata and the description,
+// website and country of origin stay when empty
+type UpdateBrandRequest struct {
+	Name            string `json:"name"`
+	Slug            string `json:"slug,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Website         string `json:"website,omitempty"`
+	CountryOfOrigin string `json:"country_of_origin,omitempty"`
+	Status          string `json:"status,omitempty"`
+	SEO             *SEO   `json:"seo,omitempty"`
+	Version         int64  `json:"version"`
+}
+
+// PatchBrandRequest changes the fields which are set, an empty Slug makes the slug from
+// the name again and an empty Description, Website or CountryOfOrigin removes it.
+// ClearSEO removes the seo metadata
+type PatchBrandRequest struct {
+	Name            *string   `json:"name,omitempty"`
+	Slug            *string   `json:"slug,omitempty"`
+	Description     *string   `json:"description,omitempty"`
+	Website         *string   `json:"website,omitempty"`
+	CountryOfOrigin *string   `json:"country_of_origin,omitempty"`
+	Status          *string   `json:"status,omitempty"`
+	SEO             *PatchSEO `json:"seo,omitempty"`
+	Version         int64     `json:"version"`
+	ClearSEO        bool      `json:"-"`
+}
+
+func (r PatchBrandRequest) MarshalJSON() ([]byte, error) {
+	type patch PatchBrandRequest
+	return mergePatch(patch(r), map[string]bool{"seo": r.ClearSEO})
+}
+
+/////////////////////// category requests //////////////////////
+
+// CreateCategoryRequest creates a category, its slug is made from the name when Slug is empty
+type CreateCategoryRequest struct {
+	Name     string `json:"name"`
+	Slug     string `json:"slug,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
+	Status   string `json:"status,omitempty"`
+	SEO      *SEO   `json:"seo,omitempty"`
+}
+
+// UpdateCategoryRequest replaces a category, the slug, the status and the seo metadata stay when empty
+type UpdateCategoryRequest struct {
+	Name    string `json:"name"`
+	Slug    string `json:"slug,omitempty"`
+	Status  string `json:"status,omitempty"`
+	SEO     *SEO   `json:"seo,omitempty"`
+	Version int64  `json:"version"`
+}
+
+// PatchCategoryRequest changes the fields which are set, an empty Slug makes the slug from
+// the name again. ClearSequence removes the sequence and ClearSEO the seo metadata
+type PatchCategoryRequest struct {
+	Name          *string   `json:"name,omitempty"`
+	Slug          *string   `json:"slug,omitempty"`
+	Sequence      *string   `json:"sequence,omitempty"`
+	Status        *string   `json:"status,omitempty"`
+	SEO           *PatchSEO `json:"seo,omitempty"`
+	Version       int64     `json:"version"`
+	ClearSequence bool      `json:"-"`
+	ClearSEO      bool      `json:"-"`
+}
+
+func (r PatchCategoryRequest) MarshalJSON() ([]byte, error) {
+	type patch PatchCategoryRequest
+	return mergePatch(patch(r), map[string]bool{
+		"sequence": r.ClearSequence,
+		"seo":      r.ClearSEO,
+	})
+}
+
+// MoveCategoryRequest puts a category under ParentID, or at the root when it is empty
+type MoveCategoryRequest struct {
+	ParentID string `json:"parent_id"`
+	Version  int64  `json:"version"`
+}
+
+// ReorderCategoriesRequest orders the children of ParentID, or the root categories when it is
+// empty. CategoryIDs must list every one of them
+type ReorderCategoriesRequest struct {
+	ParentID    string   `json:"parent_id"`
+	CategoryIDs []string `json:"category_ids"`
+}
+
+// CategoryNode is a category of the category tree
+type CategoryNode struct {
+	ID           string          `json:"id"`
+	CategoryName string          `json:"category_name"`
+	Children     []*CategoryNode `json:"children"`
+}
+
+/////////////////////// supplier requests //////////////////////
+
+type CreateSupplierRequest struct {
+	Name               string `json:"name"`
+	Email              string `json:"email"`
+	Phone              string `json:"phone"`
+	Status             string `json:"status,omitempty"`
+	IsVerifiedSupplier bool   `json:"is_verified_supplier"`
+}
+
+type UpdateSupplierRequest struct {
+	Name               string `json:"name"`
+	Email              string `json:"email"`
+	Phone              string `json:"phone"`
+	Status             string `json:"status,omitempty"`
+	IsVerifiedSupplier bool   `json:"is_verified_supplier"`
+	Version            int64  `json:"version"`
+}
+
+// PatchSupplierRequest changes the fields which are set
+type PatchSupplierRequest struct {
+	Name               *string `json:"name,omitempty"`
+	Email              *string `json:"email,omitempty"`
+	Phone              *string `json:"phone,omitempty"`
+	Status             *string `json:"status,omitempty"`
+	IsVerifiedSupplier *bool   `json:"is_verified_supplier,omitempty"`
+	Version            int64   `json:"version"`
+}
+
+/////////////////////// product requests //////////////////////
+
+// CreateProductRequest creates a product, its slug is made from the name when Slug is empty.
+// BrandID and CategoryID take slugs too
+type CreateProductRequest struct {
+	Name           string   `json:"name"`
+	Slug           string   `json:"slug,omitempty"`
+	Description    string   `json:"description"`
+	Specifications string   `json:"specifications"`
+	BrandID        string   `json:"brand_id"`
+	CategoryID     string   `json:"category_id"`
+	SupplierID     string   `json:"supplier_id"`
+	UnitPrice      float64  `json:"unit_price"`
+	DiscountPrice  float64  `json:"discount_price"`
+	Tags           []string `json:"tags"`
+	Status         string   `json:"status,omitempty"`
+	StockQuantity  int64    `json:"stock_quantity"`
+	// PublishAt and UnpublishAt schedule the product to go live and off sale, in unix milliseconds
+	PublishAt   int64 `json:"publish_at,omitempty"`
+	UnpublishAt int64 `json:"unpublish_at,omitempty"`
+	SEO         *SEO  `json:"seo,omitempty"`
+}
+
+// UpdateProductRequest replaces a product, the slug, the status and the seo metadata stay when empty
+type UpdateProductRequest struct {
+	Name           string   `json:"name"`
+	Slug           string   `json:"slug,omitempty"`
+	Description    string   `json:"description"`
+	Specifications string   `json:"specifications"`
+	BrandID        string   `json:"brand_id"`
+	CategoryID     string   `json:"category_id"`
+	SupplierID     string   `json:"supplier_id"`
+	UnitPrice      float64  `json:"unit_price"`
+	DiscountPrice  float64  `json:"discount_price"`
+	Tags           []string `json:"tags"`
+	Status         string   `json:"status,omitempty"`
+	StockQuantity  int64    `json:"stock_quantity"`
+	// PublishAt and UnpublishAt schedule the product to go live and off sale, in unix milliseconds
+	PublishAt   int64 `json:"publish_at,omitempty"`
+	UnpublishAt int64 `json:"unpublish_at,omitempty"`
+	SEO         *SEO  `json:"seo,omitempty"`
+	Version     int64 `json:"version"`
+}
+
+// PatchProductRequest changes the fields which are set, an empty Slug makes the slug from
+// the name again. ClearSpecifications, ClearTags and ClearSEO remove the specifications,
+// the tags and the seo metadata
+type PatchProductRequest struct {
+	Name           *string   `json:"name,omitempty"`
+	Slug           *string   `json:"slug,omitempty"`
+	Description    *string   `json:"description,omitempty"`
+	Specifications *string   `json:"specifications,omitempty"`
+	BrandID        *string   `json:"brand_id,omitempty"`
+	CategoryID     *string   `json:"category_id,omitempty"`
+	SupplierID     *string   `json:"supplier_id,omitempty"`
+	UnitPrice      *float64  `json:"unit_price,omitempty"`
+	DiscountPrice  *float64  `json:"discount_price,omitempty"`
+	Tags           *[]string `json:"tags,omitempty"`
+	Status         *string   `json:"status,omitempty"`
+	// a zero time removes the schedule
+	PublishAt           *int64    `json:"publish_at,omitempty"`
+	UnpublishAt         *int64    `json:"unpublish_at,omitempty"`
+	SEO                 *PatchSEO `json:"seo,omitempty"`
+	Version             int64     `json:"version"`
+	ClearSpecifications bool      `json:"-"`
+	ClearTags           bool      `json:"-"`
+	ClearSEO            bool      `json:"-"`
+}
+
+func (r PatchProductRequest) MarshalJSON() ([]byte, error) {
+	type patch PatchProductRequest
+	return mergePatch(patch(r), map[string]bool{
+		"specifications": r.ClearSpecifications,
+		"tags":           r.ClearTags,
+		"seo":            r.ClearSEO,
+	})
+}
+
+// ProductFilter filters and pages through the products
+type ProductFilter struct {
+	Name       string
+	MinPrice   float64
+	MaxPrice   float64
+	BrandIDs   []string
+	CategoryID string
+	SupplierID string
+	// Statuses are the lifecycle states to list, the live products of live brands, categories
+	// and suppliers when empty. Setting them needs the admin api key, see WithAdminKey
+	Statuses []string
+	// MinRating leaves out the unrated products and those rated lower on average
+	MinRating float64
+	// Sort is service.ProductSortPrice, the default, or service.ProductSortRating
+	Sort string
+	// IncludeDeleted lists the soft deleted products too, with the admin api key
+	IncludeDeleted bool
+	Page           int64
+	Limit          int64
+}
+
+func (f ProductFilter) values() url.Values {
+	values := url.Values{}
+	setString(values, "name", f.Name)
+	setFloat(values, "min_price", f.MinPrice)
+	setFloat(values, "max_price", f.MaxPrice)
+	for _, brandID := range f.BrandIDs {
+		values.Add("brand_ids", brandID)
+	}
+	setString(values, "category_id", f.CategoryID)
+	setString(values, "supplier_id", f.SupplierID)
+	for _, status := range f.Statuses {
+		values.Add("status", status)
+	}
+	setFloat(values, "min_rating", f.MinRating)
+	setString(values, "sort", f.Sort)
+	if f.IncludeDeleted {
+		values.Set("include_deleted", "true")
+	}
+	// the product list binds its page from "Page"
+	values.Set("Page", strconv.FormatInt(f.Page, 10))
+	values.Set("limit", strconv.FormatInt(f.Limit, 10))
+
+	return values
+}
+
+// ExportParams selects the products to export and how. Statuses and IncludeDeleted export
+// the products the storefront hides, they need the admin api key, see WithAdminKey
+type ExportParams struct {
+	Name           string
+	MinPrice       float64
+	MaxPrice       float64
+	BrandIDs       []string
+	CategoryID     string
+	SupplierID     string
+	Statuses       []string
+	IncludeDeleted bool
+	// Format is csv, jsonl or xlsx, csv when empty
+	Format string
+	// Columns are the columns to export, all of them when empty
+	Columns []string
+}
+
+func (p ExportParams) values() url.Values {
+	values := url.Values{}
+	setString(values, "name", p.Name)
+	setFloat(values, "min_price", p.MinPrice)
+	setFloat(values, "max_price", p.MaxPrice)
+	for _, brandID := range p.BrandIDs {
+		values.Add("brand_ids", brandID)
+	}
+	setString(values, "category_id", p.CategoryID)
+	setString(values, "supplier_id", p.SupplierID)
+	for _, status := range p.Statuses {
+		values.Add("status", status)
+	}
+	if p.IncludeDeleted {
+		values.Set("include_deleted", "true")
+	}
+	setString(values, "format", p.Format)
+	setString(values, "columns", strings.Join(p.Columns, ","))
+
+	return values
+}
+
+/////////////////////// supplier portal requests //////////////////////
+
+type CreatePortalProductRequest struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Specifications string   `json:"specifications"`
+	BrandID        string   `json:"brand_id"`
+	CategoryID     string   `json:"category_id"`
+	UnitPrice      float64  `json:"unit_price"`
+	DiscountPrice  float64  `json:"discount_price"`
+	Tags           []string `json:"tags"`
+	Status         string   `json:"status,omitempty"`
+	StockQuantity  int64    `json:"stock_quantity"`
+}
+
+type UpdatePortalProductRequest struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Specifications string   `json:"specifications"`
+	BrandID        string   `json:"brand_id"`
+	CategoryID     string   `json:"category_id"`
+	UnitPrice      float64  `json:"unit_price"`
+	DiscountPrice  float64  `json:"discount_price"`
+	Tags           []string `json:"tags"`
+	Version        int64    `json:"version"`
+}
+
+// PortalProductFilter filters and pages through the products of the supplier
+type PortalProductFilter struct {
+	Name       string
+	MinPrice   float64
+	MaxPrice   float64
+	BrandIDs   []string
+	CategoryID string
+	// Statuses are the lifecycle states to list, all of them when empty
+	Statuses []string
+	Page     int64
+	Limit    int64
+}
+
+func (f PortalProductFilter) values() url.Values {
+	values := url.Values{}
+	setString(values, "name", f.Name)
+	setFloat(values, "min_price", f.MinPrice)
+	setFloat(values, "max_price", f.MaxPrice)
+	for _, brandID := range f.BrandIDs {
+		values.Add("brand_ids", brandID)
+	}
+	setString(values, "category_id", f.CategoryID)
+	for _, status := range f.Statuses {
+		values.Add("status", status)
+	}
+	values.Set("page", strconv.FormatInt(f.Page, 10))
+	values.Set("limit", strconv.FormatInt(f.Limit, 10))
+
+	return values
+}
+
+// StockAdjustment moves the stock of a product, Quantity is negative for a sale
+type StockAdjustment struct {
+	Quantity int64 `json:"quantity"`
+	// Reason is sale, restock, return or adjustment
+	Reason string `json:"reason"`
+}
+
+/////////////////////// status requests //////////////////////
+
+// TransitionRequest moves a brand, category, supplier, product, review or question to
+// Status, Reason is kept in its status history
+type TransitionRequest struct {
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Version int64  `json:"version"`
+}
+
+/////////////////////// bulk requests //////////////////////
+
+// BulkOp is an operation of a bulk request, Data holds the record to create or update
+type BulkOp[T any] struct {
+	Op      string `json:"op"`
+	ID      string `json:"id,omitempty"`
+	Version int64  `json:"version,omitempty"`
+	Data    *T     `json:"data,omitempty"`
+}
+
+type BulkRequest[T any] struct {
+	// Mode is BulkModeAtomic or BulkModeBestEffort, atomic when empty
+	Mode       string      `json:"mode,omitempty"`
+	Operations []BulkOp[T] `json:"operations"`
+}
+
+// BulkItem is the outcome of an operation of a bulk request
+type BulkItem[T any] struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Data   *T     `json:"data,omitempty"`
+}
+
+type BulkResult[T any] struct {
+	Mode      string        `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped"`
+	Items     []BulkItem[T] `json:"items"`
+}
+
+/////////////////////// webhook requests //////////////////////
+
+type CreateWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret signs the deliveries, one is generated when empty
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	IsActive   *bool    `json:"is_active,omitempty"`
+}
+
+type UpdateWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret replaces the signing secret when set
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	IsActive   bool     `json:"is_active"`
+}
+
+/////////////////////// audit requests //////////////////////
+
+// AuditFilter filters and pages through the audit log
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
+	From       int64
+	To         int64
+	Page       int64
+	Limit      int64
+}
+
+func (f AuditFilter) values() url.Values {
+	values := url.Values{}
+	setString(values, "entity_type", f.EntityType)
+	setString(values, "entity_id", f.EntityID)
+	setString(values, "actor", f.Actor)
+	setInt(values, "from", f.From)
+	setInt(values, "to", f.To)
+	values.Set("page", strconv.FormatInt(f.Page, 10))
+	values.Set("limit", strconv.FormatInt(f.Limit, 10))
+
+	return values
+}
+
+/////////////////////// review requests //////////////////////
+
+type CreateReviewRequest struct {
+	CustomerID string `json:"customer_id"`
+	AuthorName string `json:"author_name"`
+	// Rating is 1 to 5
+	Rating int64  `json:"rating"`
+	Title  string `json:"title,omitempty"`
+	Body   string `json:"body,omitempty"`
+	// VerifiedPurchase tells the customer bought the product, the client needs the admin api
+	// key to set it, see WithAdminKey
+	VerifiedPurchase bool `json:"verified_purchase"`
+}
+
+// ReviewFilter filters and pages through the reviews
+type ReviewFilter struct {
+	// ProductID is ignored by ListProductReviews, which takes the product on its own
+	ProductID string
+	// Statuses are the moderation statuses to list, all of them when empty. the reviews of a
+	// product are the approved ones only
+	Statuses         []string
+	Rating           int64
+	VerifiedPurchase bool
+	// Sort is newest, the default, helpful, highest or lowest
+	Sort  string
+	Page  int64
+	Limit int64
+}
+
+func (f ReviewFilter) values() url.Values {
+	values := url.Values{}
+	setString(values, "product_id", f.ProductID)
+	for _, status := range f.Statuses {
+		values.Add("status", status)
+	}
+	setInt(values, "rating", f.Rating)
+	if f.VerifiedPurchase {
+		values.Set("verified_purchase", "true")
+	}
+	setString(values, "sort", f.Sort)
+	values.Set("page", strconv.FormatInt(f.Page, 10))
+	values.Set("limit", strconv.FormatInt(f.Limit, 10))
+
+	return values
+}
+
+// ReviewVoteRequest tells whether the voter found a review helpful
+type ReviewVoteRequest struct {
+	VoterID string `json:"voter_id"`
+	Helpful bool   `json:"helpful"`
+}
+
+/////////////////////// question requests //////////////////////
+
+type AskQuestionRequest struct {
+	CustomerID string `json:"customer_id"`
+	AuthorName string `json:"author_name"`
+	Body       string `json:"body"`
+}
+
+// QuestionFilter filters and pages through the questions
+type QuestionFilter struct {
+	// ProductID is ignored by ListProductQuestions, which takes the product on its own
+	ProductID string
+	// Statuses are the moderation statuses to list, all of them when empty. the questions
+	// about a product are the approved ones only
+	Statuses []string
+	// Answered lists the answered questions only when true, the unanswered ones when false
+	Answered *bool
+	// Sort is newest, the default, or upvotes
+	Sort  string
+	Page  int64
+	Limit int64
+}
+
+func (f QuestionFilter) values() url.Values {
+	values := url.Values{}
+	setString(values, "product_id", f.ProductID)
+	for _, status := range f.Statuses {
+		values.Add("status", status)
+	}
+	if f.Answered != nil {
+		values.Set("answered", strconv.FormatBool(*f.Answered))
+	}
+	setString(values, "sort", f.Sort)
+	values.Set("page", strconv.FormatInt(f.Page, 10))
+	values.Set("limit", strconv.FormatInt(f.Limit, 10))
+
+	return values
+}
+
+// AnswerQuestionRequest answers a question on behalf of the catalog team
+type AnswerQuestionRequest struct {
+	AuthorName string `json:"author_name"`
+	Body       string `json:"body"`
+}
+
+// PortalAnswerRequest answers a question in the name of the supplier
+type PortalAnswerRequest struct {
+	Body string `json:"body"`
+}
+
+// UpvoteRequest upvotes a question or an answer
+type UpvoteRequest struct {
+	VoterID string `json:"voter_id"`
+}
+
+/////////////////////// graphql requests //////////////////////
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// mergePatch encodes v as a JSON Merge Patch (RFC 7396), with the cleared members set to null
+func mergePatch(v interface{}, cleared map[string]bool) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, err
+	}
+
+	for member, clear := range cleared {
+		if clear {
+			members[member] = json.RawMessage("null")
+		}
+	}
+
+	return json.Marshal(members)
+}
+
+func setString(values url.Values, key, value string) {
+	if len(value) > 0 {
+		values.Set(key, value)
+	}
+}
+
+func setInt(values url.Values, key string, value int64) {
+	if value != 0 {
+		values.Set(key, strconv.FormatInt(value, 10))
+	}
+}
+
+func setFloat(values url.Values, key string, value float64) {
+	if value != 0 {
+		values.Set(key, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// CreateWebhook subscribes a url to events, the subscription returned holds the signing secret.
+// The url must be http or https and resolve to public addresses. The webhook endpoints all
+// need the admin api key, see WithAdminKey
+func (c *Client) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (*service.WebhookSubscription, error) {
+	return sendJSON[service.WebhookSubscription](ctx, c, http.MethodPost, "/api/webhooks", req)
+}
+
+// GetWebhook returns a webhook subscription, the client needs the admin api key
+func (c *Client) GetWebhook(ctx context.Context, id string) (*service.WebhookSubscription, error) {
+	return getJSON[service.WebhookSubscription](ctx, c, entityPath("webhooks", id), nil)
+}
+
+// ListWebhooks returns a page of the webhook subscriptions, the client needs the admin api key
+func (c *Client) ListWebhooks(ctx context.Context, page, limit int64) (*service.WebhookSubscriptionResult, error) {
+	return getJSON[service.WebhookSubscriptionResult](ctx, c, "/api/webhooks", pageValues(page, limit))
+}
+
+// WebhookIterator walks the webhook subscriptions, limit at a time
+func (c *Client) WebhookIterator(limit int64) *Iterator[service.WebhookSubscription] {
+	return newIterator(1, func(ctx context.Context, page int64) ([]service.WebhookSubscription, bool, error) {
+		result, err := c.ListWebhooks(ctx, page, limit)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Subscriptions, hasMore(page, limit, result.Total), nil
+	})
+}
+
+// GetWebhookEventTypes returns the event types a webhook can subscribe to, the client needs the
+// admin api key
+func (c *Client) GetWebhookEventTypes(ctx context.Context) ([]string, error) {
+	eventTypes, err := getJSON[[]string](ctx, c, "/api/webhooks/event-types", nil)
+	if err != nil || eventTypes == nil {
+		return nil, err
+	}
+
+	return *eventTypes, nil
+}
+
+// UpdateWebhook replaces a webhook subscription, the client needs the admin api key
+func (c *Client) UpdateWebhook(ctx context.Context, id string, req UpdateWebhookRequest) (*service.WebhookSubscription, error) {
+	return sendJSON[service.WebhookSubscription](ctx, c, http.MethodPut, entityPath("webhooks", id), req)
+}
+
+// DeleteWebhook removes a webhook subscription, the client needs the admin api key
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.call(ctx, newRequest(http.MethodDelete, entityPath("webhooks", id), nil), nil)
+}
+
+// ListWebhookDeliveries returns a page of the deliveries of a webhook subscription, the client
+// needs the admin api key
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id string, page, limit int64) (*service.WebhookDeliveryResult, error) {
+	return getJSON[service.WebhookDeliveryResult](ctx, c, entityPath("webhooks", id, "deliveries"), pageValues(page, limit))
+}
+
+// WebhookDeliveryIterator walks the deliveries of a webhook subscription, limit at a time
+func (c *Client) WebhookDeliveryIterator(id string, limit int64) *Iterator[service.WebhookDelivery] {
+	return newIterator(1, func(ctx context.Context, page int64) ([]service.WebhookDelivery, bool, error) {
+		result, err := c.ListWebhookDeliveries(ctx, id, page, limit)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return result.Deliveries, hasMore(page, limit, result.Total), nil
+	})
+}
+
+// RedeliverWebhook queues a delivery to be sent again, the client needs the admin api key
+func (c *Client) RedeliverWebhook(ctx context.Context, id, deliveryID string) (*service.WebhookDelivery, error) {
+	path := entityPath("webhooks", id, "deliveries", url.PathEscape(deliveryID), "redeliver")
+	return sendJSON[service.WebhookDelivery](ctx, c, http.MethodPost, path, nil)
+}
+
+func pageValues(page, limit int64) url.Values {
+	return url.Values{
+		"page":  {strconv.FormatInt(page, 10)},
+		"limit": {strconv.FormatInt(limit, 10)},
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/jsiqbal/ecommerce/config"
+	database "github.com/jsiqbal/ecommerce/db"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/spreadsheet"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFile           string
+	exportColumns        string
+	exportName           string
+	exportMinPrice       float64
+	exportMaxPrice       float64
+	exportBrandIDs       []string
+	exportCategoryID     string
+	exportSupplierID     string
+	exportIncludeDeleted bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "exports products to a local csv, jsonl or xlsx file",
+	RunE:  exportProducts,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFile, "file", "", "csv, jsonl or xlsx file to export to")
+	exportCmd.Flags().StringVar(&exportColumns, "columns", "", "comma separated columns to export, all by default")
+	exportCmd.Flags().StringVar(&exportName, "name", "", "product name filter")
+	exportCmd.Flags().Float64Var(&exportMinPrice, "min-price", 0, "minimum price filter")
+	exportCmd.Flags().Float64Var(&exportMaxPrice, "max-price", 0, "maximum price filter")
+	exportCmd.Flags().StringSliceVar(&exportBrandIDs, "brand-ids", nil, "brand ids filter")
+	exportCmd.Flags().StringVar(&exportCategoryID, "category-id", "", "category id filter")
+	exportCmd.Flags().StringVar(&exportSupplierID, "supplier-id", "", "supplier id filter")
+	exportCmd.Flags().BoolVar(&exportIncludeDeleted, "include-deleted", false, "include soft deleted products")
+	exportCmd.MarkFlagRequired("file")
+}
+
+func exportProducts(cmd *cobra.Command, args []string) error {
+	format, err := spreadsheet.FormatOf(exportFile)
+	if err != nil {
+		return err
+	}
+
+	var columns []string
+	if len(exportColumns) > 0 {
+		columns = strings.Split(exportColumns, ",")
+	}
+
+	columns, err = service.ValidateExportColumns(columns)
+	if err != nil {
+		return err
+	}
+
+	dbCnf := config.GetDB()
+
+	// connect to db
+	db, err := database.Connect(dbCnf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	file, err := os.Create(exportFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	svc := newService(db)
+
+	written, err := svc.ExportProducts(context.Background(), file, format, columns, service.FilterProductsParams{
+		Name:           exportName,
+		MinPrice:       exportMinPrice,
+		MaxPrice:       exportMaxPrice,
+		BrandIDs:       exportBrandIDs,
+		CategoryID:     exportCategoryID,
+		SupplierID:     exportSupplierID,
+		IncludeDeleted: exportIncludeDeleted,
+	})
+	if err != nil {
+		os.Remove(exportFile)
+		log.Fatal("can not export products: ", err)
+	}
+
+	log.Printf("exported %d products to %s\n", written, exportFile)
+
+	return nil
+}
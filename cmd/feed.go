@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jsiqbal/ecommerce/config"
+	database "github.com/jsiqbal/ecommerce/db"
+	"github.com/jsiqbal/ecommerce/feed"
+	"github.com/spf13/cobra"
+)
+
+var feedFile string
+
+var feedCmd = &cobra.Command{
+	Use:   "feed",
+	Short: "writes the google merchant product feed to a local xml or csv file",
+	RunE:  generateFeed,
+}
+
+func init() {
+	feedCmd.Flags().StringVar(&feedFile, "file", "", "xml or csv file to write the feed to")
+	feedCmd.MarkFlagRequired("file")
+}
+
+func generateFeed(cmd *cobra.Command, args []string) error {
+	format, err := feed.FormatOf(feedFile)
+	if err != nil {
+		return err
+	}
+
+	appCnf := config.GetApp()
+	dbCnf := config.GetDB()
+
+	// connect to db
+	db, err := database.Connect(dbCnf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	file, err := os.Create(feedFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	svc := newService(db)
+
+	written, err := svc.GenerateFeed(context.Background(), file, format, feed.Channel{
+		Title:       appCnf.FeedTitle,
+		Link:        appCnf.FeedLink,
+		Description: fmt.Sprintf("%s product feed", appCnf.FeedTitle),
+		Currency:    appCnf.FeedCurrency,
+	})
+	if err != nil {
+		os.Remove(feedFile)
+		log.Fatal("can not generate feed: ", err)
+	}
+
+	log.Printf("wrote %d products to %s\n", written, feedFile)
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jsiqbal/ecommerce/config"
+	"github.com/jsiqbal/ecommerce/db"
+	"github.com/jsiqbal/ecommerce/rpc"
+	"github.com/spf13/cobra"
+)
+
+var serveGrpcCmd = &cobra.Command{
+	Use:   "serve-grpc",
+	Short: "start a grpc server",
+	RunE:  serveGrpc,
+}
+
+func serveGrpc(cmd *cobra.Command, args []string) error {
+	appCnf := config.GetApp()
+	dbCnf := config.GetDB()
+
+	fmt.Printf("App config: %s, db config: %s\n", appCnf, dbCnf)
+
+	// connect to db
+	db, err := db.Connect(dbCnf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err != nil {
+		panic(err)
+	}
+
+	svc := newService(db)
+
+	server := rpc.NewServer(svc)
+
+	// finish the running calls on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+
+	log.Printf("grpc server listening on %s\n", appCnf.GrpcServerAddress)
+
+	err = server.Start(appCnf.GrpcServerAddress)
+	if err != nil {
+		log.Fatal("cannot start the grpc server: ", err)
+	}
+
+	return nil
+}
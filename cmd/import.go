@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jsiqbal/ecommerce/config"
+	database "github.com/jsiqbal/ecommerce/db"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/spreadsheet"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFile       string
+	importDryRun     bool
+	importErrorsFile string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "imports products from a local csv or xlsx file",
+	RunE:  importProducts,
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFile, "file", "", "csv or xlsx file to import")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "only validate the file")
+	importCmd.Flags().StringVar(&importErrorsFile, "errors-file", "", "csv or xlsx file to write the rows that failed to")
+	importCmd.MarkFlagRequired("file")
+}
+
+func importProducts(cmd *cobra.Command, args []string) error {
+	format, err := spreadsheet.ReadFormatOf(importFile)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(importFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, records, err := spreadsheet.Read(file, format)
+	if err != nil {
+		return err
+	}
+
+	rows := service.NewImportRows(header, records)
+
+	dbCnf := config.GetDB()
+
+	// connect to db
+	db, err := database.Connect(dbCnf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	svc := newService(db)
+	ctx := service.WithActor(context.Background(), "cli:import")
+
+	var rowErrs []service.ImportRowError
+
+	if importDryRun {
+		report, err := svc.ValidateImport(ctx, rows)
+		if err != nil {
+			log.Fatal("can not validate import: ", err)
+		}
+
+		log.Printf("validated %d rows: %d valid, %d invalid\n", report.TotalRows, report.ValidRows, report.InvalidRows)
+		rowErrs = report.Errors
+	} else {
+		job, err := svc.CreateImportJob(ctx, filepath.Base(importFile), format, int64(len(rows)))
+		if err != nil {
+			log.Fatal("can not create import job: ", err)
+		}
+
+		jobID := job.ID
+
+		job, err = svc.RunImport(ctx, jobID, rows)
+		if err != nil {
+			if err := svc.FailImportJob(ctx, jobID, err); err != nil {
+				log.Println("can not mark import job failed: ", err)
+			}
+
+			log.Fatal("can not run import: ", err)
+		}
+
+		log.Printf("import job %s imported %d of %d rows, %d failed\n", job.ID, job.SucceededRows, job.TotalRows, job.FailedRows)
+		rowErrs = job.Errors
+	}
+
+	for _, rowErr := range rowErrs {
+		log.Printf("line %d %s: %s\n", rowErr.Line, rowErr.Column, rowErr.Message)
+	}
+
+	if len(importErrorsFile) > 0 && len(rowErrs) > 0 {
+		return writeImportErrors(importErrorsFile, rowErrs)
+	}
+
+	return nil
+}
+
+func writeImportErrors(fileName string, rowErrs []service.ImportRowError) error {
+	format, err := spreadsheet.FormatOf(fileName)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, records := service.ImportErrorsTable(rowErrs)
+
+	return spreadsheet.Write(file, format, header, records)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/config"
+	database "github.com/jsiqbal/ecommerce/db"
+	"github.com/jsiqbal/ecommerce/util"
+	"github.com/spf13/cobra"
+)
+
+var purgeRetention time.Duration
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "permanently removes records soft deleted longer than the retention period",
+	RunE:  purge,
+}
+
+func init() {
+	purgeCmd.Flags().DurationVar(&purgeRetention, "retention", 30*24*time.Hour, "how long soft deleted records are kept")
+}
+
+func purge(cmd *cobra.Command, args []string) error {
+	dbCnf := config.GetDB()
+
+	// connect to db
+	db, err := database.Connect(dbCnf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	svc := newService(db)
+
+	deletedBefore := util.GetCurrentTimestamp() - purgeRetention.Milliseconds()
+
+	purged, err := svc.PurgeDeleted(context.Background(), deletedBefore)
+	if err != nil {
+		log.Fatal("can not purge deleted records: ", err)
+	}
+
+	log.Printf("purged records deleted more than %s ago: %v\n", purgeRetention, purged)
+
+	return nil
+}
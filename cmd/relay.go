@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jsiqbal/ecommerce/config"
+	database "github.com/jsiqbal/ecommerce/db"
+	"github.com/jsiqbal/ecommerce/outbox"
+	"github.com/jsiqbal/ecommerce/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	relayStdout      bool
+	relayWebhookURLs []string
+	relayWebhooks    bool
+	relayBatchSize   int
+)
+
+var relayCmd = &cobra.Command{
+	Use:   "relay",
+	Short: "delivers the domain events of the outbox to the sinks",
+	RunE:  runRelay,
+}
+
+func init() {
+	relayCmd.Flags().BoolVar(&relayStdout, "stdout", false, "write every event to stdout as a line of json")
+	relayCmd.Flags().StringSliceVar(&relayWebhookURLs, "webhook-url", nil, "post every event to the url")
+	relayCmd.Flags().BoolVar(&relayWebhooks, "webhooks", true, "deliver the events to the webhook subscriptions through the worker")
+	relayCmd.Flags().IntVar(&relayBatchSize, "batch-size", outbox.DefaultBatchSize, "number of events to claim at a time")
+}
+
+func runRelay(cmd *cobra.Command, args []string) error {
+	dbCnf := config.GetDB()
+
+	// connect to db
+	db, err := database.Connect(dbCnf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	var sinks []outbox.Sink
+	if relayStdout {
+		sinks = append(sinks, outbox.NewWriterSink(os.Stdout))
+	}
+
+	for _, url := range relayWebhookURLs {
+		sinks = append(sinks, outbox.NewWebhookSink(url))
+	}
+
+	if relayWebhooks {
+		svc := newService(db)
+		sinks = append(sinks, outbox.NewFuncSink("webhooks", svc.DispatchWebhooks))
+	}
+
+	if len(sinks) == 0 {
+		return errors.New("no sink given, use --stdout, --webhook-url or --webhooks")
+	}
+
+	relay := outbox.NewRelay(repo.NewOutboxRepo(db), sinks...)
+	relay.BatchSize = relayBatchSize
+
+	// stop on SIGINT or SIGTERM once the batch at hand is delivered
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("relay started with %d sinks\n", len(sinks))
+
+	relay.Run(ctx)
+
+	log.Println("relay stopped")
+
+	return nil
+}
@@ -6,9 +6,7 @@ import (
 
 	"github.com/jsiqbal/ecommerce/config"
 	"github.com/jsiqbal/ecommerce/db"
-	"github.com/jsiqbal/ecommerce/repo"
 	"github.com/jsiqbal/ecommerce/rest"
-	"github.com/jsiqbal/ecommerce/service"
 	"github.com/spf13/cobra"
 )
 
@@ -22,7 +20,7 @@ func serveRest(cmd *cobra.Command, args []string) error {
 	appCnf := config.GetApp()
 	dbCnf := config.GetDB()
 
-	fmt.Printf("App config: %+v, db config: %+v\n", appCnf, dbCnf)
+	fmt.Printf("App config: %s, db config: %s\n", appCnf, dbCnf)
 
 	// connect to db
 	db, err := db.Connect(dbCnf)
@@ -36,13 +34,7 @@ func serveRest(cmd *cobra.Command, args []string) error {
 		panic(err)
 	}
 
-	// create all the repos
-	brandRepo := repo.NewBrandRepo(db)
-	ctgryRepo := repo.NewCategoryRepo(db)
-	spplrRepo := repo.NewSupplierRepo(db)
-	productRepo := repo.NewProductRepo(db)
-
-	svc := service.NewService(brandRepo, ctgryRepo, spplrRepo, productRepo)
+	svc := newService(db)
 
 	server, err := rest.NewServer(svc, appCnf)
 	if err != nil {
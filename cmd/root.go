@@ -17,7 +17,14 @@ var (
 
 func init() {
 	RootCmd.AddCommand(serveRestCmd)
+	RootCmd.AddCommand(serveGrpcCmd)
 	RootCmd.AddCommand(seederCmd)
+	RootCmd.AddCommand(purgeCmd)
+	RootCmd.AddCommand(importCmd)
+	RootCmd.AddCommand(exportCmd)
+	RootCmd.AddCommand(feedCmd)
+	RootCmd.AddCommand(workerCmd)
+	RootCmd.AddCommand(relayCmd)
 }
 
 // Execute executes the root command
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/jsiqbal/ecommerce/config"
@@ -49,7 +50,7 @@ func seed(cmd *cobra.Command, args []string) error {
 
 	// -------------------- brand --------------------
 	// create a new brand
-	newBrand := &service.Brand{Name: "Lenovo", StatusID: 1, CreatedAt: util.GetCurrentTimestamp()}
+	newBrand := &service.Brand{Name: "Lenovo", Slug: "lenovo", Status: service.StatusActive, CreatedAt: util.GetCurrentTimestamp()}
 	brand, err := brandRepo.Add(context.Background(), newBrand)
 	if err != nil {
 		log.Fatal("can not create brand: ", err)
@@ -59,7 +60,8 @@ func seed(cmd *cobra.Command, args []string) error {
 	// create a new category
 	newCategory := &service.Category{
 		Name:      "Laptop",
-		StatusID:  1,
+		Slug:      "laptop",
+		Status:    service.StatusActive,
 		CreatedAt: util.GetCurrentTimestamp(),
 	}
 	ctgry, err := ctgryRepo.Add(context.Background(), newCategory)
@@ -73,7 +75,7 @@ func seed(cmd *cobra.Command, args []string) error {
 		Name:               "Z Studio",
 		Email:              "zstudio@gmail.com",
 		Phone:              "1234567895",
-		StatusID:           1,
+		Status:             service.StatusActive,
 		IsVerifiedSupplier: true,
 		CreatedAt:          util.GetCurrentTimestamp(),
 	}
@@ -91,6 +93,8 @@ func seed(cmd *cobra.Command, args []string) error {
 
 func createProducts(ctx context.Context, prdRepo service.ProductRepo, brandID, ctgryID, spplrID string) {
 	for i := 1; i <= 20; i++ {
+		name := util.RandomOwner()
+
 		addProduct(ctx, prdRepo, &service.Product{
 			Brand: service.Brand{
 				ID: brandID,
@@ -104,13 +108,15 @@ func createProducts(ctx context.Context, prdRepo service.ProductRepo, brandID, c
 			ProductStock: service.ProductStock{
 				StockQuantity: util.RandomQuantity(),
 			},
-			Name:           util.RandomOwner(),
+			Name: name,
+			// random names may repeat, the number keeps the slugs apart
+			Slug:           fmt.Sprintf("%s-%d", util.Slugify(name), i),
 			Description:    util.RandomString(20),
 			Specifications: util.RandomString(30),
 			UnitPrice:      float64(util.RandomMoney()),
 			DiscountPrice:  5,
 			Tags:           []string{"Laptop"},
-			StatusID:       1,
+			Status:         service.ProductStatusActive,
 			CreatedAt:      util.GetCurrentTimestamp(),
 		})
 	}
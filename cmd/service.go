@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/config"
+	"github.com/jsiqbal/ecommerce/media"
+	"github.com/jsiqbal/ecommerce/repo"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// newService wires the service with all the repos, for every command to share
+func newService(db *sqlx.DB) service.Service {
+	appCnf := config.GetApp()
+
+	return service.NewService(
+		repo.NewBrandRepo(db),
+		repo.NewCategoryRepo(db),
+		repo.NewSupplierRepo(db),
+		repo.NewProductRepo(db),
+		repo.NewProductStockRepo(db),
+		repo.NewAuditRepo(db),
+		repo.NewTransitionRepo(db),
+		repo.NewImportJobRepo(db),
+		repo.NewJobRepo(db),
+		repo.NewOutboxRepo(db),
+		repo.NewWebhookSubscriptionRepo(db),
+		repo.NewWebhookDeliveryRepo(db),
+		repo.NewSlugRepo(db),
+		repo.NewReviewRepo(db),
+		repo.NewQuestionRepo(db),
+		media.NewLocalStore(appCnf.MediaDir, appCnf.MediaURL),
+		repo.NewTransactor(db),
+	)
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/config"
+	database "github.com/jsiqbal/ecommerce/db"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/repo"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+	"github.com/jsiqbal/ecommerce/worker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workerConcurrency int
+	scheduleInterval  time.Duration
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "runs the background jobs",
+	RunE:  runWorker,
+}
+
+func init() {
+	workerCmd.Flags().IntVar(&workerConcurrency, "concurrency", 4, "number of jobs to run at the same time")
+	workerCmd.Flags().DurationVar(&scheduleInterval, "schedule-interval", 30*time.Second, "how often scheduled product publishes and unpublishes are run, 0 to not run them")
+}
+
+func runWorker(cmd *cobra.Command, args []string) error {
+	dbCnf := config.GetDB()
+
+	// connect to db
+	db, err := database.Connect(dbCnf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	svc := newService(db)
+
+	pool := worker.NewPool(repo.NewJobRepo(db), workerConcurrency)
+	registerJobHandlers(pool, svc)
+
+	// stop claiming jobs on SIGINT or SIGTERM, the running ones are finished first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("worker started with %d workers\n", workerConcurrency)
+
+	var wg sync.WaitGroup
+
+	if scheduleInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runScheduler(ctx, svc)
+		}()
+	}
+
+	pool.Run(ctx)
+	wg.Wait()
+
+	log.Println("worker stopped")
+
+	return nil
+}
+
+// runScheduler publishes and unpublishes the products whose time came, every schedule
+// interval until ctx is done
+func runScheduler(ctx context.Context, svc service.Service) {
+	ctx = service.WithActor(ctx, "worker:scheduler")
+
+	worker.Every(ctx, scheduleInterval, "product schedule", func(ctx context.Context) error {
+		// every run is traced on its own, so are the events it raises
+		ctx = logger.WithTraceID(ctx, "")
+
+		changed, err := svc.RunSchedule(ctx, util.GetCurrentTimestamp())
+		if changed > 0 {
+			log.Printf("published or unpublished %d scheduled products\n", changed)
+		}
+
+		return err
+	})
+}
+
+// registerJobHandlers maps every job type to the service doing the work
+func registerJobHandlers(pool *worker.Pool, svc service.Service) {
+	worker.Register(pool, service.JobTypeProductImport, func(ctx context.Context, payload service.ImportJobPayload) error {
+		if _, err := svc.RunImport(ctx, payload.ImportJobID, payload.Rows); err != nil {
+			if err := svc.FailImportJob(ctx, payload.ImportJobID, err); err != nil {
+				log.Println("can not mark import job failed: ", err)
+			}
+
+			return err
+		}
+
+		return nil
+	})
+
+	// the last attempt marks the delivery failed, so the handler needs the attempts of the job
+	pool.Handle(service.JobTypeWebhookDelivery, func(ctx context.Context, job *service.Job) error {
+		var payload service.WebhookDeliveryPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return err
+		}
+
+		return svc.DeliverWebhook(ctx, payload.DeliveryID, job.Attempts >= job.MaxAttempts)
+	})
+
+	// a dead job fails the import or delivery it works on, which would otherwise stay
+	// running or retrying when the worker of its last attempt went away
+	pool.OnDead(service.JobTypeProductImport, func(ctx context.Context, job *service.Job) {
+		var payload service.ImportJobPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			log.Println("can not decode dead import job: ", err)
+			return
+		}
+
+		if err := svc.FailImportJob(ctx, payload.ImportJobID, errors.New(job.LastError)); err != nil {
+			log.Println("can not mark import job failed: ", err)
+		}
+	})
+
+	pool.OnDead(service.JobTypeWebhookDelivery, func(ctx context.Context, job *service.Job) {
+		var payload service.WebhookDeliveryPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			log.Println("can not decode dead webhook delivery job: ", err)
+			return
+		}
+
+		if err := svc.FailWebhookDelivery(ctx, payload.DeliveryID, errors.New(job.LastError)); err != nil {
+			log.Println("can not mark webhook delivery failed: ", err)
+		}
+	})
+}
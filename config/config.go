@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -13,10 +14,21 @@ var dbOnce = sync.Once{}
 
 // Application holds application config
 type Application struct {
-	Env             string `mapstructure:"ENV"`
-	ServerAddress   string `mapstructure:"SERVER_ADDRESS"`
-	IsLoggingToFile bool   `mapstructure:"IS_LOGGING_TO_FILE"`
-	LogFilePath     string `mapstructure:"LOG_FILE_PATH"`
+	Env               string `mapstructure:"ENV"`
+	ServerAddress     string `mapstructure:"SERVER_ADDRESS"`
+	GrpcServerAddress string `mapstructure:"GRPC_SERVER_ADDRESS"`
+	IsLoggingToFile   bool   `mapstructure:"IS_LOGGING_TO_FILE"`
+	LogFilePath       string `mapstructure:"LOG_FILE_PATH"`
+	AuthSecret        string `mapstructure:"AUTH_SECRET"`
+	AdminAPIKey       string `mapstructure:"ADMIN_API_KEY"`
+	FeedTitle         string `mapstructure:"FEED_TITLE"`
+	FeedLink          string `mapstructure:"FEED_LINK"`
+	FeedCurrency      string `mapstructure:"FEED_CURRENCY"`
+	MediaDir          string `mapstructure:"MEDIA_DIR"`
+	MediaURL          string `mapstructure:"MEDIA_URL"`
+
+	// SupplierTokenTTL is how long a supplier portal token is valid for
+	SupplierTokenTTL time.Duration `mapstructure:"SUPPLIER_TOKEN_TTL"`
 }
 
 // DB holds database config
@@ -28,6 +40,35 @@ type DB struct {
 	DBName   string `mapstructure:"DB_NAME"`
 }
 
+// redacted replaces a secret in the printed config
+const redacted = "[REDACTED]"
+
+// String prints the config with its secrets masked
+func (a Application) String() string {
+	type application Application
+	a.AuthSecret = mask(a.AuthSecret)
+	a.AdminAPIKey = mask(a.AdminAPIKey)
+
+	return fmt.Sprintf("%+v", application(a))
+}
+
+// String prints the config with the password masked
+func (d DB) String() string {
+	type db DB
+	d.Password = mask(d.Password)
+
+	return fmt.Sprintf("%+v", db(d))
+}
+
+// mask hides a secret, an unset one stays empty so that it shows as missing
+func mask(secret string) string {
+	if len(secret) == 0 {
+		return ""
+	}
+
+	return redacted
+}
+
 var appConfig *Application
 var dbConfig *DB
 
@@ -38,12 +79,27 @@ func loadApp() error {
 	}
 
 	viper.AutomaticEnv()
+	viper.SetDefault("GRPC_SERVER_ADDRESS", "0.0.0.0:9090")
+	viper.SetDefault("FEED_TITLE", "Ecommerce")
+	viper.SetDefault("FEED_CURRENCY", "USD")
+	viper.SetDefault("MEDIA_DIR", "uploads")
+	viper.SetDefault("MEDIA_URL", "/media")
+	viper.SetDefault("SUPPLIER_TOKEN_TTL", "24h")
 
 	appConfig = &Application{
-		Env:             viper.GetString("ENV"),
-		ServerAddress:   viper.GetString("SERVER_ADDRESS"),
-		IsLoggingToFile: viper.GetBool("IS_LOGGING_TO_FILE"),
-		LogFilePath:     viper.GetString("LOG_FILE_PATH"),
+		Env:               viper.GetString("ENV"),
+		ServerAddress:     viper.GetString("SERVER_ADDRESS"),
+		GrpcServerAddress: viper.GetString("GRPC_SERVER_ADDRESS"),
+		IsLoggingToFile:   viper.GetBool("IS_LOGGING_TO_FILE"),
+		LogFilePath:       viper.GetString("LOG_FILE_PATH"),
+		AuthSecret:        viper.GetString("AUTH_SECRET"),
+		AdminAPIKey:       viper.GetString("ADMIN_API_KEY"),
+		FeedTitle:         viper.GetString("FEED_TITLE"),
+		FeedLink:          viper.GetString("FEED_LINK"),
+		FeedCurrency:      viper.GetString("FEED_CURRENCY"),
+		MediaDir:          viper.GetString("MEDIA_DIR"),
+		MediaURL:          viper.GetString("MEDIA_URL"),
+		SupplierTokenTTL:  viper.GetDuration("SUPPLIER_TOKEN_TTL"),
 	}
 
 	return nil
@@ -1,6 +1,21 @@
 package db
 
 var DbSchema = `
+	DROP TABLE IF EXISTS slug_redirects;
+	DROP TABLE IF EXISTS answer_upvotes;
+	DROP TABLE IF EXISTS answers;
+	DROP TABLE IF EXISTS question_upvotes;
+	DROP TABLE IF EXISTS questions;
+	DROP TABLE IF EXISTS review_votes;
+	DROP TABLE IF EXISTS reviews;
+	DROP TABLE IF EXISTS webhook_deliveries;
+	DROP TABLE IF EXISTS webhook_subscriptions;
+	DROP TABLE IF EXISTS outbox_events;
+	DROP TABLE IF EXISTS jobs;
+	DROP TABLE IF EXISTS import_jobs;
+	DROP TABLE IF EXISTS status_transitions;
+	DROP TABLE IF EXISTS audit_logs;
+	DROP TABLE IF EXISTS stock_movements;
 	DROP TABLE IF EXISTS product_stocks;
 	DROP TABLE IF EXISTS products;
 	DROP TABLE IF EXISTS brands;
@@ -10,8 +25,20 @@ var DbSchema = `
 	CREATE TABLE IF NOT EXISTS brands (
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 		name VARCHAR(255) NOT NULL,
-		status_id INTEGER NOT NULL,
-		created_at BIGINT NOT NULL
+		slug VARCHAR(100) NOT NULL,
+		seo_title VARCHAR(255),
+		seo_description TEXT,
+		canonical_url VARCHAR(2048),
+		description TEXT,
+		translations JSONB NOT NULL DEFAULT '{}',
+		logo_key VARCHAR(255),
+		logo_url VARCHAR(2048),
+		website VARCHAR(2048),
+		country_of_origin CHAR(2),
+		status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'inactive', 'archived')),
+		created_at BIGINT NOT NULL,
+		deleted_at BIGINT,
+		version BIGINT NOT NULL DEFAULT 1
 	);
 
 	CREATE TABLE IF NOT EXISTS categories (
@@ -19,39 +46,286 @@ var DbSchema = `
 		name VARCHAR(255) NOT NULL,
 		parent_id UUID,
 		sequence INTEGER,
-		status_id INTEGER NOT NULL,
-		created_at BIGINT NOT NULL
+		slug VARCHAR(100) NOT NULL,
+		seo_title VARCHAR(255),
+		seo_description TEXT,
+		canonical_url VARCHAR(2048),
+		translations JSONB NOT NULL DEFAULT '{}',
+		status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'inactive', 'archived')),
+		created_at BIGINT NOT NULL,
+		deleted_at BIGINT,
+		version BIGINT NOT NULL DEFAULT 1
 	);
 
+	CREATE INDEX IF NOT EXISTS categories_parent_idx ON categories (parent_id, sequence);
+
+	-- unlike the names, a slug stays taken while its record is deleted, so the record can be
+	-- restored with its links. the purge frees it, along with the redirects of the record
+	CREATE UNIQUE INDEX IF NOT EXISTS brands_slug_idx ON brands (slug);
+	CREATE UNIQUE INDEX IF NOT EXISTS brands_name_idx ON brands (LOWER(name)) WHERE deleted_at IS NULL;
+
+	CREATE UNIQUE INDEX IF NOT EXISTS categories_slug_idx ON categories (slug);
+	CREATE UNIQUE INDEX IF NOT EXISTS categories_parent_name_idx ON categories (COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), LOWER(name)) WHERE deleted_at IS NULL;
+
 	CREATE TABLE IF NOT EXISTS suppliers (
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 		name VARCHAR(255) NOT NULL,
 		email VARCHAR(255) NOT NULL,
 		phone VARCHAR(20),
-		status_id INTEGER NOT NULL,
+		status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'inactive', 'archived')),
 		is_verified_supplier BOOLEAN NOT NULL,
-		created_at BIGINT NOT NULL
+		created_at BIGINT NOT NULL,
+		deleted_at BIGINT,
+		version BIGINT NOT NULL DEFAULT 1
 	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS suppliers_email_idx ON suppliers (LOWER(email)) WHERE deleted_at IS NULL;
 	
 	CREATE TABLE IF NOT EXISTS products (
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 		name VARCHAR(255) NOT NULL,
 		description TEXT,
 		specifications TEXT,
+		translations JSONB NOT NULL DEFAULT '{}',
 		brand_id UUID REFERENCES brands(id) NOT NULL,
 		category_id UUID REFERENCES categories(id) NOT NULL,
 		supplier_id UUID REFERENCES suppliers(id) NOT NULL,
 		unit_price NUMERIC NOT NULL,
 		discount_price NUMERIC,
 		tags VARCHAR(255)[],
-		status_id INTEGER NOT NULL,
-		created_at BIGINT NOT NULL
+		slug VARCHAR(100) NOT NULL,
+		seo_title VARCHAR(255),
+		seo_description TEXT,
+		canonical_url VARCHAR(2048),
+		status VARCHAR(20) NOT NULL CHECK (status IN ('draft', 'pending_review', 'active', 'out_of_stock', 'discontinued', 'archived')),
+		publish_at BIGINT NOT NULL DEFAULT 0,
+		unpublish_at BIGINT NOT NULL DEFAULT 0 CHECK (unpublish_at = 0 OR unpublish_at > publish_at),
+		rating_count INTEGER NOT NULL DEFAULT 0,
+		rating_sum BIGINT NOT NULL DEFAULT 0,
+		rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0,
+		created_at BIGINT NOT NULL,
+		deleted_at BIGINT,
+		version BIGINT NOT NULL DEFAULT 1
 	);
 
+	CREATE UNIQUE INDEX IF NOT EXISTS products_slug_idx ON products (slug);
+	CREATE INDEX IF NOT EXISTS products_brand_idx ON products (brand_id);
+	CREATE INDEX IF NOT EXISTS products_created_idx ON products (created_at, id);
+	CREATE INDEX IF NOT EXISTS products_status_idx ON products (status);
+	CREATE INDEX IF NOT EXISTS products_publish_idx ON products (publish_at) WHERE publish_at > 0;
+	CREATE INDEX IF NOT EXISTS products_unpublish_idx ON products (unpublish_at) WHERE unpublish_at > 0;
+	CREATE UNIQUE INDEX IF NOT EXISTS products_supplier_name_idx ON products (supplier_id, name) WHERE deleted_at IS NULL;
+	CREATE INDEX IF NOT EXISTS products_rating_idx ON products (rating_average DESC, rating_count DESC);
+
 	CREATE TABLE IF NOT EXISTS product_stocks (
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 		product_id UUID REFERENCES products(id) NOT NULL,
 		stock_quantity INTEGER NOT NULL,
 		updated_at BIGINT NOT NULL
 	);
+
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		product_id UUID REFERENCES products(id) NOT NULL,
+		quantity INTEGER NOT NULL,
+		reason VARCHAR(20) NOT NULL,
+		unit_price NUMERIC NOT NULL,
+		created_at BIGINT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS audit_logs (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		entity_type VARCHAR(20) NOT NULL,
+		entity_id VARCHAR(64) NOT NULL,
+		action VARCHAR(20) NOT NULL,
+		actor VARCHAR(255) NOT NULL,
+		trace_id VARCHAR(64) NOT NULL,
+		before JSONB,
+		after JSONB,
+		changes JSONB,
+		created_at BIGINT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS audit_logs_entity_idx ON audit_logs (entity_type, entity_id, created_at);
+	CREATE INDEX IF NOT EXISTS audit_logs_actor_idx ON audit_logs (actor, created_at);
+
+	CREATE TABLE IF NOT EXISTS status_transitions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		entity_type VARCHAR(20) NOT NULL,
+		entity_id UUID NOT NULL,
+		from_status VARCHAR(20) NOT NULL,
+		to_status VARCHAR(20) NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		actor VARCHAR(255) NOT NULL,
+		created_at BIGINT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS status_transitions_entity_idx ON status_transitions (entity_type, entity_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS import_jobs (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		file_name VARCHAR(255) NOT NULL,
+		format VARCHAR(10) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		total_rows INTEGER NOT NULL DEFAULT 0,
+		processed_rows INTEGER NOT NULL DEFAULT 0,
+		succeeded_rows INTEGER NOT NULL DEFAULT 0,
+		failed_rows INTEGER NOT NULL DEFAULT 0,
+		errors JSONB NOT NULL DEFAULT '[]',
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		finished_at BIGINT
+	);
+
+	CREATE TABLE IF NOT EXISTS jobs (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		type VARCHAR(50) NOT NULL,
+		payload JSONB NOT NULL DEFAULT '{}',
+		status VARCHAR(20) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL,
+		run_at BIGINT NOT NULL,
+		locked_until BIGINT,
+		last_error TEXT,
+		actor VARCHAR(255) NOT NULL DEFAULT '',
+		trace_id VARCHAR(64) NOT NULL DEFAULT '',
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		finished_at BIGINT
+	);
+
+	CREATE INDEX IF NOT EXISTS jobs_due_idx ON jobs (run_at) WHERE status IN ('queued', 'running');
+
+	CREATE TABLE IF NOT EXISTS outbox_events (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		type VARCHAR(50) NOT NULL,
+		schema_version INTEGER NOT NULL,
+		aggregate_type VARCHAR(20) NOT NULL,
+		aggregate_id VARCHAR(64) NOT NULL,
+		idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+		data JSONB NOT NULL,
+		actor VARCHAR(255) NOT NULL,
+		trace_id VARCHAR(64) NOT NULL DEFAULT '',
+		occurred_at BIGINT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at BIGINT NOT NULL,
+		locked_until BIGINT,
+		last_error TEXT,
+		published_at BIGINT
+	);
+
+	CREATE INDEX IF NOT EXISTS outbox_events_pending_idx ON outbox_events (next_attempt_at) WHERE published_at IS NULL;
+
+	CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		url VARCHAR(2048) NOT NULL,
+		secret VARCHAR(255) NOT NULL,
+		event_types VARCHAR(50)[] NOT NULL,
+		is_active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		subscription_id UUID REFERENCES webhook_subscriptions(id) ON DELETE CASCADE NOT NULL,
+		event_id UUID NOT NULL,
+		event_type VARCHAR(50) NOT NULL,
+		idempotency_key VARCHAR(255) NOT NULL,
+		payload JSONB NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		response_code INTEGER,
+		last_error TEXT,
+		attempt_log JSONB NOT NULL DEFAULT '[]',
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		delivered_at BIGINT,
+		UNIQUE (subscription_id, event_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS slug_redirects (
+		entity_type VARCHAR(20) NOT NULL,
+		slug VARCHAR(100) NOT NULL,
+		entity_id UUID NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (entity_type, slug)
+	);
+
+	CREATE INDEX IF NOT EXISTS slug_redirects_entity_idx ON slug_redirects (entity_type, entity_id);
+
+	CREATE TABLE IF NOT EXISTS reviews (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		product_id UUID REFERENCES products(id) NOT NULL,
+		customer_id VARCHAR(64) NOT NULL,
+		author_name VARCHAR(100) NOT NULL,
+		rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+		title VARCHAR(150) NOT NULL DEFAULT '',
+		body TEXT NOT NULL DEFAULT '',
+		verified_purchase BOOLEAN NOT NULL DEFAULT FALSE,
+		status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'approved', 'rejected')),
+		helpful_count INTEGER NOT NULL DEFAULT 0,
+		unhelpful_count INTEGER NOT NULL DEFAULT 0,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		version BIGINT NOT NULL DEFAULT 1
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS reviews_customer_idx ON reviews (product_id, customer_id);
+	CREATE INDEX IF NOT EXISTS reviews_product_idx ON reviews (product_id, status, created_at);
+	CREATE INDEX IF NOT EXISTS reviews_status_idx ON reviews (status, created_at);
+
+	CREATE TABLE IF NOT EXISTS review_votes (
+		review_id UUID REFERENCES reviews(id) ON DELETE CASCADE NOT NULL,
+		voter_id VARCHAR(64) NOT NULL,
+		helpful BOOLEAN NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (review_id, voter_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS questions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		product_id UUID REFERENCES products(id) NOT NULL,
+		customer_id VARCHAR(64) NOT NULL,
+		author_name VARCHAR(100) NOT NULL,
+		body TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'approved', 'rejected')),
+		upvote_count INTEGER NOT NULL DEFAULT 0,
+		answer_count INTEGER NOT NULL DEFAULT 0,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		version BIGINT NOT NULL DEFAULT 1
+	);
+
+	CREATE INDEX IF NOT EXISTS questions_product_idx ON questions (product_id, status, created_at);
+	CREATE INDEX IF NOT EXISTS questions_status_idx ON questions (status, created_at);
+
+	CREATE TABLE IF NOT EXISTS question_upvotes (
+		question_id UUID REFERENCES questions(id) ON DELETE CASCADE NOT NULL,
+		voter_id VARCHAR(64) NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (question_id, voter_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS answers (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		question_id UUID REFERENCES questions(id) ON DELETE CASCADE NOT NULL,
+		author_type VARCHAR(20) NOT NULL CHECK (author_type IN ('supplier', 'staff')),
+		author_id VARCHAR(64) NOT NULL,
+		author_name VARCHAR(100) NOT NULL,
+		body TEXT NOT NULL,
+		upvote_count INTEGER NOT NULL DEFAULT 0,
+		created_at BIGINT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS answers_question_idx ON answers (question_id);
+
+	CREATE TABLE IF NOT EXISTS answer_upvotes (
+		answer_id UUID REFERENCES answers(id) ON DELETE CASCADE NOT NULL,
+		voter_id VARCHAR(64) NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (answer_id, voter_id)
+	);
 `
@@ -0,0 +1,237 @@
+package feed
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// supported formats
+const (
+	FormatXML = "xml"
+	FormatCSV = "csv"
+)
+
+// availability values of the google merchant spec
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+)
+
+// ConditionNew is the condition of every product of the catalog
+const ConditionNew = "new"
+
+// googleNamespace is the namespace of the g: elements of a google merchant feed
+const googleNamespace = "http://base.google.com/ns/1.0"
+
+var ErrUnsupportedFormat = errors.New("unsupported feed format, use xml or csv")
+
+// Channel describes the shop the feed belongs to
+type Channel struct {
+	Title       string
+	Link        string
+	Description string
+	Currency    string
+}
+
+// Item is a product of the feed
+type Item struct {
+	ID           string
+	Title        string
+	Description  string
+	Link         string
+	Price        float64
+	SalePrice    float64
+	Brand        string
+	Availability string
+	Condition    string
+	ProductType  string
+}
+
+// Writer writes the items of a feed one at a time
+type Writer interface {
+	WriteItem(item *Item) error
+	// Close writes the end of the feed, it does not close the underlying writer
+	Close() error
+}
+
+// FormatOf returns the format of a file by its extension
+func FormatOf(fileName string) (string, error) {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), "."))
+	if format != FormatXML && format != FormatCSV {
+		return "", ErrUnsupportedFormat
+	}
+
+	return format, nil
+}
+
+// ContentType returns the mime type of a format
+func ContentType(format string) string {
+	if format == FormatXML {
+		return "application/xml; charset=utf-8"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+// NewWriter returns a Writer of the format, writing the start of the feed first
+func NewWriter(w io.Writer, format string, channel Channel) (Writer, error) {
+	switch format {
+	case FormatXML:
+		return newXMLWriter(w, channel)
+	case FormatCSV:
+		return newCSVWriter(w, channel)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// price formats an amount the way the merchant spec expects it, e.g. "15.00 USD"
+func price(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// xmlItem is an item of a RSS 2.0 feed with the g: elements of google merchant
+type xmlItem struct {
+	XMLName      xml.Name `xml:"item"`
+	ID           string   `xml:"g:id"`
+	Title        string   `xml:"g:title"`
+	Description  string   `xml:"g:description"`
+	Link         string   `xml:"g:link,omitempty"`
+	Price        string   `xml:"g:price"`
+	SalePrice    string   `xml:"g:sale_price,omitempty"`
+	Brand        string   `xml:"g:brand,omitempty"`
+	Availability string   `xml:"g:availability"`
+	Condition    string   `xml:"g:condition"`
+	ProductType  string   `xml:"g:product_type,omitempty"`
+}
+
+type xmlWriter struct {
+	encoder  *xml.Encoder
+	currency string
+}
+
+func newXMLWriter(w io.Writer, channel Channel) (*xmlWriter, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	start := []xml.Token{
+		xml.StartElement{
+			Name: xml.Name{Local: "rss"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "version"}, Value: "2.0"},
+				{Name: xml.Name{Local: "xmlns:g"}, Value: googleNamespace},
+			},
+		},
+		xml.StartElement{Name: xml.Name{Local: "channel"}},
+	}
+
+	for _, token := range start {
+		if err := encoder.EncodeToken(token); err != nil {
+			return nil, err
+		}
+	}
+
+	elements := []struct {
+		name  string
+		value string
+	}{
+		{"title", channel.Title},
+		{"link", channel.Link},
+		{"description", channel.Description},
+	}
+
+	for _, element := range elements {
+		if err := encoder.EncodeElement(element.value, xml.StartElement{Name: xml.Name{Local: element.name}}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &xmlWriter{
+		encoder:  encoder,
+		currency: channel.Currency,
+	}, nil
+}
+
+func (x *xmlWriter) WriteItem(item *Item) error {
+	xmlItem := xmlItem{
+		ID:           item.ID,
+		Title:        item.Title,
+		Description:  item.Description,
+		Link:         item.Link,
+		Price:        price(item.Price, x.currency),
+		Brand:        item.Brand,
+		Availability: item.Availability,
+		Condition:    item.Condition,
+		ProductType:  item.ProductType,
+	}
+
+	if item.SalePrice > 0 {
+		xmlItem.SalePrice = price(item.SalePrice, x.currency)
+	}
+
+	return x.encoder.Encode(xmlItem)
+}
+
+func (x *xmlWriter) Close() error {
+	for _, name := range []string{"channel", "rss"} {
+		if err := x.encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+
+	return x.encoder.Flush()
+}
+
+// csvHeader holds the attribute names of the merchant spec, in the order of the csv columns
+var csvHeader = []string{"id", "title", "description", "link", "price", "sale_price", "brand", "availability", "condition", "product_type"}
+
+type csvWriter struct {
+	writer   *csv.Writer
+	currency string
+}
+
+func newCSVWriter(w io.Writer, channel Channel) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	return &csvWriter{
+		writer:   writer,
+		currency: channel.Currency,
+	}, nil
+}
+
+func (c *csvWriter) WriteItem(item *Item) error {
+	var salePrice string
+	if item.SalePrice > 0 {
+		salePrice = price(item.SalePrice, c.currency)
+	}
+
+	return c.writer.Write([]string{
+		item.ID,
+		item.Title,
+		item.Description,
+		item.Link,
+		price(item.Price, c.currency),
+		salePrice,
+		item.Brand,
+		item.Availability,
+		item.Condition,
+		item.ProductType,
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// loaderWait is how long a loader collects keys before fetching them as one batch
+const loaderWait = 2 * time.Millisecond
+
+// loaderMaxBatch caps the keys fetched by one batch
+const loaderMaxBatch = 100
+
+// loader batches the keys loaded by concurrently running resolvers into one
+// fetch and caches the outcome for the rest of the request
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending *batch[K, V]
+	loaded  map[K]*batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys   []K
+	values map[K]V
+	err    error
+	done   chan struct{}
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:  fetch,
+		loaded: make(map[K]*batch[K, V]),
+	}
+}
+
+// Load returns the value of the key, the zero value when the fetch found none
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+
+	b, ok := l.loaded[key]
+	if !ok {
+		if l.pending == nil {
+			l.pending = &batch[K, V]{done: make(chan struct{})}
+
+			pending := l.pending
+			time.AfterFunc(loaderWait, func() {
+				l.dispatch(ctx, pending)
+			})
+		}
+
+		b = l.pending
+		b.keys = append(b.keys, key)
+		l.loaded[key] = b
+
+		// a full batch goes right away, the timer finds it dispatched already
+		if len(b.keys) >= loaderMaxBatch {
+			go l.dispatch(ctx, b)
+		}
+	}
+
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+
+	return b.values[key], b.err
+}
+
+func (l *loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+
+	l.pending = nil
+	l.mu.Unlock()
+
+	b.values, b.err = l.fetch(ctx, b.keys)
+	close(b.done)
+}
+
+// loaders are the loaders of a single request
+type loaders struct {
+	category *loader[string, *service.Category]
+	children *loader[string, []service.Category]
+}
+
+type loadersKey struct{}
+
+// WithLoaders returns a ctx carrying fresh loaders, every request needs its own
+// so that nothing is cached across requests
+func WithLoaders(ctx context.Context, svc service.Service) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		category: newLoader(func(ctx context.Context, ids []string) (map[string]*service.Category, error) {
+			ctgries, err := svc.GetCategoriesByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := make(map[string]*service.Category, len(ctgries))
+			for i := range ctgries {
+				byID[ctgries[i].ID] = &ctgries[i]
+			}
+
+			return byID, nil
+		}),
+		children: newLoader(func(ctx context.Context, parentIDs []string) (map[string][]service.Category, error) {
+			ctgries, err := svc.GetChildCategories(ctx, parentIDs)
+			if err != nil {
+				return nil, err
+			}
+
+			byParent := make(map[string][]service.Category, len(parentIDs))
+			for _, ctgry := range ctgries {
+				byParent[ctgry.ParentID] = append(byParent[ctgry.ParentID], ctgry)
+			}
+
+			return byParent, nil
+		}),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,730 @@
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+// maxParallelism is how many resolvers of a request run at once, the loaders
+// batch the keys of the resolvers running together
+const maxParallelism = 50
+
+// maxLimit caps the page size of the lists, as on the rest api
+const maxLimit = 100
+
+// NewSchema parses the schema, resolved by the service. every request must
+// carry its own loaders, see WithLoaders
+func NewSchema(svc service.Service) (*graphql.Schema, error) {
+	return graphql.ParseSchema(schemaString, &resolver{svc: svc}, graphql.MaxParallelism(maxParallelism))
+}
+
+type resolver struct {
+	svc service.Service
+}
+
+// errUnauthorized is the error of a mutation, or a query for the products the storefront
+// hides or for soft deleted records, without the admin api key
+var errUnauthorized = apperr.Unauthorized("unauthorized", "Unauthorized")
+
+type adminKey struct{}
+
+// WithAdmin returns a ctx telling whether the request carries the admin api key of the
+// catalog team, which changes the catalog, previews products and lists the hidden ones
+func WithAdmin(ctx context.Context, admin bool) context.Context {
+	return context.WithValue(ctx, adminKey{}, admin)
+}
+
+func isAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey{}).(bool)
+	return admin
+}
+
+type idArgs struct {
+	ID graphql.ID
+}
+
+type pageArgs struct {
+	Page           int32
+	Limit          int32
+	IncludeDeleted bool
+}
+
+type productArgs struct {
+	ID      graphql.ID
+	Preview bool
+}
+
+type productsArgs struct {
+	Filter *productFilter
+	Page   int32
+	Limit  int32
+}
+
+//------------------------QUERIES------------------------
+
+func (r *resolver) Brand(ctx context.Context, args idArgs) (*brandResolver, error) {
+	brand, err := r.svc.GetBrand(ctx, string(args.ID))
+	if err != nil {
+		return nil, nullIfNotFound(err)
+	}
+
+	return &brandResolver{brand: *brand}, nil
+}
+
+func (r *resolver) Brands(ctx context.Context, args pageArgs) (*pageResolver[*brandResolver], error) {
+	if err := validatePage(args.Page, args.Limit); err != nil {
+		return nil, err
+	}
+
+	if args.IncludeDeleted && !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	result, err := r.svc.GetBrands(ctx, int64(args.Page), int64(args.Limit), args.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &pageResolver[*brandResolver]{items: []*brandResolver{}, total: result.Total, page: result.Page, limit: result.Limit}
+	for _, brand := range result.Brands {
+		page.items = append(page.items, &brandResolver{brand: brand})
+	}
+
+	return page, nil
+}
+
+func (r *resolver) Category(ctx context.Context, args idArgs) (*categoryResolver, error) {
+	ctgry, err := r.svc.GetCategory(ctx, string(args.ID))
+	if err != nil {
+		return nil, nullIfNotFound(err)
+	}
+
+	return &categoryResolver{ctgry: *ctgry}, nil
+}
+
+func (r *resolver) Categories(ctx context.Context, args pageArgs) (*pageResolver[*categoryResolver], error) {
+	if err := validatePage(args.Page, args.Limit); err != nil {
+		return nil, err
+	}
+
+	if args.IncludeDeleted && !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	result, err := r.svc.GetCategories(ctx, int64(args.Page), int64(args.Limit), args.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &pageResolver[*categoryResolver]{items: []*categoryResolver{}, total: result.Total, page: result.Page, limit: result.Limit}
+	for _, ctgry := range result.Categories {
+		page.items = append(page.items, &categoryResolver{ctgry: ctgry})
+	}
+
+	return page, nil
+}
+
+func (r *resolver) Supplier(ctx context.Context, args idArgs) (*supplierResolver, error) {
+	spplr, err := r.svc.GetSupplier(ctx, string(args.ID))
+	if err != nil {
+		return nil, nullIfNotFound(err)
+	}
+
+	return &supplierResolver{spplr: *spplr}, nil
+}
+
+func (r *resolver) Suppliers(ctx context.Context, args pageArgs) (*pageResolver[*supplierResolver], error) {
+	if err := validatePage(args.Page, args.Limit); err != nil {
+		return nil, err
+	}
+
+	if args.IncludeDeleted && !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	result, err := r.svc.GetSuppliers(ctx, int64(args.Page), int64(args.Limit), args.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &pageResolver[*supplierResolver]{items: []*supplierResolver{}, total: result.Total, page: result.Page, limit: result.Limit}
+	for _, spplr := range result.Suppliers {
+		page.items = append(page.items, &supplierResolver{spplr: spplr})
+	}
+
+	return page, nil
+}
+
+func (r *resolver) Product(ctx context.Context, args productArgs) (*productResolver, error) {
+	if args.Preview && !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	return r.product(ctx, string(args.ID), args.Preview)
+}
+
+// product returns the product with the id, the live and published one unless previewed
+func (r *resolver) product(ctx context.Context, productID string, preview bool) (*productResolver, error) {
+	var product *service.Product
+	var err error
+	if preview {
+		product, err = r.svc.GetProduct(ctx, productID)
+	} else {
+		product, err = r.svc.GetPublishedProduct(ctx, productID, util.GetCurrentTimestamp())
+	}
+
+	if err != nil {
+		return nil, nullIfNotFound(err)
+	}
+
+	product.Localize(service.GetLocales(ctx))
+	return &productResolver{product: *product}, nil
+}
+
+func (r *resolver) Products(ctx context.Context, args productsArgs) (*pageResolver[*productResolver], error) {
+	if err := validatePage(args.Page, args.Limit); err != nil {
+		return nil, err
+	}
+
+	filterParams := service.FilterProductsParams{
+		Page:  int64(args.Page),
+		Limit: int64(args.Limit),
+	}
+
+	if filter := args.Filter; filter != nil {
+		if filter.Name != nil {
+			filterParams.Name = *filter.Name
+		}
+
+		if filter.MinPrice != nil {
+			filterParams.MinPrice = *filter.MinPrice
+		}
+
+		if filter.MaxPrice != nil {
+			filterParams.MaxPrice = *filter.MaxPrice
+		}
+
+		if filter.BrandIds != nil {
+			for _, brandID := range *filter.BrandIds {
+				filterParams.BrandIDs = append(filterParams.BrandIDs, string(brandID))
+			}
+		}
+
+		if filter.CategoryId != nil {
+			filterParams.CategoryID = string(*filter.CategoryId)
+		}
+
+		if filter.SupplierId != nil {
+			filterParams.SupplierID = string(*filter.SupplierId)
+		}
+
+		if filter.IsVerifiedSupplier != nil {
+			filterParams.IsVerifiedSupplier = *filter.IsVerifiedSupplier
+		}
+
+		if filter.Statuses != nil {
+			for _, status := range *filter.Statuses {
+				if !service.IsProductStatus(status) {
+					return nil, &service.ValidationError{Field: "statuses", Message: "is not a product status"}
+				}
+			}
+
+			filterParams.Statuses = *filter.Statuses
+		}
+
+		if filter.MinRating != nil {
+			if *filter.MinRating < 0 || *filter.MinRating > 5 {
+				return nil, &service.ValidationError{Field: "minRating", Message: "must be between 0 and 5"}
+			}
+
+			filterParams.MinRating = *filter.MinRating
+		}
+
+		if filter.Sort != nil {
+			if *filter.Sort != service.ProductSortPrice && *filter.Sort != service.ProductSortRating {
+				return nil, &service.ValidationError{Field: "sort", Message: "must be price or rating"}
+			}
+
+			filterParams.Sort = *filter.Sort
+		}
+
+		if filter.IncludeDeleted != nil {
+			filterParams.IncludeDeleted = *filter.IncludeDeleted
+		}
+	}
+
+	// the products the storefront hides are for the catalog team
+	if (len(filterParams.Statuses) > 0 || filterParams.IncludeDeleted) && !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	result, err := r.svc.GetProducts(ctx, filterParams)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &pageResolver[*productResolver]{items: []*productResolver{}, total: result.Total, page: result.Page, limit: result.Limit}
+	locales := service.GetLocales(ctx)
+	for _, product := range result.Products {
+		product.Localize(locales)
+		page.items = append(page.items, &productResolver{product: product})
+	}
+
+	return page, nil
+}
+
+//------------------------MUTATIONS------------------------
+
+// the mutations run as a bulk request of a single operation, so they go
+// through the same checks of references and names as the bulk endpoints. like
+// the rest api they are for the catalog team only
+
+func (r *resolver) CreateBrand(ctx context.Context, args struct{ Input brandInput }) (*brandResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	brand := args.Input.brand(0)
+	if err := service.ValidateBrand(brand); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(r.svc.BulkBrands(ctx, service.BulkModeAtomic, []service.BulkBrandOp{{
+		Op:    service.BulkOpCreate,
+		Brand: brand,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &brandResolver{brand: *data.(*service.Brand)}, nil
+}
+
+func (r *resolver) UpdateBrand(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+	Input   brandInput
+}) (*brandResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	brand := args.Input.brand(args.Version)
+	if err := service.ValidateBrand(brand); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(r.svc.BulkBrands(ctx, service.BulkModeAtomic, []service.BulkBrandOp{{
+		Op:    service.BulkOpUpdate,
+		ID:    string(args.ID),
+		Brand: brand,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &brandResolver{brand: *data.(*service.Brand)}, nil
+}
+
+func (r *resolver) DeleteBrand(ctx context.Context, args idArgs) (graphql.ID, error) {
+	if !isAdmin(ctx) {
+		return "", errUnauthorized
+	}
+
+	_, err := bulkOutcome(r.svc.BulkBrands(ctx, service.BulkModeAtomic, []service.BulkBrandOp{{
+		Op: service.BulkOpDelete,
+		ID: string(args.ID),
+	}}))
+	if err != nil {
+		return "", err
+	}
+
+	return args.ID, nil
+}
+
+func (r *resolver) RestoreBrand(ctx context.Context, args idArgs) (*brandResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	if err := r.svc.RestoreBrand(ctx, string(args.ID)); err != nil {
+		return nil, err
+	}
+
+	return r.Brand(ctx, args)
+}
+
+func (r *resolver) CreateCategory(ctx context.Context, args struct{ Input categoryInput }) (*categoryResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	ctgry := args.Input.category(0)
+	if err := service.ValidateCategory(ctgry); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(r.svc.BulkCategories(ctx, service.BulkModeAtomic, []service.BulkCategoryOp{{
+		Op:       service.BulkOpCreate,
+		Category: ctgry,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &categoryResolver{ctgry: *data.(*service.Category)}, nil
+}
+
+func (r *resolver) UpdateCategory(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+	Input   categoryInput
+}) (*categoryResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	ctgry := args.Input.category(args.Version)
+	if err := service.ValidateCategory(ctgry); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(r.svc.BulkCategories(ctx, service.BulkModeAtomic, []service.BulkCategoryOp{{
+		Op:       service.BulkOpUpdate,
+		ID:       string(args.ID),
+		Category: ctgry,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &categoryResolver{ctgry: *data.(*service.Category)}, nil
+}
+
+func (r *resolver) DeleteCategory(ctx context.Context, args idArgs) (graphql.ID, error) {
+	if !isAdmin(ctx) {
+		return "", errUnauthorized
+	}
+
+	_, err := bulkOutcome(r.svc.BulkCategories(ctx, service.BulkModeAtomic, []service.BulkCategoryOp{{
+		Op: service.BulkOpDelete,
+		ID: string(args.ID),
+	}}))
+	if err != nil {
+		return "", err
+	}
+
+	return args.ID, nil
+}
+
+func (r *resolver) RestoreCategory(ctx context.Context, args idArgs) (*categoryResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	if err := r.svc.RestoreCategory(ctx, string(args.ID)); err != nil {
+		return nil, err
+	}
+
+	return r.Category(ctx, args)
+}
+
+func (r *resolver) CreateSupplier(ctx context.Context, args struct{ Input supplierInput }) (*supplierResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	spplr := args.Input.supplier(0)
+	if err := service.ValidateSupplier(spplr); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(r.svc.BulkSuppliers(ctx, service.BulkModeAtomic, []service.BulkSupplierOp{{
+		Op:       service.BulkOpCreate,
+		Supplier: spplr,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &supplierResolver{spplr: *data.(*service.Supplier)}, nil
+}
+
+func (r *resolver) UpdateSupplier(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+	Input   supplierInput
+}) (*supplierResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	spplr := args.Input.supplier(args.Version)
+	if err := service.ValidateSupplier(spplr); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(r.svc.BulkSuppliers(ctx, service.BulkModeAtomic, []service.BulkSupplierOp{{
+		Op:       service.BulkOpUpdate,
+		ID:       string(args.ID),
+		Supplier: spplr,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &supplierResolver{spplr: *data.(*service.Supplier)}, nil
+}
+
+func (r *resolver) DeleteSupplier(ctx context.Context, args idArgs) (graphql.ID, error) {
+	if !isAdmin(ctx) {
+		return "", errUnauthorized
+	}
+
+	_, err := bulkOutcome(r.svc.BulkSuppliers(ctx, service.BulkModeAtomic, []service.BulkSupplierOp{{
+		Op: service.BulkOpDelete,
+		ID: string(args.ID),
+	}}))
+	if err != nil {
+		return "", err
+	}
+
+	return args.ID, nil
+}
+
+func (r *resolver) RestoreSupplier(ctx context.Context, args idArgs) (*supplierResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	if err := r.svc.RestoreSupplier(ctx, string(args.ID)); err != nil {
+		return nil, err
+	}
+
+	return r.Supplier(ctx, args)
+}
+
+func (r *resolver) CreateProduct(ctx context.Context, args struct{ Input productInput }) (*productResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	product := args.Input.product(0)
+	if err := service.ValidateProduct(product); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(r.svc.BulkProducts(ctx, service.BulkModeAtomic, []service.BulkProductOp{{
+		Op:      service.BulkOpCreate,
+		Product: product,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &productResolver{product: *data.(*service.Product)}, nil
+}
+
+func (r *resolver) UpdateProduct(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+	Input   productInput
+}) (*productResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	product := args.Input.product(args.Version)
+	if err := service.ValidateProduct(product); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(r.svc.BulkProducts(ctx, service.BulkModeAtomic, []service.BulkProductOp{{
+		Op:      service.BulkOpUpdate,
+		ID:      string(args.ID),
+		Product: product,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &productResolver{product: *data.(*service.Product)}, nil
+}
+
+func (r *resolver) DeleteProduct(ctx context.Context, args idArgs) (graphql.ID, error) {
+	if !isAdmin(ctx) {
+		return "", errUnauthorized
+	}
+
+	_, err := bulkOutcome(r.svc.BulkProducts(ctx, service.BulkModeAtomic, []service.BulkProductOp{{
+		Op: service.BulkOpDelete,
+		ID: string(args.ID),
+	}}))
+	if err != nil {
+		return "", err
+	}
+
+	return args.ID, nil
+}
+
+func (r *resolver) RestoreProduct(ctx context.Context, args idArgs) (*productResolver, error) {
+	if !isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+
+	if err := r.svc.RestoreProduct(ctx, string(args.ID)); err != nil {
+		return nil, err
+	}
+
+	return r.product(ctx, string(args.ID), true)
+}
+
+// bulkOutcome returns the data of the single operation of a bulk request, or why it failed
+func bulkOutcome(result *service.BulkResult, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	item := result.Items[0]
+	if item.Status != service.BulkStatusSucceeded {
+		return nil, item.Err
+	}
+
+	return item.Data, nil
+}
+
+// nullIfNotFound lets a query for a record which does not exist answer null rather than
+// an error, as graphql clients expect
+func nullIfNotFound(err error) error {
+	if apperr.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+//------------------------INPUTS------------------------
+
+// the records are held to the same rules as the bodies of the rest api, see service.ValidateBrand and co
+
+func validatePage(page, limit int32) error {
+	if page < 1 {
+		return &service.ValidationError{Field: "page", Message: "must be at least 1"}
+	}
+
+	if limit < 1 || limit > maxLimit {
+		return &service.ValidationError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", maxLimit)}
+	}
+
+	return nil
+}
+
+func (in *brandInput) brand(version int32) *service.Brand {
+	brand := &service.Brand{
+		Name:      in.Name,
+		CreatedAt: util.GetCurrentTimestamp(),
+		Version:   int64(version),
+	}
+
+	if in.Description != nil {
+		brand.Description = *in.Description
+	}
+
+	if in.Website != nil {
+		brand.Website = *in.Website
+	}
+
+	if in.CountryOfOrigin != nil {
+		brand.CountryOfOrigin = *in.CountryOfOrigin
+	}
+
+	if in.Status != nil {
+		brand.Status = *in.Status
+	}
+
+	return brand
+}
+
+func (in *categoryInput) category(version int32) *service.Category {
+	ctgry := &service.Category{
+		Name:      in.Name,
+		CreatedAt: util.GetCurrentTimestamp(),
+		Version:   int64(version),
+	}
+
+	if in.ParentId != nil {
+		ctgry.ParentID = string(*in.ParentId)
+	}
+
+	if in.Sequence != nil {
+		ctgry.Sequence = *in.Sequence
+	}
+
+	if in.Status != nil {
+		ctgry.Status = *in.Status
+	}
+
+	return ctgry
+}
+
+func (in *supplierInput) supplier(version int32) *service.Supplier {
+	spplr := &service.Supplier{
+		Name:               in.Name,
+		Email:              in.Email,
+		Phone:              in.Phone,
+		IsVerifiedSupplier: in.IsVerifiedSupplier,
+		CreatedAt:          util.GetCurrentTimestamp(),
+		Version:            int64(version),
+	}
+
+	if in.Status != nil {
+		spplr.Status = *in.Status
+	}
+
+	return spplr
+}
+
+func (in *productInput) product(version int32) *service.Product {
+	product := &service.Product{
+		Name:          in.Name,
+		Description:   in.Description,
+		Brand:         service.Brand{ID: string(in.BrandId)},
+		Category:      service.Category{ID: string(in.CategoryId)},
+		Supplier:      service.Supplier{ID: string(in.SupplierId)},
+		UnitPrice:     in.UnitPrice,
+		DiscountPrice: in.DiscountPrice,
+		Tags:          in.Tags,
+		ProductStock: service.ProductStock{
+			StockQuantity: int64(in.StockQuantity),
+		},
+		CreatedAt: util.GetCurrentTimestamp(),
+		Version:   int64(version),
+	}
+
+	if in.Specifications != nil {
+		product.Specifications = *in.Specifications
+	}
+
+	if in.Status != nil {
+		product.Status = *in.Status
+	}
+
+	if in.PublishAt != nil {
+		product.PublishAt = int64(*in.PublishAt)
+	}
+
+	if in.UnpublishAt != nil {
+		product.UnpublishAt = int64(*in.UnpublishAt)
+	}
+
+	return product
+}
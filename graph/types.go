@@ -0,0 +1,276 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// maxCategoryDepth bounds the walk up a category tree, in case of a cycle
+const maxCategoryDepth = 32
+
+// Timestamp is a unix timestamp in milliseconds, which does not fit the 32 bit Int of graphql
+type Timestamp int64
+
+func (Timestamp) ImplementsGraphQLType(name string) bool {
+	return name == "Timestamp"
+}
+
+func (t *Timestamp) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case int32:
+		*t = Timestamp(input)
+	case int64:
+		*t = Timestamp(input)
+	case float64:
+		*t = Timestamp(input)
+	default:
+		return fmt.Errorf("wrong type for Timestamp: %T", input)
+	}
+
+	return nil
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int64(t))
+}
+
+// optionalTimestamp maps an unset timestamp to null
+func optionalTimestamp(t int64) *Timestamp {
+	if t == 0 {
+		return nil
+	}
+
+	ts := Timestamp(t)
+	return &ts
+}
+
+// optionalString maps an empty string to null
+func optionalString(s string) *string {
+	if len(s) == 0 {
+		return nil
+	}
+
+	return &s
+}
+
+type seoResolver struct {
+	seo service.SEO
+}
+
+func (r *seoResolver) Title() string        { return r.seo.Title }
+func (r *seoResolver) Description() string  { return r.seo.Description }
+func (r *seoResolver) CanonicalURL() string { return r.seo.CanonicalURL }
+
+type brandResolver struct {
+	brand service.Brand
+}
+
+func (r *brandResolver) ID() graphql.ID           { return graphql.ID(r.brand.ID) }
+func (r *brandResolver) Name() string             { return r.brand.Name }
+func (r *brandResolver) Slug() string             { return r.brand.Slug }
+func (r *brandResolver) SEO() *seoResolver        { return &seoResolver{seo: r.brand.SEO} }
+func (r *brandResolver) Description() *string     { return optionalString(r.brand.Description) }
+func (r *brandResolver) LogoURL() *string         { return optionalString(r.brand.LogoURL) }
+func (r *brandResolver) Website() *string         { return optionalString(r.brand.Website) }
+func (r *brandResolver) CountryOfOrigin() *string { return optionalString(r.brand.CountryOfOrigin) }
+func (r *brandResolver) Status() string           { return r.brand.Status }
+func (r *brandResolver) CreatedAt() Timestamp     { return Timestamp(r.brand.CreatedAt) }
+func (r *brandResolver) DeletedAt() *Timestamp    { return optionalTimestamp(r.brand.DeletedAt) }
+func (r *brandResolver) Version() int32           { return int32(r.brand.Version) }
+
+type categoryResolver struct {
+	ctgry service.Category
+}
+
+func (r *categoryResolver) ID() graphql.ID        { return graphql.ID(r.ctgry.ID) }
+func (r *categoryResolver) Name() string          { return r.ctgry.Name }
+func (r *categoryResolver) Slug() string          { return r.ctgry.Slug }
+func (r *categoryResolver) SEO() *seoResolver     { return &seoResolver{seo: r.ctgry.SEO} }
+func (r *categoryResolver) Status() string        { return r.ctgry.Status }
+func (r *categoryResolver) CreatedAt() Timestamp  { return Timestamp(r.ctgry.CreatedAt) }
+func (r *categoryResolver) DeletedAt() *Timestamp { return optionalTimestamp(r.ctgry.DeletedAt) }
+func (r *categoryResolver) Version() int32        { return int32(r.ctgry.Version) }
+
+func (r *categoryResolver) Sequence() *string {
+	if len(r.ctgry.Sequence) == 0 {
+		return nil
+	}
+
+	return &r.ctgry.Sequence
+}
+
+func (r *categoryResolver) Parent(ctx context.Context) (*categoryResolver, error) {
+	if len(r.ctgry.ParentID) == 0 {
+		return nil, nil
+	}
+
+	parent, err := loadersFrom(ctx).category.Load(ctx, r.ctgry.ParentID)
+	if err != nil || parent == nil {
+		return nil, err
+	}
+
+	return &categoryResolver{ctgry: *parent}, nil
+}
+
+func (r *categoryResolver) Children(ctx context.Context) ([]*categoryResolver, error) {
+	children, err := loadersFrom(ctx).children.Load(ctx, r.ctgry.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := []*categoryResolver{}
+	for _, child := range children {
+		resolvers = append(resolvers, &categoryResolver{ctgry: child})
+	}
+
+	return resolvers, nil
+}
+
+func (r *categoryResolver) Ancestors(ctx context.Context) ([]*categoryResolver, error) {
+	var ancestors []*categoryResolver
+
+	current := r
+	for depth := 0; depth < maxCategoryDepth; depth++ {
+		parent, err := current.Parent(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if parent == nil {
+			break
+		}
+
+		// root first
+		ancestors = append([]*categoryResolver{parent}, ancestors...)
+		current = parent
+	}
+
+	if ancestors == nil {
+		ancestors = []*categoryResolver{}
+	}
+
+	return ancestors, nil
+}
+
+type supplierResolver struct {
+	spplr service.Supplier
+}
+
+func (r *supplierResolver) ID() graphql.ID           { return graphql.ID(r.spplr.ID) }
+func (r *supplierResolver) Name() string             { return r.spplr.Name }
+func (r *supplierResolver) Email() string            { return r.spplr.Email }
+func (r *supplierResolver) Phone() string            { return r.spplr.Phone }
+func (r *supplierResolver) Status() string           { return r.spplr.Status }
+func (r *supplierResolver) IsVerifiedSupplier() bool { return r.spplr.IsVerifiedSupplier }
+func (r *supplierResolver) CreatedAt() Timestamp     { return Timestamp(r.spplr.CreatedAt) }
+func (r *supplierResolver) DeletedAt() *Timestamp    { return optionalTimestamp(r.spplr.DeletedAt) }
+func (r *supplierResolver) Version() int32           { return int32(r.spplr.Version) }
+
+// productResolver serves the brand, category and supplier the product was loaded with
+type productResolver struct {
+	product service.Product
+}
+
+func (r *productResolver) ID() graphql.ID         { return graphql.ID(r.product.ID) }
+func (r *productResolver) Name() string           { return r.product.Name }
+func (r *productResolver) Slug() string           { return r.product.Slug }
+func (r *productResolver) SEO() *seoResolver      { return &seoResolver{seo: r.product.SEO} }
+func (r *productResolver) Description() string    { return r.product.Description }
+func (r *productResolver) Specifications() string { return r.product.Specifications }
+func (r *productResolver) Brand() *brandResolver  { return &brandResolver{brand: r.product.Brand} }
+func (r *productResolver) Category() *categoryResolver {
+	return &categoryResolver{ctgry: r.product.Category}
+}
+func (r *productResolver) Supplier() *supplierResolver {
+	return &supplierResolver{spplr: r.product.Supplier}
+}
+func (r *productResolver) UnitPrice() float64     { return r.product.UnitPrice }
+func (r *productResolver) DiscountPrice() float64 { return r.product.DiscountPrice }
+func (r *productResolver) Status() string         { return r.product.Status }
+func (r *productResolver) StockQuantity() int32   { return int32(r.product.ProductStock.StockQuantity) }
+func (r *productResolver) PublishAt() *Timestamp  { return optionalTimestamp(r.product.PublishAt) }
+func (r *productResolver) UnpublishAt() *Timestamp {
+	return optionalTimestamp(r.product.UnpublishAt)
+}
+func (r *productResolver) RatingAverage() float64 { return r.product.RatingAverage }
+func (r *productResolver) RatingCount() int32     { return int32(r.product.RatingCount) }
+func (r *productResolver) CreatedAt() Timestamp   { return Timestamp(r.product.CreatedAt) }
+func (r *productResolver) DeletedAt() *Timestamp  { return optionalTimestamp(r.product.DeletedAt) }
+func (r *productResolver) Version() int32         { return int32(r.product.Version) }
+
+func (r *productResolver) Tags() []string {
+	if r.product.Tags == nil {
+		return []string{}
+	}
+
+	return r.product.Tags
+}
+
+type pageResolver[T any] struct {
+	items []T
+	total int64
+	page  int64
+	limit int64
+}
+
+func (r *pageResolver[T]) Items() []T   { return r.items }
+func (r *pageResolver[T]) Total() int32 { return int32(r.total) }
+func (r *pageResolver[T]) Page() int32  { return int32(r.page) }
+func (r *pageResolver[T]) Limit() int32 { return int32(r.limit) }
+
+type productFilter struct {
+	Name               *string
+	MinPrice           *float64
+	MaxPrice           *float64
+	BrandIds           *[]graphql.ID
+	CategoryId         *graphql.ID
+	SupplierId         *graphql.ID
+	IsVerifiedSupplier *bool
+	Statuses           *[]string
+	MinRating          *float64
+	Sort               *string
+	IncludeDeleted     *bool
+}
+
+type brandInput struct {
+	Name            string
+	Description     *string
+	Website         *string
+	CountryOfOrigin *string
+	Status          *string
+}
+
+type categoryInput struct {
+	Name     string
+	ParentId *graphql.ID
+	Sequence *string
+	Status   *string
+}
+
+type supplierInput struct {
+	Name               string
+	Email              string
+	Phone              string
+	Status             *string
+	IsVerifiedSupplier bool
+}
+
+type productInput struct {
+	Name           string
+	Description    string
+	Specifications *string
+	BrandId        graphql.ID
+	CategoryId     graphql.ID
+	SupplierId     graphql.ID
+	UnitPrice      float64
+	DiscountPrice  float64
+	Tags           []string
+	Status         *string
+	StockQuantity  int32
+	PublishAt      *Timestamp
+	UnpublishAt    *Timestamp
+}
@@ -40,6 +40,12 @@ func ModifyContext(c *gin.Context) {
 }
 
 func CreateContext(traceID string) context.Context {
+	return WithTraceID(context.Background(), traceID)
+}
+
+// WithTraceID returns a copy of ctx carrying the trace id, or a new one when it is empty,
+// for the servers that do not go through gin
+func WithTraceID(ctx context.Context, traceID string) context.Context {
 	if len(traceID) == 0 {
 		traceID = generateID()
 	}
@@ -49,6 +55,5 @@ func CreateContext(traceID string) context.Context {
 		SpanID:  generateID(),
 	}
 
-	ctx := context.WithValue(context.Background(), traceIDKey, data)
-	return ctx
+	return context.WithValue(ctx, traceIDKey, data)
 }
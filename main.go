@@ -20,6 +20,14 @@ import (
 // @host localhost:5000
 // @BasePath /
 // @query.collection.format multi
+
+// @securityDefinitions.apikey SupplierAuth
+// @in header
+// @name Authorization
+
+// @securityDefinitions.apikey AdminAuth
+// @in header
+// @name Authorization
 func main() {
 	cmd.Execute()
 }
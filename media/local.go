@@ -0,0 +1,86 @@
+// Package media keeps uploaded files, such as the logos of the brands
+package media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps the files in a directory of the local disk, which the rest server
+// serves or a cdn fronts
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore keeps the files under dir, their urls starting with baseURL
+func NewLocalStore(dir, baseURL string) *LocalStore {
+	return &LocalStore{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Put writes the file under the key, replacing the one there, and returns its url
+func (s *LocalStore) Put(ctx context.Context, key, contentType string, body io.Reader) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	// the file shows up whole or not at all
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return s.baseURL + "/" + key, nil
+}
+
+// Delete removes the file under the key, a missing one is not an error
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid media key %q", key)
+	}
+
+	return filepath.Join(s.dir, key), nil
+}
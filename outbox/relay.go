@@ -0,0 +1,118 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// defaults of a relay
+const (
+	DefaultBatchSize    = 100
+	DefaultPollInterval = time.Second
+	DefaultLockTimeout  = 5 * time.Minute
+)
+
+// retry backoff, doubling from the base delay up to the max delay. events are
+// retried until every sink accepted them
+const (
+	backoffBase = 10 * time.Second
+	backoffMax  = time.Hour
+)
+
+// Sink is a destination events are delivered to
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event *service.Event) error
+}
+
+// Relay delivers the events of the outbox to its sinks. an event is published once
+// every sink accepted it, a sink failing has the event delivered again to all of
+// them later, so sinks see an event at least once
+type Relay struct {
+	repo  service.OutboxRepo
+	sinks []Sink
+
+	// BatchSize is how many events are claimed at a time
+	BatchSize int
+	// PollInterval is how long the relay waits before looking for events again once the outbox is drained
+	PollInterval time.Duration
+	// LockTimeout is how long a batch may take before another relay considers this one dead
+	LockTimeout time.Duration
+}
+
+func NewRelay(repo service.OutboxRepo, sinks ...Sink) *Relay {
+	return &Relay{
+		repo:         repo,
+		sinks:        sinks,
+		BatchSize:    DefaultBatchSize,
+		PollInterval: DefaultPollInterval,
+		LockTimeout:  DefaultLockTimeout,
+	}
+}
+
+// Run relays events until ctx is done, finishing the batch at hand before it returns
+func (r *Relay) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		relayed, err := r.RelayBatch(context.WithoutCancel(ctx))
+		if err != nil {
+			logger.Error(ctx, "cannot relay events", err)
+		}
+
+		// a full batch means more events are probably waiting
+		if err == nil && relayed == r.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(r.PollInterval):
+		}
+	}
+}
+
+// RelayBatch claims a batch of due events and delivers them, returning how many were claimed
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	now := util.GetCurrentTimestamp()
+
+	events, err := r.repo.Claim(ctx, r.BatchSize, now, now+r.LockTimeout.Milliseconds())
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range events {
+		if err := r.deliver(ctx, &events[i]); err != nil {
+			return len(events), err
+		}
+	}
+
+	return len(events), nil
+}
+
+// deliver publishes the event to every sink and records the outcome
+func (r *Relay) deliver(ctx context.Context, event *service.Event) error {
+	var failures []string
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sink.Name(), err))
+		}
+	}
+
+	now := util.GetCurrentTimestamp()
+
+	if len(failures) == 0 {
+		return r.repo.MarkPublished(ctx, event.ID, now)
+	}
+
+	lastError := strings.Join(failures, "; ")
+	nextAttemptAt := now + util.Backoff(event.Attempts, backoffBase, backoffMax).Milliseconds()
+
+	logger.Warn(ctx, "cannot deliver event, retrying", fmt.Sprintf("id: %s, type: %s, error: %s, next attempt at: %d", event.ID, event.Type, lastError, nextAttemptAt))
+
+	return r.repo.MarkFailed(ctx, event.ID, nextAttemptAt, lastError)
+}
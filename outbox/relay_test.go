@@ -0,0 +1,269 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// memoryOutbox is an outbox repo keeping the events in memory, claiming the due ones
+// the way the outbox table does
+type memoryOutbox struct {
+	mu     sync.Mutex
+	events []*service.Event
+	locked map[string]int64
+}
+
+func newMemoryOutbox(events ...service.Event) *memoryOutbox {
+	o := &memoryOutbox{locked: make(map[string]int64)}
+	for i := range events {
+		event := events[i]
+		o.events = append(o.events, &event)
+	}
+
+	return o
+}
+
+func (o *memoryOutbox) Add(ctx context.Context, event *service.Event) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.events = append(o.events, event)
+	return nil
+}
+
+func (o *memoryOutbox) Claim(ctx context.Context, limit int, now, lockedUntil int64) ([]service.Event, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var claimed []service.Event
+	for _, event := range o.events {
+		if len(claimed) == limit {
+			break
+		}
+
+		if event.PublishedAt > 0 || event.NextAttemptAt > now || o.locked[event.ID] > now {
+			continue
+		}
+
+		event.Attempts++
+		o.locked[event.ID] = lockedUntil
+		claimed = append(claimed, *event)
+	}
+
+	return claimed, nil
+}
+
+func (o *memoryOutbox) MarkPublished(ctx context.Context, eventID string, publishedAt int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	event := o.event(eventID)
+	event.PublishedAt = publishedAt
+	delete(o.locked, eventID)
+
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(ctx context.Context, eventID string, nextAttemptAt int64, lastError string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	event := o.event(eventID)
+	event.NextAttemptAt = nextAttemptAt
+	event.LastError = lastError
+	delete(o.locked, eventID)
+
+	return nil
+}
+
+func (o *memoryOutbox) event(eventID string) *service.Event {
+	for _, event := range o.events {
+		if event.ID == eventID {
+			return event
+		}
+	}
+
+	return nil
+}
+
+// get returns a copy of the event with the id
+func (o *memoryOutbox) get(eventID string) service.Event {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return *o.event(eventID)
+}
+
+func testEvent(id string) service.Event {
+	return service.Event{
+		ID:             id,
+		Type:           service.EventProductCreated,
+		SchemaVersion:  1,
+		AggregateType:  service.AuditEntityProduct,
+		AggregateID:    "product-" + id,
+		IdempotencyKey: id,
+		Data:           json.RawMessage(`{}`),
+	}
+}
+
+func TestRelayBatchPublishesToBroker(t *testing.T) {
+	repo := newMemoryOutbox(testEvent("1"), testEvent("2"))
+	broker := NewMemoryBroker()
+	relay := NewRelay(repo, NewBrokerSink(broker, "catalog"))
+
+	relayed, err := relay.RelayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("RelayBatch: %v", err)
+	}
+
+	if relayed != 2 {
+		t.Fatalf("relayed %d events, want 2", relayed)
+	}
+
+	messages := broker.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("broker got %d messages, want 2", len(messages))
+	}
+
+	for i, id := range []string{"1", "2"} {
+		if want := "catalog." + service.EventProductCreated; messages[i].Subject != want {
+			t.Errorf("message %d subject = %q, want %q", i, messages[i].Subject, want)
+		}
+
+		if want := "product-" + id; messages[i].Key != want {
+			t.Errorf("message %d key = %q, want %q", i, messages[i].Key, want)
+		}
+
+		var event service.Event
+		if err := json.Unmarshal(messages[i].Data, &event); err != nil || event.ID != id {
+			t.Errorf("message %d is not event %s: %s", i, id, messages[i].Data)
+		}
+
+		if repo.get(id).PublishedAt == 0 {
+			t.Errorf("event %s is not marked published", id)
+		}
+	}
+
+	relayed, err = relay.RelayBatch(context.Background())
+	if err != nil || relayed != 0 {
+		t.Fatalf("second RelayBatch = %d, %v, want no events", relayed, err)
+	}
+}
+
+func TestRelayBatchClaimsBatchSize(t *testing.T) {
+	repo := newMemoryOutbox(testEvent("1"), testEvent("2"), testEvent("3"))
+	broker := NewMemoryBroker()
+	relay := NewRelay(repo, NewBrokerSink(broker, "catalog"))
+	relay.BatchSize = 2
+
+	relayed, err := relay.RelayBatch(context.Background())
+	if err != nil || relayed != 2 {
+		t.Fatalf("RelayBatch = %d, %v, want 2 events", relayed, err)
+	}
+
+	if repo.get("3").Attempts != 0 {
+		t.Errorf("event 3 was claimed beyond the batch size")
+	}
+}
+
+func TestRelayBatchBacksOffFailedEvents(t *testing.T) {
+	repo := newMemoryOutbox(testEvent("1"))
+	broker := NewMemoryBroker()
+	failing := NewFuncSink("failing", func(ctx context.Context, event *service.Event) error {
+		return errors.New("unavailable")
+	})
+	relay := NewRelay(repo, NewBrokerSink(broker, "catalog"), failing)
+
+	// every failed attempt doubles the delay before the next one
+	for attempt, delay := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		before := time.Now().UnixMilli()
+
+		relayed, err := relay.RelayBatch(context.Background())
+		if err != nil || relayed != 1 {
+			t.Fatalf("attempt %d: RelayBatch = %d, %v, want 1 event", attempt+1, relayed, err)
+		}
+
+		event := repo.get("1")
+		if event.PublishedAt != 0 {
+			t.Fatalf("attempt %d: event is published although a sink failed", attempt+1)
+		}
+
+		if event.LastError != "failing: unavailable" {
+			t.Errorf("attempt %d: last error = %q", attempt+1, event.LastError)
+		}
+
+		if wait := event.NextAttemptAt - before; wait < delay.Milliseconds() || wait > delay.Milliseconds()+1000 {
+			t.Errorf("attempt %d: next attempt in %dms, want %dms", attempt+1, wait, delay.Milliseconds())
+		}
+
+		// not due yet
+		if relayed, _ := relay.RelayBatch(context.Background()); relayed != 0 {
+			t.Fatalf("attempt %d: the event was claimed again before its next attempt", attempt+1)
+		}
+
+		repo.mu.Lock()
+		repo.event("1").NextAttemptAt = 0
+		repo.mu.Unlock()
+	}
+
+	// the sinks that accepted the event get it again on every attempt
+	if n := len(broker.Messages()); n != 3 {
+		t.Errorf("broker got %d messages, want 3", n)
+	}
+}
+
+func TestRelayRunDrainsOutbox(t *testing.T) {
+	var events []service.Event
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		events = append(events, testEvent(id))
+	}
+
+	repo := newMemoryOutbox(events...)
+	broker := NewMemoryBroker()
+	relay := NewRelay(repo, NewBrokerSink(broker, "catalog"))
+	relay.BatchSize = 2
+	relay.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(broker.Messages()) < len(events) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// an event added while running is picked up by a later poll
+	added := testEvent("6")
+	repo.Add(context.Background(), &added)
+	for len(broker.Messages()) < len(events)+1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return once ctx was done")
+	}
+
+	if n := len(broker.Messages()); n != len(events)+1 {
+		t.Fatalf("broker got %d messages, want %d", n, len(events)+1)
+	}
+
+	for _, event := range repo.events {
+		if event.PublishedAt == 0 {
+			t.Errorf("event %s is not marked published", event.ID)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// headers of an event posted to a webhook
+const (
+	HeaderEventType      = "X-Event-Type"
+	HeaderSchemaVersion  = "X-Event-Schema-Version"
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// webhookTimeout bounds a single delivery to a webhook
+const webhookTimeout = 10 * time.Second
+
+// WriterSink writes every event as a line of json, e.g. to stdout
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Name() string {
+	return "writer"
+}
+
+func (s *WriterSink) Publish(ctx context.Context, event *service.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// funcSink hands every event to a function
+type funcSink struct {
+	name string
+	fn   func(ctx context.Context, event *service.Event) error
+}
+
+// NewFuncSink returns a Sink calling fn with every event, e.g. to fan the events
+// out to the webhook subscriptions
+func NewFuncSink(name string, fn func(ctx context.Context, event *service.Event) error) Sink {
+	return &funcSink{
+		name: name,
+		fn:   fn,
+	}
+}
+
+func (s *funcSink) Name() string {
+	return s.name
+}
+
+func (s *funcSink) Publish(ctx context.Context, event *service.Event) error {
+	return s.fn(ctx, event)
+}
+
+// WebhookSink posts every event as json to a url, any status but 2xx fails the delivery
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event *service.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventType, event.Type)
+	req.Header.Set(HeaderSchemaVersion, strconv.Itoa(event.SchemaVersion))
+	req.Header.Set(HeaderIdempotencyKey, event.IdempotencyKey)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// drain the body so the connection can be reused
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// Publisher is a message broker client such as NATS or Kafka. the subject is the
+// NATS subject or Kafka topic, the key keeps the messages of an aggregate in order
+// where the broker partitions by key
+type Publisher interface {
+	Publish(ctx context.Context, subject, key string, data []byte) error
+}
+
+// BrokerSink publishes every event to a message broker on the subject
+// "<prefix>.<event type>", keyed by the id of the aggregate
+type BrokerSink struct {
+	publisher Publisher
+	prefix    string
+}
+
+func NewBrokerSink(publisher Publisher, prefix string) *BrokerSink {
+	return &BrokerSink{
+		publisher: publisher,
+		prefix:    prefix,
+	}
+}
+
+func (s *BrokerSink) Name() string {
+	return "broker"
+}
+
+func (s *BrokerSink) Publish(ctx context.Context, event *service.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.publisher.Publish(ctx, fmt.Sprintf("%s.%s", s.prefix, event.Type), event.AggregateID, data)
+}
+
+// Message is a message published to a MemoryBroker
+type Message struct {
+	Subject string
+	Key     string
+	Data    []byte
+}
+
+// MemoryBroker is a Publisher keeping the messages in memory, for tests and local runs
+type MemoryBroker struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, subject, key string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = append(b.messages, Message{
+		Subject: subject,
+		Key:     key,
+		Data:    data,
+	})
+
+	return nil
+}
+
+// Messages returns the messages published so far, oldest first
+func (b *MemoryBroker) Messages() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	messages := make([]Message, len(b.messages))
+	copy(messages, b.messages)
+
+	return messages
+}
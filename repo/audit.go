@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// DB model
+type AuditEntry struct {
+	ID         string `db:"id"`
+	EntityType string `db:"entity_type"`
+	EntityID   string `db:"entity_id"`
+	Action     string `db:"action"`
+	Actor      string `db:"actor"`
+	TraceID    string `db:"trace_id"`
+	Before     []byte `db:"before"`
+	After      []byte `db:"after"`
+	Changes    []byte `db:"changes"`
+	CreatedAt  int64  `db:"created_at"`
+}
+
+type AuditRepo interface {
+	service.AuditRepo
+}
+
+type auditRepo struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepo(db *sqlx.DB) AuditRepo {
+	return &auditRepo{
+		db: db,
+	}
+}
+
+func (r *auditRepo) Add(ctx context.Context, entry *service.AuditEntry) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO audit_logs (entity_type, entity_id, action, actor, trace_id, before, after, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Action,
+		entry.Actor,
+		entry.TraceID,
+		nullableJSON(entry.Before),
+		nullableJSON(entry.After),
+		nullableJSON(entry.Changes),
+		entry.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *auditRepo) GetItems(ctx context.Context, filterParams service.FilterAuditParams) (*service.AuditResult, error) {
+	if filterParams.Page == 0 {
+		filterParams.Page = 1
+	}
+
+	offset := (filterParams.Page - 1) * filterParams.Limit
+
+	conditions := []string{"created_at >= $1", "created_at <= $2"}
+	args := []interface{}{filterParams.From, filterParams.To}
+
+	if len(filterParams.EntityType) > 0 {
+		args = append(args, filterParams.EntityType)
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", len(args)))
+	}
+
+	if len(filterParams.EntityID) > 0 {
+		args = append(args, filterParams.EntityID)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+
+	if len(filterParams.Actor) > 0 {
+		args = append(args, filterParams.Actor)
+		conditions = append(conditions, fmt.Sprintf("actor = $%d", len(args)))
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	var dbEntries []AuditEntry
+	query := fmt.Sprintf("SELECT * FROM audit_logs%s ORDER BY created_at DESC OFFSET %d LIMIT %d", whereClause, offset, filterParams.Limit)
+	err := conn(ctx, r.db).SelectContext(ctx, &dbEntries, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCount int64
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM audit_logs"+whereClause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []service.AuditEntry{}
+	for _, dbEntry := range dbEntries {
+		entries = append(entries, service.AuditEntry{
+			ID:         dbEntry.ID,
+			EntityType: dbEntry.EntityType,
+			EntityID:   dbEntry.EntityID,
+			Action:     dbEntry.Action,
+			Actor:      dbEntry.Actor,
+			TraceID:    dbEntry.TraceID,
+			Before:     dbEntry.Before,
+			After:      dbEntry.After,
+			Changes:    dbEntry.Changes,
+			CreatedAt:  dbEntry.CreatedAt,
+		})
+	}
+
+	return &service.AuditResult{
+		Entries: entries,
+		Total:   totalCount,
+		Page:    filterParams.Page,
+		Limit:   filterParams.Limit,
+	}, nil
+}
+
+// nullableJSON stores an empty json document as NULL
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return string(data)
+}
@@ -10,14 +10,32 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/jsiqbal/ecommerce/logger"
 	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
 )
 
 // model
 type Brand struct {
-	ID        string `db:"id"`
-	Name      string `db:"name"`
-	StatusID  int    `db:"status_id"`
-	CreatedAt int64  `db:"created_at"`
+	ID              string         `db:"id"`
+	Name            string         `db:"name"`
+	Slug            string         `db:"slug"`
+	Description     sql.NullString `db:"description"`
+	Translations    Translations   `db:"translations"`
+	LogoKey         sql.NullString `db:"logo_key"`
+	LogoURL         sql.NullString `db:"logo_url"`
+	Website         sql.NullString `db:"website"`
+	CountryOfOrigin sql.NullString `db:"country_of_origin"`
+	Status          string         `db:"status"`
+	CreatedAt       int64          `db:"created_at"`
+	DeletedAt       sql.NullInt64  `db:"deleted_at"`
+	Version         int64          `db:"version"`
+	SEO
+}
+
+type BrandCategoryCount struct {
+	CategoryID   string `db:"category_id"`
+	CategoryName string `db:"category_name"`
+	CategorySlug string `db:"category_slug"`
+	ProductCount int64  `db:"product_count"`
 }
 
 type BrandRepo interface {
@@ -35,32 +53,33 @@ func NewBrandRepo(db *sqlx.DB) BrandRepo {
 }
 
 func (r *brandRepo) Add(ctx context.Context, brand *service.Brand) (*service.Brand, error) {
+	seo := toDBSEO(brand.SEO)
+
 	var newBrand Brand
-	err := r.db.QueryRowContext(ctx,
-		"INSERT INTO brands (name, status_id, created_at) VALUES ($1, $2, $3) RETURNING id, name, status_id, created_at",
-		brand.Name, brand.StatusID, brand.CreatedAt,
-	).Scan(&newBrand.ID, &newBrand.Name, &newBrand.StatusID, &newBrand.CreatedAt)
+	err := conn(ctx, r.db).GetContext(ctx, &newBrand,
+		`INSERT INTO brands (name, slug, seo_title, seo_description, canonical_url, description, translations, website, country_of_origin, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING *`,
+		brand.Name, brand.Slug, seo.Title, seo.Description, seo.CanonicalURL,
+		nullableString(brand.Description), toDBTranslations(brand.Translations), nullableString(brand.Website), nullableString(brand.CountryOfOrigin),
+		brand.Status, brand.CreatedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &service.Brand{
-		ID:        newBrand.ID,
-		Name:      newBrand.Name,
-		StatusID:  newBrand.StatusID,
-		CreatedAt: newBrand.CreatedAt,
-	}, nil
+	return toServiceBrand(&newBrand), nil
 }
 
 func (r *brandRepo) GetItemByID(ctx context.Context, brandID string) (*service.Brand, error) {
 	log.Println("hello")
 	var brand Brand
 
-	err := r.db.Get(&brand, "SELECT * FROM brands WHERE id = $1", brandID)
+	err := conn(ctx, r.db).GetContext(ctx, &brand, "SELECT * FROM brands WHERE id = $1 AND deleted_at IS NULL", brandID)
 	if err == sql.ErrNoRows {
 		// No product found
 		logger.Error(ctx, "cannot find brand", err)
-		return nil, nil
+		return nil, service.ErrBrandNotFound
 	} else if err != nil {
 		logger.Error(ctx, "error in finding brand", err)
 		return nil, err
@@ -68,41 +87,50 @@ func (r *brandRepo) GetItemByID(ctx context.Context, brandID string) (*service.B
 
 	logger.Info(ctx, "found brand", brand)
 
-	return &service.Brand{
-		ID:        brand.ID,
-		Name:      brand.Name,
-		StatusID:  brand.StatusID,
-		CreatedAt: brand.CreatedAt,
-	}, nil
+	return toServiceBrand(&brand), nil
+}
+
+func (r *brandRepo) GetItemByName(ctx context.Context, name string) (*service.Brand, error) {
+	var brand Brand
+
+	err := conn(ctx, r.db).GetContext(ctx, &brand, "SELECT * FROM brands WHERE LOWER(name) = LOWER($1) AND deleted_at IS NULL ORDER BY created_at LIMIT 1", name)
+	if err == sql.ErrNoRows {
+		// No brand found
+		return nil, service.ErrBrandNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceBrand(&brand), nil
 }
 
-func (r *brandRepo) GetItems(ctx context.Context, page int64, limit int64) (*service.BrandResult, error) {
+func (r *brandRepo) GetItems(ctx context.Context, page int64, limit int64, includeDeleted bool) (*service.BrandResult, error) {
 	// calculate offset based on page and limit for pagination
 	offset := (page - 1) * limit
 
 	// fetch brands and total count
 	var dbBrands []Brand
 
-	query := fmt.Sprintf("SELECT * FROM brands ORDER BY created_at DESC OFFSET %d LIMIT %d", offset, limit)
-	err := r.db.SelectContext(ctx, &dbBrands, query)
+	whereClause := " WHERE deleted_at IS NULL"
+	if includeDeleted {
+		whereClause = ""
+	}
+
+	query := fmt.Sprintf("SELECT * FROM brands%s ORDER BY created_at DESC OFFSET %d LIMIT %d", whereClause, offset, limit)
+	err := conn(ctx, r.db).SelectContext(ctx, &dbBrands, query)
 	if err != nil {
 		return nil, err
 	}
 
 	var totalCount int64
-	err = r.db.GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM brands")
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM brands"+whereClause)
 	if err != nil {
 		return nil, err
 	}
 
 	var brands []service.Brand
-	for _, dbBrand := range dbBrands {
-		brands = append(brands, service.Brand{
-			ID:        dbBrand.ID,
-			Name:      dbBrand.Name,
-			StatusID:  dbBrand.StatusID,
-			CreatedAt: dbBrand.CreatedAt,
-		})
+	for i := range dbBrands {
+		brands = append(brands, *toServiceBrand(&dbBrands[i]))
 	}
 
 	// return the result
@@ -116,23 +144,196 @@ func (r *brandRepo) GetItems(ctx context.Context, page int64, limit int64) (*ser
 	return result, nil
 }
 
-func (r *brandRepo) UpdateItemByID(ctx context.Context, brandID string, brand *service.Brand) error {
-	_, err := r.db.Exec(
-		"UPDATE brands SET name = $1, status_id = $2 WHERE id = $3",
-		brand.Name, brand.StatusID, brandID,
+func (r *brandRepo) UpdateItemByID(ctx context.Context, brandID string, brand *service.Brand) (int64, error) {
+	seo := toDBSEO(brand.SEO)
+
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE brands SET name = $1, slug = $2, seo_title = $3, seo_description = $4, canonical_url = $5,
+		description = $6, translations = $7, website = $8, country_of_origin = $9, status = $10, version = version + 1
+		WHERE id = $11 AND version = $12 AND deleted_at IS NULL`,
+		brand.Name, brand.Slug, seo.Title, seo.Description, seo.CanonicalURL,
+		nullableString(brand.Description), toDBTranslations(brand.Translations), nullableString(brand.Website), nullableString(brand.CountryOfOrigin),
+		brand.Status, brandID, brand.Version,
 	)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *brandRepo) PatchItemByID(ctx context.Context, brandID string, patch *service.BrandPatch) (int64, error) {
+	var patchSet patchSet
+
+	if patch.Name != nil {
+		patchSet.add("name", *patch.Name)
+	}
+
+	if patch.Slug != nil {
+		patchSet.add("slug", *patch.Slug)
+	}
+
+	patchSet.addSEO(patch.SEO)
+
+	if patch.Description != nil {
+		patchSet.add("description", nullableString(*patch.Description))
+	}
+
+	if patch.Translations != nil {
+		patchSet.add("translations", toDBTranslations(*patch.Translations))
+	}
+
+	if patch.Website != nil {
+		patchSet.add("website", nullableString(*patch.Website))
+	}
+
+	if patch.CountryOfOrigin != nil {
+		patchSet.add("country_of_origin", nullableString(*patch.CountryOfOrigin))
 	}
 
-	return nil
+	if patch.Status != nil {
+		patchSet.add("status", *patch.Status)
+	}
+
+	return patchSet.apply(ctx, r.db, "brands", brandID, patch.Version)
 }
 
 func (r *brandRepo) DeleteItemByID(ctx context.Context, brandID string) error {
-	_, err := r.db.Exec("DELETE FROM brands WHERE id = $1", brandID)
-	if err != nil {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		var productCount int64
+		err := conn(ctx, r.db).GetContext(ctx, &productCount, "SELECT COUNT(*) FROM products WHERE brand_id = $1 AND deleted_at IS NULL", brandID)
+		if err != nil {
+			return err
+		}
+
+		if productCount > 0 {
+			return &service.DependencyError{
+				Entity:     "brand",
+				Dependents: map[string]int64{"products": productCount},
+			}
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE brands SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", util.GetCurrentTimestamp(), brandID)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *brandRepo) RestoreItemByID(ctx context.Context, brandID string) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		var name string
+		err := conn(ctx, r.db).GetContext(ctx, &name, "SELECT name FROM brands WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE", brandID)
+		if err == sql.ErrNoRows {
+			return service.ErrBrandNotFound
+		} else if err != nil {
+			return err
+		}
+
+		// another brand may have taken the name while this one was deleted
+		var taken bool
+		err = conn(ctx, r.db).GetContext(ctx, &taken,
+			"SELECT EXISTS (SELECT 1 FROM brands WHERE LOWER(name) = LOWER($1) AND deleted_at IS NULL)", name)
+		if err != nil {
+			return err
+		}
+
+		if taken {
+			return service.NewConflictError(service.AuditEntityBrand, "name", service.ErrDuplicateBrand)
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE brands SET deleted_at = NULL WHERE id = $1", brandID)
 		return err
+	})
+}
+
+// PurgeDeleted permanently removes brands deleted before the given timestamp
+// which are no longer referenced by any product, along with their slug redirects
+func (r *brandRepo) PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error) {
+	var purged int64
+	err := conn(ctx, r.db).GetContext(ctx, &purged,
+		`WITH purged AS (
+			DELETE FROM brands b
+			WHERE b.deleted_at IS NOT NULL AND b.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM products p WHERE p.brand_id = b.id)
+			RETURNING b.id
+		), redirects AS (
+			DELETE FROM slug_redirects WHERE entity_type = $2 AND entity_id IN (SELECT id FROM purged)
+		)
+		SELECT COUNT(*) FROM purged`,
+		deletedBefore, service.AuditEntityBrand,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+func (r *brandRepo) SetLogo(ctx context.Context, brandID, key, url string) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE brands SET logo_key = $1, logo_url = $2, version = version + 1 WHERE id = $3 AND deleted_at IS NULL",
+		nullableString(key), nullableString(url), brandID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetCategoryCounts counts the live products of the brand per live category, the
+// categories with the most first
+func (r *brandRepo) GetCategoryCounts(ctx context.Context, brandID string) ([]service.BrandCategoryCount, error) {
+	var dbCounts []BrandCategoryCount
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbCounts,
+		`SELECT
+			c.id AS category_id,
+			c.name AS category_name,
+			c.slug AS category_slug,
+			COUNT(*) AS product_count
+		FROM products p
+		JOIN categories c ON c.id = p.category_id
+		WHERE p.brand_id = $1 AND p.deleted_at IS NULL AND c.deleted_at IS NULL AND `+liveProductConditions("p.")+`
+		GROUP BY c.id, c.name, c.slug
+		ORDER BY product_count DESC, c.name ASC`,
+		brandID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := []service.BrandCategoryCount{}
+	for _, dbCount := range dbCounts {
+		counts = append(counts, service.BrandCategoryCount{
+			CategoryID:   dbCount.CategoryID,
+			CategoryName: dbCount.CategoryName,
+			CategorySlug: dbCount.CategorySlug,
+			ProductCount: dbCount.ProductCount,
+		})
 	}
 
-	return nil
+	return counts, nil
+}
+
+func toServiceBrand(brand *Brand) *service.Brand {
+	return &service.Brand{
+		ID:              brand.ID,
+		Name:            brand.Name,
+		Slug:            brand.Slug,
+		SEO:             brand.SEO.toService(),
+		Description:     brand.Description.String,
+		Translations:    brand.Translations.toService(),
+		LogoURL:         brand.LogoURL.String,
+		LogoKey:         brand.LogoKey.String,
+		Website:         brand.Website.String,
+		CountryOfOrigin: brand.CountryOfOrigin.String,
+		Status:          brand.Status,
+		CreatedAt:       brand.CreatedAt,
+		DeletedAt:       brand.DeletedAt.Int64,
+		Version:         brand.Version,
+	}
 }
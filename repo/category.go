@@ -9,16 +9,23 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/jsiqbal/ecommerce/logger"
 	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+	"github.com/lib/pq"
 )
 
 // db model
 type Category struct {
-	ID        string         `db:"id"`
-	Name      string         `db:"name"`
-	ParentID  sql.NullString `db:"parent_id"`
-	Sequence  sql.NullString `db:"sequence"`
-	StatusID  int            `db:"status_id"`
-	CreatedAt int64          `db:"created_at"`
+	ID           string         `db:"id"`
+	Name         string         `db:"name"`
+	Slug         string         `db:"slug"`
+	Translations Translations   `db:"translations"`
+	ParentID     sql.NullString `db:"parent_id"`
+	Sequence     sql.NullString `db:"sequence"`
+	Status       string         `db:"status"`
+	CreatedAt    int64          `db:"created_at"`
+	DeletedAt    sql.NullInt64  `db:"deleted_at"`
+	Version      int64          `db:"version"`
+	SEO
 }
 
 type CategoryRepo interface {
@@ -50,34 +57,42 @@ func (r *categoryRepo) Add(ctx context.Context, ctgry *service.Category) (*servi
 		sequence = nil
 	}
 
+	seo := toDBSEO(ctgry.SEO)
+
 	var newCtgry Category
-	err := r.db.QueryRowContext(ctx,
-		"INSERT INTO categories (name, parent_id, sequence, status_id, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, name, parent_id, sequence, status_id, created_at",
-		ctgry.Name, parentID, sequence, ctgry.StatusID, ctgry.CreatedAt,
-	).Scan(&newCtgry.ID, &newCtgry.Name, &newCtgry.ParentID, &newCtgry.Sequence, &newCtgry.StatusID, &newCtgry.CreatedAt)
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		`INSERT INTO categories (name, slug, seo_title, seo_description, canonical_url, translations, parent_id, sequence, status, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, name, slug, seo_title, seo_description, canonical_url, translations, parent_id, sequence, status, created_at, version`,
+		ctgry.Name, ctgry.Slug, seo.Title, seo.Description, seo.CanonicalURL, toDBTranslations(ctgry.Translations), parentID, sequence, ctgry.Status, ctgry.CreatedAt,
+	).Scan(&newCtgry.ID, &newCtgry.Name, &newCtgry.Slug, &newCtgry.SEO.Title, &newCtgry.SEO.Description, &newCtgry.SEO.CanonicalURL,
+		&newCtgry.Translations, &newCtgry.ParentID, &newCtgry.Sequence, &newCtgry.Status, &newCtgry.CreatedAt, &newCtgry.Version)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	logger.Info(ctx, "db category", newCtgry)
 
 	return &service.Category{
-		ID:        newCtgry.ID,
-		Name:      newCtgry.Name,
-		ParentID:  newCtgry.ParentID.String,
-		Sequence:  newCtgry.Sequence.String,
-		StatusID:  newCtgry.StatusID,
-		CreatedAt: newCtgry.CreatedAt,
+		ID:           newCtgry.ID,
+		Name:         newCtgry.Name,
+		Slug:         newCtgry.Slug,
+		SEO:          newCtgry.SEO.toService(),
+		Translations: newCtgry.Translations.toService(),
+		ParentID:     newCtgry.ParentID.String,
+		Sequence:     newCtgry.Sequence.String,
+		Status:       newCtgry.Status,
+		CreatedAt:    newCtgry.CreatedAt,
+		Version:      newCtgry.Version,
 	}, nil
 }
 
 func (r *categoryRepo) GetItemByID(ctx context.Context, ctgryID string) (*service.Category, error) {
 	var ctgry Category
 
-	err := r.db.Get(&ctgry, "SELECT id, name, parent_id, sequence, status_id, created_at FROM categories WHERE id = $1", ctgryID)
+	err := conn(ctx, r.db).GetContext(ctx, &ctgry, "SELECT id, name, slug, seo_title, seo_description, canonical_url, translations, parent_id, sequence, status, created_at, version FROM categories WHERE id = $1 AND deleted_at IS NULL", ctgryID)
 	if err == sql.ErrNoRows {
 		// No category found
-		return nil, nil
+		return nil, service.ErrCategoryNotFound
 	} else if err != nil {
 		return nil, err
 	}
@@ -85,30 +100,69 @@ func (r *categoryRepo) GetItemByID(ctx context.Context, ctgryID string) (*servic
 	logger.Info(ctx, "category", ctgry)
 
 	return &service.Category{
-		ID:        ctgry.ID,
-		Name:      ctgry.Name,
-		ParentID:  ctgry.ParentID.String,
-		Sequence:  ctgry.Sequence.String,
-		StatusID:  ctgry.StatusID,
-		CreatedAt: ctgry.CreatedAt,
+		ID:           ctgry.ID,
+		Name:         ctgry.Name,
+		Slug:         ctgry.Slug,
+		SEO:          ctgry.SEO.toService(),
+		Translations: ctgry.Translations.toService(),
+		ParentID:     ctgry.ParentID.String,
+		Sequence:     ctgry.Sequence.String,
+		Status:       ctgry.Status,
+		CreatedAt:    ctgry.CreatedAt,
+		Version:      ctgry.Version,
+	}, nil
+}
+
+func (r *categoryRepo) GetItemByName(ctx context.Context, name string) (*service.Category, error) {
+	var ctgries []Category
+
+	// two rows are enough to tell the name is ambiguous
+	err := conn(ctx, r.db).SelectContext(ctx, &ctgries, "SELECT id, name, slug, seo_title, seo_description, canonical_url, translations, parent_id, sequence, status, created_at, version FROM categories WHERE LOWER(name) = LOWER($1) AND deleted_at IS NULL ORDER BY created_at LIMIT 2", name)
+	if err != nil {
+		return nil, err
+	} else if len(ctgries) == 0 {
+		// No category found
+		return nil, service.ErrCategoryNotFound
+	} else if len(ctgries) > 1 {
+		return nil, service.ErrAmbiguousCategory
+	}
+
+	ctgry := ctgries[0]
+
+	return &service.Category{
+		ID:           ctgry.ID,
+		Name:         ctgry.Name,
+		Slug:         ctgry.Slug,
+		SEO:          ctgry.SEO.toService(),
+		Translations: ctgry.Translations.toService(),
+		ParentID:     ctgry.ParentID.String,
+		Sequence:     ctgry.Sequence.String,
+		Status:       ctgry.Status,
+		CreatedAt:    ctgry.CreatedAt,
+		Version:      ctgry.Version,
 	}, nil
 }
 
-func (r *categoryRepo) GetItems(ctx context.Context, page int64, limit int64) (*service.CategoryResult, error) {
+func (r *categoryRepo) GetItems(ctx context.Context, page int64, limit int64, includeDeleted bool) (*service.CategoryResult, error) {
 	// calculate offset based on page and limit for pagination
 	offset := (page - 1) * limit
 
 	// fetch brands and total count
 	var dbctgries []Category
 
-	query := fmt.Sprintf("SELECT * FROM categories ORDER BY created_at DESC OFFSET %d LIMIT %d", offset, limit)
-	err := r.db.SelectContext(ctx, &dbctgries, query)
+	whereClause := " WHERE deleted_at IS NULL"
+	if includeDeleted {
+		whereClause = ""
+	}
+
+	query := fmt.Sprintf("SELECT * FROM categories%s ORDER BY created_at DESC OFFSET %d LIMIT %d", whereClause, offset, limit)
+	err := conn(ctx, r.db).SelectContext(ctx, &dbctgries, query)
 	if err != nil {
 		return nil, err
 	}
 
 	var totalCount int64
-	err = r.db.GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM categories")
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM categories"+whereClause)
 	if err != nil {
 		return nil, err
 	}
@@ -116,12 +170,17 @@ func (r *categoryRepo) GetItems(ctx context.Context, page int64, limit int64) (*
 	var ctries []service.Category
 	for _, dbCtgry := range dbctgries {
 		ctries = append(ctries, service.Category{
-			ID:        dbCtgry.ID,
-			Name:      dbCtgry.Name,
-			ParentID:  dbCtgry.ParentID.String,
-			Sequence:  dbCtgry.Sequence.String,
-			StatusID:  dbCtgry.StatusID,
-			CreatedAt: dbCtgry.CreatedAt,
+			ID:           dbCtgry.ID,
+			Name:         dbCtgry.Name,
+			Slug:         dbCtgry.Slug,
+			SEO:          dbCtgry.SEO.toService(),
+			Translations: dbCtgry.Translations.toService(),
+			ParentID:     dbCtgry.ParentID.String,
+			Sequence:     dbCtgry.Sequence.String,
+			Status:       dbCtgry.Status,
+			CreatedAt:    dbCtgry.CreatedAt,
+			DeletedAt:    dbCtgry.DeletedAt.Int64,
+			Version:      dbCtgry.Version,
 		})
 	}
 
@@ -136,7 +195,176 @@ func (r *categoryRepo) GetItems(ctx context.Context, page int64, limit int64) (*
 	return result, nil
 }
 
-func (r *categoryRepo) UpdateItemByID(ctx context.Context, ctgryID string, ctgry *service.Category) error {
+func (r *categoryRepo) GetItemsByIDs(ctx context.Context, ctgryIDs []string) ([]service.Category, error) {
+	var dbCtgries []Category
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbCtgries,
+		"SELECT * FROM categories WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL",
+		pq.Array(ctgryIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceCategories(dbCtgries), nil
+}
+
+func (r *categoryRepo) GetItemsByParentIDs(ctx context.Context, parentIDs []string) ([]service.Category, error) {
+	var dbCtgries []Category
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbCtgries,
+		"SELECT * FROM categories WHERE parent_id = ANY($1::uuid[]) AND deleted_at IS NULL ORDER BY sequence ASC NULLS LAST, name ASC",
+		pq.Array(parentIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceCategories(dbCtgries), nil
+}
+
+func (r *categoryRepo) GetItemsByParentID(ctx context.Context, parentID string) ([]service.Category, error) {
+	var dbCtgries []Category
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbCtgries,
+		"SELECT * FROM categories WHERE parent_id IS NOT DISTINCT FROM $1::uuid AND deleted_at IS NULL ORDER BY sequence ASC NULLS LAST, name ASC",
+		nullableID(parentID),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceCategories(dbCtgries), nil
+}
+
+// GetSubtree walks down from the category, or from every root category when ctgryID
+// is empty, with a recursive query. parents come before their children and siblings
+// are in sequence order
+func (r *categoryRepo) GetSubtree(ctx context.Context, ctgryID string, maxDepth int) ([]service.Category, error) {
+	start := "parent_id IS NULL"
+	args := []interface{}{maxDepth}
+	if len(ctgryID) > 0 {
+		start = "id = $2"
+		args = append(args, ctgryID)
+	}
+
+	var dbCtgries []Category
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbCtgries,
+		`WITH RECURSIVE tree AS (
+			SELECT id, 0 AS depth FROM categories WHERE `+start+` AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id, t.depth + 1 FROM categories c
+			JOIN tree t ON c.parent_id = t.id
+			WHERE c.deleted_at IS NULL AND t.depth < $1
+		)
+		SELECT c.* FROM tree t
+		JOIN categories c ON c.id = t.id
+		ORDER BY t.depth, c.sequence ASC NULLS LAST, c.name ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceCategories(dbCtgries), nil
+}
+
+// GetAncestors walks up from the category with a recursive query, stopping after
+// maxDepth levels in case the parents form a cycle
+func (r *categoryRepo) GetAncestors(ctx context.Context, ctgryID string, maxDepth int) ([]service.Category, error) {
+	var dbCtgries []Category
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbCtgries,
+		`WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.parent_id, a.depth + 1 FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < $2
+		)
+		SELECT c.* FROM ancestors a
+		JOIN categories c ON c.id = a.id
+		WHERE a.depth > 0
+		ORDER BY a.depth DESC`,
+		ctgryID, maxDepth,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceCategories(dbCtgries), nil
+}
+
+// GetSubtreeHeight returns how many levels of descendants the category has
+func (r *categoryRepo) GetSubtreeHeight(ctx context.Context, ctgryID string, maxDepth int) (int, error) {
+	var height int
+
+	err := conn(ctx, r.db).GetContext(ctx, &height,
+		`WITH RECURSIVE tree AS (
+			SELECT id, 0 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, t.depth + 1 FROM categories c
+			JOIN tree t ON c.parent_id = t.id
+			WHERE c.deleted_at IS NULL AND t.depth < $2
+		)
+		SELECT COALESCE(MAX(depth), 0) FROM tree`,
+		ctgryID, maxDepth,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return height, nil
+}
+
+// categoryTreeLock is the key of the advisory lock the changes to the category tree take
+const categoryTreeLock = 0x63617465676f7279
+
+// LockTree holds the changes to the shape and the statuses of the category tree by others
+// until the transaction of ctx ends
+func (r *categoryRepo) LockTree(ctx context.Context) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", categoryTreeLock)
+	return err
+}
+
+// MoveItemByID puts the category under a new parent, or at the root when parentID
+// is empty, after the siblings it finds there
+func (r *categoryRepo) MoveItemByID(ctx context.Context, ctgryID, parentID string, version int64) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE categories SET parent_id = $1::uuid,
+			sequence = (
+				SELECT COALESCE(MAX(sequence), 0) + 1 FROM categories
+				WHERE parent_id IS NOT DISTINCT FROM $1::uuid AND id <> $2 AND deleted_at IS NULL
+			),
+			version = version + 1
+		WHERE id = $2 AND version = $3 AND deleted_at IS NULL`,
+		nullableID(parentID), ctgryID, version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// SetSequences numbers the children of the parent in the order of ctgryIDs, from 1
+func (r *categoryRepo) SetSequences(ctx context.Context, parentID string, ctgryIDs []string) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE categories c SET sequence = o.position, version = c.version + 1
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS o(id, position)
+		WHERE c.id = o.id AND c.parent_id IS NOT DISTINCT FROM $2::uuid
+		AND c.sequence IS DISTINCT FROM o.position AND c.deleted_at IS NULL`,
+		pq.Array(ctgryIDs), nullableID(parentID),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *categoryRepo) UpdateItemByID(ctx context.Context, ctgryID string, ctgry *service.Category) (int64, error) {
 	var parentID interface{}
 	if ctgry.ParentID != "" {
 		parentID = ctgry.ParentID
@@ -151,22 +379,166 @@ func (r *categoryRepo) UpdateItemByID(ctx context.Context, ctgryID string, ctgry
 		sequence = nil
 	}
 
-	_, err := r.db.Exec(
-		"UPDATE categories SET name = $1, parent_id = $2, sequence = $3, status_id = $4 WHERE id = $5",
-		ctgry.Name, parentID, sequence, ctgry.StatusID, ctgryID,
+	seo := toDBSEO(ctgry.SEO)
+
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE categories SET name = $1, slug = $2, seo_title = $3, seo_description = $4, canonical_url = $5, translations = $6, parent_id = $7, sequence = $8, status = $9, version = version + 1
+		WHERE id = $10 AND version = $11 AND deleted_at IS NULL`,
+		ctgry.Name, ctgry.Slug, seo.Title, seo.Description, seo.CanonicalURL, toDBTranslations(ctgry.Translations), parentID, sequence, ctgry.Status, ctgryID, ctgry.Version,
 	)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return result.RowsAffected()
+}
+
+func (r *categoryRepo) PatchItemByID(ctx context.Context, ctgryID string, patch *service.CategoryPatch) (int64, error) {
+	var patchSet patchSet
+
+	if patch.Name != nil {
+		patchSet.add("name", *patch.Name)
+	}
+
+	if patch.Slug != nil {
+		patchSet.add("slug", *patch.Slug)
+	}
+
+	patchSet.addSEO(patch.SEO)
+
+	if patch.Translations != nil {
+		patchSet.add("translations", toDBTranslations(*patch.Translations))
+	}
+
+	if patch.Sequence != nil {
+		// an empty sequence is stored as NULL, as on update
+		patchSet.add("sequence", sql.NullString{String: *patch.Sequence, Valid: *patch.Sequence != ""})
+	}
+
+	if patch.Status != nil {
+		patchSet.add("status", *patch.Status)
+	}
+
+	return patchSet.apply(ctx, r.db, "categories", ctgryID, patch.Version)
 }
 
 func (r *categoryRepo) DeleteItemByID(ctx context.Context, ctgryID string) error {
-	_, err := r.db.Exec("DELETE FROM categories WHERE id = $1", ctgryID)
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		var childCount, productCount int64
+		err := conn(ctx, r.db).GetContext(ctx, &childCount, "SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND deleted_at IS NULL", ctgryID)
+		if err != nil {
+			return err
+		}
+
+		err = conn(ctx, r.db).GetContext(ctx, &productCount, "SELECT COUNT(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL", ctgryID)
+		if err != nil {
+			return err
+		}
+
+		if childCount > 0 || productCount > 0 {
+			dependents := make(map[string]int64)
+			if childCount > 0 {
+				dependents["child categories"] = childCount
+			}
+			if productCount > 0 {
+				dependents["products"] = productCount
+			}
+
+			return &service.DependencyError{
+				Entity:     "category",
+				Dependents: dependents,
+			}
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE categories SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", util.GetCurrentTimestamp(), ctgryID)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *categoryRepo) RestoreItemByID(ctx context.Context, ctgryID string) error {
+	var ctgry Category
+	err := conn(ctx, r.db).GetContext(ctx, &ctgry, "SELECT * FROM categories WHERE id = $1 AND deleted_at IS NOT NULL", ctgryID)
+	if err == sql.ErrNoRows {
+		return service.ErrCategoryNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// a category can not come back underneath a deleted parent
+	if ctgry.ParentID.Valid {
+		var parentDeleted bool
+		err = conn(ctx, r.db).GetContext(ctx, &parentDeleted, "SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NOT NULL)", ctgry.ParentID.String)
+		if err != nil {
+			return err
+		}
+
+		if parentDeleted {
+			return service.ErrParentDeleted
+		}
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE categories SET deleted_at = NULL WHERE id = $1", ctgryID)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// PurgeDeleted permanently removes categories deleted before the given timestamp
+// which no longer have any product or child category, along with their slug redirects
+func (r *categoryRepo) PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error) {
+	var purged int64
+	err := conn(ctx, r.db).GetContext(ctx, &purged,
+		`WITH purged AS (
+			DELETE FROM categories c
+			WHERE c.deleted_at IS NOT NULL AND c.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM products p WHERE p.category_id = c.id)
+			AND NOT EXISTS (SELECT 1 FROM categories child WHERE child.parent_id = c.id)
+			RETURNING c.id
+		), redirects AS (
+			DELETE FROM slug_redirects WHERE entity_type = $2 AND entity_id IN (SELECT id FROM purged)
+		)
+		SELECT COUNT(*) FROM purged`,
+		deletedBefore, service.AuditEntityCategory,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// nullableID stores an empty id as NULL
+func nullableID(id string) interface{} {
+	if len(id) == 0 {
+		return nil
+	}
+
+	return id
+}
+
+func toServiceCategories(dbCtgries []Category) []service.Category {
+	ctgries := []service.Category{}
+	for _, dbCtgry := range dbCtgries {
+		ctgries = append(ctgries, service.Category{
+			ID:           dbCtgry.ID,
+			Name:         dbCtgry.Name,
+			Slug:         dbCtgry.Slug,
+			SEO:          dbCtgry.SEO.toService(),
+			Translations: dbCtgry.Translations.toService(),
+			ParentID:     dbCtgry.ParentID.String,
+			Sequence:     dbCtgry.Sequence.String,
+			Status:       dbCtgry.Status,
+			CreatedAt:    dbCtgry.CreatedAt,
+			DeletedAt:    dbCtgry.DeletedAt.Int64,
+			Version:      dbCtgry.Version,
+		})
+	}
+
+	return ctgries
+}
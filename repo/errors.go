@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/lib/pq"
+)
+
+// postgres error codes, https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	adminShutdown       = "57P01"
+	crashShutdown       = "57P02"
+	cannotConnectNow    = "57P03"
+)
+
+type uniqueConstraint struct {
+	entity string
+	field  string
+	err    error
+}
+
+type foreignKey struct {
+	field string
+	err   error
+}
+
+// uniqueConstraints are the unique indexes a record can clash with another on
+var uniqueConstraints = map[string]uniqueConstraint{
+	"brands_name_idx":            {service.AuditEntityBrand, "name", service.ErrDuplicateBrand},
+	"brands_slug_idx":            {service.AuditEntityBrand, "slug", service.ErrSlugTaken},
+	"categories_parent_name_idx": {service.AuditEntityCategory, "name", service.ErrDuplicateCategory},
+	"categories_slug_idx":        {service.AuditEntityCategory, "slug", service.ErrSlugTaken},
+	"suppliers_email_idx":        {service.AuditEntitySupplier, "email", service.ErrDuplicateSupplierEmail},
+	"products_supplier_name_idx": {service.AuditEntityProduct, "name", service.ErrDuplicateProduct},
+	"products_slug_idx":          {service.AuditEntityProduct, "slug", service.ErrSlugTaken},
+	"reviews_customer_idx":       {service.AuditEntityReview, "customer_id", service.ErrDuplicateReview},
+}
+
+// foreignKeys are the references a record can make to a record which does not exist
+var foreignKeys = map[string]foreignKey{
+	"products_brand_id_fkey":                  {"brand_id", service.ErrBrandNotFound},
+	"products_category_id_fkey":               {"category_id", service.ErrCategoryNotFound},
+	"products_supplier_id_fkey":               {"supplier_id", service.ErrSupplierNotFound},
+	"product_stocks_product_id_fkey":          {"product_id", service.ErrProductNotFound},
+	"stock_movements_product_id_fkey":         {"product_id", service.ErrProductNotFound},
+	"webhook_deliveries_subscription_id_fkey": {"subscription_id", service.ErrWebhookNotFound},
+	"reviews_product_id_fkey":                 {"product_id", service.ErrProductNotFound},
+	"questions_product_id_fkey":               {"product_id", service.ErrProductNotFound},
+}
+
+// translateError turns the violations of the unique and foreign key constraints and the
+// failures to reach the database into the domain errors of the service, other errors are
+// returned as they are
+func translateError(err error) error {
+	if isUnavailable(err) {
+		// the cause is kept for the logs
+		return fmt.Errorf("%w: %w", service.ErrDatabaseUnavailable, err)
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code {
+	case uniqueViolation:
+		if c, ok := uniqueConstraints[pqErr.Constraint]; ok {
+			return service.NewConflictError(c.entity, c.field, c.err)
+		}
+	case foreignKeyViolation:
+		// only a record written with a dangling reference, a delete of a record which is
+		// still referenced is not one. the repos count the dependents before deleting
+		fk, ok := foreignKeys[pqErr.Constraint]
+		if ok && strings.HasPrefix(pqErr.Message, "insert or update") {
+			return service.NewReferenceError(fk.field, fk.err)
+		}
+	}
+
+	return err
+}
+
+// isUnavailable reports whether err is a failure to reach the database rather than one of
+// the statement, the statement may succeed when retried
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	// connection exceptions, insufficient resources such as too many connections, and the
+	// server shutting down or starting up
+	switch pqErr.Code.Class() {
+	case "08", "53":
+		return true
+	}
+
+	switch pqErr.Code {
+	case adminShutdown, crashShutdown, cannotConnectNow:
+		return true
+	}
+
+	return false
+}
+
+// translatingConn translates the errors of the statements it runs
+type translatingConn struct {
+	dbConn
+}
+
+func (c translatingConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := c.dbConn.ExecContext(ctx, query, args...)
+	return result, translateError(err)
+}
+
+func (c translatingConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := c.dbConn.QueryContext(ctx, query, args...)
+	return rows, translateError(err)
+}
+
+func (c translatingConn) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	rows, err := c.dbConn.QueryxContext(ctx, query, args...)
+	return rows, translateError(err)
+}
+
+func (c translatingConn) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return translateError(c.dbConn.GetContext(ctx, dest, query, args...))
+}
+
+func (c translatingConn) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return translateError(c.dbConn.SelectContext(ctx, dest, query, args...))
+}
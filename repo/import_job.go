@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// DB model
+type ImportJob struct {
+	ID            string        `db:"id"`
+	FileName      string        `db:"file_name"`
+	Format        string        `db:"format"`
+	Status        string        `db:"status"`
+	TotalRows     int64         `db:"total_rows"`
+	ProcessedRows int64         `db:"processed_rows"`
+	SucceededRows int64         `db:"succeeded_rows"`
+	FailedRows    int64         `db:"failed_rows"`
+	Errors        []byte        `db:"errors"`
+	CreatedAt     int64         `db:"created_at"`
+	UpdatedAt     int64         `db:"updated_at"`
+	FinishedAt    sql.NullInt64 `db:"finished_at"`
+}
+
+type ImportJobRepo interface {
+	service.ImportJobRepo
+}
+
+type importJobRepo struct {
+	db *sqlx.DB
+}
+
+func NewImportJobRepo(db *sqlx.DB) ImportJobRepo {
+	return &importJobRepo{
+		db: db,
+	}
+}
+
+func (r *importJobRepo) Add(ctx context.Context, job *service.ImportJob) (*service.ImportJob, error) {
+	errs, err := json.Marshal(job.Errors)
+	if err != nil {
+		return nil, err
+	}
+
+	var newJob ImportJob
+	err = conn(ctx, r.db).GetContext(ctx, &newJob,
+		`INSERT INTO import_jobs (file_name, format, status, total_rows, errors, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *`,
+		job.FileName, job.Format, job.Status, job.TotalRows, string(errs), job.CreatedAt, job.UpdatedAt,
+	)
+	if err != nil {
+		logger.Error(ctx, "can not create import job", err)
+		return nil, err
+	}
+
+	return toServiceImportJob(&newJob)
+}
+
+func (r *importJobRepo) GetItemByID(ctx context.Context, jobID string) (*service.ImportJob, error) {
+	var job ImportJob
+
+	err := conn(ctx, r.db).GetContext(ctx, &job, "SELECT * FROM import_jobs WHERE id = $1", jobID)
+	if err == sql.ErrNoRows {
+		// No import job found
+		return nil, service.ErrImportJobNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceImportJob(&job)
+}
+
+func (r *importJobRepo) UpdateItemByID(ctx context.Context, jobID string, job *service.ImportJob) error {
+	errs, err := json.Marshal(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	var finishedAt interface{}
+	if job.FinishedAt > 0 {
+		finishedAt = job.FinishedAt
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE import_jobs
+		SET status = $1, total_rows = $2, processed_rows = $3, succeeded_rows = $4, failed_rows = $5, errors = $6, updated_at = $7, finished_at = $8
+		WHERE id = $9`,
+		job.Status, job.TotalRows, job.ProcessedRows, job.SucceededRows, job.FailedRows, string(errs), job.UpdatedAt, finishedAt, jobID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func toServiceImportJob(job *ImportJob) (*service.ImportJob, error) {
+	errs := []service.ImportRowError{}
+	if len(job.Errors) > 0 {
+		if err := json.Unmarshal(job.Errors, &errs); err != nil {
+			return nil, err
+		}
+	}
+
+	return &service.ImportJob{
+		ID:            job.ID,
+		FileName:      job.FileName,
+		Format:        job.Format,
+		Status:        job.Status,
+		TotalRows:     job.TotalRows,
+		ProcessedRows: job.ProcessedRows,
+		SucceededRows: job.SucceededRows,
+		FailedRows:    job.FailedRows,
+		Errors:        errs,
+		CreatedAt:     job.CreatedAt,
+		UpdatedAt:     job.UpdatedAt,
+		FinishedAt:    job.FinishedAt.Int64,
+	}, nil
+}
@@ -0,0 +1,178 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/lib/pq"
+)
+
+// DB model
+type Job struct {
+	ID          string         `db:"id"`
+	Type        string         `db:"type"`
+	Payload     []byte         `db:"payload"`
+	Status      string         `db:"status"`
+	Attempts    int            `db:"attempts"`
+	MaxAttempts int            `db:"max_attempts"`
+	RunAt       int64          `db:"run_at"`
+	LockedUntil sql.NullInt64  `db:"locked_until"`
+	LastError   sql.NullString `db:"last_error"`
+	Actor       string         `db:"actor"`
+	TraceID     string         `db:"trace_id"`
+	CreatedAt   int64          `db:"created_at"`
+	UpdatedAt   int64          `db:"updated_at"`
+	FinishedAt  sql.NullInt64  `db:"finished_at"`
+}
+
+type JobRepo interface {
+	service.JobRepo
+}
+
+type jobRepo struct {
+	db *sqlx.DB
+}
+
+func NewJobRepo(db *sqlx.DB) JobRepo {
+	return &jobRepo{
+		db: db,
+	}
+}
+
+func (r *jobRepo) Add(ctx context.Context, job *service.Job) (*service.Job, error) {
+	var newJob Job
+	err := conn(ctx, r.db).GetContext(ctx, &newJob,
+		`INSERT INTO jobs (type, payload, status, max_attempts, run_at, actor, trace_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING *`,
+		job.Type, string(job.Payload), job.Status, job.MaxAttempts, job.RunAt, job.Actor, job.TraceID, job.CreatedAt, job.UpdatedAt,
+	)
+	if err != nil {
+		logger.Error(ctx, "can not create job", err)
+		return nil, err
+	}
+
+	return toServiceJob(&newJob), nil
+}
+
+func (r *jobRepo) GetItemByID(ctx context.Context, jobID string) (*service.Job, error) {
+	var job Job
+
+	err := conn(ctx, r.db).GetContext(ctx, &job, "SELECT * FROM jobs WHERE id = $1", jobID)
+	if err == sql.ErrNoRows {
+		// No job found
+		return nil, service.ErrJobNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceJob(&job), nil
+}
+
+// Claim skips the rows other workers hold locked, so every worker claims a different job
+func (r *jobRepo) Claim(ctx context.Context, types []string, now, lockedUntil int64) (*service.Job, error) {
+	var job Job
+
+	err := conn(ctx, r.db).GetContext(ctx, &job,
+		`UPDATE jobs
+		SET status = $1, attempts = attempts + 1, locked_until = $2, updated_at = $3
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE type = ANY($4)
+			AND ((status = $5 AND run_at <= $3) OR (status = $1 AND locked_until < $3 AND attempts < max_attempts))
+			ORDER BY run_at, created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		service.JobStatusRunning, lockedUntil, now, pq.Array(types), service.JobStatusQueued,
+	)
+	if err == sql.ErrNoRows {
+		// No job due
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceJob(&job), nil
+}
+
+func (r *jobRepo) Bury(ctx context.Context, types []string, now int64) ([]service.Job, error) {
+	var dbJobs []Job
+	err := conn(ctx, r.db).SelectContext(ctx, &dbJobs,
+		`UPDATE jobs
+		SET status = $1, last_error = $2, locked_until = NULL, updated_at = $3, finished_at = $3
+		WHERE type = ANY($4) AND status = $5 AND locked_until < $3 AND attempts >= max_attempts
+		RETURNING *`,
+		service.JobStatusDead, service.ErrJobAbandoned.Error(), now, pq.Array(types), service.JobStatusRunning,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]service.Job, 0, len(dbJobs))
+	for i := range dbJobs {
+		jobs = append(jobs, *toServiceJob(&dbJobs[i]))
+	}
+
+	return jobs, nil
+}
+
+func (r *jobRepo) Finish(ctx context.Context, job *service.Job) (int64, error) {
+	var lastError, finishedAt interface{}
+	if len(job.LastError) > 0 {
+		lastError = job.LastError
+	}
+
+	if job.FinishedAt > 0 {
+		finishedAt = job.FinishedAt
+	}
+
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE jobs
+		SET status = $1, run_at = $2, last_error = $3, locked_until = NULL, updated_at = $4, finished_at = $5
+		WHERE id = $6 AND status = $7 AND attempts = $8`,
+		job.Status, job.RunAt, lastError, job.UpdatedAt, finishedAt, job.ID, service.JobStatusRunning, job.Attempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *jobRepo) Requeue(ctx context.Context, jobID string, now int64) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE jobs
+		SET status = $1, attempts = 0, run_at = $2, updated_at = $2, finished_at = NULL
+		WHERE id = $3 AND status = $4`,
+		service.JobStatusQueued, now, jobID, service.JobStatusDead,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func toServiceJob(job *Job) *service.Job {
+	return &service.Job{
+		ID:          job.ID,
+		Type:        job.Type,
+		Payload:     job.Payload,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		LockedUntil: job.LockedUntil.Int64,
+		LastError:   job.LastError.String,
+		Actor:       job.Actor,
+		TraceID:     job.TraceID,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+		FinishedAt:  job.FinishedAt.Int64,
+	}
+}
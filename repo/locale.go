@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// Translations is the translations column of brands, categories and products, a JSONB
+// object keyed by locale
+type Translations service.Translations
+
+func (t *Translations) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("cannot scan %T into translations", src)
+	}
+
+	return json.Unmarshal(data, (*service.Translations)(t))
+}
+
+func (t Translations) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(service.Translations(t))
+}
+
+func toDBTranslations(translations service.Translations) Translations {
+	return Translations(translations)
+}
+
+func (t Translations) toService() service.Translations {
+	if t == nil {
+		return service.Translations{}
+	}
+
+	return service.Translations(t)
+}
@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// DB model
+type OutboxEvent struct {
+	ID             string         `db:"id"`
+	Type           string         `db:"type"`
+	SchemaVersion  int            `db:"schema_version"`
+	AggregateType  string         `db:"aggregate_type"`
+	AggregateID    string         `db:"aggregate_id"`
+	IdempotencyKey string         `db:"idempotency_key"`
+	Data           []byte         `db:"data"`
+	Actor          string         `db:"actor"`
+	TraceID        string         `db:"trace_id"`
+	OccurredAt     int64          `db:"occurred_at"`
+	Attempts       int            `db:"attempts"`
+	NextAttemptAt  int64          `db:"next_attempt_at"`
+	LockedUntil    sql.NullInt64  `db:"locked_until"`
+	LastError      sql.NullString `db:"last_error"`
+	PublishedAt    sql.NullInt64  `db:"published_at"`
+}
+
+type OutboxRepo interface {
+	service.OutboxRepo
+}
+
+type outboxRepo struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepo(db *sqlx.DB) OutboxRepo {
+	return &outboxRepo{
+		db: db,
+	}
+}
+
+func (r *outboxRepo) Add(ctx context.Context, event *service.Event) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO outbox_events (type, schema_version, aggregate_type, aggregate_id, idempotency_key, data, actor, trace_id, occurred_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		event.Type,
+		event.SchemaVersion,
+		event.AggregateType,
+		event.AggregateID,
+		event.IdempotencyKey,
+		string(event.Data),
+		event.Actor,
+		event.TraceID,
+		event.OccurredAt,
+		event.NextAttemptAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Claim skips the rows another relay holds locked, so concurrent relays never claim the same event
+func (r *outboxRepo) Claim(ctx context.Context, limit int, now, lockedUntil int64) ([]service.Event, error) {
+	var dbEvents []OutboxEvent
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbEvents,
+		`UPDATE outbox_events
+		SET attempts = attempts + 1, locked_until = $1
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE published_at IS NULL AND next_attempt_at <= $2 AND (locked_until IS NULL OR locked_until < $2)
+			ORDER BY occurred_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		lockedUntil, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the sub query
+	sort.SliceStable(dbEvents, func(i, j int) bool {
+		return dbEvents[i].OccurredAt < dbEvents[j].OccurredAt
+	})
+
+	events := make([]service.Event, len(dbEvents))
+	for i, dbEvent := range dbEvents {
+		events[i] = service.Event{
+			ID:             dbEvent.ID,
+			Type:           dbEvent.Type,
+			SchemaVersion:  dbEvent.SchemaVersion,
+			AggregateType:  dbEvent.AggregateType,
+			AggregateID:    dbEvent.AggregateID,
+			IdempotencyKey: dbEvent.IdempotencyKey,
+			Data:           dbEvent.Data,
+			Actor:          dbEvent.Actor,
+			TraceID:        dbEvent.TraceID,
+			OccurredAt:     dbEvent.OccurredAt,
+			Attempts:       dbEvent.Attempts,
+			NextAttemptAt:  dbEvent.NextAttemptAt,
+			LastError:      dbEvent.LastError.String,
+			PublishedAt:    dbEvent.PublishedAt.Int64,
+		}
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepo) MarkPublished(ctx context.Context, eventID string, publishedAt int64) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE outbox_events SET published_at = $1, locked_until = NULL, last_error = NULL WHERE id = $2",
+		publishedAt, eventID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *outboxRepo) MarkFailed(ctx context.Context, eventID string, nextAttemptAt int64, lastError string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE outbox_events SET next_attempt_at = $1, last_error = $2, locked_until = NULL WHERE id = $3",
+		nextAttemptAt, lastError, eventID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// patchSet collects the columns changed by a partial update
+type patchSet struct {
+	sets []string
+	args []interface{}
+}
+
+func (p *patchSet) add(column string, value interface{}) {
+	p.args = append(p.args, value)
+	p.sets = append(p.sets, fmt.Sprintf("%s = $%d", column, len(p.args)))
+}
+
+// apply updates only the collected columns of the row matching id and version,
+// bumping the version, and returns the number of rows affected. an empty patch
+// writes nothing but still reports whether the id and version match
+func (p *patchSet) apply(ctx context.Context, db *sqlx.DB, table, id string, version int64) (int64, error) {
+	if len(p.sets) == 0 {
+		var count int64
+		err := conn(ctx, db).GetContext(ctx, &count,
+			fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id = $1 AND version = $2 AND deleted_at IS NULL", table),
+			id, version,
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		return count, nil
+	}
+
+	args := append(p.args, id, version)
+	query := fmt.Sprintf("UPDATE %s SET %s, version = version + 1 WHERE id = $%d AND version = $%d AND deleted_at IS NULL",
+		table, strings.Join(p.sets, ", "), len(args)-1, len(args),
+	)
+
+	result, err := conn(ctx, db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -18,16 +18,34 @@ import (
 type Product struct {
 	ID             string         `db:"id"`
 	Name           string         `db:"name"`
+	Slug           string         `db:"slug"`
 	Description    string         `db:"description"`
 	Specifications sql.NullString `db:"specifications"`
+	Translations   Translations   `db:"translations"`
 	BrandID        string         `db:"brand_id"`
 	CategoryID     string         `db:"category_id"`
 	SupplierID     string         `db:"supplier_id"`
 	UnitPrice      float64        `db:"unit_price"`
 	DiscountPrice  float64        `db:"discount_price"`
 	Tags           pq.StringArray `db:"tags"`
-	StatusID       int            `db:"status_id"`
+	Status         string         `db:"status"`
+	PublishAt      int64          `db:"publish_at"`
+	UnpublishAt    int64          `db:"unpublish_at"`
+	RatingCount    int64          `db:"rating_count"`
+	RatingSum      int64          `db:"rating_sum"`
+	RatingAverage  float64        `db:"rating_average"`
 	CreatedAt      int64          `db:"created_at"`
+	DeletedAt      sql.NullInt64  `db:"deleted_at"`
+	Version        int64          `db:"version"`
+	SEO
+}
+
+type ScheduledChange struct {
+	ProductID   string `db:"product_id"`
+	ProductName string `db:"product_name"`
+	Action      string `db:"action"`
+	ScheduledAt int64  `db:"scheduled_at"`
+	Status      string `db:"status"`
 }
 
 type ProductStock struct {
@@ -37,6 +55,13 @@ type ProductStock struct {
 	UpdatedAt     int64  `db:"updated_at"`
 }
 
+// productSorts are the orders of the product sorts, the more rated first among products
+// rated the same
+var productSorts = map[string]string{
+	service.ProductSortPrice:  "unit_price ASC, id ASC",
+	service.ProductSortRating: "rating_average DESC, rating_count DESC, id ASC",
+}
+
 type ProductRepo interface {
 	service.ProductRepo
 }
@@ -52,54 +77,78 @@ func NewProductRepo(db *sqlx.DB) ProductRepo {
 }
 
 func (r *productRepo) Add(ctx context.Context, product *service.Product) (*service.Product, error) {
+	seo := toDBSEO(product.SEO)
+
 	var newProduct Product
-	err := r.db.QueryRowContext(ctx,
+	err := conn(ctx, r.db).QueryRowContext(ctx,
 		`INSERT INTO products (
 			name, 
+			slug,
+			seo_title,
+			seo_description,
+			canonical_url,
 			description, 
 			specifications, 
+			translations,
 			brand_id, 
 			category_id, 
 			supplier_id, 
 			unit_price, 
 			discount_price, 
 			tags, 
-			status_id, 
+			status, 
+			publish_at,
+			unpublish_at,
 			created_at
 		) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		RETURNING id, name, description, specifications, brand_id, category_id, supplier_id, unit_price, discount_price, tags, status_id, created_at`,
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING id, name, slug, seo_title, seo_description, canonical_url, description, specifications, translations, brand_id, category_id, supplier_id, unit_price, discount_price, tags, status, publish_at, unpublish_at, created_at, version`,
 		product.Name,
+		product.Slug,
+		seo.Title,
+		seo.Description,
+		seo.CanonicalURL,
 		product.Description,
 		product.Specifications,
+		toDBTranslations(product.Translations),
 		product.Brand.ID,
 		product.Category.ID,
 		product.Supplier.ID,
 		product.UnitPrice,
 		product.DiscountPrice,
 		pq.Array(product.Tags),
-		product.StatusID,
+		product.Status,
+		product.PublishAt,
+		product.UnpublishAt,
 		product.CreatedAt,
 	).Scan(
 		&newProduct.ID,
 		&newProduct.Name,
+		&newProduct.Slug,
+		&newProduct.SEO.Title,
+		&newProduct.SEO.Description,
+		&newProduct.SEO.CanonicalURL,
 		&newProduct.Description,
 		&newProduct.Specifications,
+		&newProduct.Translations,
 		&newProduct.BrandID,
 		&newProduct.CategoryID,
 		&newProduct.SupplierID,
 		&newProduct.UnitPrice,
 		&newProduct.DiscountPrice,
 		&newProduct.Tags,
-		&newProduct.StatusID,
-		&newProduct.CreatedAt)
+		&newProduct.Status,
+		&newProduct.PublishAt,
+		&newProduct.UnpublishAt,
+		&newProduct.CreatedAt,
+		&newProduct.Version)
 	if err != nil {
 		logger.Error(ctx, "can not create product", err)
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	// insert product stock
-	_, err = r.db.ExecContext(ctx,
+	_, err = conn(ctx, r.db).ExecContext(ctx,
 		"INSERT INTO product_stocks (product_id, stock_quantity, updated_at) VALUES ($1, $2, $3)",
 		newProduct.ID, product.ProductStock.StockQuantity, util.GetCurrentTimestamp(),
 	)
@@ -120,10 +169,32 @@ func (r *productRepo) Add(ctx context.Context, product *service.Product) (*servi
 func (r *productRepo) GetItemByID(ctx context.Context, productID string) (*service.Product, error) {
 	var dbProduct Product
 
-	err := r.db.Get(&dbProduct, "SELECT * FROM products WHERE id = $1", productID)
+	err := conn(ctx, r.db).GetContext(ctx, &dbProduct, "SELECT * FROM products WHERE id = $1 AND deleted_at IS NULL", productID)
 	if err == sql.ErrNoRows {
 		// No product found
-		return nil, nil
+		return nil, service.ErrProductNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	formattedProduct, err := r.formatProduct(ctx, &dbProduct)
+	if err != nil {
+		return nil, err
+	}
+
+	return formattedProduct, nil
+}
+
+func (r *productRepo) GetItemByName(ctx context.Context, supplierID, name string) (*service.Product, error) {
+	var dbProduct Product
+
+	err := conn(ctx, r.db).GetContext(ctx, &dbProduct,
+		"SELECT * FROM products WHERE supplier_id = $1 AND name = $2 AND deleted_at IS NULL",
+		supplierID, name,
+	)
+	if err == sql.ErrNoRows {
+		// No product found
+		return nil, service.ErrProductNotFound
 	} else if err != nil {
 		return nil, err
 	}
@@ -153,16 +224,22 @@ func (r *productRepo) GetItems(ctx context.Context, filterParams service.FilterP
 	query := "SELECT * FROM products"
 
 	// Add filters to the query
-	query += generateFilterConditions(filterParams)
+	whereClause, args := generateFilterConditions(filterParams)
+	query += whereClause
+
+	order, ok := productSorts[filterParams.Sort]
+	if !ok {
+		order = productSorts[service.ProductSortPrice]
+	}
 
 	// Add pagination
-	query += fmt.Sprintf(" ORDER BY unit_price ASC OFFSET %d LIMIT %d", offset, filterParams.Limit)
+	query += fmt.Sprintf(" ORDER BY %s OFFSET %d LIMIT %d", order, offset, filterParams.Limit)
 
 	logger.Info(ctx, "Full query", query)
 
 	// Fetch products and total count
 	var dbProducts []Product
-	err := r.db.Select(&dbProducts, query)
+	err := conn(ctx, r.db).SelectContext(ctx, &dbProducts, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -175,17 +252,12 @@ func (r *productRepo) GetItems(ctx context.Context, filterParams service.FilterP
 
 	var products []service.Product
 	for _, dbProduct := range dbProducts {
-		products = append(products, service.Product{
-			ID:             dbProduct.ID,
-			Name:           dbProduct.Name,
-			Description:    dbProduct.Description,
-			Specifications: dbProduct.Specifications.String,
-			UnitPrice:      dbProduct.UnitPrice,
-			DiscountPrice:  dbProduct.DiscountPrice,
-			Tags:           dbProduct.Tags,
-			StatusID:       dbProduct.StatusID,
-			CreatedAt:      dbProduct.CreatedAt,
-		})
+		product, err := r.formatProduct(ctx, &dbProduct)
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, *product)
 	}
 
 	result := &service.ProductResult{
@@ -198,36 +270,291 @@ func (r *productRepo) GetItems(ctx context.Context, filterParams service.FilterP
 	return result, nil
 }
 
-func (r *productRepo) UpdateItemByID(ctx context.Context, productID string, product *service.Product) error {
-	// Convert the tags slice to a comma-separated string
-	tagsString := strings.Join(product.Tags, ",")
+func (r *productRepo) GetItemsAfter(ctx context.Context, filterParams service.FilterProductsParams, createdAt int64, afterID string, limit int64) ([]service.Product, error) {
+	if filterParams.MaxPrice == 0 {
+		filterParams.MaxPrice = service.MAX_INF
+	}
+
+	whereClause, args := generateFilterConditions(filterParams)
+
+	// the next page starts after the last product of the one before, however many products
+	// were added or removed meanwhile, and without skipping over the pages before
+	if len(afterID) > 0 {
+		args = append(args, createdAt, afterID)
+		whereClause += fmt.Sprintf(" AND (created_at, id) > ($%d, $%d::uuid)", len(args)-1, len(args))
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf("SELECT * FROM products%s ORDER BY created_at ASC, id ASC LIMIT $%d", whereClause, len(args))
+
+	var dbProducts []Product
+	err := conn(ctx, r.db).SelectContext(ctx, &dbProducts, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]service.Product, 0, len(dbProducts))
+	for _, dbProduct := range dbProducts {
+		product, err := r.formatProduct(ctx, &dbProduct)
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, *product)
+	}
+
+	return products, nil
+}
+
+// GetTopByBrand returns the live products of the brand with the most units sold, the
+// newest first among equals
+func (r *productRepo) GetTopByBrand(ctx context.Context, brandID string, limit int64) ([]service.Product, error) {
+	var dbProducts []Product
 
-	_, err := r.db.Exec(
+	err := conn(ctx, r.db).SelectContext(ctx, &dbProducts,
+		`SELECT p.* FROM products p
+		LEFT JOIN (
+			SELECT product_id, SUM(-quantity) AS units_sold
+			FROM stock_movements
+			WHERE reason = $2
+			GROUP BY product_id
+		) sales ON sales.product_id = p.id
+		WHERE p.brand_id = $1 AND p.deleted_at IS NULL AND `+liveProductConditions("p.")+`
+		ORDER BY COALESCE(sales.units_sold, 0) DESC, p.created_at DESC
+		LIMIT $3`,
+		brandID, service.StockReasonSale, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	products := []service.Product{}
+	for i := range dbProducts {
+		product, err := r.formatProduct(ctx, &dbProducts[i])
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, *product)
+	}
+
+	return products, nil
+}
+
+// GetUnarchivedIDs returns the ids of the products of the brand, category or supplier
+// which are not archived yet
+func (r *productRepo) GetUnarchivedIDs(ctx context.Context, owner, ownerID string) ([]string, error) {
+	ownerColumns := map[string]string{
+		service.AuditEntityBrand:    "brand_id",
+		service.AuditEntityCategory: "category_id",
+		service.AuditEntitySupplier: "supplier_id",
+	}
+
+	column, ok := ownerColumns[owner]
+	if !ok {
+		return nil, fmt.Errorf("products do not belong to a %s", owner)
+	}
+
+	productIDs := []string{}
+	err := conn(ctx, r.db).SelectContext(ctx, &productIDs,
+		fmt.Sprintf("SELECT id FROM products WHERE %s = $1 AND status <> $2 AND deleted_at IS NULL ORDER BY created_at", column),
+		ownerID, service.ProductStatusArchived,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return productIDs, nil
+}
+
+func (r *productRepo) GetDueScheduledIDs(ctx context.Context, now int64, limit int) ([]string, error) {
+	productIDs := []string{}
+	err := conn(ctx, r.db).SelectContext(ctx, &productIDs,
+		`SELECT id FROM products
+		WHERE ((publish_at > 0 AND publish_at <= $1) OR (unpublish_at > 0 AND unpublish_at <= $1)) AND deleted_at IS NULL
+		ORDER BY created_at
+		LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return productIDs, nil
+}
+
+// scheduledChangesQuery lists every pending publish and unpublish as a row of its own
+const scheduledChangesQuery = `SELECT * FROM (
+	SELECT id AS product_id, name AS product_name, 'publish' AS action, publish_at AS scheduled_at, status
+	FROM products WHERE publish_at > 0 AND deleted_at IS NULL
+	UNION ALL
+	SELECT id, name, 'unpublish', unpublish_at, status
+	FROM products WHERE unpublish_at > 0 AND deleted_at IS NULL
+) AS changes
+WHERE scheduled_at >= $1 AND ($2 = 0 OR scheduled_at <= $2)`
+
+func (r *productRepo) GetScheduledChanges(ctx context.Context, from, to, page, limit int64) (*service.ScheduleResult, error) {
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	var dbChanges []ScheduledChange
+	err := conn(ctx, r.db).SelectContext(ctx, &dbChanges,
+		fmt.Sprintf("%s ORDER BY scheduled_at, product_id, action OFFSET %d LIMIT %d", scheduledChangesQuery, offset, limit),
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCount int64
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount,
+		"SELECT COUNT(*) FROM ("+scheduledChangesQuery+") AS scheduled",
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []service.ScheduledChange{}
+	for _, dbChange := range dbChanges {
+		changes = append(changes, service.ScheduledChange{
+			ProductID:   dbChange.ProductID,
+			ProductName: dbChange.ProductName,
+			Action:      dbChange.Action,
+			ScheduledAt: dbChange.ScheduledAt,
+			Status:      dbChange.Status,
+		})
+	}
+
+	return &service.ScheduleResult{
+		Changes: changes,
+		Total:   totalCount,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+func (r *productRepo) UpdateItemByID(ctx context.Context, productID string, product *service.Product) (int64, error) {
+	seo := toDBSEO(product.SEO)
+
+	result, err := conn(ctx, r.db).ExecContext(ctx,
 		`UPDATE products 
         SET 
             name = $1, 
-            description = $2, 
-            specifications = $3, 
-            brand_id = $4, 
-            category_id = $5, 
-            supplier_id = $6, 
-            unit_price = $7, 
-            discount_price = $8, 
-            tags = $9, 
-            status_id = $10 
-        WHERE id = $11`,
+            slug = $2,
+            seo_title = $3,
+            seo_description = $4,
+            canonical_url = $5,
+            description = $6, 
+            specifications = $7, 
+            translations = $8,
+            brand_id = $9, 
+            category_id = $10, 
+            supplier_id = $11, 
+            unit_price = $12, 
+            discount_price = $13, 
+            tags = $14, 
+            status = $15,
+            publish_at = $16,
+            unpublish_at = $17,
+            version = version + 1
+        WHERE id = $18 AND version = $19 AND deleted_at IS NULL`,
 		product.Name,
+		product.Slug,
+		seo.Title,
+		seo.Description,
+		seo.CanonicalURL,
 		product.Description,
 		product.Specifications,
+		toDBTranslations(product.Translations),
 		product.Brand.ID,
 		product.Category.ID,
 		product.Supplier.ID,
 		product.UnitPrice,
 		product.DiscountPrice,
-		tagsString, // Use the concatenated string instead of the slice
-		product.StatusID,
-		productID, // Assuming productID is the last parameter in the WHERE clause
+		pq.Array(product.Tags),
+		product.Status,
+		product.PublishAt,
+		product.UnpublishAt,
+		productID,
+		product.Version,
 	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *productRepo) PatchItemByID(ctx context.Context, productID string, patch *service.ProductPatch) (int64, error) {
+	var patchSet patchSet
+
+	if patch.Name != nil {
+		patchSet.add("name", *patch.Name)
+	}
+
+	if patch.Slug != nil {
+		patchSet.add("slug", *patch.Slug)
+	}
+
+	patchSet.addSEO(patch.SEO)
+
+	if patch.Description != nil {
+		patchSet.add("description", *patch.Description)
+	}
+
+	if patch.Specifications != nil {
+		patchSet.add("specifications", *patch.Specifications)
+	}
+
+	if patch.Translations != nil {
+		patchSet.add("translations", toDBTranslations(*patch.Translations))
+	}
+
+	if patch.BrandID != nil {
+		patchSet.add("brand_id", *patch.BrandID)
+	}
+
+	if patch.CategoryID != nil {
+		patchSet.add("category_id", *patch.CategoryID)
+	}
+
+	if patch.SupplierID != nil {
+		patchSet.add("supplier_id", *patch.SupplierID)
+	}
+
+	if patch.UnitPrice != nil {
+		patchSet.add("unit_price", *patch.UnitPrice)
+	}
+
+	if patch.DiscountPrice != nil {
+		patchSet.add("discount_price", *patch.DiscountPrice)
+	}
+
+	if patch.Tags != nil {
+		patchSet.add("tags", pq.Array(*patch.Tags))
+	}
+
+	if patch.Status != nil {
+		patchSet.add("status", *patch.Status)
+	}
+
+	if patch.PublishAt != nil {
+		patchSet.add("publish_at", *patch.PublishAt)
+	}
+
+	if patch.UnpublishAt != nil {
+		patchSet.add("unpublish_at", *patch.UnpublishAt)
+	}
+
+	return patchSet.apply(ctx, r.db, "products", productID, patch.Version)
+}
+
+func (r *productRepo) DeleteItemByID(ctx context.Context, productId string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE products SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", util.GetCurrentTimestamp(), productId)
 	if err != nil {
 		return err
 	}
@@ -235,13 +562,51 @@ func (r *productRepo) UpdateItemByID(ctx context.Context, productID string, prod
 	return nil
 }
 
-func (r *productRepo) DeleteItemByID(ctx context.Context, productId string) error {
-	_, err := r.db.Exec("DELETE FROM product_stocks WHERE product_id = $1", productId)
+func (r *productRepo) RestoreItemByID(ctx context.Context, productID string) error {
+	var dbProduct Product
+	err := conn(ctx, r.db).GetContext(ctx, &dbProduct, "SELECT * FROM products WHERE id = $1 AND deleted_at IS NOT NULL", productID)
+	if err == sql.ErrNoRows {
+		return service.ErrProductNotFound
+	} else if err != nil {
+		return err
+	}
+
+	// a product can not come back while its brand, category or supplier is deleted
+	var parentDeleted bool
+	err = conn(ctx, r.db).GetContext(ctx, &parentDeleted,
+		`SELECT
+			EXISTS (SELECT 1 FROM brands WHERE id = $1 AND deleted_at IS NOT NULL)
+			OR EXISTS (SELECT 1 FROM categories WHERE id = $2 AND deleted_at IS NOT NULL)
+			OR EXISTS (SELECT 1 FROM suppliers WHERE id = $3 AND deleted_at IS NOT NULL)`,
+		dbProduct.BrandID, dbProduct.CategoryID, dbProduct.SupplierID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if parentDeleted {
+		return service.ErrParentDeleted
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE products SET deleted_at = NULL WHERE id = $1", productID)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.Exec("DELETE FROM products WHERE id = $1", productId)
+	return nil
+}
+
+// AdjustRating counts the ratings in the rating of the product and averages them again,
+// whether the product is deleted or not
+func (r *productRepo) AdjustRating(ctx context.Context, productID string, count, sum int64) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE products
+		SET rating_count = rating_count + $1,
+			rating_sum = rating_sum + $2,
+			rating_average = CASE WHEN rating_count + $1 > 0 THEN ROUND((rating_sum + $2)::NUMERIC / (rating_count + $1), 2) ELSE 0 END
+		WHERE id = $3`,
+		count, sum, productID,
+	)
 	if err != nil {
 		return err
 	}
@@ -249,13 +614,66 @@ func (r *productRepo) DeleteItemByID(ctx context.Context, productId string) erro
 	return nil
 }
 
+// PurgeDeleted permanently removes products deleted before the given timestamp along with their stock, reviews, questions
+// and slug redirects
+func (r *productRepo) PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error) {
+	var purged int64
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		purgeable := "SELECT id FROM products WHERE deleted_at IS NOT NULL AND deleted_at < $1"
+
+		_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM stock_movements WHERE product_id IN ("+purgeable+")", deletedBefore)
+		if err != nil {
+			return err
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "DELETE FROM product_stocks WHERE product_id IN ("+purgeable+")", deletedBefore)
+		if err != nil {
+			return err
+		}
+
+		// their votes go along with them
+		_, err = conn(ctx, r.db).ExecContext(ctx, "DELETE FROM reviews WHERE product_id IN ("+purgeable+")", deletedBefore)
+		if err != nil {
+			return err
+		}
+
+		// their answers and upvotes go along with them
+		_, err = conn(ctx, r.db).ExecContext(ctx, "DELETE FROM questions WHERE product_id IN ("+purgeable+")", deletedBefore)
+		if err != nil {
+			return err
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx,
+			"DELETE FROM slug_redirects WHERE entity_type = $2 AND entity_id IN ("+purgeable+")",
+			deletedBefore, service.AuditEntityProduct,
+		)
+		if err != nil {
+			return err
+		}
+
+		result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM products WHERE deleted_at IS NOT NULL AND deleted_at < $1", deletedBefore)
+		if err != nil {
+			return err
+		}
+
+		purged, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
 func (r *productRepo) GetProductStock(ctx context.Context, productID string) (*service.ProductStock, error) {
 	var productStock ProductStock
 
-	err := r.db.Get(&productStock, "SELECT * FROM product_stocks WHERE product_id = $1", productID)
+	err := conn(ctx, r.db).GetContext(ctx, &productStock, "SELECT * FROM product_stocks WHERE product_id = $1", productID)
 	if err == sql.ErrNoRows {
 		// No product found
-		return nil, nil
+		return nil, service.ErrProductNotFound
 	} else if err != nil {
 		return nil, err
 	}
@@ -273,50 +691,59 @@ func (r *productRepo) formatProduct(ctx context.Context, dbProduct *Product) (*s
 	createdProduct := &service.Product{
 		ID:             dbProduct.ID,
 		Name:           dbProduct.Name,
+		Slug:           dbProduct.Slug,
+		SEO:            dbProduct.SEO.toService(),
 		Description:    dbProduct.Description,
 		Specifications: dbProduct.Specifications.String,
+		Translations:   dbProduct.Translations.toService(),
 		UnitPrice:      dbProduct.UnitPrice,
 		DiscountPrice:  dbProduct.DiscountPrice,
 		Tags:           dbProduct.Tags,
-		StatusID:       dbProduct.StatusID,
+		Status:         dbProduct.Status,
+		PublishAt:      dbProduct.PublishAt,
+		UnpublishAt:    dbProduct.UnpublishAt,
+		RatingAverage:  dbProduct.RatingAverage,
+		RatingCount:    dbProduct.RatingCount,
 		CreatedAt:      dbProduct.CreatedAt,
+		DeletedAt:      dbProduct.DeletedAt.Int64,
+		Version:        dbProduct.Version,
 	}
 
 	// fetch brand, then aggregate with product
 	var brand Brand
-	err := r.db.Get(&brand, "SELECT * FROM brands WHERE id = $1", dbProduct.BrandID)
+	err := conn(ctx, r.db).GetContext(ctx, &brand, "SELECT * FROM brands WHERE id = $1", dbProduct.BrandID)
 	if err != nil {
 		logger.Error(ctx, "can not get brand", err)
 		return nil, err
 	}
 
-	createdProduct.Brand = service.Brand{
-		ID:        brand.ID,
-		Name:      brand.Name,
-		StatusID:  brand.StatusID,
-		CreatedAt: brand.CreatedAt,
-	}
+	createdProduct.Brand = *toServiceBrand(&brand)
 
 	// fetch category, then aggregate with product
 	var ctgry Category
-	err = r.db.Get(&ctgry, "SELECT * FROM categories WHERE id = $1", dbProduct.CategoryID)
+	err = conn(ctx, r.db).GetContext(ctx, &ctgry, "SELECT * FROM categories WHERE id = $1", dbProduct.CategoryID)
 	if err != nil {
 		logger.Error(ctx, "can not get category", err)
 		return nil, err
 	}
 
 	createdProduct.Category = service.Category{
-		ID:        ctgry.ID,
-		Name:      ctgry.Name,
-		ParentID:  ctgry.ParentID.String,
-		Sequence:  ctgry.Sequence.String,
-		StatusID:  ctgry.StatusID,
-		CreatedAt: ctgry.CreatedAt,
+		ID:           ctgry.ID,
+		Name:         ctgry.Name,
+		Slug:         ctgry.Slug,
+		SEO:          ctgry.SEO.toService(),
+		Translations: ctgry.Translations.toService(),
+		ParentID:     ctgry.ParentID.String,
+		Sequence:     ctgry.Sequence.String,
+		Status:       ctgry.Status,
+		CreatedAt:    ctgry.CreatedAt,
+		DeletedAt:    ctgry.DeletedAt.Int64,
+		Version:      ctgry.Version,
 	}
 
 	// fetch category, then aggregate with product
 	var spplr Supplier
-	err = r.db.Get(&spplr, "SELECT * FROM suppliers WHERE id = $1", dbProduct.SupplierID)
+	err = conn(ctx, r.db).GetContext(ctx, &spplr, "SELECT * FROM suppliers WHERE id = $1", dbProduct.SupplierID)
 	if err != nil {
 		logger.Error(ctx, "can not get supplier", err)
 		return nil, err
@@ -328,8 +755,10 @@ func (r *productRepo) formatProduct(ctx context.Context, dbProduct *Product) (*s
 		Email:              spplr.Email,
 		Phone:              spplr.Phone,
 		IsVerifiedSupplier: spplr.IsVerifiedSupplier,
-		StatusID:           spplr.StatusID,
+		Status:             spplr.Status,
 		CreatedAt:          spplr.CreatedAt,
+		DeletedAt:          spplr.DeletedAt.Int64,
+		Version:            spplr.Version,
 	}
 
 	// fetch product stock, then aggregate with product
@@ -352,10 +781,11 @@ func (r *productRepo) getTotalProductCount(ctx context.Context, filterParams ser
 
 	// Add filters to the query
 
-	query += generateFilterConditions(filterParams)
+	whereClause, args := generateFilterConditions(filterParams)
+	query += whereClause
 
 	// Execute the query to get total count
-	err := r.db.GetContext(ctx, &totalCount, query)
+	err := conn(ctx, r.db).GetContext(ctx, &totalCount, query, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -363,37 +793,76 @@ func (r *productRepo) getTotalProductCount(ctx context.Context, filterParams ser
 	return totalCount, nil
 }
 
-// generateFilterConditions generates the SQL conditions based on filter parameters
-func generateFilterConditions(filterParams service.FilterProductsParams) string {
-	// Implement your logic to generate conditions based on filter parameters
-	// For example, you can check if filterParams contains non-zero values and construct conditions accordingly.
-	// Return the generated conditions as a string.
-	whereClause := fmt.Sprintf(" WHERE status_id = 1 AND unit_price >= %f AND unit_price <= %f", filterParams.MinPrice, filterParams.MaxPrice)
+// generateFilterConditions generates the SQL conditions based on filter parameters, with
+// the values given as the returned args
+func generateFilterConditions(filterParams service.FilterProductsParams) (string, []interface{}) {
+	args := []interface{}{filterParams.MinPrice, filterParams.MaxPrice}
+	conditions := []string{"unit_price >= $1", "unit_price <= $2"}
+
+	if len(filterParams.Statuses) > 0 {
+		args = append(args, pq.Array(filterParams.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	} else {
+		conditions = append(conditions, liveProductConditions(""))
+	}
+
+	if !filterParams.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if filterParams.MinRating > 0 {
+		args = append(args, filterParams.MinRating)
+		conditions = append(conditions, fmt.Sprintf("rating_count > 0 AND rating_average >= $%d", len(args)))
+	}
 
 	if filterParams.Name != "" {
-		whereClause += fmt.Sprintf(" AND name = '%s'", filterParams.Name)
+		args = append(args, filterParams.Name)
+		conditions = append(conditions, fmt.Sprintf("name = $%d", len(args)))
 	}
 
 	if len(filterParams.BrandIDs) > 0 {
-		brandConditions := make([]string, len(filterParams.BrandIDs))
-		for i, brandID := range filterParams.BrandIDs {
-			brandConditions[i] = fmt.Sprintf("brand_id = '%s'", brandID)
-		}
-
-		whereClause += fmt.Sprintf(" AND (%s)", strings.Join(brandConditions, " OR "))
+		args = append(args, pq.Array(filterParams.BrandIDs))
+		conditions = append(conditions, fmt.Sprintf("brand_id = ANY($%d::uuid[])", len(args)))
 	}
 
 	if len(filterParams.CategoryID) > 0 {
-		whereClause += fmt.Sprintf(" AND category_id = '%s'", filterParams.CategoryID)
+		args = append(args, filterParams.CategoryID)
+		conditions = append(conditions, fmt.Sprintf("category_id = $%d", len(args)))
 	}
 
 	if len(filterParams.SupplierID) > 0 {
-		whereClause += fmt.Sprintf(" AND supplier_id = '%s'", filterParams.SupplierID)
+		args = append(args, filterParams.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("supplier_id = $%d", len(args)))
 	}
 
 	if filterParams.IsVerifiedSupplier {
-		whereClause += fmt.Sprintf(" AND is_verified_supplier = '%v'", filterParams.IsVerifiedSupplier)
+		conditions = append(conditions, "is_verified_supplier")
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// liveProductConditions are the conditions of the products the storefront shows, the live
+// ones of active brands, categories and suppliers whose schedule lets them be shown now.
+// alias prefixes the columns of products
+func liveProductConditions(alias string) string {
+	active := pq.QuoteLiteral(service.StatusActive)
+
+	return fmt.Sprintf(
+		"%[1]sstatus IN (%[2]s) AND %[1]sbrand_id IN (SELECT id FROM brands WHERE status = %[3]s)"+
+			" AND %[1]scategory_id IN (SELECT id FROM categories WHERE status = %[3]s)"+
+			" AND %[1]ssupplier_id IN (SELECT id FROM suppliers WHERE status = %[3]s)"+
+			" AND %[1]spublish_at <= %[4]d AND (%[1]sunpublish_at = 0 OR %[1]sunpublish_at > %[4]d)",
+		alias, quoteLiterals(service.LiveProductStatuses), active, util.GetCurrentTimestamp(),
+	)
+}
+
+// quoteLiterals quotes the values as SQL string literals, separated by commas
+func quoteLiterals(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = pq.QuoteLiteral(value)
 	}
 
-	return whereClause
+	return strings.Join(quoted, ", ")
 }
@@ -0,0 +1,213 @@
+package repo
+
+import (
+	"context"
+
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// DB models
+type StockMovement struct {
+	ID        string  `db:"id"`
+	ProductID string  `db:"product_id"`
+	Quantity  int64   `db:"quantity"`
+	Reason    string  `db:"reason"`
+	UnitPrice float64 `db:"unit_price"`
+	CreatedAt int64   `db:"created_at"`
+}
+
+type SalesReportItem struct {
+	ProductID string  `db:"product_id"`
+	Name      string  `db:"name"`
+	UnitsSold int64   `db:"units_sold"`
+	Revenue   float64 `db:"revenue"`
+}
+
+type LowStockItem struct {
+	ProductID     string `db:"product_id"`
+	Name          string `db:"name"`
+	StockQuantity int64  `db:"stock_quantity"`
+	UpdatedAt     int64  `db:"updated_at"`
+}
+
+type ProductStockRepo interface {
+	service.ProductStockRepo
+}
+
+type productStockRepo struct {
+	db *sqlx.DB
+}
+
+func NewProductStockRepo(db *sqlx.DB) ProductStockRepo {
+	return &productStockRepo{
+		db: db,
+	}
+}
+
+func (r *productStockRepo) Add(ctx context.Context, productStock *service.ProductStock) (*service.ProductStock, error) {
+	var newStock ProductStock
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"INSERT INTO product_stocks (product_id, stock_quantity, updated_at) VALUES ($1, $2, $3) RETURNING id, product_id, stock_quantity, updated_at",
+		productStock.ProductID, productStock.StockQuantity, util.GetCurrentTimestamp(),
+	).Scan(&newStock.ID, &newStock.ProductID, &newStock.StockQuantity, &newStock.UpdatedAt)
+	if err != nil {
+		logger.Error(ctx, "can not create product stock", err)
+		return nil, translateError(err)
+	}
+
+	return toServiceProductStock(&newStock), nil
+}
+
+func (r *productStockRepo) GetItemByProductID(ctx context.Context, productID string) (*service.ProductStock, error) {
+	var productStock ProductStock
+
+	err := conn(ctx, r.db).GetContext(ctx, &productStock, "SELECT * FROM product_stocks WHERE product_id = $1", productID)
+	if err == sql.ErrNoRows {
+		// No stock found
+		return nil, service.ErrProductNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceProductStock(&productStock), nil
+}
+
+func (r *productStockRepo) UpdateItemByID(ctx context.Context, productStockID string, productStock *service.ProductStock) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE product_stocks SET stock_quantity = $1, updated_at = $2 WHERE id = $3",
+		productStock.StockQuantity, util.GetCurrentTimestamp(), productStockID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *productStockRepo) DeleteItemByID(ctx context.Context, productStockID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM product_stocks WHERE id = $1", productStockID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AdjustQuantity applies the movement to the stock of a product and records it,
+// refusing any movement that would take the stock below zero
+func (r *productStockRepo) AdjustQuantity(ctx context.Context, movement *service.StockMovement) (*service.ProductStock, error) {
+	var productStock ProductStock
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		now := util.GetCurrentTimestamp()
+
+		err := conn(ctx, r.db).GetContext(ctx, &productStock,
+			`UPDATE product_stocks
+			SET stock_quantity = stock_quantity + $1, updated_at = $2
+			WHERE product_id = $3 AND stock_quantity + $1 >= 0
+			RETURNING *`,
+			movement.Quantity, now, movement.ProductID,
+		)
+		if err == sql.ErrNoRows {
+			return service.ErrInsufficientStock
+		} else if err != nil {
+			logger.Error(ctx, "can not adjust product stock", err)
+			return err
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx,
+			"INSERT INTO stock_movements (product_id, quantity, reason, unit_price, created_at) VALUES ($1, $2, $3, $4, $5)",
+			movement.ProductID, movement.Quantity, movement.Reason, movement.UnitPrice, now,
+		)
+		if err != nil {
+			logger.Error(ctx, "can not record stock movement", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceProductStock(&productStock), nil
+}
+
+func (r *productStockRepo) GetSalesReport(ctx context.Context, supplierID string, from, to int64) ([]service.SalesReportItem, error) {
+	var dbItems []SalesReportItem
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbItems,
+		`SELECT
+			p.id AS product_id,
+			p.name,
+			COALESCE(SUM(-m.quantity), 0) AS units_sold,
+			COALESCE(SUM(-m.quantity * m.unit_price), 0) AS revenue
+		FROM stock_movements m
+		JOIN products p ON p.id = m.product_id
+		WHERE p.supplier_id = $1 AND m.reason = $2 AND m.created_at >= $3 AND m.created_at <= $4
+		GROUP BY p.id, p.name
+		ORDER BY units_sold DESC`,
+		supplierID, service.StockReasonSale, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []service.SalesReportItem{}
+	for _, dbItem := range dbItems {
+		items = append(items, service.SalesReportItem{
+			ProductID: dbItem.ProductID,
+			Name:      dbItem.Name,
+			UnitsSold: dbItem.UnitsSold,
+			Revenue:   dbItem.Revenue,
+		})
+	}
+
+	return items, nil
+}
+
+func (r *productStockRepo) GetLowStockItems(ctx context.Context, supplierID string, threshold int64) ([]service.LowStockItem, error) {
+	var dbItems []LowStockItem
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbItems,
+		`SELECT
+			p.id AS product_id,
+			p.name,
+			s.stock_quantity,
+			s.updated_at
+		FROM product_stocks s
+		JOIN products p ON p.id = s.product_id
+		WHERE p.supplier_id = $1 AND s.stock_quantity <= $2
+		ORDER BY s.stock_quantity ASC`,
+		supplierID, threshold,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []service.LowStockItem{}
+	for _, dbItem := range dbItems {
+		items = append(items, service.LowStockItem{
+			ProductID:     dbItem.ProductID,
+			Name:          dbItem.Name,
+			StockQuantity: dbItem.StockQuantity,
+			UpdatedAt:     dbItem.UpdatedAt,
+		})
+	}
+
+	return items, nil
+}
+
+func toServiceProductStock(productStock *ProductStock) *service.ProductStock {
+	return &service.ProductStock{
+		ID:            productStock.ID,
+		ProductID:     productStock.ProductID,
+		StockQuantity: productStock.StockQuantity,
+		UpdatedAt:     productStock.UpdatedAt,
+	}
+}
@@ -0,0 +1,343 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/lib/pq"
+)
+
+// DB model
+type Question struct {
+	ID          string `db:"id"`
+	ProductID   string `db:"product_id"`
+	CustomerID  string `db:"customer_id"`
+	AuthorName  string `db:"author_name"`
+	Body        string `db:"body"`
+	Status      string `db:"status"`
+	UpvoteCount int64  `db:"upvote_count"`
+	AnswerCount int64  `db:"answer_count"`
+	CreatedAt   int64  `db:"created_at"`
+	UpdatedAt   int64  `db:"updated_at"`
+	Version     int64  `db:"version"`
+}
+
+// DB model
+type Answer struct {
+	ID          string `db:"id"`
+	QuestionID  string `db:"question_id"`
+	AuthorType  string `db:"author_type"`
+	AuthorID    string `db:"author_id"`
+	AuthorName  string `db:"author_name"`
+	Body        string `db:"body"`
+	UpvoteCount int64  `db:"upvote_count"`
+	CreatedAt   int64  `db:"created_at"`
+}
+
+// questionSorts are the orders of the question sorts, the newest first among equals
+var questionSorts = map[string]string{
+	service.QuestionSortNewest:  "created_at DESC, id",
+	service.QuestionSortUpvotes: "upvote_count DESC, created_at DESC, id",
+}
+
+type QuestionRepo interface {
+	service.QuestionRepo
+}
+
+type questionRepo struct {
+	db *sqlx.DB
+}
+
+func NewQuestionRepo(db *sqlx.DB) QuestionRepo {
+	return &questionRepo{
+		db: db,
+	}
+}
+
+func (r *questionRepo) Add(ctx context.Context, question *service.Question) (*service.Question, error) {
+	var newQuestion Question
+	err := conn(ctx, r.db).GetContext(ctx, &newQuestion,
+		`INSERT INTO questions (product_id, customer_id, author_name, body, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *`,
+		question.ProductID,
+		question.CustomerID,
+		question.AuthorName,
+		question.Body,
+		question.Status,
+		question.CreatedAt,
+		question.UpdatedAt,
+	)
+	if err != nil {
+		logger.Error(ctx, "can not create question", err)
+		return nil, err
+	}
+
+	return toServiceQuestion(&newQuestion), nil
+}
+
+func (r *questionRepo) GetItemByID(ctx context.Context, questionID string) (*service.Question, error) {
+	var question Question
+
+	err := conn(ctx, r.db).GetContext(ctx, &question, "SELECT * FROM questions WHERE id = $1", questionID)
+	if err == sql.ErrNoRows {
+		// No question found
+		return nil, service.ErrQuestionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceQuestion(&question), nil
+}
+
+func (r *questionRepo) GetItems(ctx context.Context, filterParams service.FilterQuestionsParams) (*service.QuestionResult, error) {
+	if filterParams.Page == 0 {
+		filterParams.Page = 1
+	}
+
+	offset := (filterParams.Page - 1) * filterParams.Limit
+
+	conditions := []string{"TRUE"}
+	var args []interface{}
+
+	if len(filterParams.ProductID) > 0 {
+		args = append(args, filterParams.ProductID)
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", len(args)))
+	}
+
+	if len(filterParams.Statuses) > 0 {
+		args = append(args, pq.Array(filterParams.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	if filterParams.Answered != nil {
+		if *filterParams.Answered {
+			conditions = append(conditions, "answer_count > 0")
+		} else {
+			conditions = append(conditions, "answer_count = 0")
+		}
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	order, ok := questionSorts[filterParams.Sort]
+	if !ok {
+		order = questionSorts[service.QuestionSortNewest]
+	}
+
+	var dbQuestions []Question
+	query := fmt.Sprintf("SELECT * FROM questions%s ORDER BY %s OFFSET %d LIMIT %d", whereClause, order, offset, filterParams.Limit)
+	err := conn(ctx, r.db).SelectContext(ctx, &dbQuestions, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCount int64
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM questions"+whereClause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := []service.Question{}
+	for _, dbQuestion := range dbQuestions {
+		questions = append(questions, *toServiceQuestion(&dbQuestion))
+	}
+
+	return &service.QuestionResult{
+		Questions: questions,
+		Total:     totalCount,
+		Page:      filterParams.Page,
+		Limit:     filterParams.Limit,
+	}, nil
+}
+
+// UpdateStatus moves the question to the status, unless it changed since the version
+func (r *questionRepo) UpdateStatus(ctx context.Context, questionID, status string, updatedAt, version int64) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE questions SET status = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND version = $4",
+		status, updatedAt, questionID, version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *questionRepo) DeleteItemByID(ctx context.Context, questionID string) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM questions WHERE id = $1", questionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Upvote counts the upvote on the question only when the voter had not upvoted it yet, so
+// upvoting twice is harmless
+func (r *questionRepo) Upvote(ctx context.Context, questionID, voterID string, createdAt int64) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		result, err := conn(ctx, r.db).ExecContext(ctx,
+			`INSERT INTO question_upvotes (question_id, voter_id, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (question_id, voter_id) DO NOTHING`,
+			questionID, voterID, createdAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return err
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE questions SET upvote_count = upvote_count + 1 WHERE id = $1", questionID)
+		return err
+	})
+}
+
+func (r *questionRepo) AddAnswer(ctx context.Context, answer *service.Answer) (*service.Answer, error) {
+	var newAnswer Answer
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		err := conn(ctx, r.db).GetContext(ctx, &newAnswer,
+			`INSERT INTO answers (question_id, author_type, author_id, author_name, body, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING *`,
+			answer.QuestionID,
+			answer.AuthorType,
+			answer.AuthorID,
+			answer.AuthorName,
+			answer.Body,
+			answer.CreatedAt,
+		)
+		if err != nil {
+			logger.Error(ctx, "can not create answer", err)
+			return err
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE questions SET answer_count = answer_count + 1 WHERE id = $1", answer.QuestionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceAnswer(&newAnswer), nil
+}
+
+func (r *questionRepo) GetAnswer(ctx context.Context, answerID string) (*service.Answer, error) {
+	var answer Answer
+
+	err := conn(ctx, r.db).GetContext(ctx, &answer, "SELECT * FROM answers WHERE id = $1", answerID)
+	if err == sql.ErrNoRows {
+		// No answer found
+		return nil, service.ErrAnswerNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceAnswer(&answer), nil
+}
+
+func (r *questionRepo) GetAnswers(ctx context.Context, questionIDs []string) ([]service.Answer, error) {
+	answers := []service.Answer{}
+	if len(questionIDs) == 0 {
+		return answers, nil
+	}
+
+	var dbAnswers []Answer
+	err := conn(ctx, r.db).SelectContext(ctx, &dbAnswers,
+		"SELECT * FROM answers WHERE question_id = ANY($1) ORDER BY upvote_count DESC, created_at, id",
+		pq.Array(questionIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dbAnswer := range dbAnswers {
+		answers = append(answers, *toServiceAnswer(&dbAnswer))
+	}
+
+	return answers, nil
+}
+
+func (r *questionRepo) DeleteAnswer(ctx context.Context, answer *service.Answer) (int64, error) {
+	var affected int64
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM answers WHERE id = $1", answer.ID)
+		if err != nil {
+			return err
+		}
+
+		affected, err = result.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE questions SET answer_count = answer_count - 1 WHERE id = $1", answer.QuestionID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// UpvoteAnswer counts the upvote on the answer only when the voter had not upvoted it yet
+func (r *questionRepo) UpvoteAnswer(ctx context.Context, answerID, voterID string, createdAt int64) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		result, err := conn(ctx, r.db).ExecContext(ctx,
+			`INSERT INTO answer_upvotes (answer_id, voter_id, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (answer_id, voter_id) DO NOTHING`,
+			answerID, voterID, createdAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return err
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE answers SET upvote_count = upvote_count + 1 WHERE id = $1", answerID)
+		return err
+	})
+}
+
+func toServiceQuestion(question *Question) *service.Question {
+	return &service.Question{
+		ID:          question.ID,
+		ProductID:   question.ProductID,
+		CustomerID:  question.CustomerID,
+		AuthorName:  question.AuthorName,
+		Body:        question.Body,
+		Status:      question.Status,
+		UpvoteCount: question.UpvoteCount,
+		AnswerCount: question.AnswerCount,
+		CreatedAt:   question.CreatedAt,
+		UpdatedAt:   question.UpdatedAt,
+		Version:     question.Version,
+	}
+}
+
+func toServiceAnswer(answer *Answer) *service.Answer {
+	return &service.Answer{
+		ID:          answer.ID,
+		QuestionID:  answer.QuestionID,
+		AuthorType:  answer.AuthorType,
+		AuthorID:    answer.AuthorID,
+		AuthorName:  answer.AuthorName,
+		Body:        answer.Body,
+		UpvoteCount: answer.UpvoteCount,
+		CreatedAt:   answer.CreatedAt,
+	}
+}
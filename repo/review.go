@@ -0,0 +1,253 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/lib/pq"
+)
+
+// DB model
+type Review struct {
+	ID               string `db:"id"`
+	ProductID        string `db:"product_id"`
+	CustomerID       string `db:"customer_id"`
+	AuthorName       string `db:"author_name"`
+	Rating           int64  `db:"rating"`
+	Title            string `db:"title"`
+	Body             string `db:"body"`
+	VerifiedPurchase bool   `db:"verified_purchase"`
+	Status           string `db:"status"`
+	HelpfulCount     int64  `db:"helpful_count"`
+	UnhelpfulCount   int64  `db:"unhelpful_count"`
+	CreatedAt        int64  `db:"created_at"`
+	UpdatedAt        int64  `db:"updated_at"`
+	Version          int64  `db:"version"`
+}
+
+// reviewSorts are the orders of the review sorts, the newest first among equals
+var reviewSorts = map[string]string{
+	service.ReviewSortNewest:  "created_at DESC, id",
+	service.ReviewSortHelpful: "helpful_count DESC, created_at DESC, id",
+	service.ReviewSortHighest: "rating DESC, created_at DESC, id",
+	service.ReviewSortLowest:  "rating ASC, created_at DESC, id",
+}
+
+type ReviewRepo interface {
+	service.ReviewRepo
+}
+
+type reviewRepo struct {
+	db *sqlx.DB
+}
+
+func NewReviewRepo(db *sqlx.DB) ReviewRepo {
+	return &reviewRepo{
+		db: db,
+	}
+}
+
+func (r *reviewRepo) Add(ctx context.Context, review *service.Review) (*service.Review, error) {
+	var newReview Review
+	err := conn(ctx, r.db).GetContext(ctx, &newReview,
+		`INSERT INTO reviews (product_id, customer_id, author_name, rating, title, body, verified_purchase, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING *`,
+		review.ProductID,
+		review.CustomerID,
+		review.AuthorName,
+		review.Rating,
+		review.Title,
+		review.Body,
+		review.VerifiedPurchase,
+		review.Status,
+		review.CreatedAt,
+		review.UpdatedAt,
+	)
+	if err != nil {
+		logger.Error(ctx, "can not create review", err)
+		return nil, err
+	}
+
+	return toServiceReview(&newReview), nil
+}
+
+func (r *reviewRepo) GetItemByID(ctx context.Context, reviewID string) (*service.Review, error) {
+	var review Review
+
+	err := conn(ctx, r.db).GetContext(ctx, &review, "SELECT * FROM reviews WHERE id = $1", reviewID)
+	if err == sql.ErrNoRows {
+		// No review found
+		return nil, service.ErrReviewNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceReview(&review), nil
+}
+
+func (r *reviewRepo) GetItems(ctx context.Context, filterParams service.FilterReviewsParams) (*service.ReviewResult, error) {
+	if filterParams.Page == 0 {
+		filterParams.Page = 1
+	}
+
+	offset := (filterParams.Page - 1) * filterParams.Limit
+
+	conditions := []string{"TRUE"}
+	var args []interface{}
+
+	if len(filterParams.ProductID) > 0 {
+		args = append(args, filterParams.ProductID)
+		conditions = append(conditions, fmt.Sprintf("product_id = $%d", len(args)))
+	}
+
+	if len(filterParams.Statuses) > 0 {
+		args = append(args, pq.Array(filterParams.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	if filterParams.Rating > 0 {
+		args = append(args, filterParams.Rating)
+		conditions = append(conditions, fmt.Sprintf("rating = $%d", len(args)))
+	}
+
+	if filterParams.VerifiedPurchase {
+		conditions = append(conditions, "verified_purchase")
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	order, ok := reviewSorts[filterParams.Sort]
+	if !ok {
+		order = reviewSorts[service.ReviewSortNewest]
+	}
+
+	var dbReviews []Review
+	query := fmt.Sprintf("SELECT * FROM reviews%s ORDER BY %s OFFSET %d LIMIT %d", whereClause, order, offset, filterParams.Limit)
+	err := conn(ctx, r.db).SelectContext(ctx, &dbReviews, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCount int64
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM reviews"+whereClause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := []service.Review{}
+	for _, dbReview := range dbReviews {
+		reviews = append(reviews, *toServiceReview(&dbReview))
+	}
+
+	return &service.ReviewResult{
+		Reviews: reviews,
+		Total:   totalCount,
+		Page:    filterParams.Page,
+		Limit:   filterParams.Limit,
+	}, nil
+}
+
+// UpdateStatus moves the review to the status, unless it changed since the version
+func (r *reviewRepo) UpdateStatus(ctx context.Context, reviewID, status string, updatedAt, version int64) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE reviews SET status = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND version = $4",
+		status, updatedAt, reviewID, version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteItemByID deletes the review, unless it changed since the version
+func (r *reviewRepo) DeleteItemByID(ctx context.Context, reviewID string, version int64) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM reviews WHERE id = $1 AND version = $2", reviewID, version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// AddVote counts the vote on the review, moving it from one count to the other when the
+// voter changed their mind. the review is locked so the votes of a review are counted
+// one at a time
+func (r *reviewRepo) AddVote(ctx context.Context, vote *service.ReviewVote) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		var reviewID string
+		err := conn(ctx, r.db).GetContext(ctx, &reviewID, "SELECT id FROM reviews WHERE id = $1 FOR UPDATE", vote.ReviewID)
+		if err == sql.ErrNoRows {
+			return service.ErrReviewNotFound
+		} else if err != nil {
+			return err
+		}
+
+		var previous sql.NullBool
+		err = conn(ctx, r.db).GetContext(ctx, &previous,
+			"SELECT helpful FROM review_votes WHERE review_id = $1 AND voter_id = $2",
+			vote.ReviewID, vote.VoterID,
+		)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		// the same vote again
+		if previous.Valid && previous.Bool == vote.Helpful {
+			return nil
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx,
+			`INSERT INTO review_votes (review_id, voter_id, helpful, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (review_id, voter_id) DO UPDATE SET helpful = EXCLUDED.helpful, created_at = EXCLUDED.created_at`,
+			vote.ReviewID, vote.VoterID, vote.Helpful, vote.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		var helpful, unhelpful int64
+		if vote.Helpful {
+			helpful = 1
+		} else {
+			unhelpful = 1
+		}
+
+		if previous.Valid {
+			helpful, unhelpful = helpful-unhelpful, unhelpful-helpful
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx,
+			"UPDATE reviews SET helpful_count = helpful_count + $1, unhelpful_count = unhelpful_count + $2 WHERE id = $3",
+			helpful, unhelpful, vote.ReviewID,
+		)
+
+		return err
+	})
+}
+
+func toServiceReview(review *Review) *service.Review {
+	return &service.Review{
+		ID:               review.ID,
+		ProductID:        review.ProductID,
+		CustomerID:       review.CustomerID,
+		AuthorName:       review.AuthorName,
+		Rating:           review.Rating,
+		Title:            review.Title,
+		Body:             review.Body,
+		VerifiedPurchase: review.VerifiedPurchase,
+		Status:           review.Status,
+		HelpfulCount:     review.HelpfulCount,
+		UnhelpfulCount:   review.UnhelpfulCount,
+		CreatedAt:        review.CreatedAt,
+		UpdatedAt:        review.UpdatedAt,
+		Version:          review.Version,
+	}
+}
@@ -0,0 +1,148 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// slugTables are the tables of the entities which have slugs
+var slugTables = map[string]string{
+	service.AuditEntityBrand:    "brands",
+	service.AuditEntityCategory: "categories",
+	service.AuditEntityProduct:  "products",
+}
+
+type SlugRepo interface {
+	service.SlugRepo
+}
+
+type slugRepo struct {
+	db *sqlx.DB
+}
+
+func NewSlugRepo(db *sqlx.DB) SlugRepo {
+	return &slugRepo{
+		db: db,
+	}
+}
+
+func (r *slugRepo) GetEntityID(ctx context.Context, entity, slug string) (string, bool, error) {
+	table, err := slugTable(entity)
+	if err != nil {
+		return "", false, err
+	}
+
+	var entityID string
+	err = conn(ctx, r.db).GetContext(ctx, &entityID, "SELECT id FROM "+table+" WHERE slug = $1", slug)
+	if err == nil {
+		return entityID, false, nil
+	} else if err != sql.ErrNoRows {
+		return "", false, err
+	}
+
+	// the redirect of a purged entity leads nowhere
+	err = conn(ctx, r.db).GetContext(ctx, &entityID,
+		`SELECT r.entity_id FROM slug_redirects r
+		JOIN `+table+` t ON t.id = r.entity_id
+		WHERE r.entity_type = $1 AND r.slug = $2`,
+		entity, slug,
+	)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	return entityID, true, nil
+}
+
+func (r *slugRepo) IsTaken(ctx context.Context, entity, slug, exceptID string) (bool, error) {
+	table, err := slugTable(entity)
+	if err != nil {
+		return false, err
+	}
+
+	var taken bool
+	err = conn(ctx, r.db).GetContext(ctx, &taken,
+		"SELECT EXISTS (SELECT 1 FROM "+table+" WHERE slug = $1 AND id IS DISTINCT FROM $2::uuid)",
+		slug, nullableID(exceptID),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return taken, nil
+}
+
+// AddRedirect points the slug at the entity, replacing whatever it pointed at before
+func (r *slugRepo) AddRedirect(ctx context.Context, entity, slug, entityID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO slug_redirects (entity_type, slug, entity_id, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (entity_type, slug) DO UPDATE SET entity_id = EXCLUDED.entity_id, created_at = EXCLUDED.created_at`,
+		entity, slug, entityID, util.GetCurrentTimestamp(),
+	)
+
+	return err
+}
+
+func (r *slugRepo) DeleteRedirect(ctx context.Context, entity, slug string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM slug_redirects WHERE entity_type = $1 AND slug = $2", entity, slug)
+	return err
+}
+
+func slugTable(entity string) (string, error) {
+	table, ok := slugTables[entity]
+	if !ok {
+		return "", fmt.Errorf("%s has no slugs", entity)
+	}
+
+	return table, nil
+}
+
+// nullableString stores an empty string as NULL
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: len(s) > 0}
+}
+
+// SEO holds the seo columns of the entities which have slugs
+type SEO struct {
+	Title        sql.NullString `db:"seo_title"`
+	Description  sql.NullString `db:"seo_description"`
+	CanonicalURL sql.NullString `db:"canonical_url"`
+}
+
+func toDBSEO(seo service.SEO) SEO {
+	return SEO{
+		Title:        nullableString(seo.Title),
+		Description:  nullableString(seo.Description),
+		CanonicalURL: nullableString(seo.CanonicalURL),
+	}
+}
+
+func (s SEO) toService() service.SEO {
+	return service.SEO{
+		Title:        s.Title.String,
+		Description:  s.Description.String,
+		CanonicalURL: s.CanonicalURL.String,
+	}
+}
+
+// addSEO adds the seo fields set in the patch, an empty one is removed
+func (p *patchSet) addSEO(patch service.SEOPatch) {
+	if patch.Title != nil {
+		p.add("seo_title", nullableString(*patch.Title))
+	}
+
+	if patch.Description != nil {
+		p.add("seo_description", nullableString(*patch.Description))
+	}
+
+	if patch.CanonicalURL != nil {
+		p.add("canonical_url", nullableString(*patch.CanonicalURL))
+	}
+}
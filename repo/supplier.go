@@ -8,17 +8,20 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
 )
 
 // DB Models
 type Supplier struct {
-	ID                 string `db:"id"`
-	Name               string `db:"name"`
-	Email              string `db:"email"`
-	Phone              string `db:"phone"`
-	StatusID           int    `db:"status_id"`
-	IsVerifiedSupplier bool   `db:"is_verified_supplier"`
-	CreatedAt          int64  `db:"created_at"`
+	ID                 string        `db:"id"`
+	Name               string        `db:"name"`
+	Email              string        `db:"email"`
+	Phone              string        `db:"phone"`
+	Status             string        `db:"status"`
+	IsVerifiedSupplier bool          `db:"is_verified_supplier"`
+	CreatedAt          int64         `db:"created_at"`
+	DeletedAt          sql.NullInt64 `db:"deleted_at"`
+	Version            int64         `db:"version"`
 }
 
 type SupplierRepo interface {
@@ -37,12 +40,12 @@ func NewSupplierRepo(db *sqlx.DB) SupplierRepo {
 
 func (r *supplierRepo) Add(ctx context.Context, spplr *service.Supplier) (*service.Supplier, error) {
 	var newSpplr Supplier
-	err := r.db.QueryRowContext(ctx,
-		"INSERT INTO suppliers (name, email, phone, status_id, is_verified_Supplier, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *",
-		spplr.Name, spplr.Email, spplr.Phone, spplr.StatusID, spplr.IsVerifiedSupplier, spplr.CreatedAt,
-	).Scan(&newSpplr.ID, &newSpplr.Name, &newSpplr.Email, &newSpplr.Phone, &newSpplr.StatusID, &newSpplr.IsVerifiedSupplier, &newSpplr.CreatedAt)
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"INSERT INTO suppliers (name, email, phone, status, is_verified_Supplier, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, name, email, phone, status, is_verified_supplier, created_at, version",
+		spplr.Name, spplr.Email, spplr.Phone, spplr.Status, spplr.IsVerifiedSupplier, spplr.CreatedAt,
+	).Scan(&newSpplr.ID, &newSpplr.Name, &newSpplr.Email, &newSpplr.Phone, &newSpplr.Status, &newSpplr.IsVerifiedSupplier, &newSpplr.CreatedAt, &newSpplr.Version)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return &service.Supplier{
@@ -50,19 +53,20 @@ func (r *supplierRepo) Add(ctx context.Context, spplr *service.Supplier) (*servi
 		Name:               newSpplr.Name,
 		Email:              newSpplr.Email,
 		Phone:              newSpplr.Phone,
-		StatusID:           newSpplr.StatusID,
+		Status:             newSpplr.Status,
 		IsVerifiedSupplier: newSpplr.IsVerifiedSupplier,
 		CreatedAt:          newSpplr.CreatedAt,
+		Version:            newSpplr.Version,
 	}, nil
 }
 
 func (r *supplierRepo) GetItemByID(ctx context.Context, spplrID string) (*service.Supplier, error) {
 	var spplr Supplier
 
-	err := r.db.Get(&spplr, "SELECT id, name, email, phone, status_id, is_verified_supplier, created_at FROM suppliers WHERE id = $1", spplrID)
+	err := conn(ctx, r.db).GetContext(ctx, &spplr, "SELECT id, name, email, phone, status, is_verified_supplier, created_at, version FROM suppliers WHERE id = $1 AND deleted_at IS NULL", spplrID)
 	if err == sql.ErrNoRows {
 		// No product found
-		return nil, nil
+		return nil, service.ErrSupplierNotFound
 	} else if err != nil {
 		return nil, err
 	}
@@ -72,27 +76,56 @@ func (r *supplierRepo) GetItemByID(ctx context.Context, spplrID string) (*servic
 		Name:               spplr.Name,
 		Email:              spplr.Email,
 		Phone:              spplr.Phone,
-		StatusID:           spplr.StatusID,
+		Status:             spplr.Status,
 		IsVerifiedSupplier: spplr.IsVerifiedSupplier,
 		CreatedAt:          spplr.CreatedAt,
+		Version:            spplr.Version,
 	}, nil
 }
 
-func (r *supplierRepo) GetItems(ctx context.Context, page int64, limit int64) (*service.SupplierResult, error) {
+func (r *supplierRepo) GetItemByName(ctx context.Context, name string) (*service.Supplier, error) {
+	var spplr Supplier
+
+	err := conn(ctx, r.db).GetContext(ctx, &spplr, "SELECT id, name, email, phone, status, is_verified_supplier, created_at, version FROM suppliers WHERE LOWER(name) = LOWER($1) AND deleted_at IS NULL ORDER BY created_at LIMIT 1", name)
+	if err == sql.ErrNoRows {
+		// No supplier found
+		return nil, service.ErrSupplierNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &service.Supplier{
+		ID:                 spplr.ID,
+		Name:               spplr.Name,
+		Email:              spplr.Email,
+		Phone:              spplr.Phone,
+		Status:             spplr.Status,
+		IsVerifiedSupplier: spplr.IsVerifiedSupplier,
+		CreatedAt:          spplr.CreatedAt,
+		Version:            spplr.Version,
+	}, nil
+}
+
+func (r *supplierRepo) GetItems(ctx context.Context, page int64, limit int64, includeDeleted bool) (*service.SupplierResult, error) {
 	// calculate offset based on page and limit for pagination
 	offset := (page - 1) * limit
 
 	// fetch brands and total count
 	var dbSpplrs []Supplier
 
-	query := fmt.Sprintf("SELECT * FROM suppliers ORDER BY created_at DESC OFFSET %d LIMIT %d", offset, limit)
-	err := r.db.SelectContext(ctx, &dbSpplrs, query)
+	whereClause := " WHERE deleted_at IS NULL"
+	if includeDeleted {
+		whereClause = ""
+	}
+
+	query := fmt.Sprintf("SELECT * FROM suppliers%s ORDER BY created_at DESC OFFSET %d LIMIT %d", whereClause, offset, limit)
+	err := conn(ctx, r.db).SelectContext(ctx, &dbSpplrs, query)
 	if err != nil {
 		return nil, err
 	}
 
 	var totalCount int64
-	err = r.db.GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM suppliers")
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM suppliers"+whereClause)
 	if err != nil {
 		return nil, err
 	}
@@ -104,9 +137,11 @@ func (r *supplierRepo) GetItems(ctx context.Context, page int64, limit int64) (*
 			Name:               dbSpplr.Name,
 			Email:              dbSpplr.Email,
 			Phone:              dbSpplr.Phone,
-			StatusID:           dbSpplr.StatusID,
+			Status:             dbSpplr.Status,
 			IsVerifiedSupplier: dbSpplr.IsVerifiedSupplier,
 			CreatedAt:          dbSpplr.CreatedAt,
+			DeletedAt:          dbSpplr.DeletedAt.Int64,
+			Version:            dbSpplr.Version,
 		})
 	}
 
@@ -121,23 +156,98 @@ func (r *supplierRepo) GetItems(ctx context.Context, page int64, limit int64) (*
 	return result, nil
 }
 
-func (r *supplierRepo) UpdateItemByID(ctx context.Context, spplrID string, spplr *service.Supplier) error {
-	_, err := r.db.Exec(
-		"UPDATE suppliers SET name = $1, email = $2, phone = $3, status_id = $4, is_verified_supplier = $5 WHERE id = $6",
-		spplr.Name, spplr.Email, spplr.Phone, spplr.StatusID, spplr.IsVerifiedSupplier, spplrID,
+func (r *supplierRepo) UpdateItemByID(ctx context.Context, spplrID string, spplr *service.Supplier) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE suppliers SET name = $1, email = $2, phone = $3, status = $4, is_verified_supplier = $5, version = version + 1 WHERE id = $6 AND version = $7 AND deleted_at IS NULL",
+		spplr.Name, spplr.Email, spplr.Phone, spplr.Status, spplr.IsVerifiedSupplier, spplrID, spplr.Version,
 	)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return result.RowsAffected()
+}
+
+func (r *supplierRepo) PatchItemByID(ctx context.Context, spplrID string, patch *service.SupplierPatch) (int64, error) {
+	var patchSet patchSet
+
+	if patch.Name != nil {
+		patchSet.add("name", *patch.Name)
+	}
+
+	if patch.Email != nil {
+		patchSet.add("email", *patch.Email)
+	}
+
+	if patch.Phone != nil {
+		patchSet.add("phone", *patch.Phone)
+	}
+
+	if patch.Status != nil {
+		patchSet.add("status", *patch.Status)
+	}
+
+	if patch.IsVerifiedSupplier != nil {
+		patchSet.add("is_verified_supplier", *patch.IsVerifiedSupplier)
+	}
+
+	return patchSet.apply(ctx, r.db, "suppliers", spplrID, patch.Version)
 }
 
 func (r *supplierRepo) DeleteItemByID(ctx context.Context, spplrID string) error {
-	_, err := r.db.Exec("DELETE FROM suppliers WHERE id = $1", spplrID)
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		var productCount int64
+		err := conn(ctx, r.db).GetContext(ctx, &productCount, "SELECT COUNT(*) FROM products WHERE supplier_id = $1 AND deleted_at IS NULL", spplrID)
+		if err != nil {
+			return err
+		}
+
+		if productCount > 0 {
+			return &service.DependencyError{
+				Entity:     "supplier",
+				Dependents: map[string]int64{"products": productCount},
+			}
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE suppliers SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", util.GetCurrentTimestamp(), spplrID)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *supplierRepo) RestoreItemByID(ctx context.Context, spplrID string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE suppliers SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", spplrID)
 	if err != nil {
 		return err
 	}
 
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return service.ErrSupplierNotFound
+	}
+
 	return nil
 }
+
+// PurgeDeleted permanently removes suppliers deleted before the given timestamp
+// which are no longer referenced by any product
+func (r *supplierRepo) PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM suppliers s
+		WHERE s.deleted_at IS NOT NULL AND s.deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM products p WHERE p.supplier_id = s.id)`,
+		deletedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
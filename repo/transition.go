@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// DB model
+type Transition struct {
+	ID         string `db:"id"`
+	EntityType string `db:"entity_type"`
+	EntityID   string `db:"entity_id"`
+	FromStatus string `db:"from_status"`
+	ToStatus   string `db:"to_status"`
+	Reason     string `db:"reason"`
+	Actor      string `db:"actor"`
+	CreatedAt  int64  `db:"created_at"`
+}
+
+type TransitionRepo interface {
+	service.TransitionRepo
+}
+
+type transitionRepo struct {
+	db *sqlx.DB
+}
+
+func NewTransitionRepo(db *sqlx.DB) TransitionRepo {
+	return &transitionRepo{
+		db: db,
+	}
+}
+
+func (r *transitionRepo) Add(ctx context.Context, transition *service.Transition) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO status_transitions (entity_type, entity_id, from_status, to_status, reason, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		transition.EntityType,
+		transition.EntityID,
+		transition.FromStatus,
+		transition.ToStatus,
+		transition.Reason,
+		transition.Actor,
+		transition.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *transitionRepo) GetItems(ctx context.Context, entityType, entityID string, page, limit int64) (*service.TransitionResult, error) {
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	var dbTransitions []Transition
+	err := conn(ctx, r.db).SelectContext(ctx, &dbTransitions,
+		fmt.Sprintf("SELECT * FROM status_transitions WHERE entity_type = $1 AND entity_id = $2 ORDER BY created_at DESC, id OFFSET %d LIMIT %d", offset, limit),
+		entityType, entityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCount int64
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount,
+		"SELECT COUNT(*) FROM status_transitions WHERE entity_type = $1 AND entity_id = $2",
+		entityType, entityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := []service.Transition{}
+	for _, dbTransition := range dbTransitions {
+		transitions = append(transitions, service.Transition{
+			ID:         dbTransition.ID,
+			EntityType: dbTransition.EntityType,
+			EntityID:   dbTransition.EntityID,
+			FromStatus: dbTransition.FromStatus,
+			ToStatus:   dbTransition.ToStatus,
+			Reason:     dbTransition.Reason,
+			Actor:      dbTransition.Actor,
+			CreatedAt:  dbTransition.CreatedAt,
+		})
+	}
+
+	return &service.TransitionResult{
+		Transitions: transitions,
+		Total:       totalCount,
+		Page:        page,
+		Limit:       limit,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+type txKey struct{}
+
+// dbConn is implemented by both *sqlx.DB and *sqlx.Tx
+type dbConn interface {
+	sqlx.ExtContext
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+type Transactor interface {
+	service.Transactor
+}
+
+type transactor struct {
+	db *sqlx.DB
+}
+
+func NewTransactor(db *sqlx.DB) Transactor {
+	return &transactor{
+		db: db,
+	}
+}
+
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, t.db, fn)
+}
+
+// withinTx runs fn inside the transaction carried by ctx, or inside a new one
+// when there is none, so repos joining a service level transaction do not nest
+func withinTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback()
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// conn returns the transaction carried by ctx, falling back to the db. the errors of the
+// constraints are translated, except for QueryRowContext whose callers translate them
+func conn(ctx context.Context, db *sqlx.DB) dbConn {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return translatingConn{tx}
+	}
+
+	return translatingConn{db}
+}
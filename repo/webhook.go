@@ -0,0 +1,326 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/lib/pq"
+)
+
+// DB model
+type WebhookSubscription struct {
+	ID         string         `db:"id"`
+	URL        string         `db:"url"`
+	Secret     string         `db:"secret"`
+	EventTypes pq.StringArray `db:"event_types"`
+	IsActive   bool           `db:"is_active"`
+	CreatedAt  int64          `db:"created_at"`
+	UpdatedAt  int64          `db:"updated_at"`
+}
+
+// DB model
+type WebhookDelivery struct {
+	ID             string         `db:"id"`
+	SubscriptionID string         `db:"subscription_id"`
+	EventID        string         `db:"event_id"`
+	EventType      string         `db:"event_type"`
+	IdempotencyKey string         `db:"idempotency_key"`
+	Payload        []byte         `db:"payload"`
+	Status         string         `db:"status"`
+	Attempts       int            `db:"attempts"`
+	ResponseCode   sql.NullInt64  `db:"response_code"`
+	LastError      sql.NullString `db:"last_error"`
+	AttemptLog     []byte         `db:"attempt_log"`
+	CreatedAt      int64          `db:"created_at"`
+	UpdatedAt      int64          `db:"updated_at"`
+	DeliveredAt    sql.NullInt64  `db:"delivered_at"`
+}
+
+type WebhookSubscriptionRepo interface {
+	service.WebhookSubscriptionRepo
+}
+
+type webhookSubscriptionRepo struct {
+	db *sqlx.DB
+}
+
+func NewWebhookSubscriptionRepo(db *sqlx.DB) WebhookSubscriptionRepo {
+	return &webhookSubscriptionRepo{
+		db: db,
+	}
+}
+
+func (r *webhookSubscriptionRepo) Add(ctx context.Context, sub *service.WebhookSubscription) (*service.WebhookSubscription, error) {
+	var newSub WebhookSubscription
+	err := conn(ctx, r.db).GetContext(ctx, &newSub,
+		`INSERT INTO webhook_subscriptions (url, secret, event_types, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *`,
+		sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.IsActive, sub.CreatedAt, sub.UpdatedAt,
+	)
+	if err != nil {
+		logger.Error(ctx, "can not create webhook subscription", err)
+		return nil, err
+	}
+
+	return toServiceWebhookSubscription(&newSub), nil
+}
+
+func (r *webhookSubscriptionRepo) GetItemByID(ctx context.Context, subID string) (*service.WebhookSubscription, error) {
+	var sub WebhookSubscription
+
+	err := conn(ctx, r.db).GetContext(ctx, &sub, "SELECT * FROM webhook_subscriptions WHERE id = $1", subID)
+	if err == sql.ErrNoRows {
+		// No subscription found
+		return nil, service.ErrWebhookNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceWebhookSubscription(&sub), nil
+}
+
+func (r *webhookSubscriptionRepo) GetItems(ctx context.Context, page int64, limit int64) (*service.WebhookSubscriptionResult, error) {
+	// calculate offset based on page and limit for pagination
+	offset := (page - 1) * limit
+
+	var dbSubs []WebhookSubscription
+
+	query := fmt.Sprintf("SELECT * FROM webhook_subscriptions ORDER BY created_at DESC OFFSET %d LIMIT %d", offset, limit)
+	err := conn(ctx, r.db).SelectContext(ctx, &dbSubs, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCount int64
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM webhook_subscriptions")
+	if err != nil {
+		return nil, err
+	}
+
+	subs := []service.WebhookSubscription{}
+	for _, dbSub := range dbSubs {
+		subs = append(subs, *toServiceWebhookSubscription(&dbSub))
+	}
+
+	return &service.WebhookSubscriptionResult{
+		Subscriptions: subs,
+		Total:         totalCount,
+		Page:          page,
+		Limit:         limit,
+	}, nil
+}
+
+func (r *webhookSubscriptionRepo) GetActiveItems(ctx context.Context, eventType string) ([]service.WebhookSubscription, error) {
+	var dbSubs []WebhookSubscription
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbSubs,
+		"SELECT * FROM webhook_subscriptions WHERE is_active AND $1 = ANY(event_types) ORDER BY created_at",
+		eventType,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []service.WebhookSubscription
+	for _, dbSub := range dbSubs {
+		subs = append(subs, *toServiceWebhookSubscription(&dbSub))
+	}
+
+	return subs, nil
+}
+
+func (r *webhookSubscriptionRepo) UpdateItemByID(ctx context.Context, subID string, sub *service.WebhookSubscription) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE webhook_subscriptions SET url = $1, secret = $2, event_types = $3, is_active = $4, updated_at = $5 WHERE id = $6",
+		sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.IsActive, sub.UpdatedAt, subID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *webhookSubscriptionRepo) DeleteItemByID(ctx context.Context, subID string) (int64, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", subID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func toServiceWebhookSubscription(sub *WebhookSubscription) *service.WebhookSubscription {
+	return &service.WebhookSubscription{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		EventTypes: sub.EventTypes,
+		IsActive:   sub.IsActive,
+		CreatedAt:  sub.CreatedAt,
+		UpdatedAt:  sub.UpdatedAt,
+	}
+}
+
+type WebhookDeliveryRepo interface {
+	service.WebhookDeliveryRepo
+}
+
+type webhookDeliveryRepo struct {
+	db *sqlx.DB
+}
+
+func NewWebhookDeliveryRepo(db *sqlx.DB) WebhookDeliveryRepo {
+	return &webhookDeliveryRepo{
+		db: db,
+	}
+}
+
+func (r *webhookDeliveryRepo) Add(ctx context.Context, delivery *service.WebhookDelivery) (*service.WebhookDelivery, error) {
+	attemptLog, err := json.Marshal(delivery.AttemptLog)
+	if err != nil {
+		return nil, err
+	}
+
+	var newDelivery WebhookDelivery
+	err = conn(ctx, r.db).GetContext(ctx, &newDelivery,
+		`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, idempotency_key, payload, status, attempt_log, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+		RETURNING *`,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.IdempotencyKey,
+		string(delivery.Payload),
+		delivery.Status,
+		string(attemptLog),
+		delivery.CreatedAt,
+		delivery.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		// the subscription holds a delivery of the event already
+		return nil, nil
+	} else if err != nil {
+		logger.Error(ctx, "can not create webhook delivery", err)
+		return nil, err
+	}
+
+	return toServiceWebhookDelivery(&newDelivery)
+}
+
+func (r *webhookDeliveryRepo) GetItemByID(ctx context.Context, deliveryID string) (*service.WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := conn(ctx, r.db).GetContext(ctx, &delivery, "SELECT * FROM webhook_deliveries WHERE id = $1", deliveryID)
+	if err == sql.ErrNoRows {
+		// No delivery found
+		return nil, service.ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toServiceWebhookDelivery(&delivery)
+}
+
+func (r *webhookDeliveryRepo) GetItems(ctx context.Context, subID string, page int64, limit int64) (*service.WebhookDeliveryResult, error) {
+	// calculate offset based on page and limit for pagination
+	offset := (page - 1) * limit
+
+	var dbDeliveries []WebhookDelivery
+
+	err := conn(ctx, r.db).SelectContext(ctx, &dbDeliveries,
+		fmt.Sprintf("SELECT * FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY created_at DESC OFFSET %d LIMIT %d", offset, limit),
+		subID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCount int64
+	err = conn(ctx, r.db).GetContext(ctx, &totalCount, "SELECT COUNT(*) FROM webhook_deliveries WHERE subscription_id = $1", subID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := []service.WebhookDelivery{}
+	for _, dbDelivery := range dbDeliveries {
+		delivery, err := toServiceWebhookDelivery(&dbDelivery)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return &service.WebhookDeliveryResult{
+		Deliveries: deliveries,
+		Total:      totalCount,
+		Page:       page,
+		Limit:      limit,
+	}, nil
+}
+
+func (r *webhookDeliveryRepo) UpdateItemByID(ctx context.Context, deliveryID string, delivery *service.WebhookDelivery) error {
+	attemptLog, err := json.Marshal(delivery.AttemptLog)
+	if err != nil {
+		return err
+	}
+
+	var responseCode, lastError, deliveredAt interface{}
+	if delivery.ResponseCode > 0 {
+		responseCode = delivery.ResponseCode
+	}
+
+	if len(delivery.LastError) > 0 {
+		lastError = delivery.LastError
+	}
+
+	if delivery.DeliveredAt > 0 {
+		deliveredAt = delivery.DeliveredAt
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_code = $3, last_error = $4, attempt_log = $5, updated_at = $6, delivered_at = $7
+		WHERE id = $8`,
+		delivery.Status, delivery.Attempts, responseCode, lastError, string(attemptLog), delivery.UpdatedAt, deliveredAt, deliveryID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func toServiceWebhookDelivery(delivery *WebhookDelivery) (*service.WebhookDelivery, error) {
+	attemptLog := []service.WebhookAttempt{}
+	if len(delivery.AttemptLog) > 0 {
+		if err := json.Unmarshal(delivery.AttemptLog, &attemptLog); err != nil {
+			return nil, err
+		}
+	}
+
+	return &service.WebhookDelivery{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		IdempotencyKey: delivery.IdempotencyKey,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseCode:   int(delivery.ResponseCode.Int64),
+		LastError:      delivery.LastError.String,
+		AttemptLog:     attemptLog,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+		DeliveredAt:    delivery.DeliveredAt.Int64,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// @Summary Get the audit log
+// @Description Get a paginated list of catalog changes, newest first, with who made them and what changed
+// @Tags Audit
+// @Accept json
+// @Produce json
+// @Param entity_type query string false "Entity type filter (brand, category, supplier, product, stock)"
+// @Param entity_id query string false "Entity ID filter"
+// @Param actor query string false "Actor filter"
+// @Param from query integer false "Start of the range as a millisecond timestamp"
+// @Param to query integer false "End of the range as a millisecond timestamp"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/audit [get]
+func (s *Server) getAuditLogs(ctx *gin.Context) {
+	var req getAuditLogsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	result, err := s.svc.GetAuditLogs(ctx, service.FilterAuditParams{
+		EntityType: req.EntityType,
+		EntityID:   req.EntityID,
+		Actor:      req.Actor,
+		From:       req.From,
+		To:         req.To,
+		Page:       req.Page,
+		Limit:      req.Limit,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot get audit logs", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched audit logs", result))
+}
@@ -1,45 +1,65 @@
 package rest
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/apperr"
 	"github.com/jsiqbal/ecommerce/logger"
 	"github.com/jsiqbal/ecommerce/service"
 	"github.com/jsiqbal/ecommerce/util"
 )
 
+// the largest logo image accepted
+const maxLogoFileSize = 2 << 20
+
+// defaultBrandTopProducts is the number of best selling products a brand page shows
+// unless asked for another
+const defaultBrandTopProducts = 10
+
 // @Summary Create a new brand
 // @Description Create a new brand with the provided details
 // @Tags Brands
 // @Accept json
 // @Produce json
-// @Param request body updateBrandReq true "Brand details to create"
+// @Security AdminAuth
+// @Param request body createBrandReq true "Brand details to create"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/brands [post]
 func (s *Server) createBrand(ctx *gin.Context) {
 	var req createBrandReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	logger.Info(ctx, "req payload", req)
 
 	brand := &service.Brand{
-		Name:      req.Name,
-		StatusID:  req.StatusID,
-		CreatedAt: util.GetCurrentTimestamp(),
+		Name:            req.Name,
+		Slug:            req.Slug,
+		SEO:             req.SEO.toService(),
+		Description:     req.Description,
+		Translations:    toTranslations(req.Translations),
+		Website:         req.Website,
+		CountryOfOrigin: req.CountryOfOrigin,
+		Status:          req.Status,
+		CreatedAt:       util.GetCurrentTimestamp(),
 	}
 
 	newBrand, err := s.svc.AddBrand(ctx, brand)
 	if err != nil {
 		logger.Error(ctx, "cannot add brand", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
@@ -56,13 +76,14 @@ func (s *Server) createBrand(ctx *gin.Context) {
 // @Param id path string true "Brand ID"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/brands/{id} [get]
 func (s *Server) getBrand(ctx *gin.Context) {
 	var req getBrandReq
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
@@ -71,10 +92,12 @@ func (s *Server) getBrand(ctx *gin.Context) {
 	brand, err := s.svc.GetBrand(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot get brand", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
+	setETag(ctx, brand.Version)
+
 	logger.Info(ctx, "res payload", brand)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", brand))
@@ -87,24 +110,30 @@ func (s *Server) getBrand(ctx *gin.Context) {
 // @Produce json
 // @Param page query int true "Page number (starting from 1)"
 // @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Param include_deleted query bool false "Include soft deleted brands, with the admin api key"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/brands [get]
 func (s *Server) getBrands(ctx *gin.Context) {
 	var req getBrandsReq
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	logger.Info(ctx, "req payload", req)
 
-	result, err := s.svc.GetBrands(ctx, req.Page, req.Limit)
+	if !s.allowDeleted(ctx, req.IncludeDeleted) {
+		return
+	}
+
+	result, err := s.svc.GetBrands(ctx, req.Page, req.Limit, req.IncludeDeleted)
 	if err != nil {
 		logger.Error(ctx, "cannot get brands", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
@@ -118,54 +147,142 @@ func (s *Server) getBrands(ctx *gin.Context) {
 // @Tags Brands
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param id path string true "Brand ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param request body updateBrandReq true "Brand details to update"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/brands/{id} [put]
 func (s *Server) updateBrand(ctx *gin.Context) {
 	var req updateBrandReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	brandID := ctx.Param("id")
 	if len(brandID) == 0 {
 		logger.Error(ctx, "cannot pass validation", brandID)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Invalid brand ID", "Bad request"))
+		ctx.Error(apperr.Validation("invalid_parameters", "Invalid brand ID"))
 		return
 	}
 
 	logger.Info(ctx, fmt.Sprintf("req payload for brandID: %s", brandID), req)
 
-	brand, err := s.svc.GetBrand(ctx, brandID)
-	if err != nil {
-		logger.Error(ctx, "cannot get brand", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
 		return
 	}
 
-	if brand == nil {
-		logger.Error(ctx, "brand not found", nil)
-		ctx.JSON(http.StatusNotFound, s.svc.Response(ctx, "Brand Not Found", "Not found"))
+	brand, err := s.svc.GetBrand(ctx, brandID)
+	if err != nil {
+		logger.Error(ctx, "cannot get brand", err)
+		ctx.Error(err)
 		return
 	}
 
 	// update brand
 	brand.Name = req.Name
-	brand.StatusID = req.StatusID
+	brand.Slug = req.Slug
+	brand.SEO = req.SEO.toService()
+	brand.Description = req.Description
+	brand.Translations = toTranslations(req.Translations)
+	brand.Website = req.Website
+	brand.CountryOfOrigin = req.CountryOfOrigin
+	brand.Status = req.Status
+
+	brand.Version = version
 
 	err = s.svc.UpdateBrand(ctx, brandID, brand)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot update stale brand", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
 	if err != nil {
 		logger.Error(ctx, "cannot update brand", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
+	brand.Version++
+	setETag(ctx, brand.Version)
+
+	logger.Info(ctx, "res payload", brand)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", brand))
+}
+
+// @Summary Partially update a brand
+// @Description Update only the supplied fields of a brand, following JSON Merge Patch semantics
+// @Tags Brands
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Brand ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the patch is based on"
+// @Param request body patchBrandReq true "Brand fields to update"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/brands/{id} [patch]
+func (s *Server) patchBrand(ctx *gin.Context) {
+	var req patchBrandReq
+	cleared, err := bindMergePatch(ctx, &req, "seo", "translations")
+	if err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	brandID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for brandID: %s", brandID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	brand, err := s.svc.PatchBrand(ctx, brandID, &service.BrandPatch{
+		Name:            req.Name,
+		Slug:            req.Slug,
+		SEO:             toSEOPatch(req.SEO, cleared["seo"]),
+		Description:     req.Description,
+		Translations:    toTranslationsPatch(req.Translations, cleared["translations"]),
+		Website:         req.Website,
+		CountryOfOrigin: req.CountryOfOrigin,
+		Status:          req.Status,
+		Version:         version,
+	})
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot patch stale brand", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot patch brand", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, brand.Version)
+
 	logger.Info(ctx, "res payload", brand)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", brand))
@@ -176,17 +293,20 @@ func (s *Server) updateBrand(ctx *gin.Context) {
 // @Tags Brands
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param id path string true "Brand ID" format "uuid"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/brands/{id} [delete]
 func (s *Server) deleteBrand(ctx *gin.Context) {
 	var req deleteBrandReq
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
@@ -195,23 +315,204 @@ func (s *Server) deleteBrand(ctx *gin.Context) {
 	brand, err := s.svc.GetBrand(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot get brand", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
-	if brand == nil {
-		logger.Error(ctx, "brand not found", nil)
-		ctx.JSON(http.StatusNotFound, s.svc.Response(ctx, "Brand Not Found", "Not found"))
+	err = s.svc.DeleteBrand(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot delete brand", err)
+
+		ctx.Error(err)
 		return
 	}
 
-	err = s.svc.DeleteBrand(ctx, req.ID)
+	logger.Info(ctx, "res payload", brand)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", brand))
+}
+
+// @Summary Restore a deleted brand
+// @Description Restore a soft deleted brand based on the provided ID
+// @Tags Brands
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Brand ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/brands/{id}/restore [post]
+func (s *Server) restoreBrand(ctx *gin.Context) {
+	var req restoreBrandReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	err := s.svc.RestoreBrand(ctx, req.ID)
 	if err != nil {
-		logger.Error(ctx, "cannot delete brand", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		logger.Error(ctx, "cannot restore brand", err)
+		ctx.Error(err)
 		return
 	}
 
+	brand, err := s.svc.GetBrand(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get brand", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", brand)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully restored", brand))
+}
+
+// @Summary Get the page of a brand
+// @Description Get a brand with the number of its products per category and its best selling products, for the brand page of a storefront
+// @Tags Brands
+// @Produce json
+// @Param id path string true "Brand ID or slug"
+// @Param top query int false "Number of best selling products (min: 1, max: 50, default: 10)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/brands/{id}/page [get]
+func (s *Server) getBrandPage(ctx *gin.Context) {
+	var req getBrandPageReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if req.Top == 0 {
+		req.Top = defaultBrandTopProducts
+	}
+
+	brandID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for brandID: %s", brandID), req)
+
+	page, err := s.svc.GetBrandPage(ctx, brandID, req.Top)
+	if err != nil {
+		logger.Error(ctx, "cannot get brand page", err)
+		ctx.Error(err)
+		return
+	}
+
+	page.Brand.Localize(service.GetLocales(ctx))
+	localizeProducts(ctx, productRefs(page.TopProducts)...)
+
+	logger.Info(ctx, "res payload", page)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", page))
+}
+
+// @Summary Upload the logo of a brand
+// @Description Upload a PNG, JPEG, WebP or GIF image of at most 2MB as the logo of a brand, replacing the one it had
+// @Tags Brands
+// @Accept multipart/form-data
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Brand ID or slug"
+// @Param file formData file true "Logo image"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/brands/{id}/logo [put]
+func (s *Server) uploadBrandLogo(ctx *gin.Context) {
+	brandID := ctx.Param("id")
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "cannot get logo file", err)
+		ctx.Error(apperr.Validation("invalid_parameters", "Api parameter invalid", apperr.FieldError{Field: "file", Message: "is required"}))
+		return
+	}
+
+	logger.Info(ctx, "req payload", fmt.Sprintf("brandID: %s, file: %s, size: %d", brandID, fileHeader.Filename, fileHeader.Size))
+
+	if fileHeader.Size > maxLogoFileSize {
+		logger.Error(ctx, "logo file too large", fileHeader.Size)
+		ctx.Error(apperr.Validation("invalid_parameters", "Api parameter invalid", apperr.FieldError{Field: "file", Message: "must not be larger than 2MB"}))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		logger.Error(ctx, "cannot open logo file", err)
+		ctx.Error(err)
+		return
+	}
+	defer file.Close()
+
+	// the type is told by the content, the name and the header of the part can lie
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		logger.Error(ctx, "cannot read logo file", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	contentType := http.DetectContentType(head[:n])
+
+	brand, err := s.svc.SetBrandLogo(ctx, brandID, contentType, io.MultiReader(bytes.NewReader(head[:n]), file))
+	if errors.Is(err, service.ErrUnsupportedLogo) {
+		logger.Error(ctx, "unsupported logo", contentType)
+		ctx.Error(err)
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot set brand logo", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, brand.Version)
+
+	logger.Info(ctx, "res payload", brand)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", brand))
+}
+
+// @Summary Delete the logo of a brand
+// @Description Remove the logo of a brand
+// @Tags Brands
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Brand ID or slug"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/brands/{id}/logo [delete]
+func (s *Server) deleteBrandLogo(ctx *gin.Context) {
+	brandID := ctx.Param("id")
+
+	logger.Info(ctx, "req payload", brandID)
+
+	brand, err := s.svc.DeleteBrandLogo(ctx, brandID)
+	if err != nil {
+		logger.Error(ctx, "cannot delete brand logo", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, brand.Version)
+
 	logger.Info(ctx, "res payload", brand)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", brand))
@@ -0,0 +1,270 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// @Summary Create, update and delete brands in bulk
+// @Description Validate every operation up front, then run them in one transaction (atomic, default) or one by one (best_effort)
+// @Tags Brands
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param request body bulkBrandsReq true "Brand operations"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/brands/bulk [post]
+func (s *Server) bulkBrands(ctx *gin.Context) {
+	var req bulkBrandsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	ops := make([]service.BulkBrandOp, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = service.BulkBrandOp{
+			Op: op.Op,
+			ID: op.ID,
+		}
+
+		if op.Data != nil {
+			ops[i].Brand = &service.Brand{
+				Name:            op.Data.Name,
+				Slug:            op.Data.Slug,
+				SEO:             op.Data.SEO.toService(),
+				Description:     op.Data.Description,
+				Translations:    toTranslations(op.Data.Translations),
+				Website:         op.Data.Website,
+				CountryOfOrigin: op.Data.CountryOfOrigin,
+				Status:          op.Data.Status,
+				CreatedAt:       util.GetCurrentTimestamp(),
+				Version:         op.Version,
+			}
+		}
+	}
+
+	result, err := s.svc.BulkBrands(ctx, bulkMode(req.Mode), ops)
+	s.bulkResponse(ctx, result, err)
+}
+
+// @Summary Create, update and delete categories in bulk
+// @Description Validate every operation up front, then run them in one transaction (atomic, default) or one by one (best_effort)
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param request body bulkCategoriesReq true "Category operations"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/bulk [post]
+func (s *Server) bulkCategories(ctx *gin.Context) {
+	var req bulkCategoriesReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	ops := make([]service.BulkCategoryOp, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = service.BulkCategoryOp{
+			Op: op.Op,
+			ID: op.ID,
+		}
+
+		if op.Data != nil {
+			ops[i].Category = &service.Category{
+				Name:         op.Data.Name,
+				Slug:         op.Data.Slug,
+				SEO:          op.Data.SEO.toService(),
+				Translations: toTranslations(op.Data.Translations),
+				ParentID:     op.Data.ParentID,
+				Status:       op.Data.Status,
+				CreatedAt:    util.GetCurrentTimestamp(),
+				Version:      op.Version,
+			}
+		}
+	}
+
+	result, err := s.svc.BulkCategories(ctx, bulkMode(req.Mode), ops)
+	s.bulkResponse(ctx, result, err)
+}
+
+// @Summary Create, update and delete suppliers in bulk
+// @Description Validate every operation up front, then run them in one transaction (atomic, default) or one by one (best_effort)
+// @Tags Suppliers
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param request body bulkSuppliersReq true "Supplier operations"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/suppliers/bulk [post]
+func (s *Server) bulkSuppliers(ctx *gin.Context) {
+	var req bulkSuppliersReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	ops := make([]service.BulkSupplierOp, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = service.BulkSupplierOp{
+			Op: op.Op,
+			ID: op.ID,
+		}
+
+		if op.Data == nil {
+			continue
+		}
+
+		spplrID := op.ID
+		if op.Op == service.BulkOpCreate {
+			newID, err := uuid.NewUUID()
+			if err != nil {
+				logger.Error(ctx, "cannot create supplier id using uuid generator", err)
+				ctx.Error(err)
+				return
+			}
+
+			spplrID = newID.String()
+		}
+
+		ops[i].Supplier = &service.Supplier{
+			ID:                 spplrID,
+			Name:               op.Data.Name,
+			Email:              op.Data.Email,
+			Phone:              op.Data.Phone,
+			Status:             op.Data.Status,
+			IsVerifiedSupplier: op.Data.IsVerifiedSupplier,
+			CreatedAt:          util.GetCurrentTimestamp(),
+			Version:            op.Version,
+		}
+	}
+
+	result, err := s.svc.BulkSuppliers(ctx, bulkMode(req.Mode), ops)
+	s.bulkResponse(ctx, result, err)
+}
+
+// @Summary Create, update and delete products in bulk
+// @Description Validate every operation up front (brand, category and supplier existence, supplier wise name uniqueness), then run them in one transaction (atomic, default) or one by one (best_effort)
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param request body bulkProductsReq true "Product operations"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/bulk [post]
+func (s *Server) bulkProducts(ctx *gin.Context) {
+	var req bulkProductsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	ops := make([]service.BulkProductOp, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = service.BulkProductOp{
+			Op: op.Op,
+			ID: op.ID,
+		}
+
+		if op.Data != nil {
+			ops[i].Product = &service.Product{
+				Name:           op.Data.Name,
+				Slug:           op.Data.Slug,
+				SEO:            op.Data.SEO.toService(),
+				Description:    op.Data.Description,
+				Specifications: op.Data.Specifications,
+				Translations:   toTranslations(op.Data.Translations),
+				Brand: service.Brand{
+					ID: op.Data.BrandID,
+				},
+				Category: service.Category{
+					ID: op.Data.CategoryID,
+				},
+				Supplier: service.Supplier{
+					ID: op.Data.SupplierID,
+				},
+				ProductStock: service.ProductStock{
+					StockQuantity: op.Data.StockQuantity,
+				},
+				UnitPrice:     op.Data.UnitPrice,
+				DiscountPrice: op.Data.DiscountPrice,
+				Tags:          op.Data.Tags,
+				Status:        op.Data.Status,
+				PublishAt:     op.Data.PublishAt,
+				UnpublishAt:   op.Data.UnpublishAt,
+				CreatedAt:     util.GetCurrentTimestamp(),
+				Version:       op.Version,
+			}
+		}
+	}
+
+	result, err := s.svc.BulkProducts(ctx, bulkMode(req.Mode), ops)
+	s.bulkResponse(ctx, result, err)
+}
+
+// errBulkRolledBack is the error of an atomic bulk request of which an operation failed,
+// carrying the outcome of every operation
+var errBulkRolledBack = apperr.Unprocessable("bulk_rolled_back", "Bulk operations rolled back")
+
+// bulkMode defaults bulk requests to all or nothing
+func bulkMode(mode string) string {
+	if len(mode) == 0 {
+		return service.BulkModeAtomic
+	}
+
+	return mode
+}
+
+// bulkResponse reports the outcome of every operation, answering 422 when an
+// atomic request was rolled back
+func (s *Server) bulkResponse(ctx *gin.Context, result *service.BulkResult, err error) {
+	if err != nil {
+		logger.Error(ctx, "cannot run bulk operations", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	if result.Mode == service.BulkModeAtomic && result.Failed > 0 {
+		ctx.Error(errBulkRolledBack.WithDetails(result))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Bulk operations processed", result))
+}
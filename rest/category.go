@@ -1,21 +1,17 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/apperr"
 	"github.com/jsiqbal/ecommerce/logger"
 	"github.com/jsiqbal/ecommerce/service"
 	"github.com/jsiqbal/ecommerce/util"
 )
 
-// TreeNode represents a node in the category tree
-type TreeNode struct {
-	Category service.Category
-	Children []*TreeNode `json:"children"`
-}
-
 type Category struct {
 	ID           string      `json:"id"`
 	CategoryName string      `json:"category_name"`
@@ -27,32 +23,44 @@ type Category struct {
 // @Tags Categories
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param request body createCategoryReq true "Category details to create"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/categories [post]
 func (s *Server) createCategory(ctx *gin.Context) {
 	var req createCategoryReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	logger.Info(ctx, "req payload", req)
 
+	if !s.resolveRefs(ctx, service.AuditEntityCategory, &req.ParentID) {
+		return
+	}
+
 	ctgry := &service.Category{
-		Name:      req.Name,
-		ParentID:  req.ParentID,
-		StatusID:  req.StatusID,
-		CreatedAt: util.GetCurrentTimestamp(),
+		Name:         req.Name,
+		Slug:         req.Slug,
+		SEO:          req.SEO.toService(),
+		Translations: toTranslations(req.Translations),
+		ParentID:     req.ParentID,
+		Status:       req.Status,
+		CreatedAt:    util.GetCurrentTimestamp(),
 	}
 
 	newCategory, err := s.svc.AddCategory(ctx, ctgry)
 	if err != nil {
 		logger.Error(ctx, "cannot add category", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
@@ -76,7 +84,7 @@ func (s *Server) getCategory(ctx *gin.Context) {
 	var req getCategoryReq
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
@@ -85,10 +93,12 @@ func (s *Server) getCategory(ctx *gin.Context) {
 	ctgry, err := s.svc.GetCategory(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot get category", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
+	setETag(ctx, ctgry.Version)
+
 	logger.Info(ctx, "res payload", ctgry)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", ctgry))
@@ -101,24 +111,30 @@ func (s *Server) getCategory(ctx *gin.Context) {
 // @Produce json
 // @Param page query int true "Page number (starting from 1)"
 // @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Param include_deleted query bool false "Include soft deleted categories, with the admin api key"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/categories [get]
 func (s *Server) getCategories(ctx *gin.Context) {
 	var req getCategoriesReq
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	logger.Info(ctx, "req payload", req)
 
-	result, err := s.svc.GetCategories(ctx, req.Page, req.Limit)
+	if !s.allowDeleted(ctx, req.IncludeDeleted) {
+		return
+	}
+
+	result, err := s.svc.GetCategories(ctx, req.Page, req.Limit, req.IncludeDeleted)
 	if err != nil {
 		logger.Error(ctx, "cannot get categories", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
@@ -128,7 +144,7 @@ func (s *Server) getCategories(ctx *gin.Context) {
 }
 
 // @Summary Get a formatted list of categories
-// @Description Get a formatted list of categories with hierarchical structure
+// @Description Get every category nested under its parent, siblings in sequence order
 // @Tags Categories
 // @Accept json
 // @Produce json
@@ -136,80 +152,339 @@ func (s *Server) getCategories(ctx *gin.Context) {
 // @Failure 500 {object} ErrorResponse
 // @Router /api/categories/tree [get]
 func (s *Server) getFormattedCategories(ctx *gin.Context) {
-	result, err := s.svc.GetCategories(ctx, 1, service.MAX_INF)
+	tree, err := s.svc.GetCategoryTree(ctx)
 	if err != nil {
-		logger.Error(ctx, "cannot get categories", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		logger.Error(ctx, "cannot get category tree", err)
+		ctx.Error(err)
 		return
 	}
 
-	logger.Info(ctx, "res payload", result)
-
-	treeNodes := buildCategoryTree(result.Categories, "")
-
-	// convert TreeNode data to an array of Category objects
+	// convert the tree nodes to an array of Category objects
 	var categories []*Category
-	for _, node := range treeNodes {
-		category := convertTreeNodeToCategory(node)
-		categories = append(categories, category)
+	for _, node := range tree {
+		categories = append(categories, convertTreeNodeToCategory(node))
 	}
 
+	logger.Info(ctx, "res payload", categories)
+
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched categories", categories))
 }
 
+// @Summary Get a category subtree
+// @Description Get a category with the categories underneath it, siblings in sequence order
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID" format "uuid"
+// @Param depth query int false "Levels below the category to include, all of them when 0"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/{id}/subtree [get]
+func (s *Server) getCategorySubtree(ctx *gin.Context) {
+	var req getCategorySubtreeReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	subtree, err := s.svc.GetCategorySubtree(ctx, req.ID, req.Depth)
+	if err != nil {
+		logger.Error(ctx, "cannot get category subtree", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", subtree)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", subtree))
+}
+
+// @Summary Get the ancestors of a category
+// @Description Get the breadcrumb path of a category, from the root down to its parent
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/{id}/ancestors [get]
+func (s *Server) getCategoryAncestors(ctx *gin.Context) {
+	var req getCategoryReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	ancestors, err := s.svc.GetCategoryAncestors(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get category ancestors", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", ancestors)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", ancestors))
+}
+
+// @Summary Move a category
+// @Description Move a category, along with the categories underneath it, under a new active parent or to the root when parent_id is empty
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Category ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the move is based on"
+// @Param request body moveCategoryReq true "New parent"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/{id}/move [post]
+func (s *Server) moveCategory(ctx *gin.Context) {
+	var req moveCategoryReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	ctgryID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for ctgryID: %s", ctgryID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	if !s.resolveRefs(ctx, service.AuditEntityCategory, &req.ParentID) {
+		return
+	}
+
+	ctgry, err := s.svc.MoveCategory(ctx, ctgryID, req.ParentID, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot move stale category", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot move category", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, ctgry.Version)
+
+	logger.Info(ctx, "res payload", ctgry)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully moved", ctgry))
+}
+
+// @Summary Reorder sibling categories
+// @Description Set the sequence of the children of a parent, or of the root categories when parent_id is empty, to the order of category_ids
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param request body reorderCategoriesReq true "Parent and the new order of its children"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/reorder [post]
+func (s *Server) reorderCategories(ctx *gin.Context) {
+	var req reorderCategoriesReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	if !s.resolveRefs(ctx, service.AuditEntityCategory, &req.ParentID) ||
+		!s.resolveRefList(ctx, service.AuditEntityCategory, req.CategoryIDs) {
+		return
+	}
+
+	ctgries, err := s.svc.ReorderCategories(ctx, req.ParentID, req.CategoryIDs)
+	if err != nil {
+		logger.Error(ctx, "cannot reorder categories", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", ctgries)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully reordered", ctgries))
+}
+
 // @Summary Update a category
 // @Description Update an existing category with the provided details
 // @Tags Categories
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param id path string true "Category ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param request body updateCategoryReq true "Category details to update"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/categories/{id} [put]
 func (s *Server) updateCategory(ctx *gin.Context) {
 	var req updateCategoryReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	ctgryID := ctx.Param("id")
 	if len(ctgryID) == 0 {
 		logger.Error(ctx, "cannot pass validation", ctgryID)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Invalid brand ID", "Bad request"))
+		ctx.Error(apperr.Validation("invalid_parameters", "Invalid brand ID"))
 		return
 	}
 
 	logger.Info(ctx, fmt.Sprintf("req payload for categoryID: %s", ctgryID), req)
 
-	ctgry, err := s.svc.GetCategory(ctx, ctgryID)
-	if err != nil {
-		logger.Error(ctx, "cannot get category", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
 		return
 	}
 
-	if ctgry == nil {
-		logger.Error(ctx, "category not found", nil)
-		ctx.JSON(http.StatusNotFound, s.svc.Response(ctx, "Category Not Found", "Not found"))
+	ctgry, err := s.svc.GetCategory(ctx, ctgryID)
+	if err != nil {
+		logger.Error(ctx, "cannot get category", err)
+		ctx.Error(err)
 		return
 	}
 
 	// update category
 	ctgry.Name = req.Name
-	ctgry.StatusID = req.StatusID
+	ctgry.Slug = req.Slug
+	ctgry.SEO = req.SEO.toService()
+	ctgry.Translations = toTranslations(req.Translations)
+	ctgry.Status = req.Status
+
+	ctgry.Version = version
 
 	err = s.svc.UpdateCategory(ctx, ctgryID, ctgry)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot update stale category", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
 	if err != nil {
 		logger.Error(ctx, "cannot update category", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
+		return
+	}
+
+	ctgry.Version++
+	setETag(ctx, ctgry.Version)
+
+	logger.Info(ctx, "res payload", ctgry)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", ctgry))
+}
+
+// @Summary Partially update a category
+// @Description Update only the supplied fields of a category, following JSON Merge Patch semantics
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Category ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the patch is based on"
+// @Param request body patchCategoryReq true "Category fields to update"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/{id} [patch]
+func (s *Server) patchCategory(ctx *gin.Context) {
+	var req patchCategoryReq
+	cleared, err := bindMergePatch(ctx, &req, "sequence", "seo", "translations")
+	if err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	ctgryID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for ctgryID: %s", ctgryID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	if cleared["sequence"] {
+		empty := ""
+		req.Sequence = &empty
+	}
+
+	ctgry, err := s.svc.PatchCategory(ctx, ctgryID, &service.CategoryPatch{
+		Name:         req.Name,
+		Slug:         req.Slug,
+		SEO:          toSEOPatch(req.SEO, cleared["seo"]),
+		Translations: toTranslationsPatch(req.Translations, cleared["translations"]),
+		Sequence:     req.Sequence,
+		Status:       req.Status,
+		Version:      version,
+	})
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot patch stale category", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot patch category", err)
+		ctx.Error(err)
 		return
 	}
 
+	setETag(ctx, ctgry.Version)
+
 	logger.Info(ctx, "res payload", ctgry)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", ctgry))
@@ -220,17 +495,20 @@ func (s *Server) updateCategory(ctx *gin.Context) {
 // @Tags Categories
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param id path string true "Category ID" format "uuid"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/categories/{id} [delete]
 func (s *Server) deleteCategory(ctx *gin.Context) {
 	var req deleteCategoryReq
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
@@ -239,20 +517,15 @@ func (s *Server) deleteCategory(ctx *gin.Context) {
 	ctgry, err := s.svc.GetCategory(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot get category", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
-		return
-	}
-
-	if ctgry == nil {
-		logger.Error(ctx, "category not found", nil)
-		ctx.JSON(http.StatusNotFound, s.svc.Response(ctx, "Category Not Found", "Not found"))
+		ctx.Error(err)
 		return
 	}
 
 	err = s.svc.DeleteCategory(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot delete category", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+
+		ctx.Error(err)
 		return
 	}
 
@@ -261,38 +534,50 @@ func (s *Server) deleteCategory(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", ctgry))
 }
 
-// buildCategoryTree builds a tree structure from a list of categories
-func buildCategoryTree(categories []service.Category, parentID string) []*TreeNode {
-	var categoryTree []*TreeNode
+// @Summary Restore a deleted category
+// @Description Restore a soft deleted category based on the provided ID
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Category ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/{id}/restore [post]
+func (s *Server) restoreCategory(ctx *gin.Context) {
+	var req restoreCategoryReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
 
-	// create a map to quickly look up categories by ID
-	categoryMap := make(map[string]*TreeNode)
+	logger.Info(ctx, "req payload", req)
 
-	// create tree nodes for each category and add them to the map
-	for _, category := range categories {
-		node := &TreeNode{Category: category}
-		categoryMap[category.ID] = node
+	err := s.svc.RestoreCategory(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot restore category", err)
+		ctx.Error(err)
+		return
 	}
 
-	// build the tree structure for the specified parent ID
-	for _, category := range categories {
-		node := categoryMap[category.ID]
-		if category.ParentID == parentID {
-			// this category has the specified parent, add it to the tree
-			node.Children = buildCategoryTree(categories, category.ID)
-			categoryTree = append(categoryTree, node)
-		}
+	ctgry, err := s.svc.GetCategory(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get category", err)
+		ctx.Error(err)
+		return
 	}
 
-	// // sort children based on sequence
-	// sort.Slice(categoryTree, func(i, j int) bool {
-	// 	return categoryTree[i].Sequence < categoryTree[j].Sequence
-	// })
+	logger.Info(ctx, "res payload", ctgry)
 
-	return categoryTree
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully restored", ctgry))
 }
 
-func convertTreeNodeToCategory(node *TreeNode) *Category {
+func convertTreeNodeToCategory(node *service.CategoryNode) *Category {
 	category := &Category{
 		ID:           node.Category.ID,
 		CategoryName: node.Category.Name,
@@ -1,8 +1,28 @@
 package rest
 
+import "github.com/jsiqbal/ecommerce/apperr"
+
 // --------------Responses --------------
+
+// ErrorResponse is the body of every unsuccessful response, an RFC 7807 problem
+// served as application/problem+json
 type ErrorResponse struct {
-	Message string `json:"message"`
+	// Type names the kind of the error, e.g. urn:problem-type:not-found
+	Type   string `json:"type" example:"urn:problem-type:not-found"`
+	Title  string `json:"title" example:"Not Found"`
+	Status int    `json:"status" example:"404"`
+	// Detail is the error itself, for people
+	Detail   string `json:"detail" example:"brand not found"`
+	Instance string `json:"instance" example:"/api/brands/4f9c1d6e-3b2a-4c8d-9e7f-1a2b3c4d5e6f"`
+	// Code tells the error apart from the others of its kind, for programs
+	Code string `json:"code,omitempty" example:"brand_not_found"`
+	// TraceID is the trace id of the request, to find it in the server logs
+	TraceID string `json:"trace_id" example:"8d3c9a1f2b4e"`
+	// Errors are the fields of the request which were rejected and why
+	Errors []apperr.FieldError `json:"errors,omitempty"`
+	// Data holds further details of some errors, e.g. the dependents of a record which
+	// can not be deleted or the results of a bulk request which was rolled back
+	Data interface{} `json:"data,omitempty"`
 }
 
 type SuccessResponse struct {
@@ -14,34 +34,72 @@ type SuccessResponse struct {
 /////////////////////// brand dtos //////////////////////
 
 type createBrandReq struct {
-	Name     string `json:"name" binding:"required,min=2,max=50"`
-	StatusID int    `json:"status_id" binding:"required,validStatusID"`
+	Name            string                        `json:"name" binding:"required,min=2,max=50"`
+	Slug            string                        `json:"slug" binding:"omitempty,validSlug"`
+	SEO             seoReq                        `json:"seo"`
+	Description     string                        `json:"description" binding:"max=2000"`
+	Translations    map[string]nameTranslationReq `json:"translations" binding:"omitempty,max=50,dive,keys,validLocale,endkeys,required"`
+	Website         string                        `json:"website" binding:"omitempty,max=2048,validWebsite"`
+	CountryOfOrigin string                        `json:"country_of_origin" binding:"omitempty,validCountryCode"`
+	Status          string                        `json:"status" binding:"omitempty,validStatus"`
 }
 
 type getBrandReq struct {
 	ID string `uri:"id" binding:"required"`
 }
 
+type getBrandPageReq struct {
+	// Top is the number of best selling products, 10 when not given
+	Top int64 `form:"top" binding:"omitempty,min=1,max=50"`
+}
+
 type getBrandsReq struct {
-	Page  int64 `form:"page" binding:"required,min=1"`
-	Limit int64 `form:"limit" binding:"required,min=1,max=100"`
+	Page           int64 `form:"page" binding:"required,min=1"`
+	Limit          int64 `form:"limit" binding:"required,min=1,max=100"`
+	IncludeDeleted bool  `form:"include_deleted"`
 }
 
 type updateBrandReq struct {
-	Name     string `json:"name" binding:"required,min=2,max=50"`
-	StatusID int    `json:"status_id" binding:"required"`
+	Name            string                        `json:"name" binding:"required,min=2,max=50"`
+	Slug            string                        `json:"slug" binding:"omitempty,validSlug"`
+	SEO             seoReq                        `json:"seo"`
+	Description     string                        `json:"description" binding:"max=2000"`
+	Translations    map[string]nameTranslationReq `json:"translations" binding:"omitempty,max=50,dive,keys,validLocale,endkeys,required"`
+	Website         string                        `json:"website" binding:"omitempty,max=2048,validWebsite"`
+	CountryOfOrigin string                        `json:"country_of_origin" binding:"omitempty,validCountryCode"`
+	Status          string                        `json:"status" binding:"omitempty,validStatus"`
+	Version         int64                         `json:"version"`
+}
+
+type patchBrandReq struct {
+	Name            *string                        `json:"name" binding:"omitnil,min=2,max=50"`
+	Slug            *string                        `json:"slug" binding:"omitnil,eq=|validSlug"`
+	SEO             *patchSEOReq                   `json:"seo"`
+	Description     *string                        `json:"description" binding:"omitnil,max=2000"`
+	Translations    *map[string]nameTranslationReq `json:"translations" binding:"omitnil,max=50,dive,keys,validLocale,endkeys,required"`
+	Website         *string                        `json:"website" binding:"omitnil,max=2048,eq=|validWebsite"`
+	CountryOfOrigin *string                        `json:"country_of_origin" binding:"omitnil,eq=|validCountryCode"`
+	Status          *string                        `json:"status" binding:"omitnil,validStatus"`
+	Version         int64                          `json:"version"`
 }
 
 type deleteBrandReq struct {
 	ID string `uri:"id" binding:"required"`
 }
 
+type restoreBrandReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
 /////////////////////// category dtos //////////////////////
 
 type createCategoryReq struct {
-	Name     string `json:"name" binding:"required,min=2,max=50"`
-	ParentID string `json:"parent_id"`
-	StatusID int    `json:"status_id" binding:"required,validStatusID"`
+	Name         string                        `json:"name" binding:"required,min=2,max=50"`
+	Slug         string                        `json:"slug" binding:"omitempty,validSlug"`
+	SEO          seoReq                        `json:"seo"`
+	Translations map[string]nameTranslationReq `json:"translations" binding:"omitempty,max=50,dive,keys,validLocale,endkeys,required"`
+	ParentID     string                        `json:"parent_id"`
+	Status       string                        `json:"status" binding:"omitempty,validStatus"`
 }
 
 type getCategoryReq struct {
@@ -49,26 +107,60 @@ type getCategoryReq struct {
 }
 
 type getCategoriesReq struct {
-	Page  int64 `form:"page" binding:"required,min=1"`
-	Limit int64 `form:"limit" binding:"required,min=1,max=100"`
+	Page           int64 `form:"page" binding:"required,min=1"`
+	Limit          int64 `form:"limit" binding:"required,min=1,max=100"`
+	IncludeDeleted bool  `form:"include_deleted"`
 }
 
 type updateCategoryReq struct {
-	Name     string `json:"name" binding:"required,min=2,max=50"`
-	StatusID int    `json:"status_id"`
+	Name         string                        `json:"name" binding:"required,min=2,max=50"`
+	Slug         string                        `json:"slug" binding:"omitempty,validSlug"`
+	SEO          seoReq                        `json:"seo"`
+	Translations map[string]nameTranslationReq `json:"translations" binding:"omitempty,max=50,dive,keys,validLocale,endkeys,required"`
+	Status       string                        `json:"status" binding:"omitempty,validStatus"`
+	Version      int64                         `json:"version"`
+}
+
+type patchCategoryReq struct {
+	Name         *string                        `json:"name" binding:"omitnil,min=2,max=50"`
+	Slug         *string                        `json:"slug" binding:"omitnil,eq=|validSlug"`
+	SEO          *patchSEOReq                   `json:"seo"`
+	Translations *map[string]nameTranslationReq `json:"translations" binding:"omitnil,max=50,dive,keys,validLocale,endkeys,required"`
+	Sequence     *string                        `json:"sequence"`
+	Status       *string                        `json:"status" binding:"omitnil,validStatus"`
+	Version      int64                          `json:"version"`
+}
+
+type getCategorySubtreeReq struct {
+	ID    string `uri:"id" binding:"required"`
+	Depth int    `form:"depth" binding:"min=0"`
+}
+
+type moveCategoryReq struct {
+	ParentID string `json:"parent_id"`
+	Version  int64  `json:"version"`
+}
+
+type reorderCategoriesReq struct {
+	ParentID    string   `json:"parent_id"`
+	CategoryIDs []string `json:"category_ids" binding:"required,min=1,dive,required"`
 }
 
 type deleteCategoryReq struct {
 	ID string `uri:"id" binding:"required"`
 }
 
+type restoreCategoryReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
 //////////////////////// supplier dtos /////////////////////////
 
 type createSupplierReq struct {
 	Name               string `json:"name" binding:"required,min=2,max=50"`
 	Email              string `json:"email" binding:"required,email"`
 	Phone              string `json:"phone" binding:"required,validPhone"`
-	StatusID           int    `json:"status_id" binding:"required,validStatusID"`
+	Status             string `json:"status" binding:"omitempty,validStatus"`
 	IsVerifiedSupplier bool   `json:"is_verified_supplier" binding:"required"`
 }
 
@@ -77,67 +169,496 @@ type getSupplierReq struct {
 }
 
 type getSuppliersReq struct {
-	Page  int64 `form:"page" binding:"required,min=1"`
-	Limit int64 `form:"limit" binding:"required,min=1,max=100"`
+	Page           int64 `form:"page" binding:"required,min=1"`
+	Limit          int64 `form:"limit" binding:"required,min=1,max=100"`
+	IncludeDeleted bool  `form:"include_deleted"`
 }
 
 type updateSupplierReq struct {
 	Name               string `json:"name" binding:"required,min=2,max=50"`
 	Email              string `json:"email" binding:"required,email"`
 	Phone              string `json:"phone" binding:"required,validPhone"`
-	StatusID           int    `json:"status_id" binding:"required,validStatusID"`
+	Status             string `json:"status" binding:"omitempty,validStatus"`
 	IsVerifiedSupplier bool   `json:"is_verified_supplier" binding:"required"`
+	Version            int64  `json:"version"`
+}
+
+type patchSupplierReq struct {
+	Name               *string `json:"name" binding:"omitnil,min=2,max=50"`
+	Email              *string `json:"email" binding:"omitnil,email"`
+	Phone              *string `json:"phone" binding:"omitnil,validPhone"`
+	Status             *string `json:"status" binding:"omitnil,validStatus"`
+	IsVerifiedSupplier *bool   `json:"is_verified_supplier"`
+	Version            int64   `json:"version"`
 }
 
 type deleteSupplierReq struct {
 	ID string `uri:"id" binding:"required"`
 }
 
+type restoreSupplierReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
 //////////////////////////////// product dtos //////////////////////////////////
 
 type createProductReq struct {
-	Name           string   `json:"name" binding:"required,min=2,max=50"`
-	Description    string   `json:"description" binding:"required,min=2,max=500"`
-	Specifications string   `json:"specifications" binding:"min=0,max=500"`
-	BrandID        string   `json:"brand_id" binding:"required"`
-	CategoryID     string   `json:"category_id" binding:"required"`
-	SupplierID     string   `json:"supplier_id" binding:"required"`
-	UnitPrice      float64  `json:"unit_price" binding:"required,min=0"`
-	DiscountPrice  float64  `json:"discount_price" binding:"required,min=0"`
-	Tags           []string `json:"tags" binding:"required"`
-	StatusID       int      `json:"status_id" binding:"required,validStatusID"`
-	StockQuantity  int64    `json:"stock_quantity" binding:"required,min=1"`
+	Name           string                    `json:"name" binding:"required,min=2,max=50"`
+	Slug           string                    `json:"slug" binding:"omitempty,validSlug"`
+	SEO            seoReq                    `json:"seo"`
+	Description    string                    `json:"description" binding:"required,min=2,max=500"`
+	Specifications string                    `json:"specifications" binding:"min=0,max=500"`
+	Translations   map[string]translationReq `json:"translations" binding:"omitempty,max=50,dive,keys,validLocale,endkeys,required"`
+	BrandID        string                    `json:"brand_id" binding:"required"`
+	CategoryID     string                    `json:"category_id" binding:"required"`
+	SupplierID     string                    `json:"supplier_id" binding:"required"`
+	UnitPrice      float64                   `json:"unit_price" binding:"required,min=0"`
+	DiscountPrice  float64                   `json:"discount_price" binding:"required,min=0"`
+	Tags           []string                  `json:"tags" binding:"required"`
+	Status         string                    `json:"status" binding:"omitempty,validProductStatus"`
+	// PublishAt and UnpublishAt schedule the product to go live and off sale, in unix milliseconds
+	PublishAt     int64 `json:"publish_at" binding:"min=0"`
+	UnpublishAt   int64 `json:"unpublish_at" binding:"min=0"`
+	StockQuantity int64 `json:"stock_quantity" binding:"required,min=1"`
 }
 
 type getProductReq struct {
 	ID string `uri:"id" binding:"required"`
 }
 
+type getProductQueryReq struct {
+	// Preview shows a product its schedule hides, to the catalog team
+	Preview bool `form:"preview"`
+}
+
+type getScheduledChangesReq struct {
+	From  int64 `form:"from" binding:"min=0"`
+	To    int64 `form:"to" binding:"min=0"`
+	Page  int64 `form:"page" binding:"required,min=1"`
+	Limit int64 `form:"limit" binding:"required,min=1,max=100"`
+}
+
 type getProductsReq struct {
+	Name           string   `form:"name"`
+	MinPrice       float64  `form:"min_price" binding:"min=0"`
+	MaxPrice       float64  `form:"max_price" binding:"min=0"`
+	BrandIDs       []string `form:"brand_ids"`
+	CategoryID     string   `form:"category_id"`
+	SupplierID     string   `form:"supplier_id"`
+	Statuses       []string `form:"status" binding:"omitempty,dive,validProductStatus"`
+	MinRating      float64  `form:"min_rating" binding:"min=0,max=5"`
+	Sort           string   `form:"sort" binding:"omitempty,oneof=price rating"`
+	IncludeDeleted bool     `form:"include_deleted"`
+	Page           int64    `form:"Page"`
+	Limit          int64    `form:"limit" binding:"required,min=1,max=100"`
+}
+
+type updateProductReq struct {
+	Name           string                    `json:"name" binding:"required,min=2,max=50"`
+	Slug           string                    `json:"slug" binding:"omitempty,validSlug"`
+	SEO            seoReq                    `json:"seo"`
+	Description    string                    `json:"description" binding:"required,min=2,max=500"`
+	Specifications string                    `json:"specifications" binding:"min=0,max=500"`
+	Translations   map[string]translationReq `json:"translations" binding:"omitempty,max=50,dive,keys,validLocale,endkeys,required"`
+	BrandID        string                    `json:"brand_id" binding:"required"`
+	CategoryID     string                    `json:"category_id" binding:"required"`
+	SupplierID     string                    `json:"supplier_id" binding:"required"`
+	UnitPrice      float64                   `json:"unit_price" binding:"required,min=0"`
+	DiscountPrice  float64                   `json:"discount_price" binding:"required,min=0"`
+	Tags           []string                  `json:"tags" binding:"required"`
+	Status         string                    `json:"status" binding:"omitempty,validProductStatus"`
+	// PublishAt and UnpublishAt schedule the product to go live and off sale, in unix milliseconds
+	PublishAt     int64 `json:"publish_at" binding:"min=0"`
+	UnpublishAt   int64 `json:"unpublish_at" binding:"min=0"`
+	StockQuantity int64 `json:"stock_quantity" binding:"required,min=1"`
+	Version       int64 `json:"version"`
+}
+
+type patchProductReq struct {
+	Name           *string                    `json:"name" binding:"omitnil,min=2,max=50"`
+	Slug           *string                    `json:"slug" binding:"omitnil,eq=|validSlug"`
+	SEO            *patchSEOReq               `json:"seo"`
+	Description    *string                    `json:"description" binding:"omitnil,min=2,max=500"`
+	Specifications *string                    `json:"specifications" binding:"omitnil,max=500"`
+	Translations   *map[string]translationReq `json:"translations" binding:"omitnil,max=50,dive,keys,validLocale,endkeys,required"`
+	BrandID        *string                    `json:"brand_id" binding:"omitnil,min=1"`
+	CategoryID     *string                    `json:"category_id" binding:"omitnil,min=1"`
+	SupplierID     *string                    `json:"supplier_id" binding:"omitnil,min=1"`
+	UnitPrice      *float64                   `json:"unit_price" binding:"omitnil,min=0"`
+	DiscountPrice  *float64                   `json:"discount_price" binding:"omitnil,min=0"`
+	Tags           *[]string                  `json:"tags"`
+	Status         *string                    `json:"status" binding:"omitnil,validProductStatus"`
+	// a zero time removes the schedule
+	PublishAt   *int64 `json:"publish_at" binding:"omitnil,min=0"`
+	UnpublishAt *int64 `json:"unpublish_at" binding:"omitnil,min=0"`
+	Version     int64  `json:"version"`
+}
+
+type deleteProductReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+type restoreProductReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+//////////////////////////////// supplier portal dtos //////////////////////////////////
+
+type createPortalProductReq struct {
+	Name           string                    `json:"name" binding:"required,min=2,max=50"`
+	Description    string                    `json:"description" binding:"required,min=2,max=500"`
+	Specifications string                    `json:"specifications" binding:"min=0,max=500"`
+	Translations   map[string]translationReq `json:"translations" binding:"omitempty,max=50,dive,keys,validLocale,endkeys,required"`
+	BrandID        string                    `json:"brand_id" binding:"required"`
+	CategoryID     string                    `json:"category_id" binding:"required"`
+	UnitPrice      float64                   `json:"unit_price" binding:"required,min=0"`
+	DiscountPrice  float64                   `json:"discount_price" binding:"required,min=0"`
+	Tags           []string                  `json:"tags" binding:"required"`
+	// Status is draft unless given, a product goes live once the catalog team approves it
+	Status        string `json:"status" binding:"omitempty,oneof=draft pending_review"`
+	StockQuantity int64  `json:"stock_quantity" binding:"required,min=1"`
+}
+
+type getPortalProductsReq struct {
 	Name       string   `form:"name"`
 	MinPrice   float64  `form:"min_price" binding:"min=0"`
 	MaxPrice   float64  `form:"max_price" binding:"min=0"`
 	BrandIDs   []string `form:"brand_ids"`
 	CategoryID string   `form:"category_id"`
-	SupplierID string   `form:"supplier_id"`
-	Page       int64    `form:"Page"`
+	Statuses   []string `form:"status" binding:"omitempty,dive,validProductStatus"`
+	Page       int64    `form:"page"`
 	Limit      int64    `form:"limit" binding:"required,min=1,max=100"`
 }
 
-type updateProductReq struct {
-	Name           string   `json:"name" binding:"required,min=2,max=50"`
-	Description    string   `json:"description" binding:"required,min=2,max=500"`
-	Specifications string   `json:"specifications" binding:"min=0,max=500"`
-	BrandID        string   `json:"brand_id" binding:"required"`
-	CategoryID     string   `json:"category_id" binding:"required"`
-	SupplierID     string   `json:"supplier_id" binding:"required"`
-	UnitPrice      float64  `json:"unit_price" binding:"required,min=0"`
-	DiscountPrice  float64  `json:"discount_price" binding:"required,min=0"`
-	Tags           []string `json:"tags" binding:"required"`
-	StatusID       int      `json:"status_id" binding:"required,validStatusID"`
-	StockQuantity  int64    `json:"stock_quantity" binding:"required,min=1"`
+type updatePortalProductReq struct {
+	Name           string                    `json:"name" binding:"required,min=2,max=50"`
+	Description    string                    `json:"description" binding:"required,min=2,max=500"`
+	Specifications string                    `json:"specifications" binding:"min=0,max=500"`
+	Translations   map[string]translationReq `json:"translations" binding:"omitempty,max=50,dive,keys,validLocale,endkeys,required"`
+	BrandID        string                    `json:"brand_id" binding:"required"`
+	CategoryID     string                    `json:"category_id" binding:"required"`
+	UnitPrice      float64                   `json:"unit_price" binding:"required,min=0"`
+	DiscountPrice  float64                   `json:"discount_price" binding:"required,min=0"`
+	Tags           []string                  `json:"tags" binding:"required"`
+	Version        int64                     `json:"version"`
 }
 
-type deleteProductReq struct {
+type adjustStockReq struct {
+	Quantity int64  `json:"quantity" binding:"required"`
+	Reason   string `json:"reason" binding:"required,oneof=sale restock return adjustment"`
+}
+
+type getSalesReportReq struct {
+	From int64 `form:"from" binding:"min=0"`
+	To   int64 `form:"to" binding:"min=0"`
+}
+
+type getLowStockReportReq struct {
+	Threshold *int64 `form:"threshold" binding:"omitempty,min=0"`
+}
+
+//////////////////////////////// audit dtos //////////////////////////////////
+
+type getAuditLogsReq struct {
+	EntityType string `form:"entity_type" binding:"omitempty,oneof=brand category supplier product stock"`
+	EntityID   string `form:"entity_id"`
+	Actor      string `form:"actor"`
+	From       int64  `form:"from" binding:"min=0"`
+	To         int64  `form:"to" binding:"min=0"`
+	Page       int64  `form:"page" binding:"required,min=1"`
+	Limit      int64  `form:"limit" binding:"required,min=1,max=100"`
+}
+
+//////////////////////////////// bulk dtos //////////////////////////////////
+
+type bulkBrandsReq struct {
+	Mode       string        `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []bulkBrandOp `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+type bulkBrandOp struct {
+	Op      string          `json:"op" binding:"required,oneof=create update delete"`
+	ID      string          `json:"id"`
+	Version int64           `json:"version"`
+	Data    *createBrandReq `json:"data"`
+}
+
+type bulkCategoriesReq struct {
+	Mode       string           `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []bulkCategoryOp `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+type bulkCategoryOp struct {
+	Op      string             `json:"op" binding:"required,oneof=create update delete"`
+	ID      string             `json:"id"`
+	Version int64              `json:"version"`
+	Data    *createCategoryReq `json:"data"`
+}
+
+type bulkSuppliersReq struct {
+	Mode       string           `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []bulkSupplierOp `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+type bulkSupplierOp struct {
+	Op      string             `json:"op" binding:"required,oneof=create update delete"`
+	ID      string             `json:"id"`
+	Version int64              `json:"version"`
+	Data    *createSupplierReq `json:"data"`
+}
+
+type bulkProductsReq struct {
+	Mode       string          `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []bulkProductOp `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+type bulkProductOp struct {
+	Op      string            `json:"op" binding:"required,oneof=create update delete"`
+	ID      string            `json:"id"`
+	Version int64             `json:"version"`
+	Data    *createProductReq `json:"data"`
+}
+
+//////////////////////////////// import dtos //////////////////////////////////
+
+type importProductsReq struct {
+	DryRun bool `form:"dry_run"`
+}
+
+type getImportJobReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+type getImportErrorsReq struct {
+	ID     string `uri:"id" binding:"required"`
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
+
+//////////////////////////////// export dtos //////////////////////////////////
+
+type exportProductsReq struct {
+	Name           string   `form:"name"`
+	MinPrice       float64  `form:"min_price" binding:"min=0"`
+	MaxPrice       float64  `form:"max_price" binding:"min=0"`
+	BrandIDs       []string `form:"brand_ids"`
+	CategoryID     string   `form:"category_id"`
+	SupplierID     string   `form:"supplier_id"`
+	Statuses       []string `form:"status" binding:"omitempty,dive,validProductStatus"`
+	IncludeDeleted bool     `form:"include_deleted"`
+	Format         string   `form:"format" binding:"omitempty,oneof=csv jsonl xlsx"`
+	Columns        string   `form:"columns"`
+}
+
+//////////////////////////////// job dtos //////////////////////////////////
+
+type getJobReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+//////////////////////////////// webhook dtos //////////////////////////////////
+
+type createWebhookReq struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+type getWebhookReq struct {
 	ID string `uri:"id" binding:"required"`
 }
+
+type getWebhooksReq struct {
+	Page  int64 `form:"page" binding:"required,min=1"`
+	Limit int64 `form:"limit" binding:"required,min=1,max=100"`
+}
+
+type updateWebhookReq struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+	IsActive   bool     `json:"is_active"`
+}
+
+type getWebhookDeliveriesReq struct {
+	ID    string `uri:"id" binding:"required"`
+	Page  int64  `form:"page" binding:"required,min=1"`
+	Limit int64  `form:"limit" binding:"required,min=1,max=100"`
+}
+
+type redeliverWebhookReq struct {
+	ID         string `uri:"id" binding:"required"`
+	DeliveryID string `uri:"delivery_id" binding:"required"`
+}
+
+//////////////////////////////// slug dtos //////////////////////////////////
+
+type seoReq struct {
+	Title        string `json:"title" binding:"max=255"`
+	Description  string `json:"description" binding:"max=1000"`
+	CanonicalURL string `json:"canonical_url" binding:"omitempty,url,max=2048"`
+}
+
+type patchSEOReq struct {
+	Title        *string `json:"title" binding:"omitnil,max=255"`
+	Description  *string `json:"description" binding:"omitnil,max=1000"`
+	CanonicalURL *string `json:"canonical_url" binding:"omitnil,max=2048,eq=|url"`
+}
+
+type resolvePathReq struct {
+	Path string `form:"path" binding:"required,max=1000"`
+}
+
+//////////////////////////////// locale dtos //////////////////////////////////
+
+// translationReq is the name and description of a product in another language, keyed by
+// locale. empty fields fall back to those of the default locale
+type translationReq struct {
+	Name        string `json:"name" binding:"omitempty,min=2,max=50"`
+	Description string `json:"description" binding:"omitempty,min=2,max=500"`
+}
+
+// nameTranslationReq is the name of a brand or category in another language, keyed by locale
+type nameTranslationReq struct {
+	Name string `json:"name" binding:"required,min=2,max=50"`
+}
+
+//////////////////////////////// status dtos //////////////////////////////////
+
+type transitionReq struct {
+	Status string `json:"status" binding:"required,validStatus"`
+	// Reason is kept with the transition, e.g. why a supplier was deactivated
+	Reason  string `json:"reason" binding:"max=500"`
+	Version int64  `json:"version"`
+}
+
+type productTransitionReq struct {
+	Status  string `json:"status" binding:"required,validProductStatus"`
+	Reason  string `json:"reason" binding:"max=500"`
+	Version int64  `json:"version"`
+}
+
+type getTransitionsReq struct {
+	Page  int64 `form:"page" binding:"required,min=1"`
+	Limit int64 `form:"limit" binding:"required,min=1,max=100"`
+}
+
+//////////////////////////////// review dtos //////////////////////////////////
+
+type createReviewReq struct {
+	// CustomerID is trusted as it is, the storefront sends the review with the admin api key
+	// on behalf of the customer it signed in
+	CustomerID string `json:"customer_id" binding:"required,max=64"`
+	AuthorName string `json:"author_name" binding:"required,min=2,max=100"`
+	Rating     int64  `json:"rating" binding:"required,min=1,max=5"`
+	Title      string `json:"title" binding:"max=150"`
+	Body       string `json:"body" binding:"max=5000"`
+	// VerifiedPurchase is set by the storefront when the customer bought the product
+	VerifiedPurchase bool `json:"verified_purchase"`
+}
+
+type getReviewReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+type deleteReviewReq struct {
+	// Version is taken when there is no If-Match header
+	Version int64 `form:"version"`
+}
+
+type getProductReviewsReq struct {
+	Rating           int64  `form:"rating" binding:"omitempty,min=1,max=5"`
+	VerifiedPurchase bool   `form:"verified_purchase"`
+	Sort             string `form:"sort" binding:"omitempty,oneof=newest helpful highest lowest"`
+	Page             int64  `form:"page" binding:"required,min=1"`
+	Limit            int64  `form:"limit" binding:"required,min=1,max=100"`
+}
+
+type getReviewsReq struct {
+	ProductID        string   `form:"product_id"`
+	Statuses         []string `form:"status" binding:"omitempty,dive,validModeration"`
+	Rating           int64    `form:"rating" binding:"omitempty,min=1,max=5"`
+	VerifiedPurchase bool     `form:"verified_purchase"`
+	Sort             string   `form:"sort" binding:"omitempty,oneof=newest helpful highest lowest"`
+	Page             int64    `form:"page" binding:"required,min=1"`
+	Limit            int64    `form:"limit" binding:"required,min=1,max=100"`
+}
+
+type moderateReviewReq struct {
+	Status string `json:"status" binding:"required,validModeration"`
+	// Reason is kept with the transition, e.g. why a review was rejected
+	Reason  string `json:"reason" binding:"max=500"`
+	Version int64  `json:"version"`
+}
+
+type voteReviewReq struct {
+	// VoterID is trusted as it is, like the customer of a review
+	VoterID string `json:"voter_id" binding:"required,max=64"`
+	Helpful *bool  `json:"helpful" binding:"required"`
+}
+
+//////////////////////////////// question dtos //////////////////////////////////
+
+type askQuestionReq struct {
+	// CustomerID is trusted as it is, like the customer of a review
+	CustomerID string `json:"customer_id" binding:"required,max=64"`
+	AuthorName string `json:"author_name" binding:"required,min=2,max=100"`
+	Body       string `json:"body" binding:"required,min=3,max=2000"`
+}
+
+type getQuestionReq struct {
+	ID         string `uri:"id" binding:"required"`
+	QuestionID string `uri:"question_id" binding:"required"`
+}
+
+type getAnswerReq struct {
+	ID         string `uri:"id" binding:"required"`
+	QuestionID string `uri:"question_id" binding:"required"`
+	AnswerID   string `uri:"answer_id" binding:"required"`
+}
+
+type getProductQuestionsReq struct {
+	// Answered lists the answered questions only when true, the unanswered ones when false
+	Answered *bool  `form:"answered"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=newest upvotes"`
+	Page     int64  `form:"page" binding:"required,min=1"`
+	Limit    int64  `form:"limit" binding:"required,min=1,max=100"`
+}
+
+type getQuestionsReq struct {
+	ProductID string   `form:"product_id"`
+	Statuses  []string `form:"status" binding:"omitempty,dive,validModeration"`
+	Answered  *bool    `form:"answered"`
+	Sort      string   `form:"sort" binding:"omitempty,oneof=newest upvotes"`
+	Page      int64    `form:"page" binding:"required,min=1"`
+	Limit     int64    `form:"limit" binding:"required,min=1,max=100"`
+}
+
+type moderateQuestionReq struct {
+	Status string `json:"status" binding:"required,validModeration"`
+	// Reason is kept with the transition, e.g. why a question was rejected
+	Reason  string `json:"reason" binding:"max=500"`
+	Version int64  `json:"version"`
+}
+
+type answerQuestionReq struct {
+	AuthorName string `json:"author_name" binding:"required,min=2,max=100"`
+	Body       string `json:"body" binding:"required,max=5000"`
+}
+
+type portalAnswerQuestionReq struct {
+	Body string `json:"body" binding:"required,max=5000"`
+}
+
+type upvoteReq struct {
+	// VoterID is trusted as it is, like the customer of a review
+	VoterID string `json:"voter_id" binding:"required,max=64"`
+}
+
+//////////////////////////////// graphql dtos //////////////////////////////////
+
+type graphqlReq struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
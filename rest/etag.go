@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/logger"
+)
+
+var (
+	errVersionRequired = apperr.Validation("version_required", "If-Match header or version field is required")
+	errInvalidIfMatch  = apperr.Validation("invalid_if_match", "If-Match header must hold a single entity version")
+)
+
+// setETag exposes the version of an entity so it can be sent back in If-Match
+func setETag(ctx *gin.Context, version int64) {
+	ctx.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// expectedVersion returns the version an update was based on, taken from the
+// If-Match header or else from the version field of the body. it writes the
+// error itself through ctx.Error and reports whether the handler may go on
+func (s *Server) expectedVersion(ctx *gin.Context, bodyVersion int64) (version int64, fromHeader bool, ok bool) {
+	ifMatch := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if len(ifMatch) == 0 {
+		if bodyVersion <= 0 {
+			logger.Error(ctx, "cannot get expected version", errVersionRequired)
+			ctx.Error(withStatus(http.StatusPreconditionRequired, errVersionRequired))
+			return 0, false, false
+		}
+
+		return bodyVersion, false, true
+	}
+
+	tag, err := strconv.Unquote(strings.TrimPrefix(ifMatch, "W/"))
+	if err == nil {
+		version, err = strconv.ParseInt(tag, 10, 64)
+	}
+
+	if err != nil || version <= 0 {
+		logger.Error(ctx, "cannot parse If-Match header", ifMatch)
+		ctx.Error(errInvalidIfMatch)
+		return 0, true, false
+	}
+
+	return version, true, true
+}
+
+// versionConflict is the error of an update based on a stale version: 412 when the
+// version came from If-Match, 409 when it came from the body
+func versionConflict(err error, fromHeader bool) error {
+	if fromHeader {
+		return withStatus(http.StatusPreconditionFailed, err)
+	}
+
+	return err
+}
@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/spreadsheet"
+)
+
+// @Summary Export products
+// @Description Stream every product matching the filters as CSV, JSON Lines or XLSX, oldest first. Brand, category and supplier fields are flattened into columns, text cells starting like a formula get a quote before them in CSV and XLSX
+// @Tags Products
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param name query string false "Product name filter"
+// @Param min_price query number false "Minimum price filter"
+// @Param max_price query number false "Maximum price filter"
+// @Param brand_ids query array false "Array of brand IDs filter"
+// @Param category_id query string false "Category ID filter"
+// @Param supplier_id query string false "Supplier ID filter"
+// @Param status query array false "Lifecycle states filter, with the admin api key. The live products of live brands, categories and suppliers by default"
+// @Param include_deleted query bool false "Include soft deleted products, with the admin api key"
+// @Param format query string false "File format (csv, jsonl, xlsx), csv by default"
+// @Param columns query string false "Comma separated columns to export, all by default"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/products/export [get]
+func (s *Server) exportProducts(ctx *gin.Context) {
+	var req exportProductsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if len(req.Format) == 0 {
+		req.Format = spreadsheet.FormatCSV
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	if !s.allowHiddenProducts(ctx, len(req.Statuses) > 0 || req.IncludeDeleted) {
+		return
+	}
+
+	var columns []string
+	if len(req.Columns) > 0 {
+		columns = strings.Split(req.Columns, ",")
+	}
+
+	columns, err := service.ValidateExportColumns(columns)
+	if err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if !s.resolveRefList(ctx, service.AuditEntityBrand, req.BrandIDs) ||
+		!s.resolveRefs(ctx, service.AuditEntityCategory, &req.CategoryID) {
+		return
+	}
+
+	ctx.Header("Content-Type", spreadsheet.ContentType(req.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=products.%s", req.Format))
+	ctx.Status(http.StatusOK)
+
+	// the status is sent with the first rows, a failure past that point can only cut the file short
+	written, err := s.svc.ExportProducts(ctx, ctx.Writer, req.Format, columns, service.FilterProductsParams{
+		Name:           req.Name,
+		MinPrice:       req.MinPrice,
+		MaxPrice:       req.MaxPrice,
+		BrandIDs:       req.BrandIDs,
+		CategoryID:     req.CategoryID,
+		SupplierID:     req.SupplierID,
+		Statuses:       req.Statuses,
+		IncludeDeleted: req.IncludeDeleted,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot export products", err)
+		ctx.Abort()
+		return
+	}
+
+	logger.Info(ctx, "products exported", written)
+}
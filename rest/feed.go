@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/feed"
+	"github.com/jsiqbal/ecommerce/logger"
+)
+
+// feedMaxAge is how long a generated feed is served from memory and may be cached by clients
+const feedMaxAge = time.Hour
+
+// feedCache holds the last generated feed of every format
+type feedCache struct {
+	mu      sync.Mutex
+	entries map[string]*feedEntry
+}
+
+type feedEntry struct {
+	body        []byte
+	etag        string
+	generatedAt time.Time
+}
+
+func newFeedCache() *feedCache {
+	return &feedCache{
+		entries: make(map[string]*feedEntry),
+	}
+}
+
+// @Summary Google Merchant product feed
+// @Description The active catalog as a RSS 2.0 feed with the g: elements of Google Merchant. The feed is regenerated at most once an hour
+// @Tags Feeds
+// @Produce xml
+// @Success 200 {file} file
+// @Success 304
+// @Failure 500 {object} ErrorResponse
+// @Router /api/feeds/google.xml [get]
+func (s *Server) getXMLFeed(ctx *gin.Context) {
+	s.serveFeed(ctx, feed.FormatXML)
+}
+
+// @Summary Google Merchant product feed as CSV
+// @Description The active catalog as a CSV feed with the Google Merchant attribute names as columns. The feed is regenerated at most once an hour
+// @Tags Feeds
+// @Produce text/csv
+// @Success 200 {file} file
+// @Success 304
+// @Failure 500 {object} ErrorResponse
+// @Router /api/feeds/google.csv [get]
+func (s *Server) getCSVFeed(ctx *gin.Context) {
+	s.serveFeed(ctx, feed.FormatCSV)
+}
+
+// serveFeed answers with the cached feed, generating it when it is missing or
+// older than feedMaxAge. conditional requests are answered with 304
+func (s *Server) serveFeed(ctx *gin.Context, format string) {
+	entry, err := s.feed(ctx, format)
+	if err != nil {
+		logger.Error(ctx, "cannot generate feed", err)
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Type", feed.ContentType(format))
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(feedMaxAge.Seconds())))
+	ctx.Header("ETag", entry.etag)
+
+	http.ServeContent(ctx.Writer, ctx.Request, "", entry.generatedAt, bytes.NewReader(entry.body))
+}
+
+func (s *Server) feed(ctx *gin.Context, format string) (*feedEntry, error) {
+	// generating under the lock keeps concurrent requests from building the same feed twice
+	s.feeds.mu.Lock()
+	defer s.feeds.mu.Unlock()
+
+	entry, ok := s.feeds.entries[format]
+	if ok && time.Since(entry.generatedAt) < feedMaxAge {
+		return entry, nil
+	}
+
+	var buf bytes.Buffer
+
+	// the feed is shared by every request waiting on the lock, so the client which
+	// happens to build it going away must not abort it for the others
+	written, err := s.svc.GenerateFeed(context.WithoutCancel(ctx.Request.Context()), &buf, format, feed.Channel{
+		Title:       s.appCnf.FeedTitle,
+		Link:        s.appCnf.FeedLink,
+		Description: fmt.Sprintf("%s product feed", s.appCnf.FeedTitle),
+		Currency:    s.appCnf.FeedCurrency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "feed generated", fmt.Sprintf("format: %s, products: %d", format, written))
+
+	sum := sha256.Sum256(buf.Bytes())
+	entry = &feedEntry{
+		body:        buf.Bytes(),
+		etag:        fmt.Sprintf("%q", hex.EncodeToString(sum[:16])),
+		generatedAt: time.Now(),
+	}
+
+	s.feeds.entries[format] = entry
+
+	return entry, nil
+}
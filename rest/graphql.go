@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/graph"
+	"github.com/jsiqbal/ecommerce/logger"
+)
+
+// @Summary Run a GraphQL operation
+// @Description Run a query or mutation against the GraphQL schema (graph/schema.graphql). The response follows the GraphQL spec, with data and errors, rather than the envelope of the rest api
+// @Tags GraphQL
+// @Accept json
+// @Produce json
+// @Param request body graphqlReq true "GraphQL operation"
+// @Success 200 {object} object
+// @Failure 400 {object} object
+// @Router /api/graphql [post]
+func (s *Server) runGraphQL(ctx *gin.Context) {
+	var req graphqlReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": err.Error()}}})
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	// loaders cache for the request only
+	gqlCtx := graph.WithAdmin(graph.WithLoaders(ctx, s.svc), s.isAdmin(ctx))
+	res := s.gql.Exec(gqlCtx, req.Query, req.OperationName, req.Variables)
+	if len(res.Errors) > 0 {
+		logger.Error(ctx, "graphql operation failed", res.Errors)
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,205 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/spreadsheet"
+)
+
+const (
+	maxImportFileSize = 10 << 20
+	// maxImportBodySize leaves room for the rest of the form around the file
+	maxImportBodySize = maxImportFileSize + 64<<10
+)
+
+var errImportFileTooLarge = apperr.Validation("invalid_parameters", "Api parameter invalid", apperr.FieldError{Field: "file", Message: "must not be larger than 10MB"})
+
+// @Summary Import products from a spreadsheet
+// @Description Upload a CSV or XLSX file of products. With dry_run the rows are only validated and a report is returned, otherwise an import job is queued for the worker and returned
+// @Tags Imports
+// @Accept multipart/form-data
+// @Produce json
+// @Security AdminAuth
+// @Param file formData file true "CSV or XLSX file, the first row holding the column names"
+// @Param dry_run formData bool false "Only validate the file"
+// @Success 200 {object} SuccessResponse
+// @Success 202 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/imports/products [post]
+func (s *Server) importProducts(ctx *gin.Context) {
+	// the body is cut off rather than buffered to disk when it is larger than any file we take
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBodySize)
+
+	var req importProductsReq
+	if err := ctx.ShouldBind(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logger.Error(ctx, "import file too large", err)
+			ctx.Error(withStatus(http.StatusRequestEntityTooLarge, errImportFileTooLarge))
+			return
+		}
+
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "cannot get import file", err)
+		ctx.Error(apperr.Validation("invalid_parameters", "Api parameter invalid", apperr.FieldError{Field: "file", Message: "is required"}))
+		return
+	}
+
+	logger.Info(ctx, "req payload", fmt.Sprintf("file: %s, size: %d, dry run: %v", fileHeader.Filename, fileHeader.Size, req.DryRun))
+
+	if fileHeader.Size > maxImportFileSize {
+		logger.Error(ctx, "import file too large", fileHeader.Size)
+		ctx.Error(withStatus(http.StatusRequestEntityTooLarge, errImportFileTooLarge))
+		return
+	}
+
+	format, err := spreadsheet.ReadFormatOf(fileHeader.Filename)
+	if err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		logger.Error(ctx, "cannot open import file", err)
+		ctx.Error(err)
+		return
+	}
+	defer file.Close()
+
+	header, records, err := spreadsheet.Read(file, format)
+	if err != nil {
+		logger.Error(ctx, "cannot read import file", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	rows := service.NewImportRows(header, records)
+
+	if req.DryRun {
+		report, err := s.svc.ValidateImport(ctx, rows)
+		if err != nil {
+			logger.Error(ctx, "cannot validate import", err)
+			ctx.Error(err)
+			return
+		}
+
+		logger.Info(ctx, "res payload", report)
+
+		ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Import validated", report))
+		return
+	}
+
+	job, err := s.svc.StartImport(ctx, fileHeader.Filename, format, rows)
+	if err != nil {
+		logger.Error(ctx, "cannot start import", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", job)
+
+	ctx.JSON(http.StatusAccepted, s.svc.Response(ctx, "Import started", job))
+}
+
+// @Summary Get an import job
+// @Description Get the status, progress and errors of an import job
+// @Tags Imports
+// @Accept json
+// @Produce json
+// @Param id path string true "Import job ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/imports/{id} [get]
+func (s *Server) getImportJob(ctx *gin.Context) {
+	var req getImportJobReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	job, err := s.svc.GetImportJob(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get import job", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", job)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", job))
+}
+
+// @Summary Download the errors of an import job
+// @Description Download the rows that failed to import, with the reason, as a CSV or XLSX file
+// @Tags Imports
+// @Produce text/csv
+// @Param id path string true "Import job ID" format "uuid"
+// @Param format query string false "File format (csv, xlsx), csv by default"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/imports/{id}/errors [get]
+func (s *Server) getImportErrors(ctx *gin.Context) {
+	var req getImportErrorsReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if len(req.Format) == 0 {
+		req.Format = spreadsheet.FormatCSV
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	job, err := s.svc.GetImportJob(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get import job", err)
+		ctx.Error(err)
+		return
+	}
+
+	header, records := service.ImportErrorsTable(job.Errors)
+
+	var buf bytes.Buffer
+	err = spreadsheet.Write(&buf, req.Format, header, records)
+	if err != nil {
+		logger.Error(ctx, "cannot write import errors", err)
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=import-%s-errors.%s", job.ID, req.Format))
+	ctx.Data(http.StatusOK, spreadsheet.ContentType(req.Format), buf.Bytes())
+}
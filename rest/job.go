@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/logger"
+)
+
+// @Summary Get a background job
+// @Description Get the status, attempts and last error of a background job
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/jobs/{id} [get]
+func (s *Server) getJob(ctx *gin.Context) {
+	var req getJobReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	job, err := s.svc.GetJob(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get job", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", job)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", job))
+}
+
+// @Summary Requeue a dead background job
+// @Description Give a job that used up its attempts a fresh set of attempts, starting right away
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Job ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/jobs/{id}/requeue [post]
+func (s *Server) requeueJob(ctx *gin.Context) {
+	var req getJobReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	job, err := s.svc.RequeueJob(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot requeue job", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", job)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Job requeued", job))
+}
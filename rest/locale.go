@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/service"
+	"golang.org/x/text/language"
+)
+
+// messageLanguages are the languages the validation messages are written in, the first
+// being the one of a caller who reads none of them
+var messageLanguages = []language.Tag{language.English, language.Bengali, language.Spanish}
+
+var messageMatcher = language.NewMatcher(messageLanguages)
+
+// messageLanguage is the language of the messages for the caller, the closest to the
+// locales of its Accept-Language
+func messageLanguage(ctx context.Context) language.Tag {
+	_, index, _ := messageMatcher.Match(service.GetLocales(ctx)...)
+	return messageLanguages[index]
+}
+
+// localizeProducts puts the products in the language of the caller. the response varies
+// with Accept-Language then, which caches must know
+func localizeProducts(ctx *gin.Context, products ...*service.Product) {
+	locales := service.GetLocales(ctx)
+	for _, product := range products {
+		product.Localize(locales)
+	}
+
+	ctx.Header("Vary", "Accept-Language")
+}
+
+// productRefs returns pointers to the products, to localize them in place
+func productRefs(products []service.Product) []*service.Product {
+	refs := make([]*service.Product, len(products))
+	for i := range products {
+		refs[i] = &products[i]
+	}
+
+	return refs
+}
+
+type translationDTO interface {
+	toService() service.Translation
+}
+
+func (r translationReq) toService() service.Translation {
+	return service.Translation{
+		Name:        r.Name,
+		Description: r.Description,
+	}
+}
+
+func (r nameTranslationReq) toService() service.Translation {
+	return service.Translation{
+		Name: r.Name,
+	}
+}
+
+// toTranslations keys the translations by canonical locale, e.g. "pt-br" by "pt-BR", so
+// they are found whatever letter case the request used
+func toTranslations[T translationDTO](reqs map[string]T) service.Translations {
+	translations := make(service.Translations, len(reqs))
+	for locale, req := range reqs {
+		translations[language.Make(locale).String()] = req.toService()
+	}
+
+	return translations
+}
+
+// toTranslationsPatch replaces all the translations when the patch has them and removes
+// them when it sets them to null
+func toTranslationsPatch[T translationDTO](reqs *map[string]T, cleared bool) *service.Translations {
+	if cleared {
+		return &service.Translations{}
+	}
+
+	if reqs == nil {
+		return nil
+	}
+
+	translations := toTranslations(*reqs)
+	return &translations
+}
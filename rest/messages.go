@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"golang.org/x/text/language"
+)
+
+// fieldMessages are the messages of the validation errors in each of messageLanguages, keyed
+// by the rule a field broke. the rules of min and max are keyed by what they count as
+// well, see sizeKey, and a %s in a message is the parameter of the rule
+var fieldMessages = map[language.Tag]map[string]string{
+	language.English: {
+		"invalid_parameters": "Api parameter invalid",
+		"invalid":            "is invalid",
+		"type":               "must be a %s",
+		"required":           "is required",
+		"min":                "must be at least %s",
+		"min_len":            "must be at least %s characters long",
+		"min_items":          "must have at least %s items",
+		"max":                "must be at most %s",
+		"max_len":            "must be at most %s characters long",
+		"max_items":          "must have at most %s items",
+		"oneof":              "must be one of %s",
+		"email":              "must be a valid email address",
+		"url":                "must be a valid url",
+		"validStatus":        "must be active, inactive or archived",
+		"validProductStatus": "is not a product status",
+		"validModeration":    "must be pending, approved or rejected",
+		"validPhone":         "must be a supported phone number",
+		"validSlug":          "must be lowercase letters and digits in words joined by hyphens",
+		"validWebsite":       "must be an http or https url",
+		"validCountryCode":   "must be an ISO 3166-1 alpha-2 country code",
+		"validLocale":        "must be a BCP 47 language tag such as bn or pt-BR",
+	},
+	language.Bengali: {
+		"invalid_parameters": "অনুরোধের প্যারামিটার অবৈধ",
+		"invalid":            "অবৈধ",
+		"type":               "%s হতে হবে",
+		"required":           "আবশ্যক",
+		"min":                "কমপক্ষে %s হতে হবে",
+		"min_len":            "কমপক্ষে %s অক্ষরের হতে হবে",
+		"min_items":          "কমপক্ষে %sটি আইটেম থাকতে হবে",
+		"max":                "সর্বোচ্চ %s হতে পারে",
+		"max_len":            "সর্বোচ্চ %s অক্ষরের হতে পারে",
+		"max_items":          "সর্বোচ্চ %sটি আইটেম থাকতে পারে",
+		"oneof":              "%s এর মধ্যে একটি হতে হবে",
+		"email":              "একটি বৈধ ইমেইল ঠিকানা হতে হবে",
+		"url":                "একটি বৈধ url হতে হবে",
+		"validStatus":        "active, inactive বা archived হতে হবে",
+		"validProductStatus": "পণ্যের কোনো অবস্থা নয়",
+		"validModeration":    "pending, approved বা rejected হতে হবে",
+		"validPhone":         "একটি সমর্থিত ফোন নম্বর হতে হবে",
+		"validSlug":          "হাইফেন দিয়ে যুক্ত শব্দে ছোট হাতের অক্ষর ও সংখ্যা হতে হবে",
+		"validWebsite":       "একটি http বা https url হতে হবে",
+		"validCountryCode":   "একটি ISO 3166-1 alpha-2 দেশের কোড হতে হবে",
+		"validLocale":        "bn বা pt-BR এর মতো একটি BCP 47 ভাষা ট্যাগ হতে হবে",
+	},
+	language.Spanish: {
+		"invalid_parameters": "Parámetros de la api no válidos",
+		"invalid":            "no es válido",
+		"type":               "debe ser de tipo %s",
+		"required":           "es obligatorio",
+		"min":                "debe ser al menos %s",
+		"min_len":            "debe tener al menos %s caracteres",
+		"min_items":          "debe tener al menos %s elementos",
+		"max":                "debe ser como máximo %s",
+		"max_len":            "debe tener como máximo %s caracteres",
+		"max_items":          "debe tener como máximo %s elementos",
+		"oneof":              "debe ser uno de %s",
+		"email":              "debe ser una dirección de correo electrónico válida",
+		"url":                "debe ser una url válida",
+		"validStatus":        "debe ser active, inactive o archived",
+		"validProductStatus": "no es un estado de producto",
+		"validModeration":    "debe ser pending, approved o rejected",
+		"validPhone":         "debe ser un número de teléfono admitido",
+		"validSlug":          "debe ser letras minúsculas y dígitos en palabras unidas por guiones",
+		"validWebsite":       "debe ser una url http o https",
+		"validCountryCode":   "debe ser un código de país ISO 3166-1 alfa-2",
+		"validLocale":        "debe ser una etiqueta de idioma BCP 47 como bn o pt-BR",
+	},
+}
@@ -1,15 +1,29 @@
 package rest
 
 import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+	"golang.org/x/text/language"
 )
 
+const supplierIDKey = "supplierID"
+
 func corsMiddleware(c *gin.Context) {
 	// Allow all origins
 	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Allow specific headers
-	c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Authorization")
+	c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Authorization, X-Trace-ID, X-Actor, If-Match")
+
+	// Let clients read the version of an entity
+	c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 	// Allow all methods
 	c.Writer.Header().Set("Access-Control-Allow-Methods", "*")
@@ -22,3 +36,107 @@ func corsMiddleware(c *gin.Context) {
 
 	c.Next()
 }
+
+// actorMiddleware records who is making the request for the audit log
+func actorMiddleware(c *gin.Context) {
+	actor := c.GetHeader("X-Actor")
+	if len(actor) > 0 {
+		c.Request = c.Request.WithContext(service.WithActor(c.Request.Context(), actor))
+	}
+
+	c.Next()
+}
+
+// localeMiddleware records the languages the caller reads in, from Accept-Language. a
+// header which can not be parsed is ignored, the default locale is used then
+func localeMiddleware(c *gin.Context) {
+	locales, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if err == nil && len(locales) > 0 {
+		c.Request = c.Request.WithContext(service.WithLocales(c.Request.Context(), locales))
+	}
+
+	c.Next()
+}
+
+// errUnauthorized is the error of a request without a valid supplier token or admin api key
+var errUnauthorized = apperr.Unauthorized("unauthorized", "Unauthorized")
+
+// adminActor is the actor of the requests of the catalog team which do not name one
+const adminActor = "admin"
+
+// isAdmin reports whether the request carries the admin api key of the catalog team
+func (server *Server) isAdmin(c *gin.Context) bool {
+	key := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	return len(server.appCnf.AdminAPIKey) > 0 &&
+		subtle.ConstantTimeCompare([]byte(key), []byte(server.appCnf.AdminAPIKey)) == 1
+}
+
+// allowDeleted reports whether the caller may list the soft deleted records includeDeleted
+// asks for, which are for the catalog team only
+func (server *Server) allowDeleted(c *gin.Context, includeDeleted bool) bool {
+	if !includeDeleted || server.isAdmin(c) {
+		return true
+	}
+
+	logger.Error(c, "cannot show deleted records", c.Request.URL.Path)
+	c.Error(errUnauthorized)
+	return false
+}
+
+// adminAuthMiddleware lets through the requests of the catalog team only, the ones carrying
+// the admin api key as their bearer token
+func (server *Server) adminAuthMiddleware(c *gin.Context) {
+	if !server.isAdmin(c) {
+		logger.Error(c, "cannot authenticate admin", c.Request.URL.Path)
+		c.Error(errUnauthorized)
+		c.Abort()
+		return
+	}
+
+	if len(c.GetHeader("X-Actor")) == 0 {
+		c.Request = c.Request.WithContext(service.WithActor(c.Request.Context(), adminActor))
+	}
+
+	c.Next()
+}
+
+// supplierAuthMiddleware authenticates a supplier by the bearer token issued for it
+// and stores the supplier id in the gin context for the portal handlers
+func (server *Server) supplierAuthMiddleware(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	supplierID, err := util.ParseSupplierToken(server.appCnf.AuthSecret, token, util.GetCurrentTimestamp())
+	if err != nil {
+		logger.Error(c, "cannot authenticate supplier", err)
+		c.Error(errUnauthorized)
+		c.Abort()
+		return
+	}
+
+	spplr, err := server.svc.GetSupplier(c, supplierID)
+	if errors.Is(err, service.ErrSupplierNotFound) {
+		logger.Error(c, "supplier not found", supplierID)
+		c.Error(errUnauthorized)
+		c.Abort()
+		return
+	}
+
+	if err != nil {
+		logger.Error(c, "cannot get supplier", err)
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	if spplr.Status != service.StatusActive {
+		logger.Error(c, "supplier inactive", supplierID)
+		c.Error(service.ErrSupplierInactive)
+		c.Abort()
+		return
+	}
+
+	c.Set(supplierIDKey, supplierID)
+	c.Request = c.Request.WithContext(service.WithActor(c.Request.Context(), "supplier:"+supplierID))
+	c.Next()
+}
@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+var errMergePatchContentType = fmt.Errorf("content type must be %s or %s", mergePatchContentType, binding.MIMEJSON)
+
+// bindMergePatch decodes a JSON Merge Patch (RFC 7396) document into req and
+// validates the members it supplies. members set to null remove the field, only
+// the clearable ones may be removed and they are returned to the handler
+func bindMergePatch(ctx *gin.Context, req interface{}, clearable ...string) (map[string]bool, error) {
+	contentType := ctx.ContentType()
+	if contentType != mergePatchContentType && contentType != binding.MIMEJSON {
+		return nil, errMergePatchContentType
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, errors.New("merge patch must be a json object")
+	}
+
+	cleared := make(map[string]bool)
+	for member, value := range members {
+		if strings.TrimSpace(string(value)) != "null" {
+			continue
+		}
+
+		if !contains(clearable, member) {
+			return nil, fmt.Errorf("%s can not be removed", member)
+		}
+
+		cleared[member] = true
+	}
+
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, err
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	return cleared, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
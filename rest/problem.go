@@ -0,0 +1,185 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/logger"
+	"golang.org/x/text/language"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix makes the type of a problem out of the kind of its error
+const problemTypePrefix = "urn:problem-type:"
+
+// internalDetail is the detail of the problem of an error which has no kind, its own
+// message is only written to the logs
+const internalDetail = "the server could not handle the request, quote the trace id when reporting it"
+
+// kindStatus is the http status each kind of error answers with
+var kindStatus = map[apperr.Kind]int{
+	apperr.KindValidation:    http.StatusBadRequest,
+	apperr.KindUnauthorized:  http.StatusUnauthorized,
+	apperr.KindForbidden:     http.StatusForbidden,
+	apperr.KindNotFound:      http.StatusNotFound,
+	apperr.KindConflict:      http.StatusConflict,
+	apperr.KindUnprocessable: http.StatusUnprocessableEntity,
+	apperr.KindUnavailable:   http.StatusServiceUnavailable,
+}
+
+// statusError makes an error answer another status than the one of its kind, e.g.
+// 412 rather than 409 for a stale version given in If-Match
+type statusError struct {
+	status int
+	err    error
+}
+
+func withStatus(status int, err error) error {
+	return &statusError{status: status, err: err}
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// problemMiddleware answers with the error a handler reported through ctx.Error, as an
+// RFC 7807 problem. the handlers log the error and leave the response to it
+func problemMiddleware(c *gin.Context) {
+	c.Next()
+
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	problem := newProblem(c, c.Errors.Last().Err)
+
+	c.Header("Content-Type", problemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+func newProblem(ctx *gin.Context, err error) *ErrorResponse {
+	kind := apperr.KindOf(err)
+
+	status, ok := kindStatus[kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		status = statusErr.status
+	}
+
+	problem := &ErrorResponse{
+		Type:     problemTypePrefix + string(kind),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   apperr.MessageOf(err),
+		Instance: ctx.Request.URL.Path,
+		Code:     apperr.CodeOf(err),
+		TraceID:  logger.GetTraceID(ctx),
+		Errors:   apperr.FieldsOf(err),
+		Data:     apperr.DetailsOf(err),
+	}
+
+	if kind == apperr.KindInternal {
+		problem.Detail = internalDetail
+	}
+
+	var paramsErr *paramsError
+	if errors.As(err, &paramsErr) {
+		lang := messageLanguage(ctx)
+
+		problem.Detail, problem.Errors = paramsErr.localize(lang)
+		if len(problem.Errors) > 0 {
+			ctx.Header("Content-Language", lang.String())
+		}
+	}
+
+	return problem
+}
+
+// paramsError is the error of request parameters which could not be bound or broke the
+// rules of their binding tags. its messages are written with the problem, in the language
+// of the caller
+type paramsError struct {
+	err error
+}
+
+// invalidParams is the error of a request whose parameters could not be bound or broke
+// the rules of their binding tags, with the fields at fault
+func invalidParams(err error) error {
+	return &paramsError{err: err}
+}
+
+func (e *paramsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *paramsError) Unwrap() error {
+	return e.err
+}
+
+func (e *paramsError) Kind() apperr.Kind {
+	return apperr.KindValidation
+}
+
+func (e *paramsError) Code() string {
+	return "invalid_parameters"
+}
+
+func (e *paramsError) Fields() []apperr.FieldError {
+	_, fields := e.localize(messageLanguages[0])
+	return fields
+}
+
+// localize returns the detail of the problem and the fields at fault in the language. an
+// error which is not about fields, such as malformed json, keeps its own message
+func (e *paramsError) localize(lang language.Tag) (string, []apperr.FieldError) {
+	messages := fieldMessages[lang]
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(e.err, &validationErrs) {
+		fields := make([]apperr.FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, apperr.FieldError{
+				Field:   fieldPath(fieldErr),
+				Message: fieldMessage(fieldErr, lang),
+			})
+		}
+
+		return messages["invalid_parameters"], fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(e.err, &typeErr) && len(typeErr.Field) > 0 {
+		return messages["invalid_parameters"], []apperr.FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf(messages["type"], typeErr.Type.Kind().String()),
+		}}
+	}
+
+	return e.err.Error(), nil
+}
+
+// fieldPath is the path of the field in the request, e.g. seo.title, without the
+// name of the request struct
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return fieldErr.Field()
+}
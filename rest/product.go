@@ -1,10 +1,12 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/apperr"
 	"github.com/jsiqbal/ecommerce/logger"
 	"github.com/jsiqbal/ecommerce/service"
 	"github.com/jsiqbal/ecommerce/util"
@@ -15,86 +17,80 @@ import (
 // @Tags Products
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param request body createProductReq true "Product details to create"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/products [post]
 func (s *Server) createProduct(ctx *gin.Context) {
 	var req createProductReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	logger.Info(ctx, "req payload", req)
 
+	if !s.resolveRefs(ctx, service.AuditEntityBrand, &req.BrandID) ||
+		!s.resolveRefs(ctx, service.AuditEntityCategory, &req.CategoryID) {
+		return
+	}
+
 	// check supplier exists
 	spplr, err := s.svc.GetSupplier(ctx, req.SupplierID)
-	if err != nil {
-		logger.Error(ctx, "cannot get supplier", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+	if errors.Is(err, service.ErrSupplierNotFound) {
+		logger.Error(ctx, "Supplier id not found", err)
+		ctx.Error(service.NewReferenceError("supplier_id", err))
 		return
 	}
 
-	if spplr == nil {
-		logger.Error(ctx, "Supplier id not found", nil)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Supplier not found", nil))
+	if err != nil {
+		logger.Error(ctx, "cannot get supplier", err)
+		ctx.Error(err)
 		return
 	}
 
 	// check category exists
 	ctgry, err := s.svc.GetCategory(ctx, req.CategoryID)
-	if err != nil {
-		logger.Error(ctx, "cannot get category", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+	if errors.Is(err, service.ErrCategoryNotFound) {
+		logger.Error(ctx, "Category id not found", err)
+		ctx.Error(service.NewReferenceError("category_id", err))
 		return
 	}
 
-	if ctgry == nil {
-		logger.Error(ctx, "Category id not found", nil)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Category not found", nil))
+	if err != nil {
+		logger.Error(ctx, "cannot get category", err)
+		ctx.Error(err)
 		return
 	}
 
 	// check brand exists
 	brand, err := s.svc.GetBrand(ctx, req.BrandID)
-	if err != nil {
-		logger.Error(ctx, "cannot get brand", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
-		return
-	}
-
-	if brand == nil {
-		logger.Error(ctx, "Brand id not found", nil)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Brand not found", nil))
+	if errors.Is(err, service.ErrBrandNotFound) {
+		logger.Error(ctx, "Brand id not found", err)
+		ctx.Error(service.NewReferenceError("brand_id", err))
 		return
 	}
 
-	// check supplier wise product name uniqueness
-	existProduct, err := s.svc.GetProducts(ctx, service.FilterProductsParams{
-		Name:       req.Name,
-		SupplierID: req.SupplierID,
-		Limit:      1,
-	})
 	if err != nil {
-		logger.Error(ctx, "cannot get product", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
-		return
-	}
-
-	if existProduct != nil && len(existProduct.Products) > 0 {
-		logger.Error(ctx, "Product name already exists for same supplier", existProduct)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Product name already exists for this supplier", nil))
+		logger.Error(ctx, "cannot get brand", err)
+		ctx.Error(err)
 		return
 	}
 
 	product := &service.Product{
 		Name:           req.Name,
+		Slug:           req.Slug,
+		SEO:            req.SEO.toService(),
 		Description:    req.Description,
 		Specifications: req.Specifications,
+		Translations:   toTranslations(req.Translations),
 		Brand:          *brand,
 		Category:       *ctgry,
 		Supplier:       *spplr,
@@ -104,14 +100,16 @@ func (s *Server) createProduct(ctx *gin.Context) {
 		UnitPrice:     req.UnitPrice,
 		DiscountPrice: req.DiscountPrice,
 		Tags:          req.Tags,
-		StatusID:      req.StatusID,
+		Status:        req.Status,
+		PublishAt:     req.PublishAt,
+		UnpublishAt:   req.UnpublishAt,
 		CreatedAt:     util.GetCurrentTimestamp(),
 	}
 
 	newProduct, err := s.svc.AddProduct(ctx, product)
 	if err != nil {
 		logger.Error(ctx, "cannot add product", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
@@ -121,13 +119,15 @@ func (s *Server) createProduct(ctx *gin.Context) {
 }
 
 // @Summary Get a product by ID
-// @Description Get details of a product based on the provided ID
+// @Description Get details of a product based on the provided ID, a product is not found unless it is live, before its publish time or after its unpublish time unless previewed with the admin api key
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param preview query bool false "Show the product whatever its status and schedule, with the admin api key"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/products/{id} [get]
@@ -135,26 +135,49 @@ func (s *Server) getProduct(ctx *gin.Context) {
 	var req getProductReq
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var query getProductQueryReq
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	logger.Info(ctx, "req payload", req)
 
-	product, err := s.svc.GetProduct(ctx, req.ID)
+	if !s.allowHiddenProducts(ctx, query.Preview) {
+		return
+	}
+
+	var product *service.Product
+	var err error
+	if query.Preview {
+		product, err = s.svc.GetProduct(ctx, req.ID)
+	} else {
+		product, err = s.svc.GetPublishedProduct(ctx, req.ID, util.GetCurrentTimestamp())
+	}
+
 	if err != nil {
 		logger.Error(ctx, "cannot get product", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
+	setETag(ctx, product.Version)
+
+	localizeProducts(ctx, product)
+	ctx.Header("Content-Language", product.Locale)
+
 	logger.Info(ctx, "res payload", product)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", product))
 }
 
 // @Summary Get a list of products with optional filters
-// @Description Get a list of products based on specified filters. If no filters are provided, all products will be retrieved.
+// @Description Get a list of products based on specified filters. If no filters are provided, all live products will be retrieved, listing others takes the admin api key
 // @Tags Products
 // @Accept json
 // @Produce json
@@ -164,38 +187,58 @@ func (s *Server) getProduct(ctx *gin.Context) {
 // @Param brand_ids query array false "Array of brand IDs filter"
 // @Param category_id query string false "Category ID filter"
 // @Param supplier_id query string false "Supplier ID filter"
+// @Param status query array false "Lifecycle states filter, with the admin api key. The live products of live brands, categories and suppliers by default"
+// @Param min_rating query number false "Minimum average rating, leaving out unrated products"
+// @Param sort query string false "Sort by price, lowest first, or rating, highest first" Enums(price, rating)
+// @Param include_deleted query bool false "Include soft deleted products, with the admin api key"
 // @Param page query integer false "Page number for pagination"
 // @Param limit query integer true "Number of items to return per page (maximum 100)"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/products [get]
 func (s *Server) getProducts(ctx *gin.Context) {
 	var req getProductsReq
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	logger.Info(ctx, "req payload", req)
 
+	if !s.allowHiddenProducts(ctx, len(req.Statuses) > 0 || req.IncludeDeleted) {
+		return
+	}
+
+	if !s.resolveRefList(ctx, service.AuditEntityBrand, req.BrandIDs) ||
+		!s.resolveRefs(ctx, service.AuditEntityCategory, &req.CategoryID) {
+		return
+	}
+
 	result, err := s.svc.GetProducts(ctx, service.FilterProductsParams{
-		Name:       req.Name,
-		MinPrice:   req.MinPrice,
-		MaxPrice:   req.MaxPrice,
-		BrandIDs:   req.BrandIDs,
-		CategoryID: req.CategoryID,
-		SupplierID: req.SupplierID,
-		Page:       req.Page,
-		Limit:      req.Limit,
+		Name:           req.Name,
+		MinPrice:       req.MinPrice,
+		MaxPrice:       req.MaxPrice,
+		BrandIDs:       req.BrandIDs,
+		CategoryID:     req.CategoryID,
+		SupplierID:     req.SupplierID,
+		Statuses:       req.Statuses,
+		MinRating:      req.MinRating,
+		Sort:           req.Sort,
+		IncludeDeleted: req.IncludeDeleted,
+		Page:           req.Page,
+		Limit:          req.Limit,
 	})
 	if err != nil {
 		logger.Error(ctx, "cannot filter products", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
+	localizeProducts(ctx, productRefs(result.Products)...)
+
 	logger.Info(ctx, "Res payload", result)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched Products", result))
@@ -206,46 +249,60 @@ func (s *Server) getProducts(ctx *gin.Context) {
 // @Tags Products
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param id path string true "Product ID to update"
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param request body updateProductReq true "Request body to update product"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/products/{id} [put]
 func (s *Server) updateProduct(ctx *gin.Context) {
 	var req updateProductReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	productID := ctx.Param("id")
 	if len(productID) == 0 {
 		logger.Error(ctx, "cannot pass validation", productID)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Invalid product ID", "Bad request"))
+		ctx.Error(apperr.Validation("invalid_parameters", "Invalid product ID"))
 		return
 	}
 
 	logger.Info(ctx, fmt.Sprintf("req payload for productID: %s", productID), req)
 
-	product, err := s.svc.GetProduct(ctx, productID)
-	if err != nil {
-		logger.Error(ctx, "cannot get product", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
 		return
 	}
 
-	if product == nil {
-		logger.Error(ctx, "product not found", nil)
-		ctx.JSON(http.StatusNotFound, s.svc.Response(ctx, "product Not Found", "Not found"))
+	if !s.resolveRefs(ctx, service.AuditEntityBrand, &req.BrandID) ||
+		!s.resolveRefs(ctx, service.AuditEntityCategory, &req.CategoryID) {
+		return
+	}
+
+	product, err := s.svc.GetProduct(ctx, productID)
+	if err != nil {
+		logger.Error(ctx, "cannot get product", err)
+		ctx.Error(err)
 		return
 	}
 
 	product.Name = req.Name
+	product.Slug = req.Slug
+	product.SEO = req.SEO.toService()
 	product.Description = req.Description
 	product.Specifications = req.Specifications
+	product.Translations = toTranslations(req.Translations)
 	product.Brand = service.Brand{
 		ID: req.BrandID,
 	}
@@ -261,15 +318,116 @@ func (s *Server) updateProduct(ctx *gin.Context) {
 	product.UnitPrice = req.UnitPrice
 	product.DiscountPrice = req.DiscountPrice
 	product.Tags = req.Tags
-	product.StatusID = req.StatusID
+	product.Status = req.Status
+	product.PublishAt = req.PublishAt
+	product.UnpublishAt = req.UnpublishAt
+
+	product.Version = version
 
 	err = s.svc.UpdateProduct(ctx, productID, product)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot update stale product", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
 	if err != nil {
 		logger.Error(ctx, "cannot update product", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
+		return
+	}
+
+	product.Version++
+	setETag(ctx, product.Version)
+
+	logger.Info(ctx, "res payload", product)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", product))
+}
+
+// @Summary Partially update a product
+// @Description Update only the supplied fields of a product, following JSON Merge Patch semantics
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID to update"
+// @Param If-Match header string false "ETag of the version the patch is based on"
+// @Param request body patchProductReq true "Product fields to update"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id} [patch]
+func (s *Server) patchProduct(ctx *gin.Context) {
+	var req patchProductReq
+	cleared, err := bindMergePatch(ctx, &req, "specifications", "tags", "seo", "translations")
+	if err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for productID: %s", productID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	if cleared["specifications"] {
+		empty := ""
+		req.Specifications = &empty
+	}
+
+	if cleared["tags"] {
+		req.Tags = &[]string{}
+	}
+
+	if !s.resolveRefs(ctx, service.AuditEntityBrand, req.BrandID) ||
+		!s.resolveRefs(ctx, service.AuditEntityCategory, req.CategoryID) {
 		return
 	}
 
+	product, err := s.svc.PatchProduct(ctx, productID, &service.ProductPatch{
+		Name:           req.Name,
+		Slug:           req.Slug,
+		SEO:            toSEOPatch(req.SEO, cleared["seo"]),
+		Description:    req.Description,
+		Specifications: req.Specifications,
+		Translations:   toTranslationsPatch(req.Translations, cleared["translations"]),
+		BrandID:        req.BrandID,
+		CategoryID:     req.CategoryID,
+		SupplierID:     req.SupplierID,
+		UnitPrice:      req.UnitPrice,
+		DiscountPrice:  req.DiscountPrice,
+		Tags:           req.Tags,
+		Status:         req.Status,
+		PublishAt:      req.PublishAt,
+		UnpublishAt:    req.UnpublishAt,
+		Version:        version,
+	})
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot patch stale product", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot patch product", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, product.Version)
+
 	logger.Info(ctx, "res payload", product)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", product))
@@ -280,17 +438,20 @@ func (s *Server) updateProduct(ctx *gin.Context) {
 // @Tags Products
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param id path string true "Product ID to delete"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/products/{id} [delete]
 func (s *Server) deleteProduct(ctx *gin.Context) {
 	var req deleteProductReq
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
@@ -299,20 +460,15 @@ func (s *Server) deleteProduct(ctx *gin.Context) {
 	product, err := s.svc.GetProduct(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot get product", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
-		return
-	}
-
-	if product == nil {
-		logger.Error(ctx, "product not found", nil)
-		ctx.JSON(http.StatusNotFound, s.svc.Response(ctx, "Product Not Found", "Not found"))
+		ctx.Error(err)
 		return
 	}
 
 	err = s.svc.DeleteProduct(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot delete product", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+
+		ctx.Error(err)
 		return
 	}
 
@@ -320,3 +476,93 @@ func (s *Server) deleteProduct(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", product))
 }
+
+// @Summary Restore a deleted product
+// @Description Restore a soft deleted product based on the provided ID
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/restore [post]
+func (s *Server) restoreProduct(ctx *gin.Context) {
+	var req restoreProductReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	err := s.svc.RestoreProduct(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot restore product", err)
+		ctx.Error(err)
+		return
+	}
+
+	product, err := s.svc.GetProduct(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get product", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", product)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully restored", product))
+}
+
+// @Summary Get the scheduled publishes and unpublishes
+// @Description Get a paginated list of the products scheduled to go live or off sale, soonest first
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param from query integer false "Start of the range as a millisecond timestamp"
+// @Param to query integer false "End of the range as a millisecond timestamp"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/schedule [get]
+func (s *Server) getScheduledChanges(ctx *gin.Context) {
+	var req getScheduledChangesReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	result, err := s.svc.GetScheduledChanges(ctx, req.From, req.To, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get scheduled changes", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// allowHiddenProducts reports whether the caller may see the products the storefront hides
+// when hidden asks for them, the ones not live or soft deleted are for the catalog team only
+func (s *Server) allowHiddenProducts(ctx *gin.Context, hidden bool) bool {
+	if !hidden || s.isAdmin(ctx) {
+		return true
+	}
+
+	logger.Error(ctx, "cannot show hidden products", ctx.Request.URL.Path)
+	ctx.Error(errUnauthorized)
+	return false
+}
@@ -0,0 +1,517 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// @Summary Ask a question about a product
+// @Description Ask a question about a product. The question is pending until it is approved, then suppliers and the catalog team can answer it. Only the storefront sends questions, with the admin api key, vouching for the customer
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param request body askQuestionReq true "Question"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions [post]
+func (s *Server) askQuestion(ctx *gin.Context) {
+	var req askQuestionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+
+	logger.Info(ctx, "req payload", gin.H{"product_id": productID, "question": req})
+
+	question, err := s.svc.AskQuestion(ctx, &service.Question{
+		ProductID:  productID,
+		CustomerID: req.CustomerID,
+		AuthorName: req.AuthorName,
+		Body:       req.Body,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot create question", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", question)
+
+	ctx.JSON(http.StatusCreated, s.svc.Response(ctx, "Question submitted for moderation", question))
+}
+
+// @Summary Get the questions about a product
+// @Description Get a paginated list of the approved questions about a product along with their answers, the most upvoted answers first
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID or slug"
+// @Param answered query bool false "Only the answered questions when true, the unanswered ones when false"
+// @Param sort query string false "Sort order" Enums(newest, upvotes)
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions [get]
+func (s *Server) getProductQuestions(ctx *gin.Context) {
+	var req getProductQuestionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+
+	logger.Info(ctx, "req payload", gin.H{"product_id": productID, "query": req})
+
+	if _, err := s.svc.GetPublishedProduct(ctx, productID, util.GetCurrentTimestamp()); err != nil {
+		logger.Error(ctx, "cannot get product", err)
+		ctx.Error(err)
+		return
+	}
+
+	result, err := s.svc.GetQuestions(ctx, service.FilterQuestionsParams{
+		ProductID: productID,
+		Statuses:  []string{service.ModerationStatusApproved},
+		Answered:  req.Answered,
+		Sort:      req.Sort,
+		Page:      req.Page,
+		Limit:     req.Limit,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot get product questions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Get a list of questions
+// @Description Get a paginated list of questions in any status along with their answers, e.g. the pending ones to moderate
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param product_id query string false "Product ID or slug"
+// @Param status query array false "Moderation statuses filter"
+// @Param answered query bool false "Only the answered questions when true, the unanswered ones when false"
+// @Param sort query string false "Sort order" Enums(newest, upvotes)
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/questions [get]
+func (s *Server) getQuestions(ctx *gin.Context) {
+	var req getQuestionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	if !s.resolveRefs(ctx, service.AuditEntityProduct, &req.ProductID) {
+		return
+	}
+
+	result, err := s.svc.GetQuestions(ctx, service.FilterQuestionsParams{
+		ProductID: req.ProductID,
+		Statuses:  req.Statuses,
+		Answered:  req.Answered,
+		Sort:      req.Sort,
+		Page:      req.Page,
+		Limit:     req.Limit,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot get questions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Get a question about a product
+// @Description Get a question in any status along with its answers
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions/{question_id} [get]
+func (s *Server) getQuestion(ctx *gin.Context) {
+	var req getQuestionReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	question, err := s.svc.GetQuestion(ctx, req.ID, req.QuestionID)
+	if err != nil {
+		logger.Error(ctx, "cannot get question", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, question.Version)
+
+	logger.Info(ctx, "res payload", question)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", question))
+}
+
+// @Summary Moderate a question
+// @Description Approve or reject a question about a product. Only approved questions are shown, answered and upvoted
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body moderateQuestionReq true "New status and why"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions/{question_id}/transitions [post]
+func (s *Server) moderateQuestion(ctx *gin.Context) {
+	var uriReq getQuestionReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req moderateQuestionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "question_id": uriReq.QuestionID, "moderation": req})
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	question, err := s.svc.ModerateQuestion(ctx, uriReq.ID, uriReq.QuestionID, req.Status, req.Reason, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot moderate stale question", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot moderate question", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, question.Version)
+
+	logger.Info(ctx, "res payload", question)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully moderated", question))
+}
+
+// @Summary Get the moderation history of a question
+// @Description Get a paginated list of the status changes of a question, newest first, with who made them and why
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions/{question_id}/transitions [get]
+func (s *Server) getQuestionTransitions(ctx *gin.Context) {
+	var uriReq getQuestionReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req getTransitionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "question_id": uriReq.QuestionID, "query": req})
+
+	// the question has to be one of the product
+	if _, err := s.svc.GetQuestion(ctx, uriReq.ID, uriReq.QuestionID); err != nil {
+		logger.Error(ctx, "cannot get question", err)
+		ctx.Error(err)
+		return
+	}
+
+	result, err := s.svc.GetTransitions(ctx, service.AuditEntityQuestion, uriReq.QuestionID, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get question transitions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Upvote a question
+// @Description Upvote an approved question about a product. A customer upvotes a question once, upvoting it again changes nothing. Only the storefront sends upvotes, with the admin api key, vouching for the voter
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Param request body upvoteReq true "Voter"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions/{question_id}/upvotes [post]
+func (s *Server) upvoteQuestion(ctx *gin.Context) {
+	var uriReq getQuestionReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req upvoteReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "question_id": uriReq.QuestionID, "upvote": req})
+
+	question, err := s.svc.UpvoteQuestion(ctx, uriReq.ID, uriReq.QuestionID, req.VoterID)
+	if err != nil {
+		logger.Error(ctx, "cannot upvote question", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", question)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Upvote recorded", question))
+}
+
+// @Summary Delete a question
+// @Description Delete a question about a product along with its answers and upvotes
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions/{question_id} [delete]
+func (s *Server) deleteQuestion(ctx *gin.Context) {
+	var req getQuestionReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	if err := s.svc.DeleteQuestion(ctx, req.ID, req.QuestionID); err != nil {
+		logger.Error(ctx, "cannot delete question", err)
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", req.QuestionID))
+}
+
+// @Summary Answer a question
+// @Description Answer an approved question about a product on behalf of the catalog team, with the admin api key. The answer is signed by X-Actor, "admin" when it is not given. Answering raises a QuestionAnswered event, so the customer who asked can be told
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Param request body answerQuestionReq true "Answer"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions/{question_id}/answers [post]
+func (s *Server) answerQuestion(ctx *gin.Context) {
+	var uriReq getQuestionReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req answerQuestionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "question_id": uriReq.QuestionID, "answer": req})
+
+	answer, err := s.svc.AnswerQuestion(ctx, uriReq.ID, &service.Answer{
+		QuestionID: uriReq.QuestionID,
+		AuthorType: service.AnswerAuthorStaff,
+		AuthorID:   service.GetActor(ctx),
+		AuthorName: req.AuthorName,
+		Body:       req.Body,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot answer question", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", answer)
+
+	ctx.JSON(http.StatusCreated, s.svc.Response(ctx, "Successfully answered", answer))
+}
+
+// @Summary Upvote an answer
+// @Description Upvote an answer to a question about a product. A customer upvotes an answer once, upvoting it again changes nothing. Only the storefront sends upvotes, with the admin api key, vouching for the voter
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Param answer_id path string true "Answer ID" format "uuid"
+// @Param request body upvoteReq true "Voter"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions/{question_id}/answers/{answer_id}/upvotes [post]
+func (s *Server) upvoteAnswer(ctx *gin.Context) {
+	var uriReq getAnswerReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req upvoteReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "question_id": uriReq.QuestionID, "answer_id": uriReq.AnswerID, "upvote": req})
+
+	answer, err := s.svc.UpvoteAnswer(ctx, uriReq.ID, uriReq.QuestionID, uriReq.AnswerID, req.VoterID)
+	if err != nil {
+		logger.Error(ctx, "cannot upvote answer", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", answer)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Upvote recorded", answer))
+}
+
+// @Summary Delete an answer
+// @Description Delete an answer to a question about a product along with its upvotes
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Param answer_id path string true "Answer ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/questions/{question_id}/answers/{answer_id} [delete]
+func (s *Server) deleteAnswer(ctx *gin.Context) {
+	var req getAnswerReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	if err := s.svc.DeleteAnswer(ctx, req.ID, req.QuestionID, req.AnswerID); err != nil {
+		logger.Error(ctx, "cannot delete answer", err)
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", req.AnswerID))
+}
@@ -0,0 +1,410 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// @Summary Review a product
+// @Description Review a product with a rating from 1 to 5. The review is pending until it is approved, a customer reviews a product once. Only the storefront sends reviews, with the admin api key, vouching for the customer and whether they bought the product
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID or slug"
+// @Param request body createReviewReq true "Review"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/reviews [post]
+func (s *Server) createReview(ctx *gin.Context) {
+	var req createReviewReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+
+	logger.Info(ctx, "req payload", gin.H{"product_id": productID, "review": req})
+
+	review, err := s.svc.AddReview(ctx, &service.Review{
+		ProductID:        productID,
+		CustomerID:       req.CustomerID,
+		AuthorName:       req.AuthorName,
+		Rating:           req.Rating,
+		Title:            req.Title,
+		Body:             req.Body,
+		VerifiedPurchase: req.VerifiedPurchase,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot create review", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", review)
+
+	ctx.JSON(http.StatusCreated, s.svc.Response(ctx, "Review submitted for moderation", review))
+}
+
+// @Summary Get the reviews of a product
+// @Description Get a paginated list of the approved reviews of a product, newest first unless sorted otherwise
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID or slug"
+// @Param rating query int false "Only the reviews with this rating"
+// @Param verified_purchase query bool false "Only the reviews of verified purchases"
+// @Param sort query string false "Sort order" Enums(newest, helpful, highest, lowest)
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/reviews [get]
+func (s *Server) getProductReviews(ctx *gin.Context) {
+	var req getProductReviewsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+
+	logger.Info(ctx, "req payload", gin.H{"product_id": productID, "query": req})
+
+	if _, err := s.svc.GetPublishedProduct(ctx, productID, util.GetCurrentTimestamp()); err != nil {
+		logger.Error(ctx, "cannot get product", err)
+		ctx.Error(err)
+		return
+	}
+
+	result, err := s.svc.GetReviews(ctx, service.FilterReviewsParams{
+		ProductID:        productID,
+		Statuses:         []string{service.ModerationStatusApproved},
+		Rating:           req.Rating,
+		VerifiedPurchase: req.VerifiedPurchase,
+		Sort:             req.Sort,
+		Page:             req.Page,
+		Limit:            req.Limit,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot get product reviews", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Get a list of reviews
+// @Description Get a paginated list of reviews in any status, e.g. the pending ones to moderate
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param product_id query string false "Product ID or slug"
+// @Param status query array false "Moderation statuses filter"
+// @Param rating query int false "Only the reviews with this rating"
+// @Param verified_purchase query bool false "Only the reviews of verified purchases"
+// @Param sort query string false "Sort order" Enums(newest, helpful, highest, lowest)
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reviews [get]
+func (s *Server) getReviews(ctx *gin.Context) {
+	var req getReviewsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	if !s.resolveRefs(ctx, service.AuditEntityProduct, &req.ProductID) {
+		return
+	}
+
+	result, err := s.svc.GetReviews(ctx, service.FilterReviewsParams{
+		ProductID:        req.ProductID,
+		Statuses:         req.Statuses,
+		Rating:           req.Rating,
+		VerifiedPurchase: req.VerifiedPurchase,
+		Sort:             req.Sort,
+		Page:             req.Page,
+		Limit:            req.Limit,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot get reviews", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Get a review
+// @Description Get a review in any status
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Review ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reviews/{id} [get]
+func (s *Server) getReview(ctx *gin.Context) {
+	var req getReviewReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	review, err := s.svc.GetReview(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get review", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, review.Version)
+
+	logger.Info(ctx, "res payload", review)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", review))
+}
+
+// @Summary Moderate a review
+// @Description Approve or reject a review. Only approved reviews are shown and count in the rating of their product
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Review ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body moderateReviewReq true "New status and why"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reviews/{id}/transitions [post]
+func (s *Server) moderateReview(ctx *gin.Context) {
+	var uriReq getReviewReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req moderateReviewReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "moderation": req})
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	review, err := s.svc.ModerateReview(ctx, uriReq.ID, req.Status, req.Reason, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot moderate stale review", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot moderate review", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, review.Version)
+
+	logger.Info(ctx, "res payload", review)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully moderated", review))
+}
+
+// @Summary Get the moderation history of a review
+// @Description Get a paginated list of the status changes of a review, newest first, with who made them and why
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Review ID" format "uuid"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reviews/{id}/transitions [get]
+func (s *Server) getReviewTransitions(ctx *gin.Context) {
+	var uriReq getReviewReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req getTransitionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "query": req})
+
+	result, err := s.svc.GetTransitions(ctx, service.AuditEntityReview, uriReq.ID, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get review transitions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Vote on a review
+// @Description Tell whether an approved review was helpful. A customer has one vote on a review, voting again replaces it, and none on their own reviews. Only the storefront sends votes, with the admin api key, vouching for the voter
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Review ID" format "uuid"
+// @Param request body voteReviewReq true "Vote"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reviews/{id}/votes [post]
+func (s *Server) voteReview(ctx *gin.Context) {
+	var uriReq getReviewReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req voteReviewReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "vote": req})
+
+	review, err := s.svc.VoteReview(ctx, &service.ReviewVote{
+		ReviewID: uriReq.ID,
+		VoterID:  req.VoterID,
+		Helpful:  *req.Helpful,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot vote on review", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", review)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Vote recorded", review))
+}
+
+// @Summary Delete a review
+// @Description Delete a review along with its votes, taking it out of the rating of its product. The review is deleted only when it has not changed since the version in If-Match or the version query parameter
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Review ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the deletion is based on"
+// @Param version query int false "Version the deletion is based on, when there is no If-Match header"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reviews/{id} [delete]
+func (s *Server) deleteReview(ctx *gin.Context) {
+	var uriReq getReviewReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req deleteReviewReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "query": req})
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	err := s.svc.DeleteReview(ctx, uriReq.ID, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot delete stale review", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot delete review", err)
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", uriReq.ID))
+}
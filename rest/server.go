@@ -1,15 +1,18 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/jsiqbal/ecommerce/config"
+	"github.com/jsiqbal/ecommerce/graph"
 	"github.com/jsiqbal/ecommerce/logger"
 	"github.com/jsiqbal/ecommerce/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	graphql "github.com/graph-gophers/graphql-go"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -17,19 +20,50 @@ import (
 type Server struct {
 	router *gin.Engine
 	svc    service.Service
+	portal service.SupplierPortal
 	appCnf *config.Application
+	feeds  *feedCache
+	gql    *graphql.Schema
 }
 
 func NewServer(svc service.Service, appCnf *config.Application) (*Server, error) {
+	// an empty secret would sign the supplier tokens with an empty key, and an empty api
+	// key would leave the admin routes closed to everyone
+	if len(appCnf.AuthSecret) == 0 {
+		return nil, errors.New("AUTH_SECRET is required")
+	}
+
+	if len(appCnf.AdminAPIKey) == 0 {
+		return nil, errors.New("ADMIN_API_KEY is required")
+	}
+
 	server := &Server{
 		svc:    svc,
+		portal: service.NewSupplierPortal(svc),
 		appCnf: appCnf,
+		feeds:  newFeedCache(),
 	}
 
+	gql, err := graph.NewSchema(svc)
+	if err != nil {
+		return nil, err
+	}
+
+	server.gql = gql
+
 	// custom validators for status id
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		v.RegisterValidation("validStatusID", validStatusID)
+		v.RegisterValidation("validStatus", validStatus)
+		v.RegisterValidation("validProductStatus", validProductStatus)
+		v.RegisterValidation("validModeration", validModeration)
 		v.RegisterValidation("validPhone", validPhone)
+		v.RegisterValidation("validSlug", validSlug)
+		v.RegisterValidation("validWebsite", validWebsite)
+		v.RegisterValidation("validCountryCode", validCountryCode)
+		v.RegisterValidation("validLocale", validLocale)
+
+		// the validation errors name the fields the way the requests spell them
+		v.RegisterTagNameFunc(fieldName)
 	}
 
 	// check env-wise mode enabled
@@ -45,45 +79,169 @@ func NewServer(svc service.Service, appCnf *config.Application) (*Server, error)
 func (server *Server) setupRouter() {
 	router := gin.Default()
 
+	// let the request context values (trace id, actor, locales) reach the services through *gin.Context
+	router.ContextWithFallback = true
+
 	// CORS MIDDLEWARE
 	router.Use(corsMiddleware)
 
 	// LOG MIDDLEWARE
 	router.Use(logger.ModifyContext)
 
+	// ERROR MIDDLEWARE
+	router.Use(problemMiddleware)
+
+	// ACTOR MIDDLEWARE
+	router.Use(actorMiddleware)
+
+	// LOCALE MIDDLEWARE
+	router.Use(localeMiddleware)
+
 	//------------------------SWAGGER DOCS ROUTE------------------------
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	router.GET("/api/health", server.checkHealth)
 
+	//------------------------MEDIA ROUTE------------------------
+	router.Static("/media", server.appCnf.MediaDir)
+
 	//------------------------BRAND ROUTES------------------------
-	router.POST("/api/brands", server.createBrand)
+	router.POST("/api/brands", server.adminAuthMiddleware, server.createBrand)
 	router.GET("/api/brands", server.getBrands)
-	router.GET("/api/brands/:id", server.getBrand)
-	router.PUT("/api/brands/:id", server.updateBrand)
-	router.DELETE("/api/brands/:id", server.deleteBrand)
+	router.POST("/api/brands/bulk", server.adminAuthMiddleware, server.bulkBrands)
+
+	brand := router.Group("/api/brands/:id", server.slugParam(service.AuditEntityBrand))
+	brand.GET("", server.getBrand)
+	brand.PUT("", server.adminAuthMiddleware, server.updateBrand)
+	brand.PATCH("", server.adminAuthMiddleware, server.patchBrand)
+	brand.DELETE("", server.adminAuthMiddleware, server.deleteBrand)
+	brand.POST("/restore", server.adminAuthMiddleware, server.restoreBrand)
+	brand.GET("/page", server.getBrandPage)
+	brand.PUT("/logo", server.adminAuthMiddleware, server.uploadBrandLogo)
+	brand.DELETE("/logo", server.adminAuthMiddleware, server.deleteBrandLogo)
+	brand.POST("/transitions", server.adminAuthMiddleware, server.transitionBrand)
+	brand.GET("/transitions", server.getBrandTransitions)
 
 	//------------------------CATEGORY ROUTES------------------------
-	router.POST("/api/categories", server.createCategory)
+	router.POST("/api/categories", server.adminAuthMiddleware, server.createCategory)
 	router.GET("/api/categories", server.getCategories)
+	router.POST("/api/categories/bulk", server.adminAuthMiddleware, server.bulkCategories)
 	router.GET("/api/categories/tree", server.getFormattedCategories)
-	router.GET("/api/categories/:id", server.getCategory)
-	router.PUT("/api/categories/:id", server.updateCategory)
-	router.DELETE("/api/categories/:id", server.deleteCategory)
+	router.POST("/api/categories/reorder", server.adminAuthMiddleware, server.reorderCategories)
+
+	ctgry := router.Group("/api/categories/:id", server.slugParam(service.AuditEntityCategory))
+	ctgry.GET("", server.getCategory)
+	ctgry.PUT("", server.adminAuthMiddleware, server.updateCategory)
+	ctgry.PATCH("", server.adminAuthMiddleware, server.patchCategory)
+	ctgry.DELETE("", server.adminAuthMiddleware, server.deleteCategory)
+	ctgry.POST("/restore", server.adminAuthMiddleware, server.restoreCategory)
+	ctgry.GET("/subtree", server.getCategorySubtree)
+	ctgry.GET("/ancestors", server.getCategoryAncestors)
+	ctgry.POST("/move", server.adminAuthMiddleware, server.moveCategory)
+	ctgry.POST("/transitions", server.adminAuthMiddleware, server.transitionCategory)
+	ctgry.GET("/transitions", server.getCategoryTransitions)
 
 	//------------------------SUPPLIER ROUTES------------------------
-	router.POST("/api/suppliers", server.createSupplier)
+	router.POST("/api/suppliers", server.adminAuthMiddleware, server.createSupplier)
 	router.GET("/api/suppliers", server.getSuppliers)
+	router.POST("/api/suppliers/bulk", server.adminAuthMiddleware, server.bulkSuppliers)
 	router.GET("/api/suppliers/:id", server.getSupplier)
-	router.PUT("/api/suppliers/:id", server.updateSupplier)
-	router.DELETE("/api/suppliers/:id", server.deleteSupplier)
+	router.PUT("/api/suppliers/:id", server.adminAuthMiddleware, server.updateSupplier)
+	router.PATCH("/api/suppliers/:id", server.adminAuthMiddleware, server.patchSupplier)
+	router.DELETE("/api/suppliers/:id", server.adminAuthMiddleware, server.deleteSupplier)
+	router.POST("/api/suppliers/:id/restore", server.adminAuthMiddleware, server.restoreSupplier)
+	router.POST("/api/suppliers/:id/token", server.adminAuthMiddleware, server.createSupplierToken)
+	router.POST("/api/suppliers/:id/transitions", server.adminAuthMiddleware, server.transitionSupplier)
+	router.GET("/api/suppliers/:id/transitions", server.getSupplierTransitions)
 
 	//------------------------PRODUCT ROUTES------------------------
-	router.POST("/api/products", server.createProduct)
+	router.POST("/api/products", server.adminAuthMiddleware, server.createProduct)
 	router.GET("/api/products", server.getProducts)
-	router.GET("/api/products/:id", server.getProduct)
-	router.PUT("/api/products/:id", server.updateProduct)
-	router.DELETE("/api/products/:id", server.deleteProduct)
+	router.POST("/api/products/bulk", server.adminAuthMiddleware, server.bulkProducts)
+	router.GET("/api/products/export", server.exportProducts)
+	router.GET("/api/products/schedule", server.getScheduledChanges)
+
+	product := router.Group("/api/products/:id", server.slugParam(service.AuditEntityProduct))
+	product.GET("", server.getProduct)
+	product.PUT("", server.adminAuthMiddleware, server.updateProduct)
+	product.PATCH("", server.adminAuthMiddleware, server.patchProduct)
+	product.DELETE("", server.adminAuthMiddleware, server.deleteProduct)
+	product.POST("/restore", server.adminAuthMiddleware, server.restoreProduct)
+	product.POST("/transitions", server.adminAuthMiddleware, server.transitionProduct)
+	product.GET("/transitions", server.getProductTransitions)
+	product.POST("/reviews", server.adminAuthMiddleware, server.createReview)
+	product.GET("/reviews", server.getProductReviews)
+	product.POST("/questions", server.adminAuthMiddleware, server.askQuestion)
+	product.GET("/questions", server.getProductQuestions)
+	product.GET("/questions/:question_id", server.adminAuthMiddleware, server.getQuestion)
+	product.DELETE("/questions/:question_id", server.adminAuthMiddleware, server.deleteQuestion)
+	product.POST("/questions/:question_id/transitions", server.adminAuthMiddleware, server.moderateQuestion)
+	product.GET("/questions/:question_id/transitions", server.adminAuthMiddleware, server.getQuestionTransitions)
+	product.POST("/questions/:question_id/upvotes", server.adminAuthMiddleware, server.upvoteQuestion)
+	product.POST("/questions/:question_id/answers", server.adminAuthMiddleware, server.answerQuestion)
+	product.DELETE("/questions/:question_id/answers/:answer_id", server.adminAuthMiddleware, server.deleteAnswer)
+	product.POST("/questions/:question_id/answers/:answer_id/upvotes", server.adminAuthMiddleware, server.upvoteAnswer)
+
+	//------------------------REVIEW ROUTES------------------------
+	router.GET("/api/reviews", server.adminAuthMiddleware, server.getReviews)
+	router.GET("/api/reviews/:id", server.adminAuthMiddleware, server.getReview)
+	router.DELETE("/api/reviews/:id", server.adminAuthMiddleware, server.deleteReview)
+	router.POST("/api/reviews/:id/transitions", server.adminAuthMiddleware, server.moderateReview)
+	router.GET("/api/reviews/:id/transitions", server.adminAuthMiddleware, server.getReviewTransitions)
+	router.POST("/api/reviews/:id/votes", server.adminAuthMiddleware, server.voteReview)
+
+	//------------------------QUESTION ROUTES------------------------
+	router.GET("/api/questions", server.adminAuthMiddleware, server.getQuestions)
+
+	//------------------------SLUG ROUTES------------------------
+	router.GET("/api/resolve", server.resolvePath)
+
+	//------------------------IMPORT ROUTES------------------------
+	router.POST("/api/imports/products", server.adminAuthMiddleware, server.importProducts)
+	router.GET("/api/imports/:id", server.getImportJob)
+	router.GET("/api/imports/:id/errors", server.getImportErrors)
+
+	//------------------------JOB ROUTES------------------------
+	router.GET("/api/jobs/:id", server.getJob)
+	router.POST("/api/jobs/:id/requeue", server.adminAuthMiddleware, server.requeueJob)
+
+	//------------------------GRAPHQL ROUTE------------------------
+	router.POST("/api/graphql", server.runGraphQL)
+
+	//------------------------WEBHOOK ROUTES------------------------
+	hooks := router.Group("/api/webhooks", server.adminAuthMiddleware)
+	hooks.POST("", server.createWebhook)
+	hooks.GET("", server.getWebhooks)
+	hooks.GET("/event-types", server.getWebhookEventTypes)
+	hooks.GET("/:id", server.getWebhook)
+	hooks.PUT("/:id", server.updateWebhook)
+	hooks.DELETE("/:id", server.deleteWebhook)
+	hooks.GET("/:id/deliveries", server.getWebhookDeliveries)
+	hooks.POST("/:id/deliveries/:delivery_id/redeliver", server.redeliverWebhook)
+
+	//------------------------FEED ROUTES------------------------
+	router.GET("/api/feeds/google.xml", server.getXMLFeed)
+	router.GET("/api/feeds/google.csv", server.getCSVFeed)
+
+	//------------------------AUDIT ROUTES------------------------
+	router.GET("/api/audit", server.getAuditLogs)
+
+	//------------------------SUPPLIER PORTAL ROUTES------------------------
+	portal := router.Group("/api/supplier-portal", server.supplierAuthMiddleware)
+	portal.GET("/products", server.getPortalProducts)
+	portal.POST("/products", server.createPortalProduct)
+
+	portalProduct := portal.Group("/products/:id", server.slugParam(service.AuditEntityProduct))
+	portalProduct.GET("", server.getPortalProduct)
+	portalProduct.PUT("", server.updatePortalProduct)
+	portalProduct.POST("/archive", server.archivePortalProduct)
+	portalProduct.POST("/transitions", server.transitionPortalProduct)
+	portalProduct.POST("/stock", server.adjustPortalProductStock)
+	portalProduct.GET("/questions", server.getPortalProductQuestions)
+	portalProduct.POST("/questions/:question_id/answers", server.answerPortalProductQuestion)
+
+	portal.GET("/reports/sales", server.getPortalSalesReport)
+	portal.GET("/reports/low-stock", server.getPortalLowStockReport)
 
 	server.router = router
 }
@@ -92,6 +250,11 @@ func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
 
+// Handler returns the handler serving the routes, e.g. to run the api in an httptest server
+func (server *Server) Handler() http.Handler {
+	return server.router
+}
+
 func (server *Server) checkHealth(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, "OK")
 }
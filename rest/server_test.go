@@ -0,0 +1,921 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/config"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+const testAdminKey = "admin-key"
+
+// adminHeaders authenticate a request of the catalog team
+var adminHeaders = map[string]string{"Authorization": "Bearer " + testAdminKey}
+
+var (
+	testBrand    = service.Brand{ID: uuid.NewString(), Name: "Acme", Status: service.StatusActive}
+	testCategory = service.Category{ID: uuid.NewString(), Name: "Phones", Status: service.StatusActive}
+	testSupplier = service.Supplier{ID: uuid.NewString(), Name: "Supplier", Status: service.StatusActive}
+)
+
+// fakeService keeps the products in memory, checking versions the way the service does.
+// the methods the tests do not reach are left to the nil Service it embeds
+type fakeService struct {
+	service.Service
+
+	mu          sync.Mutex
+	products    map[string]*service.Product
+	transitions []service.Transition
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{products: make(map[string]*service.Product)}
+}
+
+func (f *fakeService) Response(ctx context.Context, description string, data interface{}) *service.ResponseData {
+	return &service.ResponseData{
+		Timestamp:   util.GetCurrentTimestamp(),
+		Description: description,
+		Data:        data,
+	}
+}
+
+func (f *fakeService) ResolveSlug(ctx context.Context, entity, slug string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, product := range f.products {
+		if entity == service.AuditEntityProduct && product.Slug == slug {
+			return product.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (f *fakeService) GetBrand(ctx context.Context, brandID string) (*service.Brand, error) {
+	if brandID != testBrand.ID {
+		return nil, service.ErrBrandNotFound
+	}
+
+	brand := testBrand
+	return &brand, nil
+}
+
+func (f *fakeService) GetCategory(ctx context.Context, ctgryID string) (*service.Category, error) {
+	if ctgryID != testCategory.ID {
+		return nil, service.ErrCategoryNotFound
+	}
+
+	ctgry := testCategory
+	return &ctgry, nil
+}
+
+func (f *fakeService) GetSupplier(ctx context.Context, spplrID string) (*service.Supplier, error) {
+	if spplrID != testSupplier.ID {
+		return nil, service.ErrSupplierNotFound
+	}
+
+	spplr := testSupplier
+	return &spplr, nil
+}
+
+func (f *fakeService) AddProduct(ctx context.Context, product *service.Product) (*service.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, existing := range f.products {
+		if existing.Name == product.Name {
+			return nil, service.ErrDuplicateProduct
+		}
+	}
+
+	product.ID = uuid.NewString()
+	product.Slug = util.Slugify(product.Name)
+	product.Version = 1
+	if len(product.Status) == 0 {
+		product.Status = service.ProductStatusDraft
+	}
+
+	stored := *product
+	f.products[product.ID] = &stored
+
+	return product, nil
+}
+
+func (f *fakeService) GetProduct(ctx context.Context, productID string) (*service.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	product, ok := f.products[productID]
+	if !ok {
+		return nil, service.ErrProductNotFound
+	}
+
+	copied := *product
+	return &copied, nil
+}
+
+func (f *fakeService) GetPublishedProduct(ctx context.Context, productID string, now int64) (*service.Product, error) {
+	product, err := f.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !product.Live(now) {
+		return nil, service.ErrProductNotFound
+	}
+
+	return product, nil
+}
+
+func (f *fakeService) UpdateProduct(ctx context.Context, productID string, product *service.Product) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, ok := f.products[productID]
+	if !ok {
+		return service.ErrProductNotFound
+	}
+
+	if existing.Version != product.Version {
+		return service.ErrVersionConflict
+	}
+
+	stored := *product
+	stored.Version++
+	f.products[productID] = &stored
+
+	return nil
+}
+
+func (f *fakeService) PatchProduct(ctx context.Context, productID string, patch *service.ProductPatch) (*service.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	product, ok := f.products[productID]
+	if !ok {
+		return nil, service.ErrProductNotFound
+	}
+
+	if product.Version != patch.Version {
+		return nil, service.ErrVersionConflict
+	}
+
+	if patch.Name != nil {
+		product.Name = *patch.Name
+	}
+
+	if patch.UnitPrice != nil {
+		product.UnitPrice = *patch.UnitPrice
+	}
+
+	if patch.Specifications != nil {
+		product.Specifications = *patch.Specifications
+	}
+
+	product.Version++
+
+	copied := *product
+	return &copied, nil
+}
+
+// BulkProducts creates the products, a name taken fails its operation
+func (f *fakeService) BulkProducts(ctx context.Context, mode string, ops []service.BulkProductOp) (*service.BulkResult, error) {
+	result := &service.BulkResult{Mode: mode}
+
+	f.mu.Lock()
+	taken := make(map[string]bool)
+	for _, product := range f.products {
+		taken[product.Name] = true
+	}
+	f.mu.Unlock()
+
+	for i, op := range ops {
+		item := service.BulkItemResult{Index: i, Op: op.Op, Status: service.BulkStatusSucceeded}
+
+		if taken[op.Product.Name] {
+			item.Status = service.BulkStatusFailed
+			item.Error = service.ErrDuplicateProduct.Error()
+			result.Failed++
+		} else {
+			taken[op.Product.Name] = true
+			result.Succeeded++
+		}
+
+		result.Items = append(result.Items, item)
+	}
+
+	if mode == service.BulkModeAtomic && result.Failed > 0 {
+		for i := range result.Items {
+			if result.Items[i].Status == service.BulkStatusSucceeded {
+				result.Items[i].Status = service.BulkStatusSkipped
+				result.Skipped++
+			}
+		}
+
+		result.Succeeded = 0
+		return result, nil
+	}
+
+	for i, op := range ops {
+		if result.Items[i].Status == service.BulkStatusSucceeded {
+			f.AddProduct(ctx, op.Product)
+		}
+	}
+
+	return result, nil
+}
+
+// TransitionProduct moves the product to the status, refusing to activate a draft as the
+// service does
+func (f *fakeService) TransitionProduct(ctx context.Context, productID, status, reason string, version int64) (*service.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	product, ok := f.products[productID]
+	if !ok {
+		return nil, service.ErrProductNotFound
+	}
+
+	if product.Status == service.ProductStatusDraft && status == service.ProductStatusActive {
+		return nil, &service.TransitionError{
+			Entity:  service.AuditEntityProduct,
+			From:    product.Status,
+			To:      status,
+			Allowed: []string{service.ProductStatusPendingReview, service.ProductStatusArchived},
+		}
+	}
+
+	if product.Version != version {
+		return nil, service.ErrVersionConflict
+	}
+
+	f.transitions = append([]service.Transition{{
+		EntityType: service.AuditEntityProduct,
+		EntityID:   productID,
+		FromStatus: product.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		Actor:      service.GetActor(ctx),
+	}}, f.transitions...)
+
+	product.Status = status
+	product.Version++
+
+	copied := *product
+	return &copied, nil
+}
+
+func (f *fakeService) GetTransitions(ctx context.Context, entityType, entityID string, page, limit int64) (*service.TransitionResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := &service.TransitionResult{Transitions: []service.Transition{}, Page: page, Limit: limit}
+	for _, transition := range f.transitions {
+		if transition.EntityType == entityType && transition.EntityID == entityID {
+			result.Transitions = append(result.Transitions, transition)
+			result.Total++
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteReview deletes review-1 at version 2, the version it is at
+func (f *fakeService) DeleteReview(ctx context.Context, reviewID string, version int64) error {
+	if reviewID != "review-1" {
+		return service.ErrReviewNotFound
+	}
+
+	if version != 2 {
+		return service.ErrVersionConflict
+	}
+
+	return nil
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *fakeService) {
+	svc := newFakeService()
+
+	server, err := NewServer(svc, &config.Application{
+		Env:         "test",
+		AuthSecret:  "secret",
+		AdminAPIKey: testAdminKey,
+		MediaDir:    t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	srv := httptest.NewServer(server.Handler())
+	t.Cleanup(srv.Close)
+
+	return srv, svc
+}
+
+// do sends the body as json with the headers, decoding the response into out
+func do(t *testing.T, srv *httptest.Server, method, path string, body interface{}, headers map[string]string, out interface{}) *http.Response {
+	t.Helper()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, srv.URL+path, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: cannot decode response: %v", method, path, err)
+		}
+	}
+
+	return res
+}
+
+// productResponse is the success response of a product
+type productResponse struct {
+	Description string          `json:"description"`
+	Data        service.Product `json:"data"`
+}
+
+func productReq(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":           name,
+		"description":    "a phone",
+		"brand_id":       testBrand.ID,
+		"category_id":    testCategory.ID,
+		"supplier_id":    testSupplier.ID,
+		"unit_price":     100,
+		"discount_price": 10,
+		"tags":           []string{"phone"},
+		"stock_quantity": 5,
+	}
+}
+
+// checkProblem checks the response is a problem+json of the status and code
+func checkProblem(t *testing.T, res *http.Response, problem *ErrorResponse, status int, code string) {
+	t.Helper()
+
+	if res.StatusCode != status {
+		t.Errorf("status = %d, want %d", res.StatusCode, status)
+	}
+
+	if contentType := res.Header.Get("Content-Type"); contentType != problemContentType {
+		t.Errorf("content type = %q, want %q", contentType, problemContentType)
+	}
+
+	if problem.Status != status || problem.Code != code || problem.Type == "" || problem.Instance == "" {
+		t.Errorf("problem = %+v, want status %d and code %s", problem, status, code)
+	}
+}
+
+func createTestProduct(t *testing.T, srv *httptest.Server, name string) service.Product {
+	t.Helper()
+
+	var created productResponse
+	res := do(t, srv, http.MethodPost, "/api/products", productReq(name), adminHeaders, &created)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("create product: status %d", res.StatusCode)
+	}
+
+	return created.Data
+}
+
+func TestCreateProduct(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	product := createTestProduct(t, srv, "Phone X")
+	if len(product.ID) == 0 || product.Name != "Phone X" || product.Brand.ID != testBrand.ID || product.Version != 1 {
+		t.Errorf("created product = %+v", product)
+	}
+
+	// a draft is for the catalog team only
+	var problem ErrorResponse
+	res := do(t, srv, http.MethodGet, "/api/products/"+product.Slug, nil, nil, &problem)
+	checkProblem(t, res, &problem, http.StatusNotFound, "product_not_found")
+
+	var fetched productResponse
+	res = do(t, srv, http.MethodGet, "/api/products/"+product.Slug+"?preview=true", nil, nil, &problem)
+	checkProblem(t, res, &problem, http.StatusUnauthorized, errUnauthorized.Code())
+
+	res = do(t, srv, http.MethodGet, "/api/products/"+product.Slug+"?preview=true", nil, adminHeaders, &fetched)
+	if res.StatusCode != http.StatusOK || fetched.Data.ID != product.ID {
+		t.Fatalf("get by slug = %d, %+v", res.StatusCode, fetched.Data)
+	}
+
+	if etag := res.Header.Get("ETag"); etag != `"1"` {
+		t.Errorf("ETag = %s, want \"1\"", etag)
+	}
+
+	res = do(t, srv, http.MethodPost, "/api/products", productReq("Phone X"), adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusConflict, "duplicate_product")
+}
+
+func TestCreateProductValidation(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	req := productReq("x")
+	delete(req, "description")
+
+	var problem ErrorResponse
+	res := do(t, srv, http.MethodPost, "/api/products", req, adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusBadRequest, "invalid_parameters")
+
+	fields := make(map[string]bool)
+	for _, field := range problem.Errors {
+		fields[field.Field] = true
+	}
+
+	if !fields["name"] || !fields["description"] {
+		t.Errorf("problem errors = %+v, want name and description", problem.Errors)
+	}
+
+	req = productReq("Phone")
+	req["brand_id"] = uuid.NewString()
+
+	problem = ErrorResponse{}
+	res = do(t, srv, http.MethodPost, "/api/products", req, adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusUnprocessableEntity, "invalid_reference")
+
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "brand_id" {
+		t.Errorf("problem errors = %+v, want brand_id", problem.Errors)
+	}
+}
+
+func TestUpdateProductIfMatch(t *testing.T) {
+	srv, _ := newTestServer(t)
+	product := createTestProduct(t, srv, "Phone X")
+	path := "/api/products/" + product.ID
+
+	// no version at all
+	var problem ErrorResponse
+	res := do(t, srv, http.MethodPut, path, productReq("Phone Y"), adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusPreconditionRequired, "version_required")
+
+	res = do(t, srv, http.MethodPut, path, productReq("Phone Y"), map[string]string{"Authorization": adminHeaders["Authorization"], "If-Match": "1"}, &problem)
+	checkProblem(t, res, &problem, http.StatusBadRequest, "invalid_if_match")
+
+	var updated productResponse
+	res = do(t, srv, http.MethodPut, path, productReq("Phone Y"), map[string]string{"Authorization": adminHeaders["Authorization"], "If-Match": `"1"`}, &updated)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("update: status %d", res.StatusCode)
+	}
+
+	if etag := res.Header.Get("ETag"); etag != `"2"` {
+		t.Errorf("ETag = %s, want \"2\"", etag)
+	}
+
+	// the version the update was based on is stale now
+	res = do(t, srv, http.MethodPut, path, productReq("Phone Z"), map[string]string{"Authorization": adminHeaders["Authorization"], "If-Match": `"1"`}, &problem)
+	checkProblem(t, res, &problem, http.StatusPreconditionFailed, "version_conflict")
+
+	body := productReq("Phone Z")
+	body["version"] = 1
+	res = do(t, srv, http.MethodPut, path, body, adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusConflict, "version_conflict")
+}
+
+func TestPatchProduct(t *testing.T) {
+	srv, _ := newTestServer(t)
+	product := createTestProduct(t, srv, "Phone X")
+	path := "/api/products/" + product.ID
+	ifMatch := map[string]string{"Authorization": adminHeaders["Authorization"], "If-Match": `"1"`, "Content-Type": mergePatchContentType}
+
+	var patched productResponse
+	res := do(t, srv, http.MethodPatch, path, map[string]interface{}{"unit_price": 80, "specifications": nil}, ifMatch, &patched)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("patch: status %d", res.StatusCode)
+	}
+
+	if patched.Data.UnitPrice != 80 || patched.Data.Name != "Phone X" || patched.Data.Version != 2 {
+		t.Errorf("patched product = %+v", patched.Data)
+	}
+
+	if etag := res.Header.Get("ETag"); etag != strconv.Quote("2") {
+		t.Errorf("ETag = %s, want \"2\"", etag)
+	}
+
+	var problem ErrorResponse
+	res = do(t, srv, http.MethodPatch, path, map[string]interface{}{"name": "Phone Y"}, ifMatch, &problem)
+	checkProblem(t, res, &problem, http.StatusPreconditionFailed, "version_conflict")
+
+	// only the clearable members may be null
+	res = do(t, srv, http.MethodPatch, path, map[string]interface{}{"name": nil, "version": 2}, adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusBadRequest, "invalid_parameters")
+
+	res = do(t, srv, http.MethodPatch, path, map[string]interface{}{"name": "Phone Y"}, map[string]string{"Authorization": adminHeaders["Authorization"], "Content-Type": "text/plain", "If-Match": `"2"`}, &problem)
+	checkProblem(t, res, &problem, http.StatusBadRequest, "invalid_parameters")
+
+	res = do(t, srv, http.MethodPatch, "/api/products/"+uuid.NewString(), map[string]interface{}{"name": "Phone Y"}, ifMatch, &problem)
+	checkProblem(t, res, &problem, http.StatusNotFound, "product_not_found")
+}
+
+func TestBulkProducts(t *testing.T) {
+	srv, svc := newTestServer(t)
+	createTestProduct(t, srv, "Phone X")
+
+	ops := func(names ...string) map[string]interface{} {
+		var operations []map[string]interface{}
+		for _, name := range names {
+			operations = append(operations, map[string]interface{}{"op": "create", "data": productReq(name)})
+		}
+
+		return map[string]interface{}{"operations": operations}
+	}
+
+	// atomic by default, a failing operation rolls them all back
+	var problem ErrorResponse
+	res := do(t, srv, http.MethodPost, "/api/products/bulk", ops("Phone Y", "Phone X"), adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusUnprocessableEntity, "bulk_rolled_back")
+
+	data, _ := json.Marshal(problem.Data)
+	var rolledBack service.BulkResult
+	if err := json.Unmarshal(data, &rolledBack); err != nil || rolledBack.Failed != 1 || rolledBack.Skipped != 1 {
+		t.Errorf("rolled back result = %s", data)
+	}
+
+	if len(svc.products) != 1 {
+		t.Errorf("%d products after a rolled back request, want 1", len(svc.products))
+	}
+
+	body := ops("Phone Y", "Phone Z")
+	body["mode"] = service.BulkModeBestEffort
+
+	var processed struct {
+		Data service.BulkResult `json:"data"`
+	}
+	res = do(t, srv, http.MethodPost, "/api/products/bulk", body, adminHeaders, &processed)
+	if res.StatusCode != http.StatusOK || processed.Data.Succeeded != 2 {
+		t.Errorf("best effort = %d, %+v", res.StatusCode, processed.Data)
+	}
+
+	body["mode"] = "sometimes"
+	res = do(t, srv, http.MethodPost, "/api/products/bulk", body, adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusBadRequest, "invalid_parameters")
+}
+
+func TestSupplierTokenRequiresAdmin(t *testing.T) {
+	srv, _ := newTestServer(t)
+	path := fmt.Sprintf("/api/suppliers/%s/token", testSupplier.ID)
+
+	var problem ErrorResponse
+	res := do(t, srv, http.MethodPost, path, nil, map[string]string{"Authorization": "Bearer wrong"}, &problem)
+	checkProblem(t, res, &problem, http.StatusUnauthorized, errUnauthorized.Code())
+}
+
+func TestDeleteReviewIfMatch(t *testing.T) {
+	srv, _ := newTestServer(t)
+	path := "/api/reviews/review-1"
+
+	var problem ErrorResponse
+	res := do(t, srv, http.MethodDelete, path, nil, adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusPreconditionRequired, "version_required")
+
+	headers := map[string]string{"Authorization": adminHeaders["Authorization"], "If-Match": `"1"`}
+	res = do(t, srv, http.MethodDelete, path, nil, headers, &problem)
+	checkProblem(t, res, &problem, http.StatusPreconditionFailed, service.ErrVersionConflict.Code())
+
+	res = do(t, srv, http.MethodDelete, path+"?version=1", nil, adminHeaders, &problem)
+	checkProblem(t, res, &problem, http.StatusConflict, service.ErrVersionConflict.Code())
+
+	headers["If-Match"] = `"2"`
+	if res := do(t, srv, http.MethodDelete, path, nil, headers, nil); res.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+}
+
+func TestModerationRequiresAdmin(t *testing.T) {
+	srv, _ := newTestServer(t)
+	product := createTestProduct(t, srv, "Phone X")
+	question := fmt.Sprintf("/api/products/%s/questions/question-1", product.ID)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/reviews"},
+		{http.MethodGet, "/api/reviews/review-1"},
+		{http.MethodDelete, "/api/reviews/review-1"},
+		{http.MethodPost, "/api/reviews/review-1/transitions"},
+		{http.MethodGet, "/api/questions"},
+		{http.MethodGet, question},
+		{http.MethodDelete, question},
+		{http.MethodPost, question + "/transitions"},
+		{http.MethodPost, question + "/answers"},
+		{http.MethodDelete, question + "/answers/answer-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			var problem ErrorResponse
+			res := do(t, srv, tt.method, tt.path, map[string]string{"body": "an answer"}, nil, &problem)
+			checkProblem(t, res, &problem, http.StatusUnauthorized, errUnauthorized.Code())
+		})
+	}
+}
+
+func TestWebhooksRequireAdmin(t *testing.T) {
+	srv, _ := newTestServer(t)
+	webhook := "/api/webhooks/" + uuid.NewString()
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/api/webhooks"},
+		{http.MethodGet, "/api/webhooks"},
+		{http.MethodGet, "/api/webhooks/event-types"},
+		{http.MethodGet, webhook},
+		{http.MethodPut, webhook},
+		{http.MethodDelete, webhook},
+		{http.MethodGet, webhook + "/deliveries"},
+		{http.MethodPost, webhook + "/deliveries/delivery-1/redeliver"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			var problem ErrorResponse
+			res := do(t, srv, tt.method, tt.path, map[string]string{"url": "http://169.254.169.254/"}, nil, &problem)
+			checkProblem(t, res, &problem, http.StatusUnauthorized, errUnauthorized.Code())
+		})
+	}
+}
+
+func TestCatalogWritesRequireAdmin(t *testing.T) {
+	srv, _ := newTestServer(t)
+	product := "/api/products/" + createTestProduct(t, srv, "Phone X").ID
+	brand := "/api/brands/" + testBrand.ID
+	ctgry := "/api/categories/" + testCategory.ID
+	spplr := "/api/suppliers/" + testSupplier.ID
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/api/brands"},
+		{http.MethodPost, "/api/brands/bulk"},
+		{http.MethodPut, brand},
+		{http.MethodPatch, brand},
+		{http.MethodDelete, brand},
+		{http.MethodPost, brand + "/restore"},
+		{http.MethodPut, brand + "/logo"},
+		{http.MethodDelete, brand + "/logo"},
+		{http.MethodPost, brand + "/transitions"},
+		{http.MethodPost, "/api/categories"},
+		{http.MethodPost, "/api/categories/bulk"},
+		{http.MethodPost, "/api/categories/reorder"},
+		{http.MethodPut, ctgry},
+		{http.MethodPatch, ctgry},
+		{http.MethodDelete, ctgry},
+		{http.MethodPost, ctgry + "/restore"},
+		{http.MethodPost, ctgry + "/move"},
+		{http.MethodPost, ctgry + "/transitions"},
+		{http.MethodPost, "/api/suppliers"},
+		{http.MethodPost, "/api/suppliers/bulk"},
+		{http.MethodPut, spplr},
+		{http.MethodPatch, spplr},
+		{http.MethodDelete, spplr},
+		{http.MethodPost, spplr + "/restore"},
+		{http.MethodPost, spplr + "/transitions"},
+		{http.MethodPost, "/api/products"},
+		{http.MethodPost, "/api/products/bulk"},
+		{http.MethodPut, product},
+		{http.MethodPatch, product},
+		{http.MethodDelete, product},
+		{http.MethodPost, product + "/restore"},
+		{http.MethodPost, product + "/transitions"},
+		{http.MethodPost, "/api/imports/products"},
+		{http.MethodPost, "/api/jobs/" + uuid.NewString() + "/requeue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			var problem ErrorResponse
+			res := do(t, srv, tt.method, tt.path, map[string]string{"status": service.ProductStatusActive}, nil, &problem)
+			checkProblem(t, res, &problem, http.StatusUnauthorized, errUnauthorized.Code())
+		})
+	}
+}
+
+func TestGraphQLMutationsRequireAdmin(t *testing.T) {
+	srv, fake := newTestServer(t)
+	product := createTestProduct(t, srv, "Phone X")
+
+	var res struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	query := map[string]string{"query": fmt.Sprintf(`mutation { deleteProduct(id: %q) }`, product.ID)}
+	do(t, srv, http.MethodPost, "/api/graphql", query, nil, &res)
+	if len(res.Errors) != 1 || res.Errors[0].Message != errUnauthorized.Error() {
+		t.Errorf("errors = %+v, want %s", res.Errors, errUnauthorized.Error())
+	}
+
+	if _, err := fake.GetProduct(context.Background(), product.ID); err != nil {
+		t.Errorf("the product is gone: %v", err)
+	}
+}
+
+func TestTransitionProduct(t *testing.T) {
+	srv, _ := newTestServer(t)
+	product := createTestProduct(t, srv, "Phone X")
+	path := "/api/products/" + product.ID + "/transitions"
+	headers := map[string]string{"Authorization": adminHeaders["Authorization"], "If-Match": `"1"`}
+
+	// a draft is reviewed before it goes live
+	var problem ErrorResponse
+	res := do(t, srv, http.MethodPost, path, map[string]string{"status": service.ProductStatusActive}, headers, &problem)
+	checkProblem(t, res, &problem, http.StatusConflict, "invalid_transition")
+
+	var refused service.TransitionError
+	if data, _ := json.Marshal(problem.Data); json.Unmarshal(data, &refused) != nil || len(refused.Allowed) == 0 {
+		t.Errorf("problem data = %v, want the allowed statuses", problem.Data)
+	}
+
+	var moved productResponse
+	res = do(t, srv, http.MethodPost, path, map[string]string{"status": service.ProductStatusPendingReview, "reason": "ready"}, headers, &moved)
+	if res.StatusCode != http.StatusOK || moved.Data.Status != service.ProductStatusPendingReview || moved.Data.Version != 2 {
+		t.Fatalf("transition = %d, %+v", res.StatusCode, moved.Data)
+	}
+
+	// the change was based on a version which is gone
+	res = do(t, srv, http.MethodPost, path, map[string]string{"status": service.ProductStatusActive}, headers, &problem)
+	checkProblem(t, res, &problem, http.StatusPreconditionFailed, "version_conflict")
+
+	var history struct {
+		Data service.TransitionResult `json:"data"`
+	}
+	res = do(t, srv, http.MethodGet, path+"?page=1&limit=10", nil, nil, &history)
+	if res.StatusCode != http.StatusOK || history.Data.Total != 1 {
+		t.Fatalf("transitions = %d, %+v", res.StatusCode, history.Data)
+	}
+
+	if got := history.Data.Transitions[0]; got.FromStatus != service.ProductStatusDraft || got.ToStatus != service.ProductStatusPendingReview || got.Reason != "ready" {
+		t.Errorf("transition = %+v, want draft to pending_review because ready", got)
+	}
+}
+
+func TestCustomerWritesRequireAdmin(t *testing.T) {
+	srv, _ := newTestServer(t)
+	product := createTestProduct(t, srv, "Phone X")
+	question := fmt.Sprintf("/api/products/%s/questions/question-1", product.ID)
+
+	// the customer and voter ids are trusted, so only the storefront sends them
+	body := map[string]interface{}{
+		"customer_id": "c-1",
+		"voter_id":    "c-1",
+		"author_name": "Rahim",
+		"rating":      5,
+		"body":        "does it float?",
+		"helpful":     true,
+	}
+
+	for _, path := range []string{
+		fmt.Sprintf("/api/products/%s/reviews", product.ID),
+		"/api/reviews/review-1/votes",
+		fmt.Sprintf("/api/products/%s/questions", product.ID),
+		question + "/upvotes",
+		question + "/answers/answer-1/upvotes",
+	} {
+		t.Run(path, func(t *testing.T) {
+			var problem ErrorResponse
+			res := do(t, srv, http.MethodPost, path, body, nil, &problem)
+			checkProblem(t, res, &problem, http.StatusUnauthorized, errUnauthorized.Code())
+		})
+	}
+}
+
+func TestHiddenProductsRequireAdmin(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, path := range []string{
+		"/api/products?limit=10&status=draft",
+		"/api/products?limit=10&include_deleted=true",
+		"/api/products/export?status=archived",
+		"/api/products/export?include_deleted=true",
+	} {
+		t.Run(path, func(t *testing.T) {
+			var problem ErrorResponse
+			res := do(t, srv, http.MethodGet, path, nil, nil, &problem)
+			checkProblem(t, res, &problem, http.StatusUnauthorized, errUnauthorized.Code())
+		})
+	}
+}
+
+func TestDeletedRecordsRequireAdmin(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, path := range []string{
+		"/api/brands?page=1&limit=10&include_deleted=true",
+		"/api/categories?page=1&limit=10&include_deleted=true",
+		"/api/suppliers?page=1&limit=10&include_deleted=true",
+	} {
+		t.Run(path, func(t *testing.T) {
+			var problem ErrorResponse
+			res := do(t, srv, http.MethodGet, path, nil, nil, &problem)
+			checkProblem(t, res, &problem, http.StatusUnauthorized, errUnauthorized.Code())
+		})
+	}
+
+	for _, list := range []string{"brands", "categories", "suppliers"} {
+		t.Run("graphql "+list, func(t *testing.T) {
+			var res struct {
+				Errors []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+			}
+
+			query := map[string]string{"query": fmt.Sprintf(`{ %s(includeDeleted: true) { total } }`, list)}
+			do(t, srv, http.MethodPost, "/api/graphql", query, nil, &res)
+			if len(res.Errors) != 1 || res.Errors[0].Message != errUnauthorized.Error() {
+				t.Errorf("errors = %+v, want %s", res.Errors, errUnauthorized.Error())
+			}
+		})
+	}
+}
+
+// uploadImport posts a file of the size as a product import, with the admin api key
+func uploadImport(t *testing.T, srv *httptest.Server, fileName string, size int, problem *ErrorResponse) *http.Response {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	file, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file.Write(bytes.Repeat([]byte("a"), size))
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/imports/products", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", adminHeaders["Authorization"])
+
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(problem); err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+
+	return res
+}
+
+func TestImportRefusesLargeFiles(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	var problem ErrorResponse
+	res := uploadImport(t, srv, "products.csv", maxImportBodySize+1, &problem)
+	checkProblem(t, res, &problem, http.StatusRequestEntityTooLarge, errImportFileTooLarge.Code())
+}
+
+func TestImportRefusesJSONL(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	// jsonl is written by exports but can not be read back
+	var problem ErrorResponse
+	res := uploadImport(t, srv, "products.jsonl", 10, &problem)
+	checkProblem(t, res, &problem, http.StatusBadRequest, "invalid_parameters")
+}
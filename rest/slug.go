@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// slugNotFound is the error of a slug no entity has or had
+var slugNotFound = map[string]error{
+	service.AuditEntityBrand:    service.ErrBrandNotFound,
+	service.AuditEntityCategory: service.ErrCategoryNotFound,
+	service.AuditEntityProduct:  service.ErrProductNotFound,
+}
+
+// slugParam lets the :id of the routes of a brand, category or product be its slug,
+// an old one included, by putting the id of the entity in its place
+func (server *Server) slugParam(entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i := range c.Params {
+			if c.Params[i].Key != "id" {
+				continue
+			}
+
+			if !server.resolveRefs(c, entity, &c.Params[i].Value) {
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// resolveRefs replaces the slugs given where ids of the entity are expected with the
+// ids. ids, empty and nil refs are left as they are. when a slug leads nowhere the
+// error is reported and false returned
+func (s *Server) resolveRefs(ctx *gin.Context, entity string, refs ...*string) bool {
+	for _, ref := range refs {
+		if ref == nil || len(*ref) == 0 {
+			continue
+		}
+
+		if _, err := uuid.Parse(*ref); err == nil {
+			continue
+		}
+
+		entityID, err := s.svc.ResolveSlug(ctx, entity, *ref)
+		if err != nil {
+			logger.Error(ctx, "cannot resolve slug", err)
+			ctx.Error(err)
+			return false
+		}
+
+		if len(entityID) == 0 {
+			logger.Error(ctx, "slug not found", *ref)
+			ctx.Error(slugNotFound[entity])
+			return false
+		}
+
+		*ref = entityID
+	}
+
+	return true
+}
+
+// resolveRefList is resolveRefs for a list of refs
+func (s *Server) resolveRefList(ctx *gin.Context, entity string, refs []string) bool {
+	for i := range refs {
+		if !s.resolveRefs(ctx, entity, &refs[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// @Summary Resolve a storefront path
+// @Description Find the category or product of a path of slugs such as /laptops/gaming, the category slugs from the root down, optionally followed by the slug of a product of the category. A path with an old slug or in other letter case comes back with redirect set and the canonical path to redirect to. A product the storefront does not show, a draft or archived one or one its schedule hides, is not found
+// @Tags Slugs
+// @Produce json
+// @Param path query string true "Storefront path, e.g. /laptops/gaming"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/resolve [get]
+func (s *Server) resolvePath(ctx *gin.Context) {
+	var req resolvePathReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	resolution, err := s.svc.ResolvePath(ctx, req.Path, util.GetCurrentTimestamp())
+	if err != nil {
+		logger.Error(ctx, "cannot resolve path", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", resolution)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully resolved", resolution))
+}
+
+func (r seoReq) toService() service.SEO {
+	return service.SEO{
+		Title:        r.Title,
+		Description:  r.Description,
+		CanonicalURL: r.CanonicalURL,
+	}
+}
+
+// toSEOPatch turns the seo member of a merge patch into the patch of the seo fields,
+// removing all of them when the member is null
+func toSEOPatch(r *patchSEOReq, cleared bool) service.SEOPatch {
+	if cleared {
+		empty := ""
+		return service.SEOPatch{Title: &empty, Description: &empty, CanonicalURL: &empty}
+	}
+
+	if r == nil {
+		return service.SEOPatch{}
+	}
+
+	return service.SEOPatch{
+		Title:        r.Title,
+		Description:  r.Description,
+		CanonicalURL: r.CanonicalURL,
+	}
+}
@@ -0,0 +1,379 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// @Summary Change the status of a brand
+// @Description Move a brand to active, inactive or archived; archiving it archives its products
+// @Tags Brands
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Brand ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body transitionReq true "New status and why"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/brands/{id}/transitions [post]
+func (s *Server) transitionBrand(ctx *gin.Context) {
+	var req transitionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	brandID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for brandID: %s", brandID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	brand, err := s.svc.TransitionBrand(ctx, brandID, req.Status, req.Reason, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot change status of stale brand", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot change status of brand", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, brand.Version)
+
+	logger.Info(ctx, "res payload", brand)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully changed status", brand))
+}
+
+// @Summary Get the status history of a brand
+// @Description Get a paginated list of the status changes of a brand, newest first, with who made them and why
+// @Tags Brands
+// @Accept json
+// @Produce json
+// @Param id path string true "Brand ID" format "uuid"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/brands/{id}/transitions [get]
+func (s *Server) getBrandTransitions(ctx *gin.Context) {
+	var req getTransitionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	brandID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for brandID: %s", brandID), req)
+
+	result, err := s.svc.GetTransitions(ctx, service.AuditEntityBrand, brandID, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get brand transitions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Change the status of a category
+// @Description Move a category to active, inactive or archived; deactivating or archiving it does the same to the categories underneath it and archiving it archives their products
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Category ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body transitionReq true "New status and why"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/{id}/transitions [post]
+func (s *Server) transitionCategory(ctx *gin.Context) {
+	var req transitionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	ctgryID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for ctgryID: %s", ctgryID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	ctgry, err := s.svc.TransitionCategory(ctx, ctgryID, req.Status, req.Reason, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot change status of stale category", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot change status of category", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, ctgry.Version)
+
+	logger.Info(ctx, "res payload", ctgry)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully changed status", ctgry))
+}
+
+// @Summary Get the status history of a category
+// @Description Get a paginated list of the status changes of a category, newest first, with who made them and why
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID" format "uuid"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/categories/{id}/transitions [get]
+func (s *Server) getCategoryTransitions(ctx *gin.Context) {
+	var req getTransitionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	ctgryID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for ctgryID: %s", ctgryID), req)
+
+	result, err := s.svc.GetTransitions(ctx, service.AuditEntityCategory, ctgryID, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get category transitions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Change the status of a supplier
+// @Description Move a supplier to active, inactive or archived; archiving it archives its products
+// @Tags Suppliers
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Supplier ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body transitionReq true "New status and why"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/suppliers/{id}/transitions [post]
+func (s *Server) transitionSupplier(ctx *gin.Context) {
+	var req transitionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	spplrID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for spplrID: %s", spplrID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	spplr, err := s.svc.TransitionSupplier(ctx, spplrID, req.Status, req.Reason, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot change status of stale supplier", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot change status of supplier", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, spplr.Version)
+
+	logger.Info(ctx, "res payload", spplr)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully changed status", spplr))
+}
+
+// @Summary Get the status history of a supplier
+// @Description Get a paginated list of the status changes of a supplier, newest first, with who made them and why
+// @Tags Suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID" format "uuid"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/suppliers/{id}/transitions [get]
+func (s *Server) getSupplierTransitions(ctx *gin.Context) {
+	var req getTransitionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	spplrID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for spplrID: %s", spplrID), req)
+
+	result, err := s.svc.GetTransitions(ctx, service.AuditEntitySupplier, spplrID, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get supplier transitions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Change the status of a product
+// @Description Move a product to another lifecycle state, if the state it is in allows going there; a product is activated only when its brand, category and supplier are active
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Product ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body productTransitionReq true "New status and why"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/transitions [post]
+func (s *Server) transitionProduct(ctx *gin.Context) {
+	var req productTransitionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for productID: %s", productID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	product, err := s.svc.TransitionProduct(ctx, productID, req.Status, req.Reason, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot change status of stale product", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot change status of product", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, product.Version)
+
+	logger.Info(ctx, "res payload", product)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully changed status", product))
+}
+
+// @Summary Get the status history of a product
+// @Description Get a paginated list of the status changes of a product, newest first, with who made them and why
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format "uuid"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/{id}/transitions [get]
+func (s *Server) getProductTransitions(ctx *gin.Context) {
+	var req getTransitionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for productID: %s", productID), req)
+
+	result, err := s.svc.GetTransitions(ctx, service.AuditEntityProduct, productID, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get product transitions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
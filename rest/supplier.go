@@ -1,11 +1,13 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/apperr"
 	"github.com/jsiqbal/ecommerce/logger"
 	"github.com/jsiqbal/ecommerce/service"
 	"github.com/jsiqbal/ecommerce/util"
@@ -16,16 +18,19 @@ import (
 // @Tags Suppliers
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param request body createSupplierReq true "Supplier details to create"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/suppliers [post]
 func (s *Server) createSupplier(ctx *gin.Context) {
 	var req createSupplierReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
@@ -34,7 +39,7 @@ func (s *Server) createSupplier(ctx *gin.Context) {
 	spplrID, err := uuid.NewUUID()
 	if err != nil {
 		logger.Error(ctx, "cannot create supplier id using uuid generator", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Supplier ID generation failed", "failed to generate supplier id"))
+		ctx.Error(err)
 		return
 	}
 
@@ -43,7 +48,7 @@ func (s *Server) createSupplier(ctx *gin.Context) {
 		Name:               req.Name,
 		Email:              req.Email,
 		Phone:              req.Phone,
-		StatusID:           req.StatusID,
+		Status:             req.Status,
 		IsVerifiedSupplier: req.IsVerifiedSupplier,
 		CreatedAt:          util.GetCurrentTimestamp(),
 	}
@@ -51,7 +56,7 @@ func (s *Server) createSupplier(ctx *gin.Context) {
 	newSpplr, err := s.svc.AddSupplier(ctx, spplr)
 	if err != nil {
 		logger.Error(ctx, "cannot add supplier", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
@@ -75,7 +80,7 @@ func (s *Server) getSupplier(ctx *gin.Context) {
 	var req getSupplierReq
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
@@ -84,10 +89,12 @@ func (s *Server) getSupplier(ctx *gin.Context) {
 	spplr, err := s.svc.GetSupplier(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot get supplier", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
+	setETag(ctx, spplr.Version)
+
 	logger.Info(ctx, "res payload", spplr)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", spplr))
@@ -100,24 +107,30 @@ func (s *Server) getSupplier(ctx *gin.Context) {
 // @Produce json
 // @Param page query int true "Page number" minimum 1
 // @Param limit query int true "Number of items per page" minimum 1 maximum 100
+// @Param include_deleted query bool false "Include soft deleted suppliers, with the admin api key"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/suppliers [get]
 func (s *Server) getSuppliers(ctx *gin.Context) {
 	var req getSuppliersReq
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	logger.Info(ctx, "req payload", req)
 
-	result, err := s.svc.GetSuppliers(ctx, req.Page, req.Limit)
+	if !s.allowDeleted(ctx, req.IncludeDeleted) {
+		return
+	}
+
+	result, err := s.svc.GetSuppliers(ctx, req.Page, req.Limit, req.IncludeDeleted)
 	if err != nil {
 		logger.Error(ctx, "cannot get suppliers", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
@@ -131,56 +144,135 @@ func (s *Server) getSuppliers(ctx *gin.Context) {
 // @Tags Suppliers
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param id path string true "Supplier ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param request body updateSupplierReq true "Supplier details to update"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/suppliers/{id} [put]
 func (s *Server) updateSupplier(ctx *gin.Context) {
 	var req updateSupplierReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
 	spplrID := ctx.Param("id")
 	if len(spplrID) == 0 {
 		logger.Error(ctx, "cannot pass validation", spplrID)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Invalid supplier ID", "Bad request"))
+		ctx.Error(apperr.Validation("invalid_parameters", "Invalid supplier ID"))
 		return
 	}
 
 	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s", spplrID), req)
 
-	spplr, err := s.svc.GetSupplier(ctx, spplrID)
-	if err != nil {
-		logger.Error(ctx, "cannot get supplier", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
 		return
 	}
 
-	if spplr == nil {
-		logger.Error(ctx, "supplier not found", nil)
-		ctx.JSON(http.StatusNotFound, s.svc.Response(ctx, "Supplier Not Found", "Not found"))
+	spplr, err := s.svc.GetSupplier(ctx, spplrID)
+	if err != nil {
+		logger.Error(ctx, "cannot get supplier", err)
+		ctx.Error(err)
 		return
 	}
 
 	spplr.Name = req.Name
 	spplr.Email = req.Email
 	spplr.Phone = req.Phone
-	spplr.StatusID = req.StatusID
+	spplr.Status = req.Status
 	spplr.IsVerifiedSupplier = req.IsVerifiedSupplier
 
+	spplr.Version = version
+
 	err = s.svc.UpdateSupplier(ctx, spplrID, spplr)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot update stale supplier", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
 	if err != nil {
 		logger.Error(ctx, "cannot update supplier", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+		ctx.Error(err)
 		return
 	}
 
+	spplr.Version++
+	setETag(ctx, spplr.Version)
+
+	logger.Info(ctx, "res payload", spplr)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", spplr))
+}
+
+// @Summary Partially update a supplier
+// @Description Update only the supplied fields of a supplier, following JSON Merge Patch semantics
+// @Tags Suppliers
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Supplier ID" format "uuid"
+// @Param If-Match header string false "ETag of the version the patch is based on"
+// @Param request body patchSupplierReq true "Supplier fields to update"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/suppliers/{id} [patch]
+func (s *Server) patchSupplier(ctx *gin.Context) {
+	var req patchSupplierReq
+	_, err := bindMergePatch(ctx, &req)
+	if err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	spplrID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for spplrID: %s", spplrID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	spplr, err := s.svc.PatchSupplier(ctx, spplrID, &service.SupplierPatch{
+		Name:               req.Name,
+		Email:              req.Email,
+		Phone:              req.Phone,
+		Status:             req.Status,
+		IsVerifiedSupplier: req.IsVerifiedSupplier,
+		Version:            version,
+	})
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot patch stale supplier", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot patch supplier", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, spplr.Version)
+
 	logger.Info(ctx, "res payload", spplr)
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", spplr))
@@ -191,17 +283,20 @@ func (s *Server) updateSupplier(ctx *gin.Context) {
 // @Tags Suppliers
 // @Accept json
 // @Produce json
+// @Security AdminAuth
 // @Param id path string true "Supplier ID" format "uuid"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/suppliers/{id} [delete]
 func (s *Server) deleteSupplier(ctx *gin.Context) {
 	var req deleteSupplierReq
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		logger.Error(ctx, "cannot pass validation", err)
-		ctx.JSON(http.StatusBadRequest, s.svc.Response(ctx, "Api parameter invalid", err))
+		ctx.Error(invalidParams(err))
 		return
 	}
 
@@ -210,20 +305,15 @@ func (s *Server) deleteSupplier(ctx *gin.Context) {
 	spplr, err := s.svc.GetSupplier(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot get supplier", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
-		return
-	}
-
-	if spplr == nil {
-		logger.Error(ctx, "supplier not found", nil)
-		ctx.JSON(http.StatusNotFound, s.svc.Response(ctx, "Supplier Not Found", "Not found"))
+		ctx.Error(err)
 		return
 	}
 
 	err = s.svc.DeleteSupplier(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "cannot delete supplier", err)
-		ctx.JSON(http.StatusInternalServerError, s.svc.Response(ctx, "Internal Server Error", err))
+
+		ctx.Error(err)
 		return
 	}
 
@@ -231,3 +321,83 @@ func (s *Server) deleteSupplier(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", spplr))
 }
+
+// @Summary Restore a deleted supplier
+// @Description Restore a soft deleted supplier based on the provided ID
+// @Tags Suppliers
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Supplier ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/suppliers/{id}/restore [post]
+func (s *Server) restoreSupplier(ctx *gin.Context) {
+	var req restoreSupplierReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	err := s.svc.RestoreSupplier(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot restore supplier", err)
+		ctx.Error(err)
+		return
+	}
+
+	spplr, err := s.svc.GetSupplier(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get supplier", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", spplr)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully restored", spplr))
+}
+
+// @Summary Issue a supplier portal token
+// @Description Issue the bearer token a supplier uses to authenticate against the supplier portal. Only the catalog team issues tokens, which expire after SUPPLIER_TOKEN_TTL, 24h by default
+// @Tags Suppliers
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Supplier ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/suppliers/{id}/token [post]
+func (s *Server) createSupplierToken(ctx *gin.Context) {
+	var req getSupplierReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	spplr, err := s.svc.GetSupplier(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get supplier", err)
+		ctx.Error(err)
+		return
+	}
+
+	// a token is refused once it expires, or as soon as its supplier is no longer active
+	expiresAt := util.GetCurrentTimestamp() + s.appCnf.SupplierTokenTTL.Milliseconds()
+	token := util.GenerateSupplierToken(s.appCnf.AuthSecret, spplr.ID, expiresAt)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully issued", gin.H{"token": token, "expires_at": expiresAt}))
+}
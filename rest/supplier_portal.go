@@ -0,0 +1,587 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+const defaultLowStockThreshold = 10
+
+// @Summary Get the supplier's own products
+// @Description Get a list of the authenticated supplier's products based on specified filters
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param name query string false "Product name filter"
+// @Param min_price query number false "Minimum price filter"
+// @Param max_price query number false "Maximum price filter"
+// @Param brand_ids query array false "Array of brand IDs filter"
+// @Param category_id query string false "Category ID filter"
+// @Param status query array false "Lifecycle states filter, all by default"
+// @Param page query integer false "Page number for pagination"
+// @Param limit query integer true "Number of items to return per page (maximum 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products [get]
+func (s *Server) getPortalProducts(ctx *gin.Context) {
+	var req getPortalProductsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s", supplierID), req)
+
+	if !s.resolveRefList(ctx, service.AuditEntityBrand, req.BrandIDs) ||
+		!s.resolveRefs(ctx, service.AuditEntityCategory, &req.CategoryID) {
+		return
+	}
+
+	result, err := s.portal.GetProducts(ctx, supplierID, service.FilterProductsParams{
+		Name:       req.Name,
+		MinPrice:   req.MinPrice,
+		MaxPrice:   req.MaxPrice,
+		BrandIDs:   req.BrandIDs,
+		CategoryID: req.CategoryID,
+		Statuses:   req.Statuses,
+		Page:       req.Page,
+		Limit:      req.Limit,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot filter products", err)
+		ctx.Error(err)
+		return
+	}
+
+	localizeProducts(ctx, productRefs(result.Products)...)
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched Products", result))
+}
+
+// @Summary Create a product for the supplier
+// @Description Create a new product owned by the authenticated supplier
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param request body createPortalProductReq true "Product details to create"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products [post]
+func (s *Server) createPortalProduct(ctx *gin.Context) {
+	var req createPortalProductReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s", supplierID), req)
+
+	if !s.resolveRefs(ctx, service.AuditEntityBrand, &req.BrandID) ||
+		!s.resolveRefs(ctx, service.AuditEntityCategory, &req.CategoryID) {
+		return
+	}
+
+	product := &service.Product{
+		Name:           req.Name,
+		Description:    req.Description,
+		Specifications: req.Specifications,
+		Translations:   toTranslations(req.Translations),
+		Brand: service.Brand{
+			ID: req.BrandID,
+		},
+		Category: service.Category{
+			ID: req.CategoryID,
+		},
+		ProductStock: service.ProductStock{
+			StockQuantity: req.StockQuantity,
+		},
+		UnitPrice:     req.UnitPrice,
+		DiscountPrice: req.DiscountPrice,
+		Tags:          req.Tags,
+		Status:        req.Status,
+		CreatedAt:     util.GetCurrentTimestamp(),
+	}
+
+	newProduct, err := s.portal.AddProduct(ctx, supplierID, product)
+	if err != nil {
+		logger.Error(ctx, "cannot add product", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", newProduct)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully created", newProduct))
+}
+
+// @Summary Get one of the supplier's products
+// @Description Get details of a product owned by the authenticated supplier
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param id path string true "Product ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products/{id} [get]
+func (s *Server) getPortalProduct(ctx *gin.Context) {
+	var req getProductReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s", supplierID), req)
+
+	product, err := s.portal.GetProduct(ctx, supplierID, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get product", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, product.Version)
+
+	localizeProducts(ctx, product)
+	ctx.Header("Content-Language", product.Locale)
+
+	logger.Info(ctx, "res payload", product)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", product))
+}
+
+// @Summary Update one of the supplier's products
+// @Description Update a product owned by the authenticated supplier
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param id path string true "Product ID to update"
+// @Param If-Match header string false "ETag of the version the update is based on"
+// @Param request body updatePortalProductReq true "Request body to update product"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products/{id} [put]
+func (s *Server) updatePortalProduct(ctx *gin.Context) {
+	var req updatePortalProductReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s, productID: %s", supplierID, productID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	if !s.resolveRefs(ctx, service.AuditEntityBrand, &req.BrandID) ||
+		!s.resolveRefs(ctx, service.AuditEntityCategory, &req.CategoryID) {
+		return
+	}
+
+	product := &service.Product{
+		Name:           req.Name,
+		Description:    req.Description,
+		Specifications: req.Specifications,
+		Translations:   toTranslations(req.Translations),
+		Brand: service.Brand{
+			ID: req.BrandID,
+		},
+		Category: service.Category{
+			ID: req.CategoryID,
+		},
+		UnitPrice:     req.UnitPrice,
+		DiscountPrice: req.DiscountPrice,
+		Tags:          req.Tags,
+		Version:       version,
+	}
+
+	err := s.portal.UpdateProduct(ctx, supplierID, productID, product)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot update stale product", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot update product", err)
+		ctx.Error(err)
+		return
+	}
+
+	updatedProduct, err := s.portal.GetProduct(ctx, supplierID, productID)
+	if err != nil {
+		logger.Error(ctx, "cannot get product", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, updatedProduct.Version)
+
+	logger.Info(ctx, "res payload", updatedProduct)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", updatedProduct))
+}
+
+// @Summary Archive one of the supplier's products
+// @Description Archive a product owned by the authenticated supplier so it is no longer listed
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param id path string true "Product ID to archive"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products/{id}/archive [post]
+func (s *Server) archivePortalProduct(ctx *gin.Context) {
+	var req getProductReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s", supplierID), req)
+
+	err := s.portal.ArchiveProduct(ctx, supplierID, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot archive product", err)
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully archived", req.ID))
+}
+
+// @Summary Change the status of one of the supplier's products
+// @Description Move a product owned by the authenticated supplier to another lifecycle state, e.g. submit a draft for review; approving it is up to the catalog team
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param request body productTransitionReq true "New status and why"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products/{id}/transitions [post]
+func (s *Server) transitionPortalProduct(ctx *gin.Context) {
+	var req productTransitionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	supplierID := ctx.GetString(supplierIDKey)
+	productID := ctx.Param("id")
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s, productID: %s", supplierID, productID), req)
+
+	version, fromHeader, ok := s.expectedVersion(ctx, req.Version)
+	if !ok {
+		return
+	}
+
+	product, err := s.portal.TransitionProduct(ctx, supplierID, productID, req.Status, req.Reason, version)
+	if errors.Is(err, service.ErrVersionConflict) {
+		logger.Error(ctx, "cannot change status of stale product", err)
+		ctx.Error(versionConflict(err, fromHeader))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot change status of product", err)
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, product.Version)
+
+	logger.Info(ctx, "res payload", product)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully changed status", product))
+}
+
+// @Summary Adjust the stock of one of the supplier's products
+// @Description Record a sale, restock, return or manual adjustment against a product owned by the authenticated supplier
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param id path string true "Product ID"
+// @Param request body adjustStockReq true "Stock movement"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products/{id}/stock [post]
+func (s *Server) adjustPortalProductStock(ctx *gin.Context) {
+	var req adjustStockReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s, productID: %s", supplierID, productID), req)
+
+	productStock, err := s.portal.AdjustStock(ctx, supplierID, &service.StockMovement{
+		ProductID: productID,
+		Quantity:  req.Quantity,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot adjust product stock", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", productStock)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully adjusted", productStock))
+}
+
+// @Summary Get the questions about one of the supplier's products
+// @Description Get a paginated list of the approved questions about a product owned by the authenticated supplier along with their answers, e.g. the unanswered ones
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param id path string true "Product ID"
+// @Param answered query bool false "Only the answered questions when true, the unanswered ones when false"
+// @Param sort query string false "Sort order" Enums(newest, upvotes)
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products/{id}/questions [get]
+func (s *Server) getPortalProductQuestions(ctx *gin.Context) {
+	var req getProductQuestionsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	productID := ctx.Param("id")
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s, productID: %s", supplierID, productID), req)
+
+	result, err := s.portal.GetQuestions(ctx, supplierID, productID, service.FilterQuestionsParams{
+		Answered: req.Answered,
+		Sort:     req.Sort,
+		Page:     req.Page,
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot get product questions", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", result))
+}
+
+// @Summary Answer a question about one of the supplier's products
+// @Description Answer an approved question about a product owned by the authenticated supplier, in the name of the supplier. Answering raises a QuestionAnswered event, so the customer who asked can be told
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param id path string true "Product ID"
+// @Param question_id path string true "Question ID" format "uuid"
+// @Param request body portalAnswerQuestionReq true "Answer"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/products/{id}/questions/{question_id}/answers [post]
+func (s *Server) answerPortalProductQuestion(ctx *gin.Context) {
+	var uriReq getQuestionReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req portalAnswerQuestionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s, productID: %s, questionID: %s", supplierID, uriReq.ID, uriReq.QuestionID), req)
+
+	answer, err := s.portal.AnswerQuestion(ctx, supplierID, uriReq.ID, &service.Answer{
+		QuestionID: uriReq.QuestionID,
+		Body:       req.Body,
+	})
+	if err != nil {
+		logger.Error(ctx, "cannot answer question", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", answer)
+
+	ctx.JSON(http.StatusCreated, s.svc.Response(ctx, "Successfully answered", answer))
+}
+
+// @Summary Get the supplier's sales report
+// @Description Get units sold and revenue per product of the authenticated supplier within a time range
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param from query integer false "Start of the range as a millisecond timestamp"
+// @Param to query integer false "End of the range as a millisecond timestamp"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/reports/sales [get]
+func (s *Server) getPortalSalesReport(ctx *gin.Context) {
+	var req getSalesReportReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s", supplierID), req)
+
+	result, err := s.portal.GetSalesReport(ctx, supplierID, req.From, req.To)
+	if err != nil {
+		logger.Error(ctx, "cannot get sales report", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched sales report", result))
+}
+
+// @Summary Get the supplier's low stock report
+// @Description Get the authenticated supplier's products whose stock is at or below the threshold
+// @Tags Supplier Portal
+// @Accept json
+// @Produce json
+// @Security SupplierAuth
+// @Param threshold query integer false "Stock quantity threshold (default 10)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplier-portal/reports/low-stock [get]
+func (s *Server) getPortalLowStockReport(ctx *gin.Context) {
+	var req getLowStockReportReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	threshold := int64(defaultLowStockThreshold)
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	}
+
+	supplierID := ctx.GetString(supplierIDKey)
+
+	logger.Info(ctx, fmt.Sprintf("req payload for supplierID: %s", supplierID), threshold)
+
+	result, err := s.portal.GetLowStockReport(ctx, supplierID, threshold)
+	if err != nil {
+		logger.Error(ctx, "cannot get low stock report", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched low stock report", result))
+}
@@ -1,13 +1,33 @@
 package rest
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
+	"github.com/jsiqbal/ecommerce/service"
 	"github.com/jsiqbal/ecommerce/util"
+	"golang.org/x/text/language"
 )
 
-var validStatusID validator.Func = func(fieldLevel validator.FieldLevel) bool {
-	if statudID, ok := fieldLevel.Field().Interface().(int); ok {
-		return util.IsSupportedStatusID(statudID)
+var validStatus validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if status, ok := fieldLevel.Field().Interface().(string); ok {
+		return service.IsStatus(status)
+	}
+	return false
+}
+
+var validProductStatus validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if status, ok := fieldLevel.Field().Interface().(string); ok {
+		return service.IsProductStatus(status)
+	}
+	return false
+}
+
+var validModeration validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if status, ok := fieldLevel.Field().Interface().(string); ok {
+		return service.IsModerationStatus(status)
 	}
 	return false
 }
@@ -18,3 +38,85 @@ var validPhone validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var validSlug validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if slug, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedSlug(slug)
+	}
+	return false
+}
+
+var validWebsite validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if website, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedWebsite(website)
+	}
+	return false
+}
+
+var validCountryCode validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if code, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedCountryCode(code)
+	}
+	return false
+}
+
+var validLocale validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if locale, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedLocale(locale)
+	}
+	return false
+}
+
+// fieldName names a field in the validation errors the way the request spells it
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+
+		if len(name) > 0 {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// fieldMessage tells why a field broke the rule of its binding tag, in the language
+func fieldMessage(fieldErr validator.FieldError, lang language.Tag) string {
+	messages := fieldMessages[lang]
+	tag := fieldErr.Tag()
+
+	// of an "empty or valid" rule such as eq=|validSlug, the second half is what was broken
+	if i := strings.LastIndexByte(tag, '|'); i >= 0 {
+		tag = tag[i+1:]
+	}
+
+	param := fieldErr.Param()
+	switch tag {
+	case "min", "max":
+		return fmt.Sprintf(messages[tag+sizeKey(fieldErr.Kind())], param)
+	case "oneof":
+		return fmt.Sprintf(messages[tag], strings.Join(strings.Fields(param), ", "))
+	}
+
+	if message, ok := messages[tag]; ok {
+		return message
+	}
+
+	return messages["invalid"]
+}
+
+// sizeKey tells apart the messages of min and max by what they count for a field of the
+// kind, its characters, its items or its value
+func sizeKey(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "_len"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "_items"
+	default:
+		return ""
+	}
+}
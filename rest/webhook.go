@@ -0,0 +1,323 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+)
+
+// @Summary Subscribe a webhook
+// @Description Subscribe a url to event types. Deliveries are signed with HMAC-SHA256 using the secret, which is generated when not given and only returned here
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param request body createWebhookReq true "Webhook subscription"
+// @Success 201 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/webhooks [post]
+func (s *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	// the secret is kept out of the logs
+	logger.Info(ctx, "req payload", gin.H{"url": req.URL, "event_types": req.EventTypes, "is_active": req.IsActive})
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	sub, err := s.svc.AddWebhookSubscription(ctx, &service.WebhookSubscription{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		IsActive:   isActive,
+	})
+	if errors.Is(err, service.ErrInvalidEventType) {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot create webhook subscription", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", sub.ID)
+
+	ctx.JSON(http.StatusCreated, s.svc.Response(ctx, "Webhook subscribed", sub))
+}
+
+// @Summary Get a list of webhook subscriptions
+// @Description Get a paginated list of webhook subscriptions, without their secrets
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/webhooks [get]
+func (s *Server) getWebhooks(ctx *gin.Context) {
+	var req getWebhooksReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	result, err := s.svc.GetWebhookSubscriptions(ctx, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get webhook subscriptions", err)
+		ctx.Error(err)
+		return
+	}
+
+	for i := range result.Subscriptions {
+		result.Subscriptions[i].Secret = ""
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched webhooks", result))
+}
+
+// @Summary Get the event types
+// @Description Get the event types webhooks can subscribe to
+// @Tags Webhooks
+// @Produce json
+// @Security AdminAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/webhooks/event-types [get]
+func (s *Server) getWebhookEventTypes(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched event types", service.EventTypes()))
+}
+
+// @Summary Get a webhook subscription
+// @Description Get a webhook subscription, without its secret
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Webhook ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/webhooks/{id} [get]
+func (s *Server) getWebhook(ctx *gin.Context) {
+	var req getWebhookReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	sub, err := s.svc.GetWebhookSubscription(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot get webhook subscription", err)
+		ctx.Error(err)
+		return
+	}
+
+	sub.Secret = ""
+
+	logger.Info(ctx, "res payload", sub)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully fetched", sub))
+}
+
+// @Summary Update a webhook subscription
+// @Description Replace the url, event types and state of a webhook subscription. The secret is only replaced when one is given
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Webhook ID" format "uuid"
+// @Param request body updateWebhookReq true "Webhook subscription"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/webhooks/{id} [put]
+func (s *Server) updateWebhook(ctx *gin.Context) {
+	var uriReq getWebhookReq
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	var req updateWebhookReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", gin.H{"id": uriReq.ID, "url": req.URL, "event_types": req.EventTypes, "is_active": req.IsActive})
+
+	sub, err := s.svc.UpdateWebhookSubscription(ctx, uriReq.ID, &service.WebhookSubscription{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		IsActive:   req.IsActive,
+	})
+	if errors.Is(err, service.ErrInvalidEventType) {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if err != nil {
+		logger.Error(ctx, "cannot update webhook subscription", err)
+		ctx.Error(err)
+		return
+	}
+
+	sub.Secret = ""
+
+	logger.Info(ctx, "res payload", sub)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully updated", sub))
+}
+
+// @Summary Delete a webhook subscription
+// @Description Delete a webhook subscription along with its delivery logs
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Webhook ID" format "uuid"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/webhooks/{id} [delete]
+func (s *Server) deleteWebhook(ctx *gin.Context) {
+	var req getWebhookReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	err := s.svc.DeleteWebhookSubscription(ctx, req.ID)
+	if err != nil {
+		logger.Error(ctx, "cannot delete webhook subscription", err)
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Successfully deleted", req.ID))
+}
+
+// @Summary Get the deliveries of a webhook subscription
+// @Description Get a paginated log of the deliveries of a webhook subscription, newest first, with the response code of every attempt
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Webhook ID" format "uuid"
+// @Param page query int true "Page number (starting from 1)"
+// @Param limit query int true "Number of items per page (min: 1, max: 100)"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/webhooks/{id}/deliveries [get]
+func (s *Server) getWebhookDeliveries(ctx *gin.Context) {
+	var req getWebhookDeliveriesReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	if _, err := s.svc.GetWebhookSubscription(ctx, req.ID); err != nil {
+		logger.Error(ctx, "cannot get webhook subscription", err)
+		ctx.Error(err)
+		return
+	}
+
+	result, err := s.svc.GetWebhookDeliveries(ctx, req.ID, req.Page, req.Limit)
+	if err != nil {
+		logger.Error(ctx, "cannot get webhook deliveries", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", result)
+
+	ctx.JSON(http.StatusOK, s.svc.Response(ctx, "Fetched deliveries", result))
+}
+
+// @Summary Redeliver a webhook delivery
+// @Description Send a delivery again with a fresh set of attempts, whatever the outcome of the previous ones
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param id path string true "Webhook ID" format "uuid"
+// @Param delivery_id path string true "Delivery ID" format "uuid"
+// @Success 202 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (s *Server) redeliverWebhook(ctx *gin.Context) {
+	var req redeliverWebhookReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.Error(ctx, "cannot pass validation", err)
+		ctx.Error(invalidParams(err))
+		return
+	}
+
+	logger.Info(ctx, "req payload", req)
+
+	delivery, err := s.svc.RedeliverWebhook(ctx, req.ID, req.DeliveryID)
+	if err != nil {
+		logger.Error(ctx, "cannot redeliver webhook", err)
+		ctx.Error(err)
+		return
+	}
+
+	logger.Info(ctx, "res payload", delivery)
+
+	ctx.JSON(http.StatusAccepted, s.svc.Response(ctx, "Redelivery queued", delivery))
+}
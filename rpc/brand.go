@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/jsiqbal/ecommerce/pb"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+type brandServer struct {
+	pb.UnimplementedBrandServiceServer
+	svc service.Service
+}
+
+func (s *brandServer) CreateBrand(ctx context.Context, req *pb.CreateBrandRequest) (*pb.Brand, error) {
+	brand := &service.Brand{
+		Name:      req.Name,
+		Status:    req.Status,
+		CreatedAt: util.GetCurrentTimestamp(),
+	}
+
+	if err := service.ValidateBrand(brand); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(s.svc.BulkBrands(ctx, service.BulkModeAtomic, []service.BulkBrandOp{{
+		Op:    service.BulkOpCreate,
+		Brand: brand,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbBrand(data.(*service.Brand)), nil
+}
+
+func (s *brandServer) GetBrand(ctx context.Context, req *pb.GetRequest) (*pb.Brand, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	brand, err := s.svc.GetBrand(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbBrand(brand), nil
+}
+
+func (s *brandServer) ListBrands(ctx context.Context, req *pb.ListRequest) (*pb.ListBrandsResponse, error) {
+	if err := validatePage(req.Page, req.Limit); err != nil {
+		return nil, err
+	}
+
+	result, err := s.svc.GetBrands(ctx, req.Page, req.Limit, req.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.ListBrandsResponse{
+		Total: result.Total,
+		Page:  result.Page,
+		Limit: result.Limit,
+	}
+
+	for i := range result.Brands {
+		res.Brands = append(res.Brands, toPbBrand(&result.Brands[i]))
+	}
+
+	return res, nil
+}
+
+func (s *brandServer) UpdateBrand(ctx context.Context, req *pb.UpdateBrandRequest) (*pb.Brand, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	brand := &service.Brand{
+		Name:    req.Name,
+		Status:  req.Status,
+		Version: req.Version,
+	}
+
+	if err := service.ValidateBrand(brand); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(s.svc.BulkBrands(ctx, service.BulkModeAtomic, []service.BulkBrandOp{{
+		Op:    service.BulkOpUpdate,
+		ID:    req.Id,
+		Brand: brand,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbBrand(data.(*service.Brand)), nil
+}
+
+func (s *brandServer) DeleteBrand(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	_, err := bulkOutcome(s.svc.BulkBrands(ctx, service.BulkModeAtomic, []service.BulkBrandOp{{
+		Op: service.BulkOpDelete,
+		ID: req.Id,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
+
+func (s *brandServer) RestoreBrand(ctx context.Context, req *pb.RestoreRequest) (*pb.Brand, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.svc.RestoreBrand(ctx, req.Id); err != nil {
+		return nil, err
+	}
+
+	return s.GetBrand(ctx, &pb.GetRequest{Id: req.Id})
+}
+
+func toPbBrand(brand *service.Brand) *pb.Brand {
+	return &pb.Brand{
+		Id:        brand.ID,
+		Name:      brand.Name,
+		Status:    brand.Status,
+		CreatedAt: brand.CreatedAt,
+		DeletedAt: brand.DeletedAt,
+		Version:   brand.Version,
+	}
+}
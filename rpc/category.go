@@ -0,0 +1,144 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/jsiqbal/ecommerce/pb"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+type categoryServer struct {
+	pb.UnimplementedCategoryServiceServer
+	svc service.Service
+}
+
+func (s *categoryServer) CreateCategory(ctx context.Context, req *pb.CreateCategoryRequest) (*pb.Category, error) {
+	ctgry := &service.Category{
+		Name:      req.Name,
+		ParentID:  req.ParentId,
+		Sequence:  req.Sequence,
+		Status:    req.Status,
+		CreatedAt: util.GetCurrentTimestamp(),
+	}
+
+	if err := service.ValidateCategory(ctgry); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(s.svc.BulkCategories(ctx, service.BulkModeAtomic, []service.BulkCategoryOp{{
+		Op:       service.BulkOpCreate,
+		Category: ctgry,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbCategory(data.(*service.Category)), nil
+}
+
+func (s *categoryServer) GetCategory(ctx context.Context, req *pb.GetRequest) (*pb.Category, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	ctgry, err := s.svc.GetCategory(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbCategory(ctgry), nil
+}
+
+func (s *categoryServer) ListCategories(ctx context.Context, req *pb.ListRequest) (*pb.ListCategoriesResponse, error) {
+	if err := validatePage(req.Page, req.Limit); err != nil {
+		return nil, err
+	}
+
+	result, err := s.svc.GetCategories(ctx, req.Page, req.Limit, req.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.ListCategoriesResponse{
+		Total: result.Total,
+		Page:  result.Page,
+		Limit: result.Limit,
+	}
+
+	for i := range result.Categories {
+		res.Categories = append(res.Categories, toPbCategory(&result.Categories[i]))
+	}
+
+	return res, nil
+}
+
+func (s *categoryServer) UpdateCategory(ctx context.Context, req *pb.UpdateCategoryRequest) (*pb.Category, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	ctgry := &service.Category{
+		Name:     req.Name,
+		ParentID: req.ParentId,
+		Sequence: req.Sequence,
+		Status:   req.Status,
+		Version:  req.Version,
+	}
+
+	if err := service.ValidateCategory(ctgry); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(s.svc.BulkCategories(ctx, service.BulkModeAtomic, []service.BulkCategoryOp{{
+		Op:       service.BulkOpUpdate,
+		ID:       req.Id,
+		Category: ctgry,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbCategory(data.(*service.Category)), nil
+}
+
+func (s *categoryServer) DeleteCategory(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	_, err := bulkOutcome(s.svc.BulkCategories(ctx, service.BulkModeAtomic, []service.BulkCategoryOp{{
+		Op: service.BulkOpDelete,
+		ID: req.Id,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
+
+func (s *categoryServer) RestoreCategory(ctx context.Context, req *pb.RestoreRequest) (*pb.Category, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.svc.RestoreCategory(ctx, req.Id); err != nil {
+		return nil, err
+	}
+
+	return s.GetCategory(ctx, &pb.GetRequest{Id: req.Id})
+}
+
+func toPbCategory(ctgry *service.Category) *pb.Category {
+	return &pb.Category{
+		Id:        ctgry.ID,
+		Name:      ctgry.Name,
+		ParentId:  ctgry.ParentID,
+		Sequence:  ctgry.Sequence,
+		Status:    ctgry.Status,
+		CreatedAt: ctgry.CreatedAt,
+		DeletedAt: ctgry.DeletedAt,
+		Version:   ctgry.Version,
+	}
+}
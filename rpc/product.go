@@ -0,0 +1,185 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/jsiqbal/ecommerce/pb"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+type productServer struct {
+	pb.UnimplementedProductServiceServer
+	svc service.Service
+}
+
+func (s *productServer) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
+	product := &service.Product{
+		Name:           req.Name,
+		Description:    req.Description,
+		Specifications: req.Specifications,
+		Brand:          service.Brand{ID: req.BrandId},
+		Category:       service.Category{ID: req.CategoryId},
+		Supplier:       service.Supplier{ID: req.SupplierId},
+		UnitPrice:      req.UnitPrice,
+		DiscountPrice:  req.DiscountPrice,
+		Tags:           req.Tags,
+		Status:         req.Status,
+		ProductStock: service.ProductStock{
+			StockQuantity: req.StockQuantity,
+		},
+		CreatedAt: util.GetCurrentTimestamp(),
+	}
+
+	if err := service.ValidateProduct(product); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(s.svc.BulkProducts(ctx, service.BulkModeAtomic, []service.BulkProductOp{{
+		Op:      service.BulkOpCreate,
+		Product: product,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbProduct(data.(*service.Product)), nil
+}
+
+func (s *productServer) GetProduct(ctx context.Context, req *pb.GetRequest) (*pb.Product, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	product, err := s.svc.GetProduct(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbProduct(product), nil
+}
+
+func (s *productServer) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	if err := validatePage(req.Page, req.Limit); err != nil {
+		return nil, err
+	}
+
+	result, err := s.svc.GetProducts(ctx, service.FilterProductsParams{
+		Name:               req.Name,
+		MinPrice:           req.MinPrice,
+		MaxPrice:           req.MaxPrice,
+		BrandIDs:           req.BrandIds,
+		CategoryID:         req.CategoryId,
+		SupplierID:         req.SupplierId,
+		IsVerifiedSupplier: req.IsVerifiedSupplier,
+		Statuses:           req.Statuses,
+		MinRating:          req.MinRating,
+		Sort:               req.Sort,
+		IncludeDeleted:     req.IncludeDeleted,
+		Page:               req.Page,
+		Limit:              req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.ListProductsResponse{
+		Total: result.Total,
+		Page:  result.Page,
+		Limit: result.Limit,
+	}
+
+	for i := range result.Products {
+		res.Products = append(res.Products, toPbProduct(&result.Products[i]))
+	}
+
+	return res, nil
+}
+
+func (s *productServer) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	product := &service.Product{
+		Name:           req.Name,
+		Description:    req.Description,
+		Specifications: req.Specifications,
+		Brand:          service.Brand{ID: req.BrandId},
+		Category:       service.Category{ID: req.CategoryId},
+		Supplier:       service.Supplier{ID: req.SupplierId},
+		UnitPrice:      req.UnitPrice,
+		DiscountPrice:  req.DiscountPrice,
+		Tags:           req.Tags,
+		Status:         req.Status,
+		ProductStock: service.ProductStock{
+			StockQuantity: req.StockQuantity,
+		},
+		Version: req.Version,
+	}
+
+	if err := service.ValidateProduct(product); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(s.svc.BulkProducts(ctx, service.BulkModeAtomic, []service.BulkProductOp{{
+		Op:      service.BulkOpUpdate,
+		ID:      req.Id,
+		Product: product,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbProduct(data.(*service.Product)), nil
+}
+
+func (s *productServer) DeleteProduct(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	_, err := bulkOutcome(s.svc.BulkProducts(ctx, service.BulkModeAtomic, []service.BulkProductOp{{
+		Op: service.BulkOpDelete,
+		ID: req.Id,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
+
+func (s *productServer) RestoreProduct(ctx context.Context, req *pb.RestoreRequest) (*pb.Product, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.svc.RestoreProduct(ctx, req.Id); err != nil {
+		return nil, err
+	}
+
+	return s.GetProduct(ctx, &pb.GetRequest{Id: req.Id})
+}
+
+func toPbProduct(product *service.Product) *pb.Product {
+	return &pb.Product{
+		Id:             product.ID,
+		Name:           product.Name,
+		Description:    product.Description,
+		Specifications: product.Specifications,
+		Brand:          toPbBrand(&product.Brand),
+		Category:       toPbCategory(&product.Category),
+		Supplier:       toPbSupplier(&product.Supplier),
+		UnitPrice:      product.UnitPrice,
+		DiscountPrice:  product.DiscountPrice,
+		Tags:           product.Tags,
+		Status:         product.Status,
+		StockQuantity:  product.ProductStock.StockQuantity,
+		RatingAverage:  product.RatingAverage,
+		RatingCount:    product.RatingCount,
+		CreatedAt:      product.CreatedAt,
+		DeletedAt:      product.DeletedAt,
+		Version:        product.Version,
+	}
+}
@@ -0,0 +1,191 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/pb"
+	"github.com/jsiqbal/ecommerce/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadata keys, the grpc counterparts of the X-Trace-ID and X-Actor headers of the rest api
+const (
+	traceIDKey = "x-trace-id"
+	actorKey   = "x-actor"
+)
+
+// maxLimit caps the page size of the lists, as on the rest api
+const maxLimit = 100
+
+type Server struct {
+	grpcServer *grpc.Server
+}
+
+func NewServer(svc service.Service) *Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			traceInterceptor,
+			actorInterceptor,
+			logInterceptor,
+		),
+	)
+
+	pb.RegisterBrandServiceServer(grpcServer, &brandServer{svc: svc})
+	pb.RegisterCategoryServiceServer(grpcServer, &categoryServer{svc: svc})
+	pb.RegisterSupplierServiceServer(grpcServer, &supplierServer{svc: svc})
+	pb.RegisterProductServiceServer(grpcServer, &productServer{svc: svc})
+
+	return &Server{
+		grpcServer: grpcServer,
+	}
+}
+
+func (server *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return server.grpcServer.Serve(listener)
+}
+
+// Stop stops taking calls and returns once the running ones are finished
+func (server *Server) Stop() {
+	server.grpcServer.GracefulStop()
+}
+
+// traceInterceptor carries the trace id of the caller, or a new one, into the
+// context the logger reads it from and sends it back in the response header
+func traceInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx = logger.WithTraceID(ctx, firstMetadata(ctx, traceIDKey))
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(traceIDKey, logger.GetTraceID(ctx))); err != nil {
+		logger.Warn(ctx, "cannot set trace id header", err)
+	}
+
+	return handler(ctx, req)
+}
+
+// actorInterceptor records who is making the call for the audit log
+func actorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if actor := firstMetadata(ctx, actorKey); len(actor) > 0 {
+		ctx = service.WithActor(ctx, actor)
+	}
+
+	return handler(ctx, req)
+}
+
+// logInterceptor logs every call and maps the errors of the service to status codes
+func logInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	logger.Info(ctx, "rpc "+info.FullMethod, req)
+
+	res, err := handler(ctx, req)
+	if err != nil {
+		logger.Error(ctx, "rpc "+info.FullMethod+" failed", err)
+		return nil, toStatus(err)
+	}
+
+	return res, nil
+}
+
+// toStatus maps an error of the service to the status code a client can act on
+func toStatus(err error) error {
+	// already a status, e.g. from a handler
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var conflictErr *service.ConflictError
+
+	switch apperr.KindOf(err) {
+	case apperr.KindValidation:
+		return status.Error(codes.InvalidArgument, apperr.MessageOf(err))
+	case apperr.KindUnauthorized:
+		return status.Error(codes.Unauthenticated, apperr.MessageOf(err))
+	case apperr.KindForbidden:
+		return status.Error(codes.PermissionDenied, apperr.MessageOf(err))
+	case apperr.KindNotFound:
+		return status.Error(codes.NotFound, apperr.MessageOf(err))
+	case apperr.KindConflict:
+		switch {
+		case errors.As(err, &conflictErr):
+			return status.Error(codes.AlreadyExists, apperr.MessageOf(err))
+		case errors.Is(err, service.ErrVersionConflict):
+			return status.Error(codes.Aborted, apperr.MessageOf(err))
+		default:
+			// the state of other records is in the way, such as a deleted parent
+			return status.Error(codes.FailedPrecondition, apperr.MessageOf(err))
+		}
+	case apperr.KindUnprocessable:
+		// e.g. a reference to a record which does not exist, not the record asked for
+		return status.Error(codes.FailedPrecondition, apperr.MessageOf(err))
+	case apperr.KindUnavailable:
+		return status.Error(codes.Unavailable, apperr.MessageOf(err))
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func validatePage(page, limit int64) error {
+	if page < 1 {
+		return &service.ValidationError{Field: "page", Message: "must be at least 1"}
+	}
+
+	if limit < 1 || limit > maxLimit {
+		return &service.ValidationError{Field: "limit", Message: "must be between 1 and 100"}
+	}
+
+	return nil
+}
+
+func validateID(id string) error {
+	if len(id) == 0 {
+		return &service.ValidationError{Field: "id", Message: "is required"}
+	}
+
+	return nil
+}
+
+// bulkOutcome returns the data of the single operation of a bulk request, or why it failed.
+// the writes run as such a request, so they go through the same checks of references
+// and names as the bulk endpoints
+func bulkOutcome(result *service.BulkResult, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	item := result.Items[0]
+	if item.Status != service.BulkStatusSucceeded {
+		return nil, item.Err
+	}
+
+	return item.Data, nil
+}
@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/jsiqbal/ecommerce/pb"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+type supplierServer struct {
+	pb.UnimplementedSupplierServiceServer
+	svc service.Service
+}
+
+func (s *supplierServer) CreateSupplier(ctx context.Context, req *pb.CreateSupplierRequest) (*pb.Supplier, error) {
+	spplr := &service.Supplier{
+		Name:               req.Name,
+		Email:              req.Email,
+		Phone:              req.Phone,
+		Status:             req.Status,
+		IsVerifiedSupplier: req.IsVerifiedSupplier,
+		CreatedAt:          util.GetCurrentTimestamp(),
+	}
+
+	if err := service.ValidateSupplier(spplr); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(s.svc.BulkSuppliers(ctx, service.BulkModeAtomic, []service.BulkSupplierOp{{
+		Op:       service.BulkOpCreate,
+		Supplier: spplr,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbSupplier(data.(*service.Supplier)), nil
+}
+
+func (s *supplierServer) GetSupplier(ctx context.Context, req *pb.GetRequest) (*pb.Supplier, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	spplr, err := s.svc.GetSupplier(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbSupplier(spplr), nil
+}
+
+func (s *supplierServer) ListSuppliers(ctx context.Context, req *pb.ListRequest) (*pb.ListSuppliersResponse, error) {
+	if err := validatePage(req.Page, req.Limit); err != nil {
+		return nil, err
+	}
+
+	result, err := s.svc.GetSuppliers(ctx, req.Page, req.Limit, req.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.ListSuppliersResponse{
+		Total: result.Total,
+		Page:  result.Page,
+		Limit: result.Limit,
+	}
+
+	for i := range result.Suppliers {
+		res.Suppliers = append(res.Suppliers, toPbSupplier(&result.Suppliers[i]))
+	}
+
+	return res, nil
+}
+
+func (s *supplierServer) UpdateSupplier(ctx context.Context, req *pb.UpdateSupplierRequest) (*pb.Supplier, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	spplr := &service.Supplier{
+		Name:               req.Name,
+		Email:              req.Email,
+		Phone:              req.Phone,
+		Status:             req.Status,
+		IsVerifiedSupplier: req.IsVerifiedSupplier,
+		Version:            req.Version,
+	}
+
+	if err := service.ValidateSupplier(spplr); err != nil {
+		return nil, err
+	}
+
+	data, err := bulkOutcome(s.svc.BulkSuppliers(ctx, service.BulkModeAtomic, []service.BulkSupplierOp{{
+		Op:       service.BulkOpUpdate,
+		ID:       req.Id,
+		Supplier: spplr,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbSupplier(data.(*service.Supplier)), nil
+}
+
+func (s *supplierServer) DeleteSupplier(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	_, err := bulkOutcome(s.svc.BulkSuppliers(ctx, service.BulkModeAtomic, []service.BulkSupplierOp{{
+		Op: service.BulkOpDelete,
+		ID: req.Id,
+	}}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
+
+func (s *supplierServer) RestoreSupplier(ctx context.Context, req *pb.RestoreRequest) (*pb.Supplier, error) {
+	if err := validateID(req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.svc.RestoreSupplier(ctx, req.Id); err != nil {
+		return nil, err
+	}
+
+	return s.GetSupplier(ctx, &pb.GetRequest{Id: req.Id})
+}
+
+func toPbSupplier(spplr *service.Supplier) *pb.Supplier {
+	return &pb.Supplier{
+		Id:                 spplr.ID,
+		Name:               spplr.Name,
+		Email:              spplr.Email,
+		Phone:              spplr.Phone,
+		Status:             spplr.Status,
+		IsVerifiedSupplier: spplr.IsVerifiedSupplier,
+		CreatedAt:          spplr.CreatedAt,
+		DeletedAt:          spplr.DeletedAt,
+		Version:            spplr.Version,
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// audited entity types
+const (
+	AuditEntityBrand    = "brand"
+	AuditEntityCategory = "category"
+	AuditEntitySupplier = "supplier"
+	AuditEntityProduct  = "product"
+	AuditEntityStock    = "stock"
+	AuditEntityReview   = "review"
+	AuditEntityQuestion = "question"
+	AuditEntityAnswer   = "answer"
+)
+
+// audited actions
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+)
+
+const anonymousActor = "anonymous"
+
+type AuditEntry struct {
+	ID         string          `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Action     string          `json:"action"`
+	Actor      string          `json:"actor"`
+	TraceID    string          `json:"trace_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Changes    json.RawMessage `json:"changes"`
+	CreatedAt  int64           `json:"created_at"`
+}
+
+type FilterAuditParams struct {
+	EntityType string `json:"entity_type"`
+	EntityID   string `json:"entity_id"`
+	Actor      string `json:"actor"`
+	From       int64  `json:"from"`
+	To         int64  `json:"to"`
+	Page       int64  `json:"page"`
+	Limit      int64  `json:"limit"`
+}
+
+type AuditResult struct {
+	Entries []AuditEntry `json:"entries"`
+	Total   int64        `json:"total"`
+	Page    int64        `json:"page"`
+	Limit   int64        `json:"limit"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor responsible for the changes made with it
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// GetActor returns the actor carried by ctx
+func GetActor(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || len(actor) == 0 {
+		return anonymousActor
+	}
+
+	return actor
+}
+
+func (s *service) GetAuditLogs(ctx context.Context, filterParams FilterAuditParams) (*AuditResult, error) {
+	if filterParams.To == 0 {
+		filterParams.To = MAX_INF
+	}
+
+	result, err := s.auditRepo.GetItems(ctx, filterParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// audit records a change of an entity and the domain events it raises. it must be
+// called with the ctx of the transaction making the change so the entry and the
+// events are only kept if the change is
+func (s *service) audit(ctx context.Context, entityType, entityID, action string, before, after interface{}) error {
+	beforeJSON, err := marshalAuditState(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := marshalAuditState(after)
+	if err != nil {
+		return err
+	}
+
+	changes, err := diffAuditStates(beforeJSON, afterJSON)
+	if err != nil {
+		return err
+	}
+
+	entry := &AuditEntry{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      GetActor(ctx),
+		TraceID:    logger.GetTraceID(ctx),
+		Before:     beforeJSON,
+		After:      afterJSON,
+		Changes:    changes,
+		CreatedAt:  util.GetCurrentTimestamp(),
+	}
+
+	err = s.auditRepo.Add(ctx, entry)
+	if err != nil {
+		logger.Error(ctx, "cannot record audit entry", err)
+		return err
+	}
+
+	return s.raiseEvents(ctx, entityType, entityID, action, before, after)
+}
+
+func marshalAuditState(state interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(data) == "null" {
+		return nil, nil
+	}
+
+	return data, nil
+}
+
+// diffAuditStates returns the top level fields that differ between both states
+// as {"field": {"before": ..., "after": ...}}
+func diffAuditStates(before, after json.RawMessage) (json.RawMessage, error) {
+	beforeFields := make(map[string]interface{})
+	if before != nil {
+		if err := json.Unmarshal(before, &beforeFields); err != nil {
+			return nil, err
+		}
+	}
+
+	afterFields := make(map[string]interface{})
+	if after != nil {
+		if err := json.Unmarshal(after, &afterFields); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make(map[string]map[string]interface{})
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			changes[field] = map[string]interface{}{"before": value, "after": afterFields[field]}
+		}
+	}
+
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = map[string]interface{}{"before": nil, "after": value}
+		}
+	}
+
+	return json.Marshal(changes)
+}
@@ -1,10 +1,33 @@
 package service
 
 type Brand struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	StatusID  int    `json:"status_id"`
-	CreatedAt int64  `json:"created_at"`
+	ID              string       `json:"id"`
+	Name            string       `json:"name"`
+	Slug            string       `json:"slug"`
+	SEO             SEO          `json:"seo"`
+	Description     string       `json:"description"`
+	Translations    Translations `json:"translations"`
+	LogoURL         string       `json:"logo_url"`
+	LogoKey         string       `json:"-"`
+	Website         string       `json:"website"`
+	CountryOfOrigin string       `json:"country_of_origin"`
+	Status          string       `json:"status"`
+	CreatedAt       int64        `json:"created_at"`
+	DeletedAt       int64        `json:"deleted_at,omitempty"`
+	Version         int64        `json:"version"`
+}
+
+// BrandPatch is a partial update of a brand, nil fields are left untouched
+type BrandPatch struct {
+	Name            *string
+	Slug            *string
+	SEO             SEOPatch
+	Description     *string
+	Translations    *Translations
+	Website         *string
+	CountryOfOrigin *string
+	Status          *string
+	Version         int64
 }
 
 type BrandResult struct {
@@ -13,3 +36,19 @@ type BrandResult struct {
 	Page   int64   `json:"page"`
 	Limit  int64   `json:"limit"`
 }
+
+// BrandCategoryCount is the number of live products of a brand in a category
+type BrandCategoryCount struct {
+	CategoryID   string `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	CategorySlug string `json:"category_slug"`
+	ProductCount int64  `json:"product_count"`
+}
+
+// BrandPage is what a storefront shows on the page of a brand, its products per
+// category and the best selling ones
+type BrandPage struct {
+	Brand          Brand                `json:"brand"`
+	CategoryCounts []BrandCategoryCount `json:"category_counts"`
+	TopProducts    []Product            `json:"top_products"`
+}
@@ -0,0 +1,569 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// bulk operations
+const (
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+)
+
+// bulk modes
+const (
+	// BulkModeAtomic runs every operation in one transaction, nothing is kept unless all of them succeed
+	BulkModeAtomic = "atomic"
+	// BulkModeBestEffort runs every operation on its own, keeping the ones that succeed
+	BulkModeBestEffort = "best_effort"
+)
+
+// bulk item statuses
+const (
+	BulkStatusSucceeded = "succeeded"
+	BulkStatusFailed    = "failed"
+	// BulkStatusSkipped marks an operation of an atomic request that was not kept because another one failed
+	BulkStatusSkipped = "skipped"
+)
+
+type BulkBrandOp struct {
+	Op    string
+	ID    string
+	Brand *Brand
+}
+
+type BulkCategoryOp struct {
+	Op       string
+	ID       string
+	Category *Category
+}
+
+type BulkSupplierOp struct {
+	Op       string
+	ID       string
+	Supplier *Supplier
+}
+
+type BulkProductOp struct {
+	Op      string
+	ID      string
+	Product *Product
+}
+
+type BulkItemResult struct {
+	Index  int         `json:"index"`
+	Op     string      `json:"op"`
+	ID     string      `json:"id,omitempty"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+	// Err is the error behind Error, for callers mapping it to a status of their own
+	Err error `json:"-"`
+}
+
+type BulkResult struct {
+	Mode      string           `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Skipped   int              `json:"skipped"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// bulkItem is one operation of a bulk request, validated before any operation runs
+type bulkItem struct {
+	op  string
+	id  string
+	err error
+	run func(ctx context.Context) (interface{}, error)
+}
+
+func (s *service) BulkBrands(ctx context.Context, mode string, ops []BulkBrandOp) (*BulkResult, error) {
+	refs := newBulkRefs(s)
+
+	items := make([]bulkItem, len(ops))
+	for i, op := range ops {
+		op := op
+		items[i] = bulkItem{op: op.Op, id: op.ID}
+
+		switch op.Op {
+		case BulkOpCreate:
+			if op.Brand == nil {
+				items[i].err = ErrMissingBulkData
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				return s.AddBrand(ctx, op.Brand)
+			}
+		case BulkOpUpdate:
+			if items[i].err = requireBulkTarget(op.Op, op.ID, op.Brand != nil); items[i].err != nil {
+				continue
+			}
+
+			if op.Brand.Version <= 0 {
+				items[i].err = ErrMissingBulkVersion
+				continue
+			}
+
+			if _, items[i].err = refs.brand(ctx, op.ID); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				if err := s.UpdateBrand(ctx, op.ID, op.Brand); err != nil {
+					return nil, err
+				}
+
+				return s.GetBrand(ctx, op.ID)
+			}
+		case BulkOpDelete:
+			if items[i].err = requireBulkTarget(op.Op, op.ID, true); items[i].err != nil {
+				continue
+			}
+
+			if _, items[i].err = refs.brand(ctx, op.ID); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				return nil, s.DeleteBrand(ctx, op.ID)
+			}
+		default:
+			items[i].err = ErrInvalidBulkOp
+		}
+	}
+
+	return s.runBulk(ctx, mode, items)
+}
+
+func (s *service) BulkCategories(ctx context.Context, mode string, ops []BulkCategoryOp) (*BulkResult, error) {
+	refs := newBulkRefs(s)
+
+	items := make([]bulkItem, len(ops))
+	for i, op := range ops {
+		op := op
+		items[i] = bulkItem{op: op.Op, id: op.ID}
+
+		switch op.Op {
+		case BulkOpCreate:
+			if op.Category == nil {
+				items[i].err = ErrMissingBulkData
+				continue
+			}
+
+			if len(op.Category.ParentID) > 0 {
+				if _, items[i].err = refs.category(ctx, op.Category.ParentID); items[i].err != nil {
+					continue
+				}
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				return s.AddCategory(ctx, op.Category)
+			}
+		case BulkOpUpdate:
+			if items[i].err = requireBulkTarget(op.Op, op.ID, op.Category != nil); items[i].err != nil {
+				continue
+			}
+
+			if op.Category.Version <= 0 {
+				items[i].err = ErrMissingBulkVersion
+				continue
+			}
+
+			if _, items[i].err = refs.category(ctx, op.ID); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				if err := s.UpdateCategory(ctx, op.ID, op.Category); err != nil {
+					return nil, err
+				}
+
+				return s.GetCategory(ctx, op.ID)
+			}
+		case BulkOpDelete:
+			if items[i].err = requireBulkTarget(op.Op, op.ID, true); items[i].err != nil {
+				continue
+			}
+
+			if _, items[i].err = refs.category(ctx, op.ID); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				return nil, s.DeleteCategory(ctx, op.ID)
+			}
+		default:
+			items[i].err = ErrInvalidBulkOp
+		}
+	}
+
+	return s.runBulk(ctx, mode, items)
+}
+
+func (s *service) BulkSuppliers(ctx context.Context, mode string, ops []BulkSupplierOp) (*BulkResult, error) {
+	refs := newBulkRefs(s)
+
+	items := make([]bulkItem, len(ops))
+	for i, op := range ops {
+		op := op
+		items[i] = bulkItem{op: op.Op, id: op.ID}
+
+		switch op.Op {
+		case BulkOpCreate:
+			if op.Supplier == nil {
+				items[i].err = ErrMissingBulkData
+				continue
+			}
+
+			items[i].id = op.Supplier.ID
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				return s.AddSupplier(ctx, op.Supplier)
+			}
+		case BulkOpUpdate:
+			if items[i].err = requireBulkTarget(op.Op, op.ID, op.Supplier != nil); items[i].err != nil {
+				continue
+			}
+
+			if op.Supplier.Version <= 0 {
+				items[i].err = ErrMissingBulkVersion
+				continue
+			}
+
+			if _, items[i].err = refs.supplier(ctx, op.ID); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				if err := s.UpdateSupplier(ctx, op.ID, op.Supplier); err != nil {
+					return nil, err
+				}
+
+				return s.GetSupplier(ctx, op.ID)
+			}
+		case BulkOpDelete:
+			if items[i].err = requireBulkTarget(op.Op, op.ID, true); items[i].err != nil {
+				continue
+			}
+
+			if _, items[i].err = refs.supplier(ctx, op.ID); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				return nil, s.DeleteSupplier(ctx, op.ID)
+			}
+		default:
+			items[i].err = ErrInvalidBulkOp
+		}
+	}
+
+	return s.runBulk(ctx, mode, items)
+}
+
+func (s *service) BulkProducts(ctx context.Context, mode string, ops []BulkProductOp) (*BulkResult, error) {
+	refs := newBulkRefs(s)
+
+	// supplier wise product names claimed by earlier operations of the request
+	claimedNames := make(map[string]string)
+
+	items := make([]bulkItem, len(ops))
+	for i, op := range ops {
+		op := op
+		items[i] = bulkItem{op: op.Op, id: op.ID}
+
+		switch op.Op {
+		case BulkOpCreate:
+			if op.Product == nil {
+				items[i].err = ErrMissingBulkData
+				continue
+			}
+
+			if items[i].err = s.validateBulkProduct(ctx, refs, claimedNames, "", op.Product); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				return s.AddProduct(ctx, op.Product)
+			}
+		case BulkOpUpdate:
+			if items[i].err = requireBulkTarget(op.Op, op.ID, op.Product != nil); items[i].err != nil {
+				continue
+			}
+
+			if op.Product.Version <= 0 {
+				items[i].err = ErrMissingBulkVersion
+				continue
+			}
+
+			if _, items[i].err = refs.product(ctx, op.ID); items[i].err != nil {
+				continue
+			}
+
+			if items[i].err = s.validateBulkProduct(ctx, refs, claimedNames, op.ID, op.Product); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				if err := s.UpdateProduct(ctx, op.ID, op.Product); err != nil {
+					return nil, err
+				}
+
+				return s.GetProduct(ctx, op.ID)
+			}
+		case BulkOpDelete:
+			if items[i].err = requireBulkTarget(op.Op, op.ID, true); items[i].err != nil {
+				continue
+			}
+
+			if _, items[i].err = refs.product(ctx, op.ID); items[i].err != nil {
+				continue
+			}
+
+			items[i].run = func(ctx context.Context) (interface{}, error) {
+				return nil, s.DeleteProduct(ctx, op.ID)
+			}
+		default:
+			items[i].err = ErrInvalidBulkOp
+		}
+	}
+
+	return s.runBulk(ctx, mode, items)
+}
+
+// validateBulkProduct checks the brand, category and supplier of a product exist
+// and that its name is unique for the supplier, both in the catalog and in the request
+func (s *service) validateBulkProduct(ctx context.Context, refs *bulkRefs, claimedNames map[string]string, productID string, product *Product) error {
+	brand, err := refs.brand(ctx, product.Brand.ID)
+	if err != nil {
+		return err
+	}
+
+	ctgry, err := refs.category(ctx, product.Category.ID)
+	if err != nil {
+		return err
+	}
+
+	spplr, err := refs.supplier(ctx, product.Supplier.ID)
+	if err != nil {
+		return err
+	}
+
+	nameKey := spplr.ID + "/" + product.Name
+	if claimedBy, ok := claimedNames[nameKey]; ok && (claimedBy != productID || productID == "") {
+		return NewConflictError(AuditEntityProduct, "name", ErrDuplicateProduct)
+	}
+
+	existProduct, err := s.productRepo.GetItemByName(ctx, spplr.ID, product.Name)
+	if err == nil && existProduct.ID != productID {
+		return NewConflictError(AuditEntityProduct, "name", ErrDuplicateProduct)
+	} else if err != nil && !errors.Is(err, ErrProductNotFound) {
+		return err
+	}
+
+	claimedNames[nameKey] = productID
+	product.Brand = *brand
+	product.Category = *ctgry
+	product.Supplier = *spplr
+
+	return nil
+}
+
+// runBulk runs the validated operations in the given mode and reports the outcome of each
+func (s *service) runBulk(ctx context.Context, mode string, items []bulkItem) (*BulkResult, error) {
+	result := &BulkResult{
+		Mode:  mode,
+		Items: make([]BulkItemResult, len(items)),
+	}
+
+	for i, item := range items {
+		result.Items[i] = BulkItemResult{
+			Index: i,
+			Op:    item.op,
+			ID:    item.id,
+		}
+	}
+
+	aborted := false
+
+	switch mode {
+	case BulkModeBestEffort:
+		for i := range items {
+			if items[i].err == nil {
+				result.Items[i].Data, items[i].err = items[i].run(ctx)
+			}
+		}
+	case BulkModeAtomic:
+		for _, item := range items {
+			if item.err != nil {
+				aborted = true
+			}
+		}
+
+		if aborted {
+			break
+		}
+
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			for i := range items {
+				data, err := items[i].run(ctx)
+				if err != nil {
+					items[i].err = err
+					return err
+				}
+
+				result.Items[i].Data = data
+			}
+
+			return nil
+		})
+		if err != nil {
+			aborted = true
+
+			// the transaction itself failed, not one of the operations
+			failedItem := false
+			for _, item := range items {
+				if item.err != nil {
+					failedItem = true
+				}
+			}
+
+			if !failedItem {
+				return nil, err
+			}
+		}
+	default:
+		return nil, ErrInvalidBulkMode
+	}
+
+	for i, item := range items {
+		switch {
+		case item.err != nil:
+			result.Items[i].Status = BulkStatusFailed
+			result.Items[i].Error = item.err.Error()
+			result.Items[i].Err = item.err
+			result.Failed++
+		case aborted:
+			result.Items[i].Status = BulkStatusSkipped
+			result.Items[i].Data = nil
+			result.Skipped++
+		default:
+			result.Items[i].Status = BulkStatusSucceeded
+			result.Succeeded++
+		}
+	}
+
+	return result, nil
+}
+
+func requireBulkTarget(op, id string, hasData bool) error {
+	if len(id) == 0 {
+		return ErrMissingBulkID
+	}
+
+	if op == BulkOpUpdate && !hasData {
+		return ErrMissingBulkData
+	}
+
+	return nil
+}
+
+// bulkRefs looks up the records referenced by a bulk request once
+type bulkRefs struct {
+	svc        *service
+	brands     map[string]*Brand
+	categories map[string]*Category
+	suppliers  map[string]*Supplier
+	products   map[string]*Product
+	// ids of the records referenced by name, keyed by kind and lowercase name
+	names map[string]string
+}
+
+func newBulkRefs(svc *service) *bulkRefs {
+	return &bulkRefs{
+		svc:        svc,
+		brands:     make(map[string]*Brand),
+		categories: make(map[string]*Category),
+		suppliers:  make(map[string]*Supplier),
+		products:   make(map[string]*Product),
+		names:      make(map[string]string),
+	}
+}
+
+func (r *bulkRefs) brand(ctx context.Context, brandID string) (*Brand, error) {
+	brand, ok := r.brands[brandID]
+	if !ok {
+		var err error
+		brand, err = r.svc.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil && !errors.Is(err, ErrBrandNotFound) {
+			return nil, err
+		}
+
+		r.brands[brandID] = brand
+	}
+
+	if brand == nil {
+		return nil, ErrBrandNotFound
+	}
+
+	return brand, nil
+}
+
+func (r *bulkRefs) category(ctx context.Context, ctgryID string) (*Category, error) {
+	ctgry, ok := r.categories[ctgryID]
+	if !ok {
+		var err error
+		ctgry, err = r.svc.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil && !errors.Is(err, ErrCategoryNotFound) {
+			return nil, err
+		}
+
+		r.categories[ctgryID] = ctgry
+	}
+
+	if ctgry == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	return ctgry, nil
+}
+
+func (r *bulkRefs) supplier(ctx context.Context, spplrID string) (*Supplier, error) {
+	spplr, ok := r.suppliers[spplrID]
+	if !ok {
+		var err error
+		spplr, err = r.svc.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil && !errors.Is(err, ErrSupplierNotFound) {
+			return nil, err
+		}
+
+		r.suppliers[spplrID] = spplr
+	}
+
+	if spplr == nil {
+		return nil, ErrSupplierNotFound
+	}
+
+	return spplr, nil
+}
+
+func (r *bulkRefs) product(ctx context.Context, productID string) (*Product, error) {
+	product, ok := r.products[productID]
+	if !ok {
+		var err error
+		product, err = r.svc.productRepo.GetItemByID(ctx, productID)
+		if err != nil && !errors.Is(err, ErrProductNotFound) {
+			return nil, err
+		}
+
+		r.products[productID] = product
+	}
+
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+
+	return product, nil
+}
@@ -1,12 +1,34 @@
 package service
 
 type Category struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	ParentID  string `json:"parent_id"`
-	Sequence  string `json:"sequence"`
-	StatusID  int    `json:"status_id"`
-	CreatedAt int64  `json:"created_at"`
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Slug         string       `json:"slug"`
+	SEO          SEO          `json:"seo"`
+	Translations Translations `json:"translations"`
+	ParentID     string       `json:"parent_id"`
+	Sequence     string       `json:"sequence"`
+	Status       string       `json:"status"`
+	CreatedAt    int64        `json:"created_at"`
+	DeletedAt    int64        `json:"deleted_at,omitempty"`
+	Version      int64        `json:"version"`
+}
+
+// CategoryPatch is a partial update of a category, nil fields are left untouched
+type CategoryPatch struct {
+	Name         *string
+	Slug         *string
+	SEO          SEOPatch
+	Translations *Translations
+	Sequence     *string
+	Status       *string
+	Version      int64
+}
+
+// CategoryNode is a category of a category tree with the categories underneath it
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
 }
 
 type CategoryResult struct {
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// MaxCategoryDepth is how many levels a category tree may have, the root categories being the first
+const MaxCategoryDepth = 8
+
+// categoryWalkLimit bounds the recursive queries, in case the parents of older data form a cycle
+const categoryWalkLimit = 64
+
+// GetCategoryTree returns every category nested under its parent, siblings in sequence order
+func (s *service) GetCategoryTree(ctx context.Context) ([]*CategoryNode, error) {
+	ctgries, err := s.ctgryRepo.GetSubtree(ctx, "", categoryWalkLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCategoryTree(ctgries), nil
+}
+
+// GetCategorySubtree returns the category with its descendants down to depth levels below
+// it, all of them when depth is not positive. nil when there is no such category
+func (s *service) GetCategorySubtree(ctx context.Context, ctgryID string, depth int) (*CategoryNode, error) {
+	if depth <= 0 || depth > categoryWalkLimit {
+		depth = categoryWalkLimit
+	}
+
+	ctgries, err := s.ctgryRepo.GetSubtree(ctx, ctgryID, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := buildCategoryTree(ctgries)
+	if len(roots) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+
+	return roots[0], nil
+}
+
+// GetCategoryAncestors returns the path from the root down to the parent of the category
+func (s *service) GetCategoryAncestors(ctx context.Context, ctgryID string) ([]Category, error) {
+	if _, err := s.ctgryRepo.GetItemByID(ctx, ctgryID); err != nil {
+		return nil, err
+	}
+
+	return s.ctgryRepo.GetAncestors(ctx, ctgryID, categoryWalkLimit)
+}
+
+// MoveCategory puts the category, along with its descendants, under a new parent or
+// at the root when parentID is empty. it goes after the siblings it finds there
+func (s *service) MoveCategory(ctx context.Context, ctgryID, parentID string, version int64) (*Category, error) {
+	var moved *Category
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.checkCategoryPlacement(ctx, ctgryID, parentID); err != nil {
+			return err
+		}
+
+		affected, err := s.ctgryRepo.MoveItemByID(ctx, ctgryID, parentID, version)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the version the move was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		moved, err = s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityCategory, ctgryID, AuditActionUpdate, before, moved)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return moved, nil
+}
+
+// ReorderCategories numbers the children of the parent, or the root categories when
+// parentID is empty, in the order of ctgryIDs and returns them in that order
+func (s *service) ReorderCategories(ctx context.Context, parentID string, ctgryIDs []string) ([]Category, error) {
+	var reordered []Category
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if len(parentID) > 0 {
+			_, err := s.ctgryRepo.GetItemByID(ctx, parentID)
+			if errors.Is(err, ErrCategoryNotFound) {
+				return ErrParentCategoryNotFound
+			} else if err != nil {
+				return err
+			}
+		}
+
+		children, err := s.ctgryRepo.GetItemsByParentID(ctx, parentID)
+		if err != nil {
+			return err
+		}
+
+		if len(children) != len(ctgryIDs) {
+			return ErrInvalidCategoryOrder
+		}
+
+		before := make(map[string]Category, len(children))
+		for _, child := range children {
+			before[child.ID] = child
+		}
+
+		listed := make(map[string]bool, len(ctgryIDs))
+		for _, id := range ctgryIDs {
+			if _, ok := before[id]; !ok || listed[id] {
+				return ErrInvalidCategoryOrder
+			}
+
+			listed[id] = true
+		}
+
+		if _, err := s.ctgryRepo.SetSequences(ctx, parentID, ctgryIDs); err != nil {
+			return err
+		}
+
+		reordered, err = s.ctgryRepo.GetItemsByParentID(ctx, parentID)
+		if err != nil {
+			return err
+		}
+
+		// only the categories which got a new sequence changed
+		for i := range reordered {
+			after := reordered[i]
+			if after.Version == before[after.ID].Version {
+				continue
+			}
+
+			if err := s.audit(ctx, AuditEntityCategory, after.ID, AuditActionUpdate, before[after.ID], after); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reordered, nil
+}
+
+// checkCategoryPlacement checks that the category, or a new one when ctgryID is empty,
+// can go under the parent: the parent exists and is active, is not the category or one
+// of its descendants, and the tree does not get deeper than MaxCategoryDepth. it locks
+// the tree for the transaction of ctx, which must make the change
+func (s *service) checkCategoryPlacement(ctx context.Context, ctgryID, parentID string) error {
+	// two moves checked at once could each make a cycle with the other
+	if err := s.ctgryRepo.LockTree(ctx); err != nil {
+		return err
+	}
+
+	levels := 1
+
+	if len(parentID) > 0 {
+		if parentID == ctgryID {
+			return ErrCategoryCycle
+		}
+
+		parent, err := s.ctgryRepo.GetItemByID(ctx, parentID)
+		if errors.Is(err, ErrCategoryNotFound) {
+			return ErrParentCategoryNotFound
+		} else if err != nil {
+			return err
+		}
+
+		// the categories under an inactive or archived one went along with it
+		if parent.Status != StatusActive {
+			return ErrParentInactive
+		}
+
+		ancestors, err := s.ctgryRepo.GetAncestors(ctx, parentID, categoryWalkLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, ancestor := range ancestors {
+			if ancestor.ID == ctgryID {
+				return ErrCategoryCycle
+			}
+		}
+
+		// the ancestors and the parent are above the category
+		levels += len(ancestors) + 1
+	}
+
+	if len(ctgryID) > 0 {
+		height, err := s.ctgryRepo.GetSubtreeHeight(ctx, ctgryID, categoryWalkLimit)
+		if err != nil {
+			return err
+		}
+
+		levels += height
+	}
+
+	if levels > MaxCategoryDepth {
+		return ErrCategoryTooDeep
+	}
+
+	return nil
+}
+
+// buildCategoryTree nests the categories under their parents. the categories come parents
+// first, those whose parent is not among them are the roots
+func buildCategoryTree(ctgries []Category) []*CategoryNode {
+	roots := []*CategoryNode{}
+	nodes := make(map[string]*CategoryNode, len(ctgries))
+
+	for _, ctgry := range ctgries {
+		node := &CategoryNode{
+			Category: ctgry,
+			Children: []*CategoryNode{},
+		}
+		nodes[ctgry.ID] = node
+
+		if parent, ok := nodes[ctgry.ParentID]; ok && len(ctgry.ParentID) > 0 {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+
+		roots = append(roots, node)
+	}
+
+	return roots
+}
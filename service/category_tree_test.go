@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// memoryCategories keeps a category tree in memory, counting the locks taken on it
+type memoryCategories struct {
+	CategoryRepo
+	ctgries map[string]Category
+	locks   int
+}
+
+func newMemoryCategories(ctgries ...Category) *memoryCategories {
+	m := &memoryCategories{ctgries: make(map[string]Category)}
+	for _, ctgry := range ctgries {
+		m.ctgries[ctgry.ID] = ctgry
+	}
+
+	return m
+}
+
+func (m *memoryCategories) LockTree(ctx context.Context) error {
+	m.locks++
+	return nil
+}
+
+func (m *memoryCategories) GetItemByID(ctx context.Context, ctgryID string) (*Category, error) {
+	ctgry, ok := m.ctgries[ctgryID]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+
+	return &ctgry, nil
+}
+
+func (m *memoryCategories) GetAncestors(ctx context.Context, ctgryID string, maxDepth int) ([]Category, error) {
+	var ancestors []Category
+	for parentID := m.ctgries[ctgryID].ParentID; len(parentID) > 0; parentID = m.ctgries[parentID].ParentID {
+		ancestors = append([]Category{m.ctgries[parentID]}, ancestors...)
+	}
+
+	return ancestors, nil
+}
+
+func (m *memoryCategories) GetSubtreeHeight(ctx context.Context, ctgryID string, maxDepth int) (int, error) {
+	height := 0
+	for _, ctgry := range m.ctgries {
+		if ctgry.ParentID != ctgryID {
+			continue
+		}
+
+		below, _ := m.GetSubtreeHeight(ctx, ctgry.ID, maxDepth)
+		if below+1 > height {
+			height = below + 1
+		}
+	}
+
+	return height, nil
+}
+
+func TestCheckCategoryPlacement(t *testing.T) {
+	ctgries := newMemoryCategories(
+		Category{ID: "electronics", Status: StatusActive},
+		Category{ID: "phones", ParentID: "electronics", Status: StatusActive},
+		Category{ID: "cases", ParentID: "phones", Status: StatusActive},
+		Category{ID: "seasonal", Status: StatusInactive},
+		Category{ID: "retired", Status: StatusArchived},
+	)
+	svc := &service{ctgryRepo: ctgries}
+
+	tests := []struct {
+		name     string
+		ctgryID  string
+		parentID string
+		want     error
+	}{
+		{"to the root", "phones", "", nil},
+		{"under an active parent", "cases", "electronics", nil},
+		{"a new category", "", "phones", nil},
+		{"under itself", "phones", "phones", ErrCategoryCycle},
+		{"under a descendant", "electronics", "cases", ErrCategoryCycle},
+		{"under a missing parent", "phones", "garden", ErrParentCategoryNotFound},
+		{"under an inactive parent", "phones", "seasonal", ErrParentInactive},
+		{"under an archived parent", "phones", "retired", ErrParentInactive},
+		{"a new category under an inactive parent", "", "seasonal", ErrParentInactive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locks := ctgries.locks
+
+			if err := svc.checkCategoryPlacement(context.Background(), tt.ctgryID, tt.parentID); !errors.Is(err, tt.want) {
+				t.Errorf("checkCategoryPlacement = %v, want %v", err, tt.want)
+			}
+
+			if ctgries.locks != locks+1 {
+				t.Errorf("the tree was locked %d times, want once", ctgries.locks-locks)
+			}
+		})
+	}
+}
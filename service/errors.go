@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsiqbal/ecommerce/apperr"
+)
+
+var (
+	ErrProductNotFound         = apperr.NotFound("product_not_found", "product not found")
+	ErrBrandNotFound           = apperr.NotFound("brand_not_found", "brand not found")
+	ErrCategoryNotFound        = apperr.NotFound("category_not_found", "category not found")
+	ErrAmbiguousCategory       = apperr.Unprocessable("ambiguous_category", "several categories have the name, reference the category by its id or slug")
+	ErrParentCategoryNotFound  = apperr.NotFound("parent_category_not_found", "parent category not found")
+	ErrCategoryCycle           = apperr.Unprocessable("category_cycle", "a category can not be moved under itself or its descendants")
+	ErrCategoryTooDeep         = apperr.Unprocessable("category_too_deep", "the category tree would be nested too deep")
+	ErrInvalidCategoryOrder    = apperr.Unprocessable("invalid_category_order", "the order must list every child of the parent once")
+	ErrSupplierNotFound        = apperr.NotFound("supplier_not_found", "supplier not found")
+	ErrInsufficientStock       = apperr.Conflict("insufficient_stock", "insufficient stock")
+	ErrDuplicateProduct        = apperr.Conflict("duplicate_product", "product name already exists for this supplier")
+	ErrInvalidStockReason      = apperr.Validation("invalid_stock_reason", "invalid stock movement reason")
+	ErrInvalidStockQuantity    = apperr.Validation("invalid_stock_quantity", "invalid stock movement quantity")
+	ErrParentDeleted           = apperr.Conflict("parent_deleted", "a record it belongs to is deleted, restore that first")
+	ErrParentInactive          = apperr.Unprocessable("parent_inactive", "a record it belongs to is not active, activate that first")
+	ErrApprovalRequired        = apperr.Forbidden("approval_required", "only the catalog team can approve a product")
+	ErrVersionConflict         = apperr.Conflict("version_conflict", "the record was modified by someone else, reload it and retry")
+	ErrInvalidBulkMode         = apperr.Validation("invalid_bulk_mode", "invalid bulk mode")
+	ErrInvalidBulkOp           = apperr.Validation("invalid_bulk_op", "invalid bulk operation")
+	ErrMissingBulkID           = apperr.Validation("missing_bulk_id", "id is required for update and delete")
+	ErrMissingBulkData         = apperr.Validation("missing_bulk_data", "data is required for create and update")
+	ErrMissingBulkVersion      = apperr.Validation("missing_bulk_version", "version is required for update")
+	ErrImportJobNotFound       = apperr.NotFound("import_job_not_found", "import job not found")
+	ErrInvalidExportColumn     = apperr.Validation("invalid_export_column", "invalid export column")
+	ErrJobNotFound             = apperr.NotFound("job_not_found", "job not found")
+	ErrJobAbandoned            = apperr.Unavailable("job_abandoned", "the worker running the last attempt stopped before it finished")
+	ErrJobNotDead              = apperr.Conflict("job_not_dead", "only dead jobs can be requeued")
+	ErrInvalidEventType        = apperr.Validation("invalid_event_type", "invalid event type")
+	ErrWebhookNotFound         = apperr.NotFound("webhook_not_found", "webhook subscription not found")
+	ErrWebhookDeliveryNotFound = apperr.NotFound("webhook_delivery_not_found", "webhook delivery not found")
+	ErrWebhookInactive         = apperr.Conflict("webhook_inactive", "webhook subscription is inactive")
+	ErrWebhookURLNotAllowed    = apperr.Validation("webhook_url_not_allowed", "a webhook url must be http or https and resolve to public addresses only")
+	ErrInvalidSlug             = apperr.Validation("invalid_slug", "a slug is lowercase letters and digits in words joined by hyphens")
+	ErrPathNotFound            = apperr.NotFound("path_not_found", "path not found")
+	ErrSlugTaken               = apperr.Conflict("slug_taken", "the slug is taken")
+	ErrDuplicateBrand          = apperr.Conflict("duplicate_brand", "brand name already exists")
+	ErrDuplicateCategory       = apperr.Conflict("duplicate_category", "category name already exists under this parent")
+	ErrDuplicateSupplierEmail  = apperr.Conflict("duplicate_supplier_email", "supplier email already exists")
+	ErrUnsupportedLogo         = apperr.Validation("unsupported_logo", "a logo must be a png, jpeg, webp or gif image")
+	ErrSupplierInactive        = apperr.Forbidden("supplier_inactive", "the supplier is inactive")
+	ErrReviewNotFound          = apperr.NotFound("review_not_found", "review not found")
+	ErrDuplicateReview         = apperr.Conflict("duplicate_review", "the customer reviewed this product already")
+	ErrReviewNotApproved       = apperr.Conflict("review_not_approved", "only approved reviews can be voted on")
+	ErrOwnReviewVote           = apperr.Forbidden("own_review_vote", "a customer can not vote on their own review")
+	ErrQuestionNotFound        = apperr.NotFound("question_not_found", "question not found")
+	ErrQuestionNotApproved     = apperr.Conflict("question_not_approved", "only approved questions can be answered and upvoted")
+	ErrAnonymousStaffAnswer    = apperr.Forbidden("anonymous_staff_answer", "only a known member of the catalog team answers on its behalf")
+	ErrAnswerNotFound          = apperr.NotFound("answer_not_found", "answer not found")
+	ErrDatabaseUnavailable     = apperr.Unavailable("database_unavailable", "the database is unavailable, retry later")
+)
+
+// DependencyError reports that a record cannot be deleted while other records still reference it
+type DependencyError struct {
+	Entity     string           `json:"entity"`
+	Dependents map[string]int64 `json:"dependents"`
+}
+
+func (e *DependencyError) Error() string {
+	var names []string
+	for name := range e.Dependents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%d %s", e.Dependents[name], name))
+	}
+
+	return fmt.Sprintf("%s is still referenced by %s", e.Entity, strings.Join(parts, ", "))
+}
+
+func (e *DependencyError) Kind() apperr.Kind {
+	return apperr.KindConflict
+}
+
+func (e *DependencyError) Code() string {
+	return "still_referenced"
+}
+
+// Details are the dependents, for the caller to deal with them first
+func (e *DependencyError) Details() interface{} {
+	return e
+}
+
+// ConflictError reports that a record clashes with another on a field which must be
+// unique, Err being the error of the clash such as ErrDuplicateBrand
+type ConflictError struct {
+	Entity  string `json:"entity"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+func NewConflictError(entity, field string, err error) *ConflictError {
+	return &ConflictError{Entity: entity, Field: field, Message: err.Error(), Err: err}
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConflictError) Kind() apperr.Kind {
+	return apperr.KindConflict
+}
+
+func (e *ConflictError) Code() string {
+	return apperr.CodeOf(e.Err)
+}
+
+func (e *ConflictError) Fields() []apperr.FieldError {
+	return []apperr.FieldError{{Field: e.Field, Message: e.Message}}
+}
+
+// ReferenceError reports that a field of a record refers to a record which does not
+// exist, Err being the error of the missing record such as ErrBrandNotFound
+type ReferenceError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+func NewReferenceError(field string, err error) *ReferenceError {
+	return &ReferenceError{Field: field, Message: err.Error(), Err: err}
+}
+
+func (e *ReferenceError) Error() string {
+	return e.Message
+}
+
+func (e *ReferenceError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ReferenceError) Kind() apperr.Kind {
+	return apperr.KindUnprocessable
+}
+
+func (e *ReferenceError) Code() string {
+	return "invalid_reference"
+}
+
+func (e *ReferenceError) Fields() []apperr.FieldError {
+	return []apperr.FieldError{{Field: e.Field, Message: e.Message}}
+}
+
+// TransitionError reports that a record can not go from its status to another, Allowed
+// being the statuses it may go to
+type TransitionError struct {
+	Entity  string   `json:"entity"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Allowed []string `json:"allowed"`
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.From) == 0 {
+		return fmt.Sprintf("a new %s can not be %s", e.Entity, e.To)
+	}
+
+	return fmt.Sprintf("a %s can not go from %s to %s", e.Entity, e.From, e.To)
+}
+
+// Kind is a conflict, the status stored being the one the record can not leave that way
+func (e *TransitionError) Kind() apperr.Kind {
+	return apperr.KindConflict
+}
+
+func (e *TransitionError) Code() string {
+	return "invalid_transition"
+}
+
+func (e *TransitionError) Fields() []apperr.FieldError {
+	return []apperr.FieldError{{Field: "status", Message: e.Error()}}
+}
+
+// Details tell the statuses the record may go to instead
+func (e *TransitionError) Details() interface{} {
+	return e
+}
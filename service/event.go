@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// domain event types
+const (
+	EventBrandCreated       = "BrandCreated"
+	EventBrandUpdated       = "BrandUpdated"
+	EventBrandDeleted       = "BrandDeleted"
+	EventBrandRestored      = "BrandRestored"
+	EventCategoryCreated    = "CategoryCreated"
+	EventCategoryUpdated    = "CategoryUpdated"
+	EventCategoryDeleted    = "CategoryDeleted"
+	EventCategoryRestored   = "CategoryRestored"
+	EventSupplierCreated    = "SupplierCreated"
+	EventSupplierUpdated    = "SupplierUpdated"
+	EventSupplierDeleted    = "SupplierDeleted"
+	EventSupplierRestored   = "SupplierRestored"
+	EventProductCreated     = "ProductCreated"
+	EventProductUpdated     = "ProductUpdated"
+	EventProductDeleted     = "ProductDeleted"
+	EventProductRestored    = "ProductRestored"
+	EventPriceChanged       = "PriceChanged"
+	EventStockAdjusted      = "StockAdjusted"
+	EventStatusChanged      = "StatusChanged"
+	EventProductPublished   = "ProductPublished"
+	EventProductUnpublished = "ProductUnpublished"
+	EventReviewCreated      = "ReviewCreated"
+	EventReviewUpdated      = "ReviewUpdated"
+	EventReviewDeleted      = "ReviewDeleted"
+	EventQuestionCreated    = "QuestionCreated"
+	EventQuestionUpdated    = "QuestionUpdated"
+	EventQuestionDeleted    = "QuestionDeleted"
+	EventQuestionAnswered   = "QuestionAnswered"
+	EventAnswerDeleted      = "AnswerDeleted"
+)
+
+// eventSchemaVersions holds the version of the data of every event type. bump
+// the version of a type whenever its data changes in a way consumers could break on
+var eventSchemaVersions = map[string]int{
+	EventBrandCreated:       1,
+	EventBrandUpdated:       1,
+	EventBrandDeleted:       1,
+	EventBrandRestored:      1,
+	EventCategoryCreated:    1,
+	EventCategoryUpdated:    1,
+	EventCategoryDeleted:    1,
+	EventCategoryRestored:   1,
+	EventSupplierCreated:    1,
+	EventSupplierUpdated:    1,
+	EventSupplierDeleted:    1,
+	EventSupplierRestored:   1,
+	EventProductCreated:     1,
+	EventProductUpdated:     1,
+	EventProductDeleted:     1,
+	EventProductRestored:    1,
+	EventPriceChanged:       1,
+	EventStockAdjusted:      1,
+	EventStatusChanged:      1,
+	EventProductPublished:   1,
+	EventProductUnpublished: 1,
+	EventReviewCreated:      1,
+	EventReviewUpdated:      1,
+	EventReviewDeleted:      1,
+	EventQuestionCreated:    1,
+	EventQuestionUpdated:    1,
+	EventQuestionDeleted:    1,
+	EventQuestionAnswered:   1,
+	EventAnswerDeleted:      1,
+}
+
+// changeEvents maps the audited changes of an entity to the event they raise
+var changeEvents = map[string]map[string]string{
+	AuditEntityBrand: {
+		AuditActionCreate:  EventBrandCreated,
+		AuditActionUpdate:  EventBrandUpdated,
+		AuditActionDelete:  EventBrandDeleted,
+		AuditActionRestore: EventBrandRestored,
+	},
+	AuditEntityCategory: {
+		AuditActionCreate:  EventCategoryCreated,
+		AuditActionUpdate:  EventCategoryUpdated,
+		AuditActionDelete:  EventCategoryDeleted,
+		AuditActionRestore: EventCategoryRestored,
+	},
+	AuditEntitySupplier: {
+		AuditActionCreate:  EventSupplierCreated,
+		AuditActionUpdate:  EventSupplierUpdated,
+		AuditActionDelete:  EventSupplierDeleted,
+		AuditActionRestore: EventSupplierRestored,
+	},
+	AuditEntityProduct: {
+		AuditActionCreate:  EventProductCreated,
+		AuditActionUpdate:  EventProductUpdated,
+		AuditActionDelete:  EventProductDeleted,
+		AuditActionRestore: EventProductRestored,
+	},
+	AuditEntityStock: {
+		AuditActionUpdate: EventStockAdjusted,
+	},
+	AuditEntityReview: {
+		AuditActionCreate: EventReviewCreated,
+		AuditActionUpdate: EventReviewUpdated,
+		AuditActionDelete: EventReviewDeleted,
+	},
+	AuditEntityQuestion: {
+		AuditActionCreate: EventQuestionCreated,
+		AuditActionUpdate: EventQuestionUpdated,
+		AuditActionDelete: EventQuestionDeleted,
+	},
+	// answering raises QuestionAnswered on the question, along with the question
+	AuditEntityAnswer: {
+		AuditActionDelete: EventAnswerDeleted,
+	},
+}
+
+// Event is a change of the catalog other systems may react to. events are
+// delivered at least once, consumers drop the ones whose idempotency key they saw before
+type Event struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`
+	SchemaVersion  int             `json:"schema_version"`
+	AggregateType  string          `json:"aggregate_type"`
+	AggregateID    string          `json:"aggregate_id"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	Data           json.RawMessage `json:"data"`
+	Actor          string          `json:"actor"`
+	TraceID        string          `json:"trace_id"`
+	OccurredAt     int64           `json:"occurred_at"`
+
+	// delivery state of the outbox
+	Attempts      int    `json:"-"`
+	NextAttemptAt int64  `json:"-"`
+	LastError     string `json:"-"`
+	PublishedAt   int64  `json:"-"`
+}
+
+// PriceChange is the data of a PriceChanged event
+type PriceChange struct {
+	ProductID           string  `json:"product_id"`
+	UnitPriceBefore     float64 `json:"unit_price_before"`
+	UnitPrice           float64 `json:"unit_price"`
+	DiscountPriceBefore float64 `json:"discount_price_before"`
+	DiscountPrice       float64 `json:"discount_price"`
+}
+
+// StockAdjustment is the data of a StockAdjusted event
+type StockAdjustment struct {
+	ProductID      string `json:"product_id"`
+	QuantityBefore int64  `json:"quantity_before"`
+	Quantity       int64  `json:"quantity"`
+	Change         int64  `json:"change"`
+}
+
+// StatusChange is the data of a StatusChanged event, raised by a brand, category,
+// supplier, product, review or question, the aggregate of the event
+type StatusChange struct {
+	EntityID     string `json:"entity_id"`
+	StatusBefore string `json:"status_before"`
+	Status       string `json:"status"`
+}
+
+// EventTypes returns every event type, sorted
+func EventTypes() []string {
+	types := make([]string, 0, len(eventSchemaVersions))
+	for eventType := range eventSchemaVersions {
+		types = append(types, eventType)
+	}
+
+	sort.Strings(types)
+
+	return types
+}
+
+// IsEventType reports whether eventType is a known event type
+func IsEventType(eventType string) bool {
+	_, ok := eventSchemaVersions[eventType]
+	return ok
+}
+
+// raiseEvents writes the events raised by a change of an entity to the outbox.
+// it runs with the ctx of the transaction making the change, so the events are
+// only kept, and later relayed, if the change is
+func (s *service) raiseEvents(ctx context.Context, entityType, entityID, action string, before, after interface{}) error {
+	eventType, ok := changeEvents[entityType][action]
+	if !ok {
+		return nil
+	}
+
+	// deletes carry the last state of the entity
+	var data interface{} = after
+	if action == AuditActionDelete {
+		data = before
+	}
+
+	if entityType == AuditEntityStock {
+		data = stockAdjustment(entityID, before, after)
+	}
+
+	if err := s.addEvent(ctx, eventType, entityType, entityID, data); err != nil {
+		return err
+	}
+
+	if action != AuditActionUpdate {
+		return nil
+	}
+
+	if change, ok := statusChange(entityID, before, after); ok {
+		if err := s.addEvent(ctx, EventStatusChanged, entityType, entityID, change); err != nil {
+			return err
+		}
+	}
+
+	if entityType == AuditEntityProduct {
+		if change, ok := priceChange(before, after); ok {
+			return s.addEvent(ctx, EventPriceChanged, entityType, entityID, change)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) addEvent(ctx context.Context, eventType, aggregateType, aggregateID string, data interface{}) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := util.GetCurrentTimestamp()
+
+	event := &Event{
+		Type:           eventType,
+		SchemaVersion:  eventSchemaVersions[eventType],
+		AggregateType:  aggregateType,
+		AggregateID:    aggregateID,
+		IdempotencyKey: uuid.NewString(),
+		Data:           dataJSON,
+		Actor:          GetActor(ctx),
+		TraceID:        logger.GetTraceID(ctx),
+		OccurredAt:     now,
+		NextAttemptAt:  now,
+	}
+
+	err = s.outboxRepo.Add(ctx, event)
+	if err != nil {
+		logger.Error(ctx, "cannot add event to the outbox", err)
+		return err
+	}
+
+	return nil
+}
+
+// priceChange reports whether an update changed the unit or discount price of a product
+func priceChange(before, after interface{}) (*PriceChange, bool) {
+	beforeProduct, ok := before.(*Product)
+	if !ok || beforeProduct == nil {
+		return nil, false
+	}
+
+	afterProduct, ok := after.(*Product)
+	if !ok || afterProduct == nil {
+		return nil, false
+	}
+
+	if beforeProduct.UnitPrice == afterProduct.UnitPrice && beforeProduct.DiscountPrice == afterProduct.DiscountPrice {
+		return nil, false
+	}
+
+	return &PriceChange{
+		ProductID:           afterProduct.ID,
+		UnitPriceBefore:     beforeProduct.UnitPrice,
+		UnitPrice:           afterProduct.UnitPrice,
+		DiscountPriceBefore: beforeProduct.DiscountPrice,
+		DiscountPrice:       afterProduct.DiscountPrice,
+	}, true
+}
+
+// statusChange reports whether an update moved a brand, category, supplier or product
+// to another status
+func statusChange(entityID string, before, after interface{}) (*StatusChange, bool) {
+	statusBefore, ok := entityStatus(before)
+	if !ok {
+		return nil, false
+	}
+
+	status, ok := entityStatus(after)
+	if !ok || status == statusBefore {
+		return nil, false
+	}
+
+	return &StatusChange{
+		EntityID:     entityID,
+		StatusBefore: statusBefore,
+		Status:       status,
+	}, true
+}
+
+func entityStatus(entity interface{}) (string, bool) {
+	switch entity := entity.(type) {
+	case *Brand:
+		if entity != nil {
+			return entity.Status, true
+		}
+	case *Category:
+		if entity != nil {
+			return entity.Status, true
+		}
+	case *Supplier:
+		if entity != nil {
+			return entity.Status, true
+		}
+	case *Product:
+		if entity != nil {
+			return entity.Status, true
+		}
+	case *Review:
+		if entity != nil {
+			return entity.Status, true
+		}
+	case *Question:
+		if entity != nil {
+			return entity.Status, true
+		}
+	}
+
+	return "", false
+}
+
+func stockAdjustment(productID string, before, after interface{}) *StockAdjustment {
+	adjustment := &StockAdjustment{
+		ProductID: productID,
+	}
+
+	if stock, ok := before.(*ProductStock); ok && stock != nil {
+		adjustment.QuantityBefore = stock.StockQuantity
+	}
+
+	if stock, ok := after.(*ProductStock); ok && stock != nil {
+		adjustment.Quantity = stock.StockQuantity
+	}
+
+	adjustment.Change = adjustment.Quantity - adjustment.QuantityBefore
+
+	return adjustment
+}
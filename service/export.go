@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jsiqbal/ecommerce/spreadsheet"
+)
+
+// exportPageSize is how many products are loaded from the database at a time while exporting
+const exportPageSize = 500
+
+// exportColumn is a flattened product field
+type exportColumn struct {
+	name  string
+	value func(product *Product) interface{}
+}
+
+// exportColumns are the exportable product columns in their default order, the
+// brand, category and supplier names use the headers the product import accepts
+var exportColumns = []exportColumn{
+	{"id", func(p *Product) interface{} { return p.ID }},
+	{"name", func(p *Product) interface{} { return p.Name }},
+	{"description", func(p *Product) interface{} { return p.Description }},
+	{"specifications", func(p *Product) interface{} { return p.Specifications }},
+	{"unit_price", func(p *Product) interface{} { return p.UnitPrice }},
+	{"discount_price", func(p *Product) interface{} { return p.DiscountPrice }},
+	{"tags", func(p *Product) interface{} { return p.Tags }},
+	{"status", func(p *Product) interface{} { return p.Status }},
+	{"publish_at", func(p *Product) interface{} { return p.PublishAt }},
+	{"unpublish_at", func(p *Product) interface{} { return p.UnpublishAt }},
+	{"rating_average", func(p *Product) interface{} { return p.RatingAverage }},
+	{"rating_count", func(p *Product) interface{} { return p.RatingCount }},
+	{"created_at", func(p *Product) interface{} { return p.CreatedAt }},
+	{"deleted_at", func(p *Product) interface{} { return p.DeletedAt }},
+	{"version", func(p *Product) interface{} { return p.Version }},
+	{"brand_id", func(p *Product) interface{} { return p.Brand.ID }},
+	{"brand_name", func(p *Product) interface{} { return p.Brand.Name }},
+	{"category_id", func(p *Product) interface{} { return p.Category.ID }},
+	{"category_name", func(p *Product) interface{} { return p.Category.Name }},
+	{"supplier_id", func(p *Product) interface{} { return p.Supplier.ID }},
+	{"supplier_name", func(p *Product) interface{} { return p.Supplier.Name }},
+	{"supplier_email", func(p *Product) interface{} { return p.Supplier.Email }},
+	{"supplier_phone", func(p *Product) interface{} { return p.Supplier.Phone }},
+	{"supplier_is_verified", func(p *Product) interface{} { return p.Supplier.IsVerifiedSupplier }},
+	{"stock_quantity", func(p *Product) interface{} { return p.ProductStock.StockQuantity }},
+}
+
+// ExportColumns returns the names of the exportable product columns in their default order
+func ExportColumns() []string {
+	names := make([]string, len(exportColumns))
+	for i, column := range exportColumns {
+		names[i] = column.name
+	}
+
+	return names
+}
+
+// ValidateExportColumns checks the requested columns, no columns means all of them
+func ValidateExportColumns(columns []string) ([]string, error) {
+	if len(columns) == 0 {
+		return ExportColumns(), nil
+	}
+
+	for i, name := range columns {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := findExportColumn(name); !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidExportColumn, name)
+		}
+
+		columns[i] = name
+	}
+
+	return columns, nil
+}
+
+func findExportColumn(name string) (exportColumn, bool) {
+	for _, column := range exportColumns {
+		if column.name == name {
+			return column, true
+		}
+	}
+
+	return exportColumn{}, false
+}
+
+// ExportProducts writes every product matching the filter to w, oldest first, loading
+// them a page at a time so the catalog is never held in memory. page, limit and sort of
+// the filter are ignored, it returns the number of products written
+func (s *service) ExportProducts(ctx context.Context, w io.Writer, format string, columns []string, filterParams FilterProductsParams) (int64, error) {
+	columns, err := ValidateExportColumns(columns)
+	if err != nil {
+		return 0, err
+	}
+
+	selected := make([]exportColumn, len(columns))
+	for i, name := range columns {
+		selected[i], _ = findExportColumn(name)
+	}
+
+	writer, err := spreadsheet.NewWriter(w, format, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		written   int64
+		createdAt int64
+		afterID   string
+	)
+
+	for {
+		products, err := s.productRepo.GetItemsAfter(ctx, filterParams, createdAt, afterID, exportPageSize)
+		if err != nil {
+			return written, err
+		}
+
+		for i := range products {
+			values := make([]interface{}, len(selected))
+			for j, column := range selected {
+				values[j] = exportValue(format, column.value(&products[i]))
+			}
+
+			if err := writer.WriteRow(values); err != nil {
+				return written, err
+			}
+
+			written++
+		}
+
+		if int64(len(products)) < exportPageSize {
+			break
+		}
+
+		last := products[len(products)-1]
+		createdAt, afterID = last.CreatedAt, last.ID
+	}
+
+	return written, writer.Close()
+}
+
+// exportValue flattens list values into the ";" separated form the import reads,
+// json lines keep them as arrays
+func exportValue(format string, value interface{}) interface{} {
+	if tags, ok := value.([]string); ok && format != spreadsheet.FormatJSONL {
+		return strings.Join(tags, ";")
+	}
+
+	return value
+}
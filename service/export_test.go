@@ -0,0 +1,73 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"testing"
+
+	"github.com/jsiqbal/ecommerce/spreadsheet"
+)
+
+// memoryProducts keeps the products in memory, oldest first, recording where every page
+// started
+type memoryProducts struct {
+	ProductRepo
+	products []Product
+	cursors  []string
+}
+
+func (m *memoryProducts) GetItemsAfter(ctx context.Context, filterParams FilterProductsParams, createdAt int64, afterID string, limit int64) ([]Product, error) {
+	m.cursors = append(m.cursors, afterID)
+
+	page := []Product{}
+	for _, product := range m.products {
+		after := len(afterID) == 0 || product.CreatedAt > createdAt || (product.CreatedAt == createdAt && product.ID > afterID)
+		if after && int64(len(page)) < limit {
+			page = append(page, product)
+		}
+	}
+
+	return page, nil
+}
+
+func TestExportProductsPagesByCursor(t *testing.T) {
+	products := &memoryProducts{}
+	for i := 0; i < 2*exportPageSize+1; i++ {
+		// products created in the same millisecond follow each other by id
+		products.products = append(products.products, Product{
+			ID:        fmt.Sprintf("product-%04d", i),
+			Name:      fmt.Sprintf("=product %d", i),
+			CreatedAt: int64(i / 3),
+		})
+	}
+
+	svc := &service{productRepo: products}
+
+	var buf bytes.Buffer
+	written, err := svc.ExportProducts(context.Background(), &buf, spreadsheet.FormatCSV, []string{"id", "name"}, FilterProductsParams{})
+	if err != nil {
+		t.Fatalf("ExportProducts: %v", err)
+	}
+
+	if written != int64(len(products.products)) {
+		t.Errorf("written %d products, want %d", written, len(products.products))
+	}
+
+	wantCursors := []string{"", products.products[exportPageSize-1].ID, products.products[2*exportPageSize-1].ID}
+	if fmt.Sprint(products.cursors) != fmt.Sprint(wantCursors) {
+		t.Errorf("pages started after %q, want %q", products.cursors, wantCursors)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("cannot read the export: %v", err)
+	}
+
+	for i, product := range products.products {
+		if record := records[i+1]; record[0] != product.ID || record[1] != "'"+product.Name {
+			t.Fatalf("row %d = %q, want %s", i+1, record, product.ID)
+		}
+	}
+}
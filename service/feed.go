@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jsiqbal/ecommerce/feed"
+)
+
+// categoryPathSeparator joins the category names of a product type the way google merchant expects them
+const categoryPathSeparator = " > "
+
+// maxCategoryDepth stops the walk up the category tree should a parent_id loop back on itself
+const maxCategoryDepth = 32
+
+// GenerateFeed writes the active catalog to w as a product feed, oldest first, loading
+// the products a page at a time after the last one of the page before, as the export
+// does. it returns the number of products written
+func (s *service) GenerateFeed(ctx context.Context, w io.Writer, format string, channel feed.Channel) (int64, error) {
+	writer, err := feed.NewWriter(w, format, channel)
+	if err != nil {
+		return 0, err
+	}
+
+	paths := make(map[string]string)
+	link := strings.TrimSuffix(channel.Link, "/")
+
+	var (
+		written   int64
+		createdAt int64
+		afterID   string
+	)
+
+	// without statuses the products are the live, not deleted ones
+	for {
+		products, err := s.productRepo.GetItemsAfter(ctx, FilterProductsParams{}, createdAt, afterID, exportPageSize)
+		if err != nil {
+			return written, err
+		}
+
+		for i := range products {
+			product := &products[i]
+
+			productType, err := s.categoryPath(ctx, paths, product.Category.ID)
+			if err != nil {
+				return written, err
+			}
+
+			item := &feed.Item{
+				ID:           product.ID,
+				Title:        product.Name,
+				Description:  product.Description,
+				Price:        product.UnitPrice,
+				Brand:        product.Brand.Name,
+				Availability: feed.AvailabilityOutOfStock,
+				Condition:    feed.ConditionNew,
+				ProductType:  productType,
+			}
+
+			if len(link) > 0 {
+				item.Link = fmt.Sprintf("%s/products/%s", link, product.ID)
+			}
+
+			// the discount price is the price the product is sold at while it is below the unit price
+			if product.DiscountPrice > 0 && product.DiscountPrice < product.UnitPrice {
+				item.SalePrice = product.DiscountPrice
+			}
+
+			if product.ProductStock.StockQuantity > 0 {
+				item.Availability = feed.AvailabilityInStock
+			}
+
+			if err := writer.WriteItem(item); err != nil {
+				return written, err
+			}
+
+			written++
+		}
+
+		if int64(len(products)) < exportPageSize {
+			break
+		}
+
+		last := products[len(products)-1]
+		createdAt, afterID = last.CreatedAt, last.ID
+	}
+
+	return written, writer.Close()
+}
+
+// categoryPath returns the names of a category and its ancestors from the root
+// down, e.g. "Electronics > Computers > Laptops". paths caches the categories
+// already resolved
+func (s *service) categoryPath(ctx context.Context, paths map[string]string, ctgryID string) (string, error) {
+	if path, ok := paths[ctgryID]; ok {
+		return path, nil
+	}
+
+	var names []string
+
+	id := ctgryID
+	for depth := 0; len(id) > 0 && depth < maxCategoryDepth; depth++ {
+		if path, ok := paths[id]; ok {
+			if len(path) > 0 {
+				names = append(names, path)
+			}
+			break
+		}
+
+		ctgry, err := s.ctgryRepo.GetItemByID(ctx, id)
+		if errors.Is(err, ErrCategoryNotFound) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		names = append(names, ctgry.Name)
+		id = ctgry.ParentID
+	}
+
+	// the names were collected from the leaf up
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+
+	path := strings.Join(names, categoryPathSeparator)
+	paths[ctgryID] = path
+
+	return path, nil
+}
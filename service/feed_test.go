@@ -0,0 +1,61 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"testing"
+
+	"github.com/jsiqbal/ecommerce/feed"
+)
+
+func TestGenerateFeedPagesByCursor(t *testing.T) {
+	ctgries := newMemoryCategories(
+		Category{ID: "electronics", Name: "Electronics"},
+		Category{ID: "phones", Name: "Phones", ParentID: "electronics"},
+	)
+
+	products := &memoryProducts{}
+	for i := 0; i < exportPageSize+2; i++ {
+		products.products = append(products.products, Product{
+			ID:        fmt.Sprintf("product-%04d", i),
+			Name:      fmt.Sprintf("product %d", i),
+			Category:  Category{ID: "phones"},
+			UnitPrice: 100,
+			CreatedAt: int64(i / 2),
+		})
+	}
+
+	svc := &service{productRepo: products, ctgryRepo: ctgries}
+
+	var buf bytes.Buffer
+	written, err := svc.GenerateFeed(context.Background(), &buf, feed.FormatCSV, feed.Channel{Currency: "USD"})
+	if err != nil {
+		t.Fatalf("GenerateFeed: %v", err)
+	}
+
+	if written != int64(len(products.products)) {
+		t.Errorf("written %d products, want %d", written, len(products.products))
+	}
+
+	wantCursors := []string{"", products.products[exportPageSize-1].ID}
+	if fmt.Sprint(products.cursors) != fmt.Sprint(wantCursors) {
+		t.Errorf("pages started after %q, want %q", products.cursors, wantCursors)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("cannot read the feed: %v", err)
+	}
+
+	if len(records) != len(products.products)+1 {
+		t.Fatalf("feed has %d rows, want %d", len(records), len(products.products)+1)
+	}
+
+	for i, product := range products.products {
+		if records[i+1][0] != product.ID {
+			t.Fatalf("row %d = %q, want %s", i+1, records[i+1], product.ID)
+		}
+	}
+}
@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// import job statuses
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// importProgressEvery is how many rows are processed between two progress updates of a job
+const importProgressEvery = 25
+
+// product import columns
+const (
+	ImportColumnName           = "name"
+	ImportColumnDescription    = "description"
+	ImportColumnSpecifications = "specifications"
+	ImportColumnBrand          = "brand"
+	ImportColumnCategory       = "category"
+	ImportColumnSupplier       = "supplier"
+	ImportColumnUnitPrice      = "unit_price"
+	ImportColumnDiscountPrice  = "discount_price"
+	ImportColumnTags           = "tags"
+	ImportColumnStatus         = "status"
+	ImportColumnStockQuantity  = "stock_quantity"
+)
+
+// importColumnAliases maps the accepted header names to the import columns,
+// brand, category and supplier can be given either by name or by id
+var importColumnAliases = map[string]string{
+	"brand_id":      ImportColumnBrand,
+	"brand_name":    ImportColumnBrand,
+	"category_id":   ImportColumnCategory,
+	"category_name": ImportColumnCategory,
+	"supplier_id":   ImportColumnSupplier,
+	"supplier_name": ImportColumnSupplier,
+	"price":         ImportColumnUnitPrice,
+	"status_id":     ImportColumnStatus,
+	"stock":         ImportColumnStockQuantity,
+	"quantity":      ImportColumnStockQuantity,
+}
+
+// ImportRow is a data row of an import file keyed by import column
+type ImportRow struct {
+	Line   int               `json:"line"`
+	Values map[string]string `json:"values"`
+}
+
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	TotalRows   int64            `json:"total_rows"`
+	ValidRows   int64            `json:"valid_rows"`
+	InvalidRows int64            `json:"invalid_rows"`
+	Errors      []ImportRowError `json:"errors"`
+}
+
+type ImportJob struct {
+	ID            string           `json:"id"`
+	FileName      string           `json:"file_name"`
+	Format        string           `json:"format"`
+	Status        string           `json:"status"`
+	TotalRows     int64            `json:"total_rows"`
+	ProcessedRows int64            `json:"processed_rows"`
+	SucceededRows int64            `json:"succeeded_rows"`
+	FailedRows    int64            `json:"failed_rows"`
+	Errors        []ImportRowError `json:"errors"`
+	CreatedAt     int64            `json:"created_at"`
+	UpdatedAt     int64            `json:"updated_at"`
+	FinishedAt    int64            `json:"finished_at,omitempty"`
+}
+
+// NewImportRows maps the header of an import file to the import columns and
+// keys every data row by them, numbering rows by their line in the file
+func NewImportRows(header []string, records [][]string) []ImportRow {
+	columns := make([]string, len(header))
+	for i, name := range header {
+		column := strings.ToLower(strings.TrimSpace(name))
+		column = strings.NewReplacer(" ", "_", "-", "_").Replace(column)
+		if alias, ok := importColumnAliases[column]; ok {
+			column = alias
+		}
+
+		columns[i] = column
+	}
+
+	rows := []ImportRow{}
+	for i, record := range records {
+		row := ImportRow{
+			Line:   i + 2,
+			Values: make(map[string]string),
+		}
+
+		empty := true
+		for j, value := range record {
+			if j >= len(columns) {
+				break
+			}
+
+			value = strings.TrimSpace(value)
+			if len(value) > 0 {
+				empty = false
+			}
+
+			row.Values[columns[j]] = value
+		}
+
+		// spreadsheets often end with blank rows
+		if empty {
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// ImportErrorsTable lays the errors of an import out as the rows of an errors file
+func ImportErrorsTable(rowErrs []ImportRowError) ([]string, [][]string) {
+	records := make([][]string, len(rowErrs))
+	for i, rowErr := range rowErrs {
+		line := ""
+		if rowErr.Line > 0 {
+			line = strconv.Itoa(rowErr.Line)
+		}
+
+		records[i] = []string{line, rowErr.Column, rowErr.Message}
+	}
+
+	return []string{"line", "column", "message"}, records
+}
+
+// ValidateImport is the dry run of an import, it validates every row without creating anything
+func (s *service) ValidateImport(ctx context.Context, rows []ImportRow) (*ImportReport, error) {
+	refs := newBulkRefs(s)
+	claimedNames := make(map[string]string)
+
+	report := &ImportReport{
+		TotalRows: int64(len(rows)),
+		Errors:    []ImportRowError{},
+	}
+
+	for _, row := range rows {
+		_, rowErrs := s.importProduct(ctx, refs, claimedNames, row)
+		if len(rowErrs) > 0 {
+			report.InvalidRows++
+			report.Errors = append(report.Errors, rowErrs...)
+			continue
+		}
+
+		report.ValidRows++
+	}
+
+	return report, nil
+}
+
+func (s *service) CreateImportJob(ctx context.Context, fileName, format string, totalRows int64) (*ImportJob, error) {
+	now := util.GetCurrentTimestamp()
+
+	return s.importJobRepo.Add(ctx, &ImportJob{
+		FileName:  fileName,
+		Format:    format,
+		Status:    ImportStatusPending,
+		TotalRows: totalRows,
+		Errors:    []ImportRowError{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+func (s *service) GetImportJob(ctx context.Context, jobID string) (*ImportJob, error) {
+	return s.importJobRepo.GetItemByID(ctx, jobID)
+}
+
+// RunImport creates a product for every valid row of the job. every row is
+// created on its own, rows that fail are recorded in the errors of the job
+func (s *service) RunImport(ctx context.Context, jobID string, rows []ImportRow) (*ImportJob, error) {
+	job, err := s.importJobRepo.GetItemByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Status = ImportStatusRunning
+	job.TotalRows = int64(len(rows))
+	if err := s.saveImportJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	refs := newBulkRefs(s)
+	claimedNames := make(map[string]string)
+
+	for i, row := range rows {
+		product, rowErrs := s.importProduct(ctx, refs, claimedNames, row)
+		if len(rowErrs) == 0 {
+			if _, err := s.AddProduct(ctx, product); err != nil {
+				logger.Error(ctx, "cannot import product", err)
+				rowErrs = []ImportRowError{{Line: row.Line, Message: err.Error()}}
+			}
+		}
+
+		job.ProcessedRows++
+		if len(rowErrs) > 0 {
+			job.FailedRows++
+			job.Errors = append(job.Errors, rowErrs...)
+		} else {
+			job.SucceededRows++
+		}
+
+		if (i+1)%importProgressEvery == 0 {
+			if err := s.saveImportJob(ctx, job); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	job.Status = ImportStatusCompleted
+	job.FinishedAt = util.GetCurrentTimestamp()
+	if err := s.saveImportJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// FailImportJob marks a job that could not run to the end. a job which finished keeps its
+// outcome, e.g. when its worker went away after the import was saved
+func (s *service) FailImportJob(ctx context.Context, jobID string, cause error) error {
+	job, err := s.importJobRepo.GetItemByID(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	if job.Status == ImportStatusCompleted || job.Status == ImportStatusFailed {
+		return nil
+	}
+
+	job.Status = ImportStatusFailed
+	job.Errors = append(job.Errors, ImportRowError{Message: cause.Error()})
+	job.FinishedAt = util.GetCurrentTimestamp()
+
+	return s.saveImportJob(ctx, job)
+}
+
+func (s *service) saveImportJob(ctx context.Context, job *ImportJob) error {
+	job.UpdatedAt = util.GetCurrentTimestamp()
+
+	err := s.importJobRepo.UpdateItemByID(ctx, job.ID, job)
+	if err != nil {
+		logger.Error(ctx, "cannot save import job", err)
+		return err
+	}
+
+	return nil
+}
+
+// importProduct maps a row to a product, applying the same rules as the create
+// product api, and returns every problem found in the row
+func (s *service) importProduct(ctx context.Context, refs *bulkRefs, claimedNames map[string]string, row ImportRow) (*Product, []ImportRowError) {
+	var rowErrs []ImportRowError
+	fail := func(column, message string) {
+		rowErrs = append(rowErrs, ImportRowError{Line: row.Line, Column: column, Message: message})
+	}
+
+	product := &Product{
+		Name:           row.Values[ImportColumnName],
+		Description:    row.Values[ImportColumnDescription],
+		Specifications: row.Values[ImportColumnSpecifications],
+		CreatedAt:      util.GetCurrentTimestamp(),
+	}
+
+	if l := utf8.RuneCountInString(product.Name); l < 2 || l > 50 {
+		fail(ImportColumnName, "must be between 2 and 50 characters")
+	}
+
+	if l := utf8.RuneCountInString(product.Description); l < 2 || l > 500 {
+		fail(ImportColumnDescription, "must be between 2 and 500 characters")
+	}
+
+	if utf8.RuneCountInString(product.Specifications) > 500 {
+		fail(ImportColumnSpecifications, "must be at most 500 characters")
+	}
+
+	var err error
+	if product.UnitPrice, err = importNumber(row, ImportColumnUnitPrice, true); err != nil {
+		fail(ImportColumnUnitPrice, err.Error())
+	}
+
+	if product.DiscountPrice, err = importNumber(row, ImportColumnDiscountPrice, false); err != nil {
+		fail(ImportColumnDiscountPrice, err.Error())
+	}
+
+	// products without a status are imported as drafts
+	product.Status = strings.ToLower(strings.TrimSpace(row.Values[ImportColumnStatus]))
+	if len(product.Status) > 0 && !IsProductStatus(product.Status) {
+		fail(ImportColumnStatus, "is not a supported status")
+	}
+
+	stockQuantity, err := importNumber(row, ImportColumnStockQuantity, true)
+	if err == nil && stockQuantity < 1 {
+		err = errors.New("must be at least 1")
+	}
+	if err != nil {
+		fail(ImportColumnStockQuantity, err.Error())
+	}
+	product.ProductStock = ProductStock{StockQuantity: int64(stockQuantity)}
+
+	product.Tags = []string{}
+	for _, tag := range strings.Split(row.Values[ImportColumnTags], ";") {
+		if tag = strings.TrimSpace(tag); len(tag) > 0 {
+			product.Tags = append(product.Tags, tag)
+		}
+	}
+
+	brand, err := refs.brandByRef(ctx, row.Values[ImportColumnBrand])
+	if err != nil {
+		fail(ImportColumnBrand, err.Error())
+	}
+
+	ctgry, err := refs.categoryByRef(ctx, row.Values[ImportColumnCategory])
+	if err != nil {
+		fail(ImportColumnCategory, err.Error())
+	}
+
+	spplr, err := refs.supplierByRef(ctx, row.Values[ImportColumnSupplier])
+	if err != nil {
+		fail(ImportColumnSupplier, err.Error())
+	}
+
+	if len(rowErrs) > 0 {
+		return nil, rowErrs
+	}
+
+	product.Brand = Brand{ID: brand.ID}
+	product.Category = Category{ID: ctgry.ID}
+	product.Supplier = Supplier{ID: spplr.ID}
+
+	err = s.validateBulkProduct(ctx, refs, claimedNames, "", product)
+	if errors.Is(err, ErrDuplicateProduct) {
+		fail(ImportColumnName, err.Error())
+	} else if err != nil {
+		fail("", err.Error())
+	}
+
+	if len(rowErrs) > 0 {
+		return nil, rowErrs
+	}
+
+	return product, nil
+}
+
+// importNumber parses a numeric column, empty optional columns are zero
+func importNumber(row ImportRow, column string, required bool) (float64, error) {
+	value := row.Values[column]
+	if len(value) == 0 {
+		if required {
+			return 0, errors.New("is required")
+		}
+
+		return 0, nil
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+
+	if number < 0 {
+		return 0, errors.New("must not be negative")
+	}
+
+	return number, nil
+}
+
+// brandByRef finds a brand by id, or by name when the reference is not an id
+func (r *bulkRefs) brandByRef(ctx context.Context, ref string) (*Brand, error) {
+	if len(ref) == 0 {
+		return nil, errors.New("is required")
+	}
+
+	if _, err := uuid.Parse(ref); err == nil {
+		return r.brand(ctx, ref)
+	}
+
+	nameKey := "brand/" + strings.ToLower(ref)
+	if brandID, ok := r.names[nameKey]; ok {
+		return r.brand(ctx, brandID)
+	}
+
+	brand, err := r.svc.brandRepo.GetItemByName(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	r.names[nameKey] = brand.ID
+	r.brands[brand.ID] = brand
+
+	return brand, nil
+}
+
+// categoryByRef finds a category by id, by slug, or by name when the reference is
+// neither. names are unique only under a parent, a name several categories have is
+// refused rather than resolved to one of them
+func (r *bulkRefs) categoryByRef(ctx context.Context, ref string) (*Category, error) {
+	if len(ref) == 0 {
+		return nil, errors.New("is required")
+	}
+
+	if _, err := uuid.Parse(ref); err == nil {
+		return r.category(ctx, ref)
+	}
+
+	nameKey := "category/" + strings.ToLower(ref)
+	if categoryID, ok := r.names[nameKey]; ok {
+		return r.category(ctx, categoryID)
+	}
+
+	if util.IsSupportedSlug(ref) {
+		categoryID, _, err := r.svc.slugRepo.GetEntityID(ctx, AuditEntityCategory, ref)
+		if err != nil {
+			return nil, err
+		} else if len(categoryID) > 0 {
+			r.names[nameKey] = categoryID
+			return r.category(ctx, categoryID)
+		}
+	}
+
+	ctgry, err := r.svc.ctgryRepo.GetItemByName(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	r.names[nameKey] = ctgry.ID
+	r.categories[ctgry.ID] = ctgry
+
+	return ctgry, nil
+}
+
+// supplierByRef finds a supplier by id, or by name when the reference is not an id
+func (r *bulkRefs) supplierByRef(ctx context.Context, ref string) (*Supplier, error) {
+	if len(ref) == 0 {
+		return nil, errors.New("is required")
+	}
+
+	if _, err := uuid.Parse(ref); err == nil {
+		return r.supplier(ctx, ref)
+	}
+
+	nameKey := "supplier/" + strings.ToLower(ref)
+	if supplierID, ok := r.names[nameKey]; ok {
+		return r.supplier(ctx, supplierID)
+	}
+
+	spplr, err := r.svc.spplrRepo.GetItemByName(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	r.names[nameKey] = spplr.ID
+	r.suppliers[spplr.ID] = spplr
+
+	return spplr, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// job statuses, a job that used up its attempts is dead and waits to be requeued by hand
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+)
+
+// job types
+const (
+	JobTypeProductImport = "product_import"
+)
+
+// DefaultJobMaxAttempts is how many times a job runs before it is dead, unless enqueued with its own
+const DefaultJobMaxAttempts = 5
+
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"-"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       int64           `json:"run_at"`
+	LockedUntil int64           `json:"-"`
+	LastError   string          `json:"last_error,omitempty"`
+	Actor       string          `json:"actor"`
+	TraceID     string          `json:"trace_id"`
+	CreatedAt   int64           `json:"created_at"`
+	UpdatedAt   int64           `json:"updated_at"`
+	FinishedAt  int64           `json:"finished_at,omitempty"`
+}
+
+// JobOptions tunes an enqueued job, the zero value runs it right away with the default attempts
+type JobOptions struct {
+	// RunAt delays the job until the unix time in milliseconds
+	RunAt       int64
+	MaxAttempts int
+}
+
+// ImportJobPayload is the payload of a product import job
+type ImportJobPayload struct {
+	ImportJobID string      `json:"import_job_id"`
+	Rows        []ImportRow `json:"rows"`
+}
+
+// EnqueueJob queues a job of the type with payload marshalled to json. the job
+// keeps the actor and trace id of ctx and joins the transaction of ctx, if any
+func (s *service) EnqueueJob(ctx context.Context, jobType string, payload interface{}, opts JobOptions) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := util.GetCurrentTimestamp()
+
+	job := &Job{
+		Type:        jobType,
+		Payload:     data,
+		Status:      JobStatusQueued,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+		Actor:       GetActor(ctx),
+		TraceID:     logger.GetTraceID(ctx),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultJobMaxAttempts
+	}
+
+	if job.RunAt < now {
+		job.RunAt = now
+	}
+
+	createdJob, err := s.jobRepo.Add(ctx, job)
+	if err != nil {
+		logger.Error(ctx, "cannot enqueue job", err)
+		return nil, err
+	}
+
+	return createdJob, nil
+}
+
+func (s *service) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	return s.jobRepo.GetItemByID(ctx, jobID)
+}
+
+// RequeueJob gives a dead job a fresh set of attempts, starting right away
+func (s *service) RequeueJob(ctx context.Context, jobID string) (*Job, error) {
+	job, err := s.jobRepo.GetItemByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != JobStatusDead {
+		return nil, ErrJobNotDead
+	}
+
+	now := util.GetCurrentTimestamp()
+
+	affected, err := s.jobRepo.Requeue(ctx, jobID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// another request requeued it first
+	if affected == 0 {
+		return nil, ErrJobNotDead
+	}
+
+	return s.jobRepo.GetItemByID(ctx, jobID)
+}
+
+// StartImport records an import job and queues the rows for a worker to import
+func (s *service) StartImport(ctx context.Context, fileName, format string, rows []ImportRow) (*ImportJob, error) {
+	var job *ImportJob
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		job, err = s.CreateImportJob(ctx, fileName, format, int64(len(rows)))
+		if err != nil {
+			return err
+		}
+
+		// rows already imported would only fail as duplicates on a second run, so an import runs once
+		_, err = s.EnqueueJob(ctx, JobTypeProductImport, ImportJobPayload{
+			ImportJobID: job.ID,
+			Rows:        rows,
+		}, JobOptions{MaxAttempts: 1})
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the language of the name and description of an entity, the one its
+// translations are translated from
+var DefaultLocale = language.English
+
+// Translation is the name, and for a product the description, of an entity in another
+// language. empty fields fall back to those of the default locale
+type Translation struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Translations are the translations of an entity keyed by BCP 47 language tag, e.g. "bn"
+// or "pt-BR"
+type Translations map[string]Translation
+
+// lookup finds the translation of the first of the locales which has one, a regional
+// locale falling back to its language, e.g. "bn-BD" to "bn". a locale of the default
+// language ends the search, as the entity is written in it
+func (t Translations) lookup(locales []language.Tag) (string, Translation, bool) {
+	defaultBase, _ := DefaultLocale.Base()
+
+	for _, locale := range locales {
+		for tag := locale; tag != language.Und; tag = tag.Parent() {
+			if translation, ok := t[tag.String()]; ok {
+				return tag.String(), translation, true
+			}
+		}
+
+		if base, _ := locale.Base(); base == defaultBase {
+			break
+		}
+	}
+
+	return "", Translation{}, false
+}
+
+// Localize puts the name and description of the product, and the names of its brand and
+// category, in the first of the locales they are translated to. Locale tells which one
+// the product is in
+func (p *Product) Localize(locales []language.Tag) {
+	p.Locale = DefaultLocale.String()
+
+	if locale, translation, ok := p.Translations.lookup(locales); ok {
+		p.Locale = locale
+
+		if len(translation.Name) > 0 {
+			p.Name = translation.Name
+		}
+
+		if len(translation.Description) > 0 {
+			p.Description = translation.Description
+		}
+	}
+
+	p.Brand.Localize(locales)
+	p.Category.Localize(locales)
+}
+
+// Localize puts the name of the brand in the first of the locales it is translated to
+func (b *Brand) Localize(locales []language.Tag) {
+	if _, translation, ok := b.Translations.lookup(locales); ok && len(translation.Name) > 0 {
+		b.Name = translation.Name
+	}
+}
+
+// Localize puts the name of the category in the first of the locales it is translated to
+func (c *Category) Localize(locales []language.Tag) {
+	if _, translation, ok := c.Translations.lookup(locales); ok && len(translation.Name) > 0 {
+		c.Name = translation.Name
+	}
+}
+
+type localesKey struct{}
+
+// WithLocales returns a copy of ctx carrying the locales the caller reads in, the most
+// preferred first
+func WithLocales(ctx context.Context, locales []language.Tag) context.Context {
+	return context.WithValue(ctx, localesKey{}, locales)
+}
+
+// GetLocales returns the locales carried by ctx, none when the caller did not tell
+func GetLocales(ctx context.Context) []language.Tag {
+	locales, _ := ctx.Value(localesKey{}).([]language.Tag)
+	return locales
+}
@@ -2,38 +2,101 @@ package service
 
 import (
 	"context"
+	"io"
+
+	"github.com/jsiqbal/ecommerce/feed"
 )
 
+// Transactor runs fn in a database transaction carried by the ctx passed to fn,
+// every repo call made with that ctx joins the transaction
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type BrandRepo interface {
 	Add(ctx context.Context, brand *Brand) (*Brand, error)
 	GetItemByID(ctx context.Context, brandID string) (*Brand, error)
-	GetItems(ctx context.Context, page int64, limit int64) (*BrandResult, error)
-	UpdateItemByID(ctx context.Context, brandID string, brand *Brand) error
+	GetItemByName(ctx context.Context, name string) (*Brand, error)
+	GetItems(ctx context.Context, page int64, limit int64, includeDeleted bool) (*BrandResult, error)
+	UpdateItemByID(ctx context.Context, brandID string, brand *Brand) (int64, error)
+	PatchItemByID(ctx context.Context, brandID string, patch *BrandPatch) (int64, error)
 	DeleteItemByID(ctx context.Context, brandID string) error
+	RestoreItemByID(ctx context.Context, brandID string) error
+	PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error)
+	// SetLogo points the brand at the logo stored under the key, empty ones remove it
+	SetLogo(ctx context.Context, brandID, key, url string) (int64, error)
+	GetCategoryCounts(ctx context.Context, brandID string) ([]BrandCategoryCount, error)
 }
 
 type CategoryRepo interface {
 	Add(ctx context.Context, ctgry *Category) (*Category, error)
 	GetItemByID(ctx context.Context, ctgryID string) (*Category, error)
-	GetItems(ctx context.Context, page int64, limit int64) (*CategoryResult, error)
-	UpdateItemByID(ctx context.Context, ctgryID string, ctgry *Category) error
+	// GetItemByName returns the category with the name, ErrAmbiguousCategory when
+	// categories under different parents have it
+	GetItemByName(ctx context.Context, name string) (*Category, error)
+	GetItems(ctx context.Context, page int64, limit int64, includeDeleted bool) (*CategoryResult, error)
+	GetItemsByIDs(ctx context.Context, ctgryIDs []string) ([]Category, error)
+	// GetItemsByParentIDs returns the children of the categories, in sequence order
+	GetItemsByParentIDs(ctx context.Context, parentIDs []string) ([]Category, error)
+	// GetItemsByParentID returns the children of the category, or the root categories when parentID is empty
+	GetItemsByParentID(ctx context.Context, parentID string) ([]Category, error)
+	// GetSubtree returns the category and its descendants down to maxDepth levels below it,
+	// or every tree when ctgryID is empty, parents first and siblings in sequence order
+	GetSubtree(ctx context.Context, ctgryID string, maxDepth int) ([]Category, error)
+	// GetAncestors returns the ancestors of the category, root first
+	GetAncestors(ctx context.Context, ctgryID string, maxDepth int) ([]Category, error)
+	GetSubtreeHeight(ctx context.Context, ctgryID string, maxDepth int) (int, error)
+	// LockTree holds the changes to the shape and the statuses of the tree by others until
+	// the transaction of ctx ends, so the checks before a change still hold when it is made
+	LockTree(ctx context.Context) error
+	MoveItemByID(ctx context.Context, ctgryID, parentID string, version int64) (int64, error)
+	SetSequences(ctx context.Context, parentID string, ctgryIDs []string) (int64, error)
+	UpdateItemByID(ctx context.Context, ctgryID string, ctgry *Category) (int64, error)
+	PatchItemByID(ctx context.Context, ctgryID string, patch *CategoryPatch) (int64, error)
 	DeleteItemByID(ctx context.Context, ctgryID string) error
+	RestoreItemByID(ctx context.Context, ctgryID string) error
+	PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error)
 }
 
 type SupplierRepo interface {
 	Add(ctx context.Context, spplr *Supplier) (*Supplier, error)
 	GetItemByID(ctx context.Context, spplrID string) (*Supplier, error)
-	GetItems(ctx context.Context, page int64, limit int64) (*SupplierResult, error)
-	UpdateItemByID(ctx context.Context, spplrID string, spplr *Supplier) error
+	GetItemByName(ctx context.Context, name string) (*Supplier, error)
+	GetItems(ctx context.Context, page int64, limit int64, includeDeleted bool) (*SupplierResult, error)
+	UpdateItemByID(ctx context.Context, spplrID string, spplr *Supplier) (int64, error)
+	PatchItemByID(ctx context.Context, spplrID string, patch *SupplierPatch) (int64, error)
 	DeleteItemByID(ctx context.Context, spplrID string) error
+	RestoreItemByID(ctx context.Context, spplrID string) error
+	PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error)
 }
 
 type ProductRepo interface {
 	Add(ctx context.Context, product *Product) (*Product, error)
 	GetItemByID(ctx context.Context, productID string) (*Product, error)
+	// GetItemByName returns the product of the supplier with the name, deleted ones aside
+	GetItemByName(ctx context.Context, supplierID, name string) (*Product, error)
 	GetItems(ctx context.Context, filterParams FilterProductsParams) (*ProductResult, error)
-	UpdateItemByID(ctx context.Context, productID string, product *Product) error
+	// GetItemsAfter returns up to limit products matching the filter, oldest first, which
+	// come after the product created at createdAt with the id afterID. the first ones when
+	// afterID is empty. page, limit and sort of the filter are ignored
+	GetItemsAfter(ctx context.Context, filterParams FilterProductsParams, createdAt int64, afterID string, limit int64) ([]Product, error)
+	UpdateItemByID(ctx context.Context, productID string, product *Product) (int64, error)
+	PatchItemByID(ctx context.Context, productID string, patch *ProductPatch) (int64, error)
 	DeleteItemByID(ctx context.Context, productID string) error
+	RestoreItemByID(ctx context.Context, productID string) error
+	PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error)
+	// GetTopByBrand returns the best selling live products of the brand, by units sold
+	GetTopByBrand(ctx context.Context, brandID string, limit int64) ([]Product, error)
+	// GetUnarchivedIDs returns the ids of the products of the brand, category or supplier,
+	// owner being its entity type, which are not archived
+	GetUnarchivedIDs(ctx context.Context, owner, ownerID string) ([]string, error)
+	// GetDueScheduledIDs returns the ids of up to limit products which are due to be
+	// published or unpublished by now
+	GetDueScheduledIDs(ctx context.Context, now int64, limit int) ([]string, error)
+	GetScheduledChanges(ctx context.Context, from, to, page, limit int64) (*ScheduleResult, error)
+	// AdjustRating adds the counts and the sum of the ratings of approved reviews to the
+	// rating of the product, negative ones taking reviews out
+	AdjustRating(ctx context.Context, productID string, count, sum int64) error
 }
 
 type ProductStockRepo interface {
@@ -41,6 +104,117 @@ type ProductStockRepo interface {
 	GetItemByProductID(ctx context.Context, productID string) (*ProductStock, error)
 	UpdateItemByID(ctx context.Context, productStockID string, product *ProductStock) error
 	DeleteItemByID(ctx context.Context, productStockID string) error
+	AdjustQuantity(ctx context.Context, movement *StockMovement) (*ProductStock, error)
+	GetSalesReport(ctx context.Context, supplierID string, from, to int64) ([]SalesReportItem, error)
+	GetLowStockItems(ctx context.Context, supplierID string, threshold int64) ([]LowStockItem, error)
+}
+
+type ImportJobRepo interface {
+	Add(ctx context.Context, job *ImportJob) (*ImportJob, error)
+	GetItemByID(ctx context.Context, jobID string) (*ImportJob, error)
+	UpdateItemByID(ctx context.Context, jobID string, job *ImportJob) error
+}
+
+type JobRepo interface {
+	Add(ctx context.Context, job *Job) (*Job, error)
+	GetItemByID(ctx context.Context, jobID string) (*Job, error)
+	// Claim locks the next due job of the types until lockedUntil, counting the attempt. a
+	// running job whose lock expired is due again while it has attempts left, its worker
+	// is presumed dead
+	Claim(ctx context.Context, types []string, now, lockedUntil int64) (*Job, error)
+	// Bury marks the running jobs of the types whose lock expired without attempts left
+	// dead, the worker of their last attempt is presumed dead, and returns them
+	Bury(ctx context.Context, types []string, now int64) ([]Job, error)
+	// Finish saves the outcome of the attempt, unless the job was claimed again meanwhile
+	Finish(ctx context.Context, job *Job) (int64, error)
+	Requeue(ctx context.Context, jobID string, now int64) (int64, error)
+}
+
+type OutboxRepo interface {
+	Add(ctx context.Context, event *Event) error
+	// Claim locks up to limit undelivered events that are due until lockedUntil, counting the attempt
+	Claim(ctx context.Context, limit int, now, lockedUntil int64) ([]Event, error)
+	MarkPublished(ctx context.Context, eventID string, publishedAt int64) error
+	MarkFailed(ctx context.Context, eventID string, nextAttemptAt int64, lastError string) error
+}
+
+type WebhookSubscriptionRepo interface {
+	Add(ctx context.Context, sub *WebhookSubscription) (*WebhookSubscription, error)
+	GetItemByID(ctx context.Context, subID string) (*WebhookSubscription, error)
+	GetItems(ctx context.Context, page int64, limit int64) (*WebhookSubscriptionResult, error)
+	// GetActiveItems returns the active subscriptions to the event type
+	GetActiveItems(ctx context.Context, eventType string) ([]WebhookSubscription, error)
+	UpdateItemByID(ctx context.Context, subID string, sub *WebhookSubscription) error
+	DeleteItemByID(ctx context.Context, subID string) (int64, error)
+}
+
+type WebhookDeliveryRepo interface {
+	// Add returns nil when the subscription already holds a delivery of the event
+	Add(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error)
+	GetItemByID(ctx context.Context, deliveryID string) (*WebhookDelivery, error)
+	GetItems(ctx context.Context, subID string, page int64, limit int64) (*WebhookDeliveryResult, error)
+	UpdateItemByID(ctx context.Context, deliveryID string, delivery *WebhookDelivery) error
+}
+
+// SlugRepo looks up the entities by slug, entity being the brand, category or product
+// entity type of the audit log, and keeps the old slugs redirecting to their entities
+type SlugRepo interface {
+	// GetEntityID returns the id of the entity with the slug, else of the one which had it
+	// before, redirected telling which. empty when there is none
+	GetEntityID(ctx context.Context, entity, slug string) (entityID string, redirected bool, err error)
+	// IsTaken reports whether an entity other than exceptID has the slug, deleted ones included
+	IsTaken(ctx context.Context, entity, slug, exceptID string) (bool, error)
+	AddRedirect(ctx context.Context, entity, slug, entityID string) error
+	DeleteRedirect(ctx context.Context, entity, slug string) error
+}
+
+// MediaStore keeps uploaded files, such as the logos of the brands, under keys
+type MediaStore interface {
+	// Put stores the file under the key and returns its url
+	Put(ctx context.Context, key, contentType string, body io.Reader) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type TransitionRepo interface {
+	Add(ctx context.Context, transition *Transition) error
+	// GetItems returns the transitions of the entity, newest first
+	GetItems(ctx context.Context, entityType, entityID string, page, limit int64) (*TransitionResult, error)
+}
+
+type ReviewRepo interface {
+	Add(ctx context.Context, review *Review) (*Review, error)
+	GetItemByID(ctx context.Context, reviewID string) (*Review, error)
+	GetItems(ctx context.Context, filterParams FilterReviewsParams) (*ReviewResult, error)
+	UpdateStatus(ctx context.Context, reviewID, status string, updatedAt, version int64) (int64, error)
+	DeleteItemByID(ctx context.Context, reviewID string, version int64) (int64, error)
+	// AddVote records the vote, replacing the one the voter gave the review before, and
+	// counts it on the review
+	AddVote(ctx context.Context, vote *ReviewVote) error
+}
+
+type QuestionRepo interface {
+	Add(ctx context.Context, question *Question) (*Question, error)
+	// GetItemByID returns the question without its answers
+	GetItemByID(ctx context.Context, questionID string) (*Question, error)
+	GetItems(ctx context.Context, filterParams FilterQuestionsParams) (*QuestionResult, error)
+	UpdateStatus(ctx context.Context, questionID, status string, updatedAt, version int64) (int64, error)
+	DeleteItemByID(ctx context.Context, questionID string) (int64, error)
+	// Upvote counts the upvote of the voter on the question, unless they upvoted it before
+	Upvote(ctx context.Context, questionID, voterID string, createdAt int64) error
+	// AddAnswer records the answer and counts it on its question
+	AddAnswer(ctx context.Context, answer *Answer) (*Answer, error)
+	GetAnswer(ctx context.Context, answerID string) (*Answer, error)
+	// GetAnswers returns the answers to the questions, the most upvoted first
+	GetAnswers(ctx context.Context, questionIDs []string) ([]Answer, error)
+	// DeleteAnswer removes the answer and takes it out of the count of its question
+	DeleteAnswer(ctx context.Context, answer *Answer) (int64, error)
+	// UpvoteAnswer counts the upvote of the voter on the answer, unless they upvoted it before
+	UpvoteAnswer(ctx context.Context, answerID, voterID string, createdAt int64) error
+}
+
+type AuditRepo interface {
+	Add(ctx context.Context, entry *AuditEntry) error
+	GetItems(ctx context.Context, filterParams FilterAuditParams) (*AuditResult, error)
 }
 
 type Service interface {
@@ -48,25 +222,125 @@ type Service interface {
 
 	AddBrand(ctx context.Context, brand *Brand) (*Brand, error)
 	GetBrand(ctx context.Context, brandID string) (*Brand, error)
-	GetBrands(ctx context.Context, page, limit int64) (*BrandResult, error)
+	GetBrands(ctx context.Context, page, limit int64, includeDeleted bool) (*BrandResult, error)
 	UpdateBrand(ctx context.Context, brandID string, brand *Brand) error
+	PatchBrand(ctx context.Context, brandID string, patch *BrandPatch) (*Brand, error)
 	DeleteBrand(ctx context.Context, brandID string) error
+	RestoreBrand(ctx context.Context, brandID string) error
+	TransitionBrand(ctx context.Context, brandID, status, reason string, version int64) (*Brand, error)
+	GetBrandPage(ctx context.Context, brandID string, topLimit int64) (*BrandPage, error)
+	SetBrandLogo(ctx context.Context, brandID, contentType string, body io.Reader) (*Brand, error)
+	DeleteBrandLogo(ctx context.Context, brandID string) (*Brand, error)
 
 	AddCategory(ctx context.Context, ctgry *Category) (*Category, error)
 	GetCategory(ctx context.Context, ctgryID string) (*Category, error)
-	GetCategories(ctx context.Context, page, limit int64) (*CategoryResult, error)
+	GetCategories(ctx context.Context, page, limit int64, includeDeleted bool) (*CategoryResult, error)
+	GetCategoriesByIDs(ctx context.Context, ctgryIDs []string) ([]Category, error)
+	GetChildCategories(ctx context.Context, parentIDs []string) ([]Category, error)
+	GetCategoryTree(ctx context.Context) ([]*CategoryNode, error)
+	GetCategorySubtree(ctx context.Context, ctgryID string, depth int) (*CategoryNode, error)
+	GetCategoryAncestors(ctx context.Context, ctgryID string) ([]Category, error)
+	MoveCategory(ctx context.Context, ctgryID, parentID string, version int64) (*Category, error)
+	ReorderCategories(ctx context.Context, parentID string, ctgryIDs []string) ([]Category, error)
 	UpdateCategory(ctx context.Context, ctgryID string, ctgry *Category) error
+	PatchCategory(ctx context.Context, ctgryID string, patch *CategoryPatch) (*Category, error)
 	DeleteCategory(ctx context.Context, ctgryID string) error
+	RestoreCategory(ctx context.Context, ctgryID string) error
+	TransitionCategory(ctx context.Context, ctgryID, status, reason string, version int64) (*Category, error)
 
 	AddSupplier(ctx context.Context, spplr *Supplier) (*Supplier, error)
 	GetSupplier(ctx context.Context, spplrID string) (*Supplier, error)
-	GetSuppliers(ctx context.Context, page, limit int64) (*SupplierResult, error)
+	GetSuppliers(ctx context.Context, page, limit int64, includeDeleted bool) (*SupplierResult, error)
 	UpdateSupplier(ctx context.Context, spplrID string, spplr *Supplier) error
+	PatchSupplier(ctx context.Context, spplrID string, patch *SupplierPatch) (*Supplier, error)
 	DeleteSupplier(ctx context.Context, spplrID string) error
+	RestoreSupplier(ctx context.Context, spplrID string) error
+	TransitionSupplier(ctx context.Context, spplrID, status, reason string, version int64) (*Supplier, error)
 
 	AddProduct(ctx context.Context, product *Product) (*Product, error)
 	GetProduct(ctx context.Context, productID string) (*Product, error)
 	GetProducts(ctx context.Context, filterParams FilterProductsParams) (*ProductResult, error)
 	UpdateProduct(ctx context.Context, productID string, product *Product) error
+	PatchProduct(ctx context.Context, productID string, patch *ProductPatch) (*Product, error)
 	DeleteProduct(ctx context.Context, productID string) error
+	RestoreProduct(ctx context.Context, productID string) error
+	TransitionProduct(ctx context.Context, productID, status, reason string, version int64) (*Product, error)
+	// GetPublishedProduct returns the product unless its schedule hides it at now
+	GetPublishedProduct(ctx context.Context, productID string, now int64) (*Product, error)
+	GetScheduledChanges(ctx context.Context, from, to, page, limit int64) (*ScheduleResult, error)
+	RunSchedule(ctx context.Context, now int64) (int, error)
+
+	GetTransitions(ctx context.Context, entityType, entityID string, page, limit int64) (*TransitionResult, error)
+
+	AddReview(ctx context.Context, review *Review) (*Review, error)
+	GetReview(ctx context.Context, reviewID string) (*Review, error)
+	GetReviews(ctx context.Context, filterParams FilterReviewsParams) (*ReviewResult, error)
+	ModerateReview(ctx context.Context, reviewID, status, reason string, version int64) (*Review, error)
+	VoteReview(ctx context.Context, vote *ReviewVote) (*Review, error)
+	DeleteReview(ctx context.Context, reviewID string, version int64) error
+
+	AskQuestion(ctx context.Context, question *Question) (*Question, error)
+	GetQuestion(ctx context.Context, productID, questionID string) (*Question, error)
+	GetQuestions(ctx context.Context, filterParams FilterQuestionsParams) (*QuestionResult, error)
+	ModerateQuestion(ctx context.Context, productID, questionID, status, reason string, version int64) (*Question, error)
+	DeleteQuestion(ctx context.Context, productID, questionID string) error
+	UpvoteQuestion(ctx context.Context, productID, questionID, voterID string) (*Question, error)
+	AnswerQuestion(ctx context.Context, productID string, answer *Answer) (*Answer, error)
+	DeleteAnswer(ctx context.Context, productID, questionID, answerID string) error
+	UpvoteAnswer(ctx context.Context, productID, questionID, answerID, voterID string) (*Answer, error)
+
+	BulkBrands(ctx context.Context, mode string, ops []BulkBrandOp) (*BulkResult, error)
+	BulkCategories(ctx context.Context, mode string, ops []BulkCategoryOp) (*BulkResult, error)
+	BulkSuppliers(ctx context.Context, mode string, ops []BulkSupplierOp) (*BulkResult, error)
+	BulkProducts(ctx context.Context, mode string, ops []BulkProductOp) (*BulkResult, error)
+
+	AdjustStock(ctx context.Context, movement *StockMovement) (*ProductStock, error)
+	GetSalesReport(ctx context.Context, supplierID string, from, to int64) ([]SalesReportItem, error)
+	GetLowStockReport(ctx context.Context, supplierID string, threshold int64) ([]LowStockItem, error)
+
+	ValidateImport(ctx context.Context, rows []ImportRow) (*ImportReport, error)
+	CreateImportJob(ctx context.Context, fileName, format string, totalRows int64) (*ImportJob, error)
+	GetImportJob(ctx context.Context, jobID string) (*ImportJob, error)
+	RunImport(ctx context.Context, jobID string, rows []ImportRow) (*ImportJob, error)
+	FailImportJob(ctx context.Context, jobID string, cause error) error
+
+	ExportProducts(ctx context.Context, w io.Writer, format string, columns []string, filterParams FilterProductsParams) (int64, error)
+	GenerateFeed(ctx context.Context, w io.Writer, format string, channel feed.Channel) (int64, error)
+
+	StartImport(ctx context.Context, fileName, format string, rows []ImportRow) (*ImportJob, error)
+	EnqueueJob(ctx context.Context, jobType string, payload interface{}, opts JobOptions) (*Job, error)
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+	RequeueJob(ctx context.Context, jobID string) (*Job, error)
+
+	AddWebhookSubscription(ctx context.Context, sub *WebhookSubscription) (*WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, subID string) (*WebhookSubscription, error)
+	GetWebhookSubscriptions(ctx context.Context, page, limit int64) (*WebhookSubscriptionResult, error)
+	UpdateWebhookSubscription(ctx context.Context, subID string, sub *WebhookSubscription) (*WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, subID string) error
+	GetWebhookDeliveries(ctx context.Context, subID string, page, limit int64) (*WebhookDeliveryResult, error)
+	RedeliverWebhook(ctx context.Context, subID, deliveryID string) (*WebhookDelivery, error)
+	DispatchWebhooks(ctx context.Context, event *Event) error
+	DeliverWebhook(ctx context.Context, deliveryID string, final bool) error
+	FailWebhookDelivery(ctx context.Context, deliveryID string, cause error) error
+
+	PurgeDeleted(ctx context.Context, deletedBefore int64) (map[string]int64, error)
+
+	ResolveSlug(ctx context.Context, entity, slug string) (string, error)
+	ResolvePath(ctx context.Context, path string, now int64) (*PathResolution, error)
+
+	GetAuditLogs(ctx context.Context, filterParams FilterAuditParams) (*AuditResult, error)
+}
+
+type SupplierPortal interface {
+	GetProducts(ctx context.Context, supplierID string, filterParams FilterProductsParams) (*ProductResult, error)
+	GetProduct(ctx context.Context, supplierID, productID string) (*Product, error)
+	AddProduct(ctx context.Context, supplierID string, product *Product) (*Product, error)
+	UpdateProduct(ctx context.Context, supplierID, productID string, product *Product) error
+	ArchiveProduct(ctx context.Context, supplierID, productID string) error
+	TransitionProduct(ctx context.Context, supplierID, productID, status, reason string, version int64) (*Product, error)
+	AdjustStock(ctx context.Context, supplierID string, movement *StockMovement) (*ProductStock, error)
+	GetSalesReport(ctx context.Context, supplierID string, from, to int64) ([]SalesReportItem, error)
+	GetLowStockReport(ctx context.Context, supplierID string, threshold int64) ([]LowStockItem, error)
+	GetQuestions(ctx context.Context, supplierID, productID string, filterParams FilterQuestionsParams) (*QuestionResult, error)
+	AnswerQuestion(ctx context.Context, supplierID, productID string, answer *Answer) (*Answer, error)
 }
@@ -3,17 +3,53 @@ package service
 type Product struct {
 	ID             string       `json:"id"`
 	Name           string       `json:"name"`
+	Slug           string       `json:"slug"`
+	SEO            SEO          `json:"seo"`
 	Description    string       `json:"description"`
 	Specifications string       `json:"specifications"`
-	Brand          Brand        `json:"brand"`
-	Category       Category     `json:"category"`
-	Supplier       Supplier     `json:"supplier"`
-	UnitPrice      float64      `json:"unit_price"`
-	DiscountPrice  float64      `json:"discount_price"`
-	Tags           []string     `json:"tags"`
-	StatusID       int          `json:"status_id"`
-	CreatedAt      int64        `json:"created_at"`
-	ProductStock   ProductStock `json:"product_stock"`
+	Translations   Translations `json:"translations"`
+	// Locale is the language the name and description are in, set by Localize
+	Locale        string   `json:"locale,omitempty"`
+	Brand         Brand    `json:"brand"`
+	Category      Category `json:"category"`
+	Supplier      Supplier `json:"supplier"`
+	UnitPrice     float64  `json:"unit_price"`
+	DiscountPrice float64  `json:"discount_price"`
+	Tags          []string `json:"tags"`
+	Status        string   `json:"status"`
+	// PublishAt and UnpublishAt are the unix milliseconds the product is scheduled to go live
+	// and to be taken off sale at, zero when it is not
+	PublishAt   int64 `json:"publish_at,omitempty"`
+	UnpublishAt int64 `json:"unpublish_at,omitempty"`
+	// RatingAverage and RatingCount are the average rating and the number of the approved
+	// reviews of the product
+	RatingAverage float64      `json:"rating_average"`
+	RatingCount   int64        `json:"rating_count"`
+	CreatedAt     int64        `json:"created_at"`
+	DeletedAt     int64        `json:"deleted_at,omitempty"`
+	Version       int64        `json:"version"`
+	ProductStock  ProductStock `json:"product_stock"`
+}
+
+// ProductPatch is a partial update of a product, nil fields are left untouched
+type ProductPatch struct {
+	Name           *string
+	Slug           *string
+	SEO            SEOPatch
+	Description    *string
+	Specifications *string
+	Translations   *Translations
+	BrandID        *string
+	CategoryID     *string
+	SupplierID     *string
+	UnitPrice      *float64
+	DiscountPrice  *float64
+	Tags           *[]string
+	Status         *string
+	// a zero time removes the schedule
+	PublishAt   *int64
+	UnpublishAt *int64
+	Version     int64
 }
 
 type ProductStock struct {
@@ -23,6 +59,12 @@ type ProductStock struct {
 	UpdatedAt     int64  `json:"updated_at"`
 }
 
+// product sort orders, by price, lowest first, unless told otherwise
+const (
+	ProductSortPrice  = "price"
+	ProductSortRating = "rating"
+)
+
 type FilterProductsParams struct {
 	Name               string   `json:"name"`
 	MaxPrice           float64  `json:"max_price"`
@@ -31,8 +73,15 @@ type FilterProductsParams struct {
 	CategoryID         string   `json:"category_id"`
 	SupplierID         string   `json:"supplier_id"`
 	IsVerifiedSupplier bool     `json:"is_verified_supplier"`
-	Page               int64    `json:"page"`
-	Limit              int64    `json:"limit"`
+	// Statuses are the statuses of the products to list, the live ones of live brands,
+	// categories and suppliers, whose schedule lets them be shown, when empty
+	Statuses []string `json:"statuses"`
+	// MinRating leaves out the unrated products and those rated lower on average
+	MinRating      float64 `json:"min_rating"`
+	Sort           string  `json:"sort"`
+	IncludeDeleted bool    `json:"include_deleted"`
+	Page           int64   `json:"page"`
+	Limit          int64   `json:"limit"`
 }
 
 type ProductResult struct {
@@ -41,3 +90,26 @@ type ProductResult struct {
 	Page     int64     `json:"page"`
 	Limit    int64     `json:"limit"`
 }
+
+type StockMovement struct {
+	ID        string  `json:"id"`
+	ProductID string  `json:"product_id"`
+	Quantity  int64   `json:"quantity"`
+	Reason    string  `json:"reason"`
+	UnitPrice float64 `json:"unit_price"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+type SalesReportItem struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	UnitsSold int64   `json:"units_sold"`
+	Revenue   float64 `json:"revenue"`
+}
+
+type LowStockItem struct {
+	ProductID     string `json:"product_id"`
+	Name          string `json:"name"`
+	StockQuantity int64  `json:"stock_quantity"`
+	UpdatedAt     int64  `json:"updated_at"`
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// question sort orders
+const (
+	QuestionSortNewest  = "newest"
+	QuestionSortUpvotes = "upvotes"
+)
+
+// answer author types, the supplier of the product or the catalog team
+const (
+	AnswerAuthorSupplier = "supplier"
+	AnswerAuthorStaff    = "staff"
+)
+
+// Question is a question of a customer on a product, shown along with its answers once it
+// is approved
+type Question struct {
+	ID          string   `json:"id"`
+	ProductID   string   `json:"product_id"`
+	CustomerID  string   `json:"customer_id"`
+	AuthorName  string   `json:"author_name"`
+	Body        string   `json:"body"`
+	Status      string   `json:"status"`
+	UpvoteCount int64    `json:"upvote_count"`
+	AnswerCount int64    `json:"answer_count"`
+	Answers     []Answer `json:"answers"`
+	CreatedAt   int64    `json:"created_at"`
+	UpdatedAt   int64    `json:"updated_at"`
+	Version     int64    `json:"version"`
+}
+
+// Answer is an answer to a question by the supplier of the product or the catalog team,
+// AuthorID being the supplier or the actor who answered
+type Answer struct {
+	ID          string `json:"id"`
+	QuestionID  string `json:"question_id"`
+	AuthorType  string `json:"author_type"`
+	AuthorID    string `json:"author_id"`
+	AuthorName  string `json:"author_name"`
+	Body        string `json:"body"`
+	UpvoteCount int64  `json:"upvote_count"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// QuestionAnswer is the data of a QuestionAnswered event, for the customer who asked to be
+// told about the answer
+type QuestionAnswer struct {
+	QuestionID string `json:"question_id"`
+	ProductID  string `json:"product_id"`
+	CustomerID string `json:"customer_id"`
+	Question   string `json:"question"`
+	Answer     Answer `json:"answer"`
+}
+
+type FilterQuestionsParams struct {
+	ProductID string `json:"product_id"`
+	// Statuses are the statuses of the questions to list, every status when empty
+	Statuses []string `json:"statuses"`
+	// Answered lists the answered questions only when true, the unanswered ones when false
+	Answered *bool  `json:"answered"`
+	Sort     string `json:"sort"`
+	Page     int64  `json:"page"`
+	Limit    int64  `json:"limit"`
+}
+
+type QuestionResult struct {
+	Questions []Question `json:"questions"`
+	Total     int64      `json:"total"`
+	Page      int64      `json:"page"`
+	Limit     int64      `json:"limit"`
+}
+
+// AskQuestion records a question on a product, pending until it is moderated
+func (s *service) AskQuestion(ctx context.Context, question *Question) (*Question, error) {
+	var newQuestion *Question
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.productRepo.GetItemByID(ctx, question.ProductID); err != nil {
+			return err
+		}
+
+		now := util.GetCurrentTimestamp()
+		question.Status = ModerationStatusPending
+		question.CreatedAt = now
+		question.UpdatedAt = now
+
+		var err error
+		newQuestion, err = s.questionRepo.Add(ctx, question)
+		if err != nil {
+			return err
+		}
+
+		newQuestion.Answers = []Answer{}
+
+		if err := s.statusChanged(ctx, AuditEntityQuestion, newQuestion.ID, "", newQuestion.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityQuestion, newQuestion.ID, AuditActionCreate, nil, newQuestion)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newQuestion, nil
+}
+
+// GetQuestion returns the question of the product along with its answers
+func (s *service) GetQuestion(ctx context.Context, productID, questionID string) (*Question, error) {
+	question, err := s.productQuestion(ctx, productID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	answers, err := s.questionRepo.GetAnswers(ctx, []string{questionID})
+	if err != nil {
+		return nil, err
+	}
+
+	question.Answers = answers
+
+	return question, nil
+}
+
+// GetQuestions returns the questions along with their answers
+func (s *service) GetQuestions(ctx context.Context, filterParams FilterQuestionsParams) (*QuestionResult, error) {
+	result, err := s.questionRepo.GetItems(ctx, filterParams)
+	if err != nil {
+		return nil, err
+	}
+
+	questionIDs := make([]string, len(result.Questions))
+	for i := range result.Questions {
+		questionIDs[i] = result.Questions[i].ID
+	}
+
+	answers, err := s.questionRepo.GetAnswers(ctx, questionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byQuestion := make(map[string][]Answer)
+	for _, answer := range answers {
+		byQuestion[answer.QuestionID] = append(byQuestion[answer.QuestionID], answer)
+	}
+
+	for i := range result.Questions {
+		question := &result.Questions[i]
+
+		question.Answers = byQuestion[question.ID]
+		if question.Answers == nil {
+			question.Answers = []Answer{}
+		}
+	}
+
+	return result, nil
+}
+
+// ModerateQuestion approves or rejects the question of the product, version being the one
+// the change is based on
+func (s *service) ModerateQuestion(ctx context.Context, productID, questionID, status, reason string, version int64) (*Question, error) {
+	var after *Question
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.productQuestion(ctx, productID, questionID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkTransition(AuditEntityQuestion, before.Status, status); err != nil {
+			return err
+		}
+
+		affected, err := s.questionRepo.UpdateStatus(ctx, questionID, status, util.GetCurrentTimestamp(), version)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the moderation was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err = s.questionRepo.GetItemByID(ctx, questionID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityQuestion, questionID, before.Status, after.Status, reason); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityQuestion, questionID, AuditActionUpdate, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetQuestion(ctx, productID, after.ID)
+}
+
+// DeleteQuestion removes the question of the product along with its answers and upvotes
+func (s *service) DeleteQuestion(ctx context.Context, productID, questionID string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.productQuestion(ctx, productID, questionID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := s.questionRepo.DeleteItemByID(ctx, questionID)
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return ErrQuestionNotFound
+		}
+
+		return s.audit(ctx, AuditEntityQuestion, questionID, AuditActionDelete, before, nil)
+	})
+}
+
+// UpvoteQuestion counts the upvote of the voter on an approved question of the product,
+// once however often they upvote it
+func (s *service) UpvoteQuestion(ctx context.Context, productID, questionID, voterID string) (*Question, error) {
+	question, err := s.productQuestion(ctx, productID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if question.Status != ModerationStatusApproved {
+		return nil, ErrQuestionNotApproved
+	}
+
+	if err := s.questionRepo.Upvote(ctx, questionID, voterID, util.GetCurrentTimestamp()); err != nil {
+		return nil, err
+	}
+
+	return s.GetQuestion(ctx, productID, questionID)
+}
+
+// AnswerQuestion answers an approved question of the product and raises QuestionAnswered
+// for the customer who asked it to be told. a staff answer needs the actor who gives it,
+// an anonymous caller never answers on behalf of the catalog team
+func (s *service) AnswerQuestion(ctx context.Context, productID string, answer *Answer) (*Answer, error) {
+	if answer.AuthorType == AnswerAuthorStaff && (len(answer.AuthorID) == 0 || answer.AuthorID == anonymousActor) {
+		return nil, ErrAnonymousStaffAnswer
+	}
+
+	var newAnswer *Answer
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		question, err := s.productQuestion(ctx, productID, answer.QuestionID)
+		if err != nil {
+			return err
+		}
+
+		if question.Status != ModerationStatusApproved {
+			return ErrQuestionNotApproved
+		}
+
+		answer.CreatedAt = util.GetCurrentTimestamp()
+
+		newAnswer, err = s.questionRepo.AddAnswer(ctx, answer)
+		if err != nil {
+			return err
+		}
+
+		if err := s.audit(ctx, AuditEntityAnswer, newAnswer.ID, AuditActionCreate, nil, newAnswer); err != nil {
+			return err
+		}
+
+		return s.addEvent(ctx, EventQuestionAnswered, AuditEntityQuestion, question.ID, &QuestionAnswer{
+			QuestionID: question.ID,
+			ProductID:  question.ProductID,
+			CustomerID: question.CustomerID,
+			Question:   question.Body,
+			Answer:     *newAnswer,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newAnswer, nil
+}
+
+// DeleteAnswer removes an answer to the question of the product along with its upvotes
+func (s *service) DeleteAnswer(ctx context.Context, productID, questionID, answerID string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.questionAnswer(ctx, productID, questionID, answerID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := s.questionRepo.DeleteAnswer(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return ErrAnswerNotFound
+		}
+
+		return s.audit(ctx, AuditEntityAnswer, answerID, AuditActionDelete, before, nil)
+	})
+}
+
+// UpvoteAnswer counts the upvote of the voter on an answer to the question of the product,
+// once however often they upvote it
+func (s *service) UpvoteAnswer(ctx context.Context, productID, questionID, answerID, voterID string) (*Answer, error) {
+	if _, err := s.questionAnswer(ctx, productID, questionID, answerID); err != nil {
+		return nil, err
+	}
+
+	if err := s.questionRepo.UpvoteAnswer(ctx, answerID, voterID, util.GetCurrentTimestamp()); err != nil {
+		return nil, err
+	}
+
+	return s.questionRepo.GetAnswer(ctx, answerID)
+}
+
+// productQuestion returns the question when it is one of the product, a question of
+// another product is reported as not found
+func (s *service) productQuestion(ctx context.Context, productID, questionID string) (*Question, error) {
+	question, err := s.questionRepo.GetItemByID(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if question.ProductID != productID {
+		return nil, ErrQuestionNotFound
+	}
+
+	return question, nil
+}
+
+// questionAnswer returns the answer when it is one to the question of the product
+func (s *service) questionAnswer(ctx context.Context, productID, questionID, answerID string) (*Answer, error) {
+	if _, err := s.productQuestion(ctx, productID, questionID); err != nil {
+		return nil, err
+	}
+
+	answer, err := s.questionRepo.GetAnswer(ctx, answerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if answer.QuestionID != questionID {
+		return nil, ErrAnswerNotFound
+	}
+
+	return answer, nil
+}
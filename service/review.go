@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// review sort orders
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortHelpful = "helpful"
+	ReviewSortHighest = "highest"
+	ReviewSortLowest  = "lowest"
+)
+
+// Review is the rating and opinion of a customer on a product, VerifiedPurchase telling
+// the customer bought it
+type Review struct {
+	ID               string `json:"id"`
+	ProductID        string `json:"product_id"`
+	CustomerID       string `json:"customer_id"`
+	AuthorName       string `json:"author_name"`
+	Rating           int64  `json:"rating"`
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	VerifiedPurchase bool   `json:"verified_purchase"`
+	Status           string `json:"status"`
+	HelpfulCount     int64  `json:"helpful_count"`
+	UnhelpfulCount   int64  `json:"unhelpful_count"`
+	CreatedAt        int64  `json:"created_at"`
+	UpdatedAt        int64  `json:"updated_at"`
+	Version          int64  `json:"version"`
+}
+
+// ReviewVote is whether a customer found a review helpful, a customer votes once on a
+// review and may change their mind
+type ReviewVote struct {
+	ReviewID  string `json:"review_id"`
+	VoterID   string `json:"voter_id"`
+	Helpful   bool   `json:"helpful"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type FilterReviewsParams struct {
+	ProductID string `json:"product_id"`
+	// Statuses are the statuses of the reviews to list, every status when empty
+	Statuses         []string `json:"statuses"`
+	Rating           int64    `json:"rating"`
+	VerifiedPurchase bool     `json:"verified_purchase"`
+	Sort             string   `json:"sort"`
+	Page             int64    `json:"page"`
+	Limit            int64    `json:"limit"`
+}
+
+type ReviewResult struct {
+	Reviews []Review `json:"reviews"`
+	Total   int64    `json:"total"`
+	Page    int64    `json:"page"`
+	Limit   int64    `json:"limit"`
+}
+
+// AddReview records a review of a product, pending until it is moderated
+func (s *service) AddReview(ctx context.Context, review *Review) (*Review, error) {
+	var newReview *Review
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.productRepo.GetItemByID(ctx, review.ProductID); err != nil {
+			return err
+		}
+
+		now := util.GetCurrentTimestamp()
+		review.Status = ModerationStatusPending
+		review.CreatedAt = now
+		review.UpdatedAt = now
+
+		var err error
+		newReview, err = s.reviewRepo.Add(ctx, review)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityReview, newReview.ID, "", newReview.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityReview, newReview.ID, AuditActionCreate, nil, newReview)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newReview, nil
+}
+
+func (s *service) GetReview(ctx context.Context, reviewID string) (*Review, error) {
+	review, err := s.reviewRepo.GetItemByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (s *service) GetReviews(ctx context.Context, filterParams FilterReviewsParams) (*ReviewResult, error) {
+	result, err := s.reviewRepo.GetItems(ctx, filterParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ModerateReview approves or rejects the review, version being the one the change is
+// based on. the rating of its product counts the approved reviews only
+func (s *service) ModerateReview(ctx context.Context, reviewID, status, reason string, version int64) (*Review, error) {
+	var after *Review
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.reviewRepo.GetItemByID(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkTransition(AuditEntityReview, before.Status, status); err != nil {
+			return err
+		}
+
+		affected, err := s.reviewRepo.UpdateStatus(ctx, reviewID, status, util.GetCurrentTimestamp(), version)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the moderation was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		switch {
+		case status == ModerationStatusApproved:
+			err = s.productRepo.AdjustRating(ctx, before.ProductID, 1, before.Rating)
+		case before.Status == ModerationStatusApproved:
+			err = s.productRepo.AdjustRating(ctx, before.ProductID, -1, -before.Rating)
+		}
+		if err != nil {
+			return err
+		}
+
+		after, err = s.reviewRepo.GetItemByID(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityReview, reviewID, before.Status, after.Status, reason); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityReview, reviewID, AuditActionUpdate, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// VoteReview records whether the voter found an approved review helpful, replacing the
+// vote they gave it before
+func (s *service) VoteReview(ctx context.Context, vote *ReviewVote) (*Review, error) {
+	var after *Review
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		review, err := s.reviewRepo.GetItemByID(ctx, vote.ReviewID)
+		if err != nil {
+			return err
+		}
+
+		if review.Status != ModerationStatusApproved {
+			return ErrReviewNotApproved
+		}
+
+		if review.CustomerID == vote.VoterID {
+			return ErrOwnReviewVote
+		}
+
+		vote.CreatedAt = util.GetCurrentTimestamp()
+
+		if err := s.reviewRepo.AddVote(ctx, vote); err != nil {
+			return err
+		}
+
+		after, err = s.reviewRepo.GetItemByID(ctx, vote.ReviewID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// DeleteReview removes the review along with its votes, taking it out of the rating of
+// its product. version is the one the deletion is based on, so a review moderated in the
+// meantime is not deleted unseen
+func (s *service) DeleteReview(ctx context.Context, reviewID string, version int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.reviewRepo.GetItemByID(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := s.reviewRepo.DeleteItemByID(ctx, reviewID, version)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the deletion was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		if before.Status == ModerationStatusApproved {
+			if err := s.productRepo.AdjustRating(ctx, before.ProductID, -1, -before.Rating); err != nil {
+				return err
+			}
+		}
+
+		return s.audit(ctx, AuditEntityReview, reviewID, AuditActionDelete, before, nil)
+	})
+}
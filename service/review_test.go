@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// memoryReviews keeps the reviews in memory, checking versions the way the repo does
+type memoryReviews struct {
+	ReviewRepo
+	reviews map[string]Review
+}
+
+func (m *memoryReviews) GetItemByID(ctx context.Context, reviewID string) (*Review, error) {
+	review, ok := m.reviews[reviewID]
+	if !ok {
+		return nil, ErrReviewNotFound
+	}
+
+	return &review, nil
+}
+
+func (m *memoryReviews) UpdateStatus(ctx context.Context, reviewID, status string, updatedAt, version int64) (int64, error) {
+	review, ok := m.reviews[reviewID]
+	if !ok || review.Version != version {
+		return 0, nil
+	}
+
+	review.Status = status
+	review.UpdatedAt = updatedAt
+	review.Version++
+	m.reviews[reviewID] = review
+
+	return 1, nil
+}
+
+func (m *memoryReviews) DeleteItemByID(ctx context.Context, reviewID string, version int64) (int64, error) {
+	review, ok := m.reviews[reviewID]
+	if !ok || review.Version != version {
+		return 0, nil
+	}
+
+	delete(m.reviews, reviewID)
+
+	return 1, nil
+}
+
+// memoryRatings keeps the rating of every product in memory
+type memoryRatings struct {
+	ProductRepo
+	counts map[string]int64
+	sums   map[string]int64
+}
+
+func (m *memoryRatings) AdjustRating(ctx context.Context, productID string, count, sum int64) error {
+	m.counts[productID] += count
+	m.sums[productID] += sum
+	return nil
+}
+
+func TestReviewsCountInTheRatingWhileApproved(t *testing.T) {
+	reviews := &memoryReviews{reviews: map[string]Review{
+		"review-1": {ID: "review-1", ProductID: "phone", Rating: 5, Status: ModerationStatusPending, Version: 1},
+		"review-2": {ID: "review-2", ProductID: "phone", Rating: 2, Status: ModerationStatusPending, Version: 1},
+	}}
+	ratings := &memoryRatings{counts: map[string]int64{}, sums: map[string]int64{}}
+	audit := &memoryAudit{}
+	svc := &service{
+		reviewRepo:     reviews,
+		productRepo:    ratings,
+		transitionRepo: &memoryTransitions{},
+		auditRepo:      audit,
+		outboxRepo:     memoryOutbox{audit: audit},
+		tx:             noTx{},
+	}
+
+	ctx := WithActor(context.Background(), "moderator")
+
+	checkRating := func(count, sum int64) {
+		t.Helper()
+
+		if ratings.counts["phone"] != count || ratings.sums["phone"] != sum {
+			t.Errorf("rating counts %d reviews summing to %d, want %d summing to %d", ratings.counts["phone"], ratings.sums["phone"], count, sum)
+		}
+	}
+
+	if _, err := svc.ModerateReview(ctx, "review-1", ModerationStatusApproved, "", 1); err != nil {
+		t.Fatalf("ModerateReview: %v", err)
+	}
+
+	if _, err := svc.ModerateReview(ctx, "review-2", ModerationStatusApproved, "", 1); err != nil {
+		t.Fatalf("ModerateReview: %v", err)
+	}
+
+	checkRating(2, 7)
+
+	// a rejected review no longer counts
+	if _, err := svc.ModerateReview(ctx, "review-2", ModerationStatusRejected, "spam", 2); err != nil {
+		t.Fatalf("ModerateReview: %v", err)
+	}
+
+	checkRating(1, 5)
+
+	// nor does a deleted one, and a rejected one is deleted without touching the rating
+	if err := svc.DeleteReview(ctx, "review-2", 3); err != nil {
+		t.Fatalf("DeleteReview: %v", err)
+	}
+
+	checkRating(1, 5)
+
+	if err := svc.DeleteReview(ctx, "review-1", 1); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("DeleteReview of a stale version = %v, want %v", err, ErrVersionConflict)
+	}
+
+	checkRating(1, 5)
+
+	if err := svc.DeleteReview(ctx, "review-1", 2); err != nil {
+		t.Fatalf("DeleteReview: %v", err)
+	}
+
+	checkRating(0, 0)
+
+	if err := svc.DeleteReview(ctx, "review-1", 2); !errors.Is(err, ErrReviewNotFound) {
+		t.Errorf("DeleteReview of a deleted review = %v, want %v", err, ErrReviewNotFound)
+	}
+}
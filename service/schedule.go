@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/logger"
+)
+
+// scheduled actions of a product
+const (
+	ScheduleActionPublish   = "publish"
+	ScheduleActionUnpublish = "unpublish"
+)
+
+// scheduleBatchSize is the number of due products the scheduler takes at a time
+const scheduleBatchSize = 100
+
+// ScheduledChange is a publish or unpublish of a product at the time it was scheduled for,
+// Status being the status of the product. it is the data of the ProductPublished and
+// ProductUnpublished events too
+type ScheduledChange struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Action      string `json:"action"`
+	ScheduledAt int64  `json:"scheduled_at"`
+	Status      string `json:"status"`
+}
+
+type ScheduleResult struct {
+	Changes []ScheduledChange `json:"changes"`
+	Total   int64             `json:"total"`
+	Page    int64             `json:"page"`
+	Limit   int64             `json:"limit"`
+}
+
+// Published reports whether the schedule of the product lets it be shown at now, it is
+// when its publish time, if any, passed and its unpublish time, if any, did not
+func (p *Product) Published(now int64) bool {
+	return (p.PublishAt == 0 || p.PublishAt <= now) && (p.UnpublishAt == 0 || p.UnpublishAt > now)
+}
+
+// Live reports whether the storefront may show the product at now, its status being a
+// live one and its schedule letting it be shown
+func (p *Product) Live(now int64) bool {
+	return IsLiveProductStatus(p.Status) && p.Published(now)
+}
+
+// validateSchedule checks that a product is unpublished after it is published, zero
+// times being none
+func validateSchedule(publishAt, unpublishAt int64) error {
+	if publishAt < 0 {
+		return &ValidationError{Field: "publish_at", Message: "must not be negative"}
+	}
+
+	if unpublishAt < 0 {
+		return &ValidationError{Field: "unpublish_at", Message: "must not be negative"}
+	}
+
+	if publishAt > 0 && unpublishAt > 0 && unpublishAt <= publishAt {
+		return &ValidationError{Field: "unpublish_at", Message: "must be after publish_at"}
+	}
+
+	return nil
+}
+
+// validatePublishable checks that a product scheduled to be published can be activated by
+// then, a draft being reviewed first. the scheduler would drop the publish otherwise
+func validatePublishable(status string, publishAt int64) error {
+	if publishAt == 0 || IsLiveProductStatus(status) {
+		return nil
+	}
+
+	if err := checkTransition(AuditEntityProduct, status, ProductStatusActive); err != nil {
+		return &ValidationError{Field: "publish_at", Message: fmt.Sprintf("can not be set on a %s product, which can not be activated", status)}
+	}
+
+	return nil
+}
+
+// patchedSchedule returns the publish and unpublish times of the product after the patch
+func patchedSchedule(before *Product, patch *ProductPatch) (int64, int64) {
+	publishAt, unpublishAt := before.PublishAt, before.UnpublishAt
+
+	if patch.PublishAt != nil {
+		publishAt = *patch.PublishAt
+	}
+
+	if patch.UnpublishAt != nil {
+		unpublishAt = *patch.UnpublishAt
+	}
+
+	return publishAt, unpublishAt
+}
+
+// GetPublishedProduct returns the product unless its status or schedule hides it at now,
+// for the storefront. a draft or archived product is not found, like a missing one
+func (s *service) GetPublishedProduct(ctx context.Context, productID string, now int64) (*Product, error) {
+	product, err := s.productRepo.GetItemByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !product.Live(now) {
+		return nil, ErrProductNotFound
+	}
+
+	return product, nil
+}
+
+// GetScheduledChanges returns the publishes and unpublishes scheduled between from and to,
+// to being unbounded when zero, soonest first
+func (s *service) GetScheduledChanges(ctx context.Context, from, to, page, limit int64) (*ScheduleResult, error) {
+	result, err := s.productRepo.GetScheduledChanges(ctx, from, to, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RunSchedule publishes and unpublishes the products whose time came by now and returns
+// how many it changed. a product which fails is left to the next run
+func (s *service) RunSchedule(ctx context.Context, now int64) (int, error) {
+	var changed int
+	var firstErr error
+
+	failed := make(map[string]bool)
+
+	for {
+		productIDs, err := s.productRepo.GetDueScheduledIDs(ctx, now, scheduleBatchSize)
+		if err != nil {
+			return changed, err
+		}
+
+		var progressed bool
+		for _, productID := range productIDs {
+			if failed[productID] {
+				continue
+			}
+
+			if err := s.runProductSchedule(ctx, productID, now); err != nil {
+				logger.Error(ctx, fmt.Sprintf("cannot run the schedule of product %s", productID), err)
+				failed[productID] = true
+
+				if firstErr == nil {
+					firstErr = err
+				}
+
+				continue
+			}
+
+			changed++
+			progressed = true
+		}
+
+		// the rest are due no more, or failed already
+		if len(productIDs) < scheduleBatchSize || !progressed {
+			return changed, firstErr
+		}
+	}
+}
+
+// runProductSchedule publishes or unpublishes, or both, a product whose time came, and
+// clears the times it ran for. publishing activates a product which can be activated,
+// unpublishing discontinues a live one
+func (s *service) runProductSchedule(ctx context.Context, productID string, now int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.productRepo.GetItemByID(ctx, productID)
+		if errors.Is(err, ErrProductNotFound) {
+			// deleted meanwhile
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var none int64
+		patch := &ProductPatch{Version: before.Version}
+
+		var actions []string
+
+		// the status after the publish, and after the unpublish
+		published := before.Status
+
+		if before.PublishAt > 0 && before.PublishAt <= now {
+			patch.PublishAt = &none
+
+			if published != ProductStatusActive && published != ProductStatusOutOfStock {
+				err := s.checkProductStatus(ctx, published, ProductStatusActive, before)
+				switch apperr.KindOf(err) {
+				case apperr.KindValidation, apperr.KindUnprocessable, apperr.KindNotFound, apperr.KindConflict:
+					// the schedule is dropped rather than run again on every tick, e.g. of a
+					// product sent back to draft after it was scheduled
+					logger.Warn(ctx, fmt.Sprintf("cannot publish scheduled product %s", productID), err)
+				default:
+					if err != nil {
+						return err
+					}
+
+					published = ProductStatusActive
+				}
+			}
+
+			if published == ProductStatusActive || published == ProductStatusOutOfStock {
+				actions = append(actions, ScheduleActionPublish)
+			}
+		}
+
+		status := published
+
+		if before.UnpublishAt > 0 && before.UnpublishAt <= now {
+			patch.UnpublishAt = &none
+
+			if status == ProductStatusActive || status == ProductStatusOutOfStock {
+				status = ProductStatusDiscontinued
+			}
+
+			actions = append(actions, ScheduleActionUnpublish)
+		}
+
+		if status != before.Status {
+			patch.Status = &status
+		}
+
+		affected, err := s.productRepo.PatchItemByID(ctx, productID, patch)
+		if err != nil {
+			return err
+		}
+
+		// changed meanwhile, the next run takes it again
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err := s.productRepo.GetItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityProduct, productID, before.Status, published, "scheduled publish"); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityProduct, productID, published, after.Status, "scheduled unpublish"); err != nil {
+			return err
+		}
+
+		if err := s.audit(ctx, AuditEntityProduct, productID, AuditActionUpdate, before, after); err != nil {
+			return err
+		}
+
+		for _, action := range actions {
+			change := &ScheduledChange{
+				ProductID:   productID,
+				ProductName: after.Name,
+				Action:      action,
+				ScheduledAt: before.PublishAt,
+				Status:      after.Status,
+			}
+
+			eventType := EventProductPublished
+			if action == ScheduleActionUnpublish {
+				change.ScheduledAt = before.UnpublishAt
+				eventType = EventProductUnpublished
+			}
+
+			if err := s.addEvent(ctx, eventType, AuditEntityProduct, productID, change); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+)
+
+// memoryScheduled keeps the products in memory for the scheduler, checking versions the
+// way the repo does
+type memoryScheduled struct {
+	ProductRepo
+	products map[string]Product
+}
+
+func (m *memoryScheduled) GetDueScheduledIDs(ctx context.Context, now int64, limit int) ([]string, error) {
+	var productIDs []string
+	for _, product := range m.products {
+		due := (product.PublishAt > 0 && product.PublishAt <= now) || (product.UnpublishAt > 0 && product.UnpublishAt <= now)
+		if due && len(productIDs) < limit {
+			productIDs = append(productIDs, product.ID)
+		}
+	}
+
+	sort.Strings(productIDs)
+
+	return productIDs, nil
+}
+
+func (m *memoryScheduled) GetItemByID(ctx context.Context, productID string) (*Product, error) {
+	product, ok := m.products[productID]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+
+	return &product, nil
+}
+
+func (m *memoryScheduled) PatchItemByID(ctx context.Context, productID string, patch *ProductPatch) (int64, error) {
+	product, ok := m.products[productID]
+	if !ok || product.Version != patch.Version {
+		return 0, nil
+	}
+
+	if patch.Status != nil {
+		product.Status = *patch.Status
+	}
+
+	if patch.PublishAt != nil {
+		product.PublishAt = *patch.PublishAt
+	}
+
+	if patch.UnpublishAt != nil {
+		product.UnpublishAt = *patch.UnpublishAt
+	}
+
+	product.Version++
+	m.products[productID] = product
+
+	return 1, nil
+}
+
+type memorySuppliers struct {
+	SupplierRepo
+	suppliers map[string]Supplier
+}
+
+func (m *memorySuppliers) GetItemByID(ctx context.Context, spplrID string) (*Supplier, error) {
+	spplr, ok := m.suppliers[spplrID]
+	if !ok {
+		return nil, ErrSupplierNotFound
+	}
+
+	return &spplr, nil
+}
+
+func TestRunSchedule(t *testing.T) {
+	product := func(productID, status string, publishAt, unpublishAt int64) Product {
+		return Product{
+			ID:          productID,
+			Name:        productID,
+			Brand:       Brand{ID: "acme"},
+			Category:    Category{ID: "phones"},
+			Supplier:    Supplier{ID: "supplier"},
+			Status:      status,
+			PublishAt:   publishAt,
+			UnpublishAt: unpublishAt,
+			Version:     1,
+		}
+	}
+
+	products := &memoryScheduled{products: map[string]Product{
+		"reviewed":      product("reviewed", ProductStatusPendingReview, 100, 0),
+		"sold-out":      product("sold-out", ProductStatusOutOfStock, 0, 100),
+		"flash-sale":    product("flash-sale", ProductStatusPendingReview, 50, 100),
+		"next-week":     product("next-week", ProductStatusPendingReview, 500, 0),
+		"sent-back":     product("sent-back", ProductStatusDraft, 100, 0),
+		"not-scheduled": product("not-scheduled", ProductStatusActive, 0, 0),
+	}}
+
+	audit := &memoryAudit{}
+	svc := &service{
+		productRepo:    products,
+		brandRepo:      &memoryBrands{brands: map[string]Brand{"acme": {ID: "acme", Status: StatusActive}}},
+		ctgryRepo:      newMemoryCategories(Category{ID: "phones", Status: StatusActive}),
+		spplrRepo:      &memorySuppliers{suppliers: map[string]Supplier{"supplier": {ID: "supplier", Status: StatusActive}}},
+		transitionRepo: &memoryTransitions{},
+		auditRepo:      audit,
+		outboxRepo:     memoryOutbox{audit: audit},
+		tx:             noTx{},
+	}
+
+	changed, err := svc.RunSchedule(context.Background(), 200)
+	if err != nil {
+		t.Fatalf("RunSchedule: %v", err)
+	}
+
+	if changed != 4 {
+		t.Errorf("changed %d products, want 4", changed)
+	}
+
+	want := map[string]struct {
+		status      string
+		publishAt   int64
+		unpublishAt int64
+	}{
+		"reviewed":      {ProductStatusActive, 0, 0},
+		"sold-out":      {ProductStatusDiscontinued, 0, 0},
+		"flash-sale":    {ProductStatusDiscontinued, 0, 0},
+		"next-week":     {ProductStatusPendingReview, 500, 0},
+		"sent-back":     {ProductStatusDraft, 0, 0},
+		"not-scheduled": {ProductStatusActive, 0, 0},
+	}
+
+	for productID, w := range want {
+		got := products.products[productID]
+		if got.Status != w.status || got.PublishAt != w.publishAt || got.UnpublishAt != w.unpublishAt {
+			t.Errorf("%s is %s, publishing at %d and unpublishing at %d, want %s at %d and %d",
+				productID, got.Status, got.PublishAt, got.UnpublishAt, w.status, w.publishAt, w.unpublishAt)
+		}
+	}
+
+	var published, unpublished int
+	for _, event := range audit.events {
+		switch event.Type {
+		case EventProductPublished:
+			published++
+		case EventProductUnpublished:
+			unpublished++
+		}
+	}
+
+	// the draft sent back from review is neither published nor unpublished
+	if published != 2 || unpublished != 2 {
+		t.Errorf("raised %d publishes and %d unpublishes, want 2 of each", published, unpublished)
+	}
+
+	// nothing is due any more
+	if changed, err := svc.RunSchedule(context.Background(), 200); changed != 0 || err != nil {
+		t.Errorf("RunSchedule again = %d, %v, want nothing changed", changed, err)
+	}
+}
+
+func TestScheduledPublishNeedsReview(t *testing.T) {
+	svc := &service{}
+
+	_, err := svc.AddProduct(context.Background(), &Product{Name: "Phone X", PublishAt: 100})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "publish_at" {
+		t.Fatalf("AddProduct of a draft scheduled to publish = %v, want a publish_at validation error", err)
+	}
+
+	for _, status := range []string{ProductStatusPendingReview, ProductStatusActive, ProductStatusOutOfStock, ProductStatusDiscontinued} {
+		if err := validatePublishable(status, 100); err != nil {
+			t.Errorf("validatePublishable(%s) = %v, want nil", status, err)
+		}
+	}
+
+	if err := validatePublishable(ProductStatusArchived, 100); err == nil {
+		t.Errorf("validatePublishable(%s) = nil, want an error", ProductStatusArchived)
+	}
+}
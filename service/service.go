@@ -2,7 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/logger"
 	"github.com/jsiqbal/ecommerce/util"
 )
 
@@ -10,12 +16,40 @@ const (
 	MAX_INF = 1000000000000000
 )
 
+// logoExtensions are the extensions of the files of the logo image types
+var logoExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// stock movement reasons
+const (
+	StockReasonSale       = "sale"
+	StockReasonRestock    = "restock"
+	StockReasonReturn     = "return"
+	StockReasonAdjustment = "adjustment"
+)
+
 type service struct {
-	brandRepo        BrandRepo
-	ctgryRepo        CategoryRepo
-	spplrRepo        SupplierRepo
-	productRepo      ProductRepo
-	productStockRepo ProductStockRepo
+	brandRepo           BrandRepo
+	ctgryRepo           CategoryRepo
+	spplrRepo           SupplierRepo
+	productRepo         ProductRepo
+	productStockRepo    ProductStockRepo
+	auditRepo           AuditRepo
+	transitionRepo      TransitionRepo
+	importJobRepo       ImportJobRepo
+	jobRepo             JobRepo
+	outboxRepo          OutboxRepo
+	webhookSubRepo      WebhookSubscriptionRepo
+	webhookDeliveryRepo WebhookDeliveryRepo
+	slugRepo            SlugRepo
+	reviewRepo          ReviewRepo
+	questionRepo        QuestionRepo
+	media               MediaStore
+	tx                  Transactor
 }
 
 func NewService(
@@ -23,12 +57,38 @@ func NewService(
 	ctgryRepo CategoryRepo,
 	spplrRepo SupplierRepo,
 	productRepo ProductRepo,
+	productStockRepo ProductStockRepo,
+	auditRepo AuditRepo,
+	transitionRepo TransitionRepo,
+	importJobRepo ImportJobRepo,
+	jobRepo JobRepo,
+	outboxRepo OutboxRepo,
+	webhookSubRepo WebhookSubscriptionRepo,
+	webhookDeliveryRepo WebhookDeliveryRepo,
+	slugRepo SlugRepo,
+	reviewRepo ReviewRepo,
+	questionRepo QuestionRepo,
+	media MediaStore,
+	tx Transactor,
 ) Service {
 	return &service{
-		brandRepo:   brandRepo,
-		ctgryRepo:   ctgryRepo,
-		spplrRepo:   spplrRepo,
-		productRepo: productRepo,
+		brandRepo:           brandRepo,
+		ctgryRepo:           ctgryRepo,
+		spplrRepo:           spplrRepo,
+		productRepo:         productRepo,
+		productStockRepo:    productStockRepo,
+		auditRepo:           auditRepo,
+		transitionRepo:      transitionRepo,
+		importJobRepo:       importJobRepo,
+		jobRepo:             jobRepo,
+		outboxRepo:          outboxRepo,
+		webhookSubRepo:      webhookSubRepo,
+		webhookDeliveryRepo: webhookDeliveryRepo,
+		slugRepo:            slugRepo,
+		reviewRepo:          reviewRepo,
+		questionRepo:        questionRepo,
+		media:               media,
+		tx:                  tx,
 	}
 }
 
@@ -43,7 +103,42 @@ func (s *service) Response(ctx context.Context, description string, data interfa
 //----------------BRAND----------------
 
 func (s *service) AddBrand(ctx context.Context, brand *Brand) (*Brand, error) {
-	newBrand, err := s.brandRepo.Add(ctx, brand)
+	var newBrand *Brand
+
+	if len(brand.Status) == 0 {
+		brand.Status = StatusActive
+	}
+
+	if err := checkTransition(AuditEntityBrand, "", brand.Status); err != nil {
+		return nil, err
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.checkBrandName(ctx, "", brand.Name); err != nil {
+			return err
+		}
+
+		var err error
+		brand.Slug, err = s.newSlug(ctx, AuditEntityBrand, "", brand.Slug, brand.Name)
+		if err != nil {
+			return err
+		}
+
+		newBrand, err = s.brandRepo.Add(ctx, brand)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityBrand, newBrand.ID, "", newBrand.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityBrand, newBrand.ID, "", newBrand.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityBrand, newBrand.ID, AuditActionCreate, nil, newBrand)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -60,8 +155,8 @@ func (s *service) GetBrand(ctx context.Context, brandID string) (*Brand, error)
 	return brand, nil
 }
 
-func (s *service) GetBrands(ctx context.Context, page, limit int64) (*BrandResult, error) {
-	result, err := s.brandRepo.GetItems(ctx, page, limit)
+func (s *service) GetBrands(ctx context.Context, page, limit int64, includeDeleted bool) (*BrandResult, error) {
+	result, err := s.brandRepo.GetItems(ctx, page, limit, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -70,32 +165,366 @@ func (s *service) GetBrands(ctx context.Context, page, limit int64) (*BrandResul
 }
 
 func (s *service) UpdateBrand(ctx context.Context, brandID string, brand *Brand) error {
-	err := s.brandRepo.UpdateItemByID(ctx, brandID, brand)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		// the slug, the seo metadata and the profile stay unless others are given, a patch
+		// removes them
+		if err := s.checkBrandName(ctx, brandID, brand.Name); err != nil {
+			return err
+		}
+
+		brand.Slug, err = s.updatedSlug(ctx, AuditEntityBrand, brandID, before.Slug, brand.Slug)
+		if err != nil {
+			return err
+		}
+
+		if brand.SEO == (SEO{}) {
+			brand.SEO = before.SEO
+		}
+
+		keepBrandProfile(brand, before)
+
+		// the status stays unless another is given, which it must be able to go to
+		if len(brand.Status) == 0 {
+			brand.Status = before.Status
+		}
+
+		if brand.Status != before.Status {
+			if err := checkTransition(AuditEntityBrand, before.Status, brand.Status); err != nil {
+				return err
+			}
+		}
+
+		affected, err := s.brandRepo.UpdateItemByID(ctx, brandID, brand)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the update was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err := s.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityBrand, brandID, before.Slug, after.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityBrand, brandID, before.Status, after.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityBrand, brandID, AuditActionUpdate, before, after)
+	})
+}
+
+// PatchBrand updates only the fields set in the patch and returns the patched brand
+func (s *service) PatchBrand(ctx context.Context, brandID string, patch *BrandPatch) (*Brand, error) {
+	var patched *Brand
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		if patch.Name != nil {
+			if err := s.checkBrandName(ctx, brandID, *patch.Name); err != nil {
+				return err
+			}
+		}
+
+		if patch.Status != nil && *patch.Status != before.Status {
+			if err := checkTransition(AuditEntityBrand, before.Status, *patch.Status); err != nil {
+				return err
+			}
+		}
+
+		if patch.Slug != nil {
+			name := before.Name
+			if patch.Name != nil {
+				name = *patch.Name
+			}
+
+			slug, err := s.patchedSlug(ctx, AuditEntityBrand, brandID, before.Slug, patch.Slug, name)
+			if err != nil {
+				return err
+			}
+
+			patch.Slug = &slug
+		}
+
+		affected, err := s.brandRepo.PatchItemByID(ctx, brandID, patch)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the patch was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		patched, err = s.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityBrand, brandID, before.Slug, patched.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityBrand, brandID, before.Status, patched.Status, ""); err != nil {
+			return err
+		}
+
+		// an empty patch changes nothing worth recording
+		if patched.Version == before.Version {
+			return nil
+		}
+
+		return s.audit(ctx, AuditEntityBrand, brandID, AuditActionUpdate, before, patched)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return patched, nil
 }
 
 func (s *service) DeleteBrand(ctx context.Context, brandID string) error {
-	err := s.brandRepo.DeleteItemByID(ctx, brandID)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		err = s.brandRepo.DeleteItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityBrand, brandID, AuditActionDelete, before, nil)
+	})
+}
+
+func (s *service) RestoreBrand(ctx context.Context, brandID string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.brandRepo.RestoreItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		after, err := s.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityBrand, brandID, AuditActionRestore, nil, after)
+	})
+}
+
+// GetBrandPage returns the brand with its product counts per category and its topLimit
+// best selling products
+func (s *service) GetBrandPage(ctx context.Context, brandID string, topLimit int64) (*BrandPage, error) {
+	brand, err := s.brandRepo.GetItemByID(ctx, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.brandRepo.GetCategoryCounts(ctx, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	topProducts, err := s.productRepo.GetTopByBrand(ctx, brandID, topLimit)
 	if err != nil {
+		return nil, err
+	}
+
+	return &BrandPage{
+		Brand:          *brand,
+		CategoryCounts: counts,
+		TopProducts:    topProducts,
+	}, nil
+}
+
+// SetBrandLogo stores the image as the logo of the brand, in place of the one it had
+func (s *service) SetBrandLogo(ctx context.Context, brandID, contentType string, body io.Reader) (*Brand, error) {
+	ext, ok := logoExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedLogo
+	}
+
+	before, err := s.brandRepo.GetItemByID(ctx, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	// every logo gets a key of its own, so a cached old one is never served for it
+	key := fmt.Sprintf("brands/%s/logo-%s%s", brandID, strings.SplitN(uuid.NewString(), "-", 2)[0], ext)
+
+	url, err := s.media.Put(ctx, key, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.setBrandLogo(ctx, before, key, url)
+	if err != nil {
+		s.deleteMedia(ctx, key)
+		return nil, err
+	}
+
+	s.deleteMedia(ctx, before.LogoKey)
+
+	return after, nil
+}
+
+// DeleteBrandLogo removes the logo of the brand
+func (s *service) DeleteBrandLogo(ctx context.Context, brandID string) (*Brand, error) {
+	before, err := s.brandRepo.GetItemByID(ctx, brandID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(before.LogoKey) == 0 {
+		return before, nil
+	}
+
+	after, err := s.setBrandLogo(ctx, before, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.deleteMedia(ctx, before.LogoKey)
+
+	return after, nil
+}
+
+func (s *service) setBrandLogo(ctx context.Context, before *Brand, key, url string) (*Brand, error) {
+	var after *Brand
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		affected, err := s.brandRepo.SetLogo(ctx, before.ID, key, url)
+		if err != nil {
+			return err
+		}
+
+		// deleted meanwhile
+		if affected == 0 {
+			return ErrBrandNotFound
+		}
+
+		after, err = s.brandRepo.GetItemByID(ctx, before.ID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityBrand, before.ID, AuditActionUpdate, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// checkBrandName makes sure no other live brand has the name, in any letter case
+func (s *service) checkBrandName(ctx context.Context, brandID, name string) error {
+	brand, err := s.brandRepo.GetItemByName(ctx, name)
+	if errors.Is(err, ErrBrandNotFound) {
+		return nil
+	} else if err != nil {
 		return err
 	}
 
+	if brand.ID != brandID {
+		return NewConflictError(AuditEntityBrand, "name", ErrDuplicateBrand)
+	}
+
 	return nil
 }
 
+// keepBrandProfile keeps the profile fields of the brand which the update leaves empty
+func keepBrandProfile(brand, before *Brand) {
+	if len(brand.Description) == 0 {
+		brand.Description = before.Description
+	}
+
+	if len(brand.Website) == 0 {
+		brand.Website = before.Website
+	}
+
+	if len(brand.CountryOfOrigin) == 0 {
+		brand.CountryOfOrigin = before.CountryOfOrigin
+	}
+}
+
+// deleteMedia removes a file which nothing points at anymore. one left behind does no
+// harm, so a failure is only logged
+func (s *service) deleteMedia(ctx context.Context, key string) {
+	if len(key) == 0 {
+		return
+	}
+
+	if err := s.media.Delete(ctx, key); err != nil {
+		logger.Error(ctx, "cannot delete media", err)
+	}
+}
+
 //----------------CATEGORY----------------
 
 func (s *service) AddCategory(ctx context.Context, ctgry *Category) (*Category, error) {
-	ctgry, err := s.ctgryRepo.Add(ctx, ctgry)
+	var newCtgry *Category
+
+	if len(ctgry.Status) == 0 {
+		ctgry.Status = StatusActive
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if len(ctgry.ParentID) > 0 {
+			if err := s.checkCategoryPlacement(ctx, "", ctgry.ParentID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.checkCategoryStatus(ctx, "", ctgry.Status, ctgry.ParentID); err != nil {
+			return err
+		}
+
+		var err error
+		ctgry.Slug, err = s.newSlug(ctx, AuditEntityCategory, "", ctgry.Slug, ctgry.Name)
+		if err != nil {
+			return err
+		}
+
+		newCtgry, err = s.ctgryRepo.Add(ctx, ctgry)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityCategory, newCtgry.ID, "", newCtgry.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityCategory, newCtgry.ID, "", newCtgry.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityCategory, newCtgry.ID, AuditActionCreate, nil, newCtgry)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return ctgry, nil
+	return newCtgry, nil
 }
 
 func (s *service) GetCategory(ctx context.Context, ctgryID string) (*Category, error) {
@@ -107,8 +536,8 @@ func (s *service) GetCategory(ctx context.Context, ctgryID string) (*Category, e
 	return ctgry, nil
 }
 
-func (s *service) GetCategories(ctx context.Context, page, limit int64) (*CategoryResult, error) {
-	result, err := s.ctgryRepo.GetItems(ctx, page, limit)
+func (s *service) GetCategories(ctx context.Context, page, limit int64, includeDeleted bool) (*CategoryResult, error) {
+	result, err := s.ctgryRepo.GetItems(ctx, page, limit, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -116,28 +545,215 @@ func (s *service) GetCategories(ctx context.Context, page, limit int64) (*Catego
 	return result, nil
 }
 
+func (s *service) GetCategoriesByIDs(ctx context.Context, ctgryIDs []string) ([]Category, error) {
+	if len(ctgryIDs) == 0 {
+		return []Category{}, nil
+	}
+
+	return s.ctgryRepo.GetItemsByIDs(ctx, ctgryIDs)
+}
+
+// GetChildCategories returns the children of all the given categories at once
+func (s *service) GetChildCategories(ctx context.Context, parentIDs []string) ([]Category, error) {
+	if len(parentIDs) == 0 {
+		return []Category{}, nil
+	}
+
+	return s.ctgryRepo.GetItemsByParentIDs(ctx, parentIDs)
+}
+
 func (s *service) UpdateCategory(ctx context.Context, ctgryID string, ctgry *Category) error {
-	err := s.ctgryRepo.UpdateItemByID(ctx, ctgryID, ctgry)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		// the slug and the seo metadata stay unless others are given, a patch removes them
+		ctgry.Slug, err = s.updatedSlug(ctx, AuditEntityCategory, ctgryID, before.Slug, ctgry.Slug)
+		if err != nil {
+			return err
+		}
+
+		if ctgry.SEO == (SEO{}) {
+			ctgry.SEO = before.SEO
+		}
+
+		// a new parent goes through the same checks as a move
+		if before.ParentID != ctgry.ParentID {
+			if err := s.checkCategoryPlacement(ctx, ctgryID, ctgry.ParentID); err != nil {
+				return err
+			}
+		}
+
+		// the status stays unless another is given, which it must be able to go to
+		if len(ctgry.Status) == 0 {
+			ctgry.Status = before.Status
+		}
+
+		if ctgry.Status != before.Status {
+			if err := s.checkCategoryStatus(ctx, before.Status, ctgry.Status, ctgry.ParentID); err != nil {
+				return err
+			}
+		}
+
+		affected, err := s.ctgryRepo.UpdateItemByID(ctx, ctgryID, ctgry)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the update was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err := s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityCategory, ctgryID, before.Slug, after.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityCategory, ctgryID, before.Status, after.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityCategory, ctgryID, AuditActionUpdate, before, after)
+	})
+}
+
+// PatchCategory updates only the fields set in the patch and returns the patched category
+func (s *service) PatchCategory(ctx context.Context, ctgryID string, patch *CategoryPatch) (*Category, error) {
+	var patched *Category
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		if patch.Slug != nil {
+			name := before.Name
+			if patch.Name != nil {
+				name = *patch.Name
+			}
+
+			slug, err := s.patchedSlug(ctx, AuditEntityCategory, ctgryID, before.Slug, patch.Slug, name)
+			if err != nil {
+				return err
+			}
+
+			patch.Slug = &slug
+		}
+
+		if patch.Status != nil && *patch.Status != before.Status {
+			if err := s.checkCategoryStatus(ctx, before.Status, *patch.Status, before.ParentID); err != nil {
+				return err
+			}
+		}
+
+		affected, err := s.ctgryRepo.PatchItemByID(ctx, ctgryID, patch)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the patch was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		patched, err = s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityCategory, ctgryID, before.Slug, patched.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityCategory, ctgryID, before.Status, patched.Status, ""); err != nil {
+			return err
+		}
+
+		// an empty patch changes nothing worth recording
+		if patched.Version == before.Version {
+			return nil
+		}
+
+		return s.audit(ctx, AuditEntityCategory, ctgryID, AuditActionUpdate, before, patched)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return patched, nil
 }
 
 func (s *service) DeleteCategory(ctx context.Context, ctgryID string) error {
-	err := s.ctgryRepo.DeleteItemByID(ctx, ctgryID)
-	if err != nil {
-		return err
-	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// a category moved under this one meanwhile would be left under a deleted parent
+		if err := s.ctgryRepo.LockTree(ctx); err != nil {
+			return err
+		}
 
-	return nil
+		before, err := s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		err = s.ctgryRepo.DeleteItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityCategory, ctgryID, AuditActionDelete, before, nil)
+	})
+}
+
+func (s *service) RestoreCategory(ctx context.Context, ctgryID string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.ctgryRepo.RestoreItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		after, err := s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityCategory, ctgryID, AuditActionRestore, nil, after)
+	})
 }
 
 //----------------SUPPLIER----------------
 
 func (s *service) AddSupplier(ctx context.Context, spplr *Supplier) (*Supplier, error) {
-	newSpplr, err := s.spplrRepo.Add(ctx, spplr)
+	var newSpplr *Supplier
+
+	if len(spplr.Status) == 0 {
+		spplr.Status = StatusActive
+	}
+
+	if err := checkTransition(AuditEntitySupplier, "", spplr.Status); err != nil {
+		return nil, err
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		newSpplr, err = s.spplrRepo.Add(ctx, spplr)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntitySupplier, newSpplr.ID, "", newSpplr.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntitySupplier, newSpplr.ID, AuditActionCreate, nil, newSpplr)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -154,8 +770,8 @@ func (s *service) GetSupplier(ctx context.Context, spplrID string) (*Supplier, e
 	return spllr, nil
 }
 
-func (s *service) GetSuppliers(ctx context.Context, page, limit int64) (*SupplierResult, error) {
-	result, err := s.spplrRepo.GetItems(ctx, page, limit)
+func (s *service) GetSuppliers(ctx context.Context, page, limit int64, includeDeleted bool) (*SupplierResult, error) {
+	result, err := s.spplrRepo.GetItems(ctx, page, limit, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -164,32 +780,175 @@ func (s *service) GetSuppliers(ctx context.Context, page, limit int64) (*Supplie
 }
 
 func (s *service) UpdateSupplier(ctx context.Context, spplrID string, spplr *Supplier) error {
-	err := s.spplrRepo.UpdateItemByID(ctx, spplrID, spplr)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		// the status stays unless another is given, which it must be able to go to
+		if len(spplr.Status) == 0 {
+			spplr.Status = before.Status
+		}
+
+		if spplr.Status != before.Status {
+			if err := checkTransition(AuditEntitySupplier, before.Status, spplr.Status); err != nil {
+				return err
+			}
+		}
+
+		affected, err := s.spplrRepo.UpdateItemByID(ctx, spplrID, spplr)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the update was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err := s.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntitySupplier, spplrID, before.Status, after.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntitySupplier, spplrID, AuditActionUpdate, before, after)
+	})
+}
+
+// PatchSupplier updates only the fields set in the patch and returns the patched supplier
+func (s *service) PatchSupplier(ctx context.Context, spplrID string, patch *SupplierPatch) (*Supplier, error) {
+	var patched *Supplier
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		if patch.Status != nil && *patch.Status != before.Status {
+			if err := checkTransition(AuditEntitySupplier, before.Status, *patch.Status); err != nil {
+				return err
+			}
+		}
+
+		affected, err := s.spplrRepo.PatchItemByID(ctx, spplrID, patch)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the patch was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		patched, err = s.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntitySupplier, spplrID, before.Status, patched.Status, ""); err != nil {
+			return err
+		}
+
+		// an empty patch changes nothing worth recording
+		if patched.Version == before.Version {
+			return nil
+		}
+
+		return s.audit(ctx, AuditEntitySupplier, spplrID, AuditActionUpdate, before, patched)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return patched, nil
 }
 
 func (s *service) DeleteSupplier(ctx context.Context, spplrID string) error {
-	err := s.spplrRepo.DeleteItemByID(ctx, spplrID)
-	if err != nil {
-		return err
-	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		err = s.spplrRepo.DeleteItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntitySupplier, spplrID, AuditActionDelete, before, nil)
+	})
+}
+
+func (s *service) RestoreSupplier(ctx context.Context, spplrID string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.spplrRepo.RestoreItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		after, err := s.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntitySupplier, spplrID, AuditActionRestore, nil, after)
+	})
 }
 
 //----------------PRODUCT----------------
 
 func (s *service) AddProduct(ctx context.Context, product *Product) (*Product, error) {
-	product, err := s.productRepo.Add(ctx, product)
+	var newProduct *Product
+
+	if len(product.Status) == 0 {
+		product.Status = ProductStatusDraft
+	}
+
+	if err := validateSchedule(product.PublishAt, product.UnpublishAt); err != nil {
+		return nil, err
+	}
+
+	if err := validatePublishable(product.Status, product.PublishAt); err != nil {
+		return nil, err
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.checkProductStatus(ctx, "", product.Status, product); err != nil {
+			return err
+		}
+
+		var err error
+		product.Slug, err = s.newSlug(ctx, AuditEntityProduct, "", product.Slug, product.Name)
+		if err != nil {
+			return err
+		}
+
+		newProduct, err = s.productRepo.Add(ctx, product)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityProduct, newProduct.ID, "", newProduct.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityProduct, newProduct.ID, "", newProduct.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityProduct, newProduct.ID, AuditActionCreate, nil, newProduct)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return product, nil
+	return newProduct, nil
 }
 
 func (s *service) GetProduct(ctx context.Context, productID string) (*Product, error) {
@@ -202,48 +961,282 @@ func (s *service) GetProduct(ctx context.Context, productID string) (*Product, e
 }
 
 func (s *service) GetProducts(ctx context.Context, filterParams FilterProductsParams) (*ProductResult, error) {
-	itemsResult, err := s.productRepo.GetItems(ctx, filterParams)
+	result, err := s.productRepo.GetItems(ctx, filterParams)
 	if err != nil {
 		return nil, err
 	}
 
-	if itemsResult == nil {
-		return nil, nil
+	return result, nil
+}
+
+func (s *service) UpdateProduct(ctx context.Context, productID string, product *Product) error {
+	if err := validateSchedule(product.PublishAt, product.UnpublishAt); err != nil {
+		return err
 	}
 
-	var products []Product
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.productRepo.GetItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
 
-	for _, product := range itemsResult.Products {
-		p, err := s.GetProduct(ctx, product.ID)
+		// the slug and the seo metadata stay unless others are given, a patch removes them
+		product.Slug, err = s.updatedSlug(ctx, AuditEntityProduct, productID, before.Slug, product.Slug)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		if p == nil {
-			continue
+		if product.SEO == (SEO{}) {
+			product.SEO = before.SEO
 		}
 
-		products = append(products, *p)
-	}
+		// the status stays unless another is given, which it must be able to go to
+		if len(product.Status) == 0 {
+			product.Status = before.Status
+		}
+
+		if product.Status != before.Status {
+			if err := s.checkProductStatus(ctx, before.Status, product.Status, product); err != nil {
+				return err
+			}
+		}
+
+		if err := validatePublishable(product.Status, product.PublishAt); err != nil {
+			return err
+		}
+
+		affected, err := s.productRepo.UpdateItemByID(ctx, productID, product)
+		if err != nil {
+			return err
+		}
 
-	itemsResult.Products = products
-	return itemsResult, nil
+		// nothing matched the id and version the update was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err := s.productRepo.GetItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityProduct, productID, before.Slug, after.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityProduct, productID, before.Status, after.Status, ""); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityProduct, productID, AuditActionUpdate, before, after)
+	})
 }
 
-func (s *service) UpdateProduct(ctx context.Context, productID string, product *Product) error {
-	err := s.productRepo.UpdateItemByID(ctx, productID, product)
+// PatchProduct updates only the fields set in the patch and returns the patched product
+func (s *service) PatchProduct(ctx context.Context, productID string, patch *ProductPatch) (*Product, error) {
+	var patched *Product
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.productRepo.GetItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if patch.Slug != nil {
+			name := before.Name
+			if patch.Name != nil {
+				name = *patch.Name
+			}
+
+			slug, err := s.patchedSlug(ctx, AuditEntityProduct, productID, before.Slug, patch.Slug, name)
+			if err != nil {
+				return err
+			}
+
+			patch.Slug = &slug
+		}
+
+		if patch.Status != nil && *patch.Status != before.Status {
+			if err := s.checkProductStatus(ctx, before.Status, *patch.Status, patchedProduct(before, patch)); err != nil {
+				return err
+			}
+		}
+
+		publishAt, unpublishAt := patchedSchedule(before, patch)
+		if err := validateSchedule(publishAt, unpublishAt); err != nil {
+			return err
+		}
+
+		if patch.Status != nil || patch.PublishAt != nil {
+			status := before.Status
+			if patch.Status != nil {
+				status = *patch.Status
+			}
+
+			if err := validatePublishable(status, publishAt); err != nil {
+				return err
+			}
+		}
+
+		affected, err := s.productRepo.PatchItemByID(ctx, productID, patch)
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the patch was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		patched, err = s.productRepo.GetItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.moveSlug(ctx, AuditEntityProduct, productID, before.Slug, patched.Slug); err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityProduct, productID, before.Status, patched.Status, ""); err != nil {
+			return err
+		}
+
+		// an empty patch changes nothing worth recording
+		if patched.Version == before.Version {
+			return nil
+		}
+
+		return s.audit(ctx, AuditEntityProduct, productID, AuditActionUpdate, before, patched)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return patched, nil
 }
 
 func (s *service) DeleteProduct(ctx context.Context, productID string) error {
-	err := s.productRepo.DeleteItemByID(ctx, productID)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.productRepo.GetItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		err = s.productRepo.DeleteItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityProduct, productID, AuditActionDelete, before, nil)
+	})
+}
+
+func (s *service) RestoreProduct(ctx context.Context, productID string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.productRepo.RestoreItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		after, err := s.productRepo.GetItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityProduct, productID, AuditActionRestore, nil, after)
+	})
+}
+
+//----------------PRODUCT STOCK----------------
+
+func (s *service) AdjustStock(ctx context.Context, movement *StockMovement) (*ProductStock, error) {
+	switch movement.Reason {
+	case StockReasonSale, StockReasonRestock, StockReasonReturn:
+		if movement.Quantity <= 0 {
+			return nil, ErrInvalidStockQuantity
+		}
+	case StockReasonAdjustment:
+		if movement.Quantity == 0 {
+			return nil, ErrInvalidStockQuantity
+		}
+	default:
+		return nil, ErrInvalidStockReason
+	}
+
+	// sales take units out of the stock
+	if movement.Reason == StockReasonSale {
+		movement.Quantity = -movement.Quantity
+	}
+
+	var productStock *ProductStock
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.productStockRepo.GetItemByProductID(ctx, movement.ProductID)
+		if err != nil {
+			return err
+		}
+
+		productStock, err = s.productStockRepo.AdjustQuantity(ctx, movement)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityStock, movement.ProductID, AuditActionUpdate, before, productStock)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return productStock, nil
+}
+
+func (s *service) GetSalesReport(ctx context.Context, supplierID string, from, to int64) ([]SalesReportItem, error) {
+	if to == 0 {
+		to = MAX_INF
+	}
+
+	items, err := s.productStockRepo.GetSalesReport(ctx, supplierID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (s *service) GetLowStockReport(ctx context.Context, supplierID string, threshold int64) ([]LowStockItem, error) {
+	items, err := s.productStockRepo.GetLowStockItems(ctx, supplierID, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+//----------------PURGE----------------
+
+// PurgeDeleted permanently removes every record soft deleted before the given timestamp.
+// products go first so the brands, categories and suppliers they reference can follow
+func (s *service) PurgeDeleted(ctx context.Context, deletedBefore int64) (map[string]int64, error) {
+	purged := make(map[string]int64)
+
+	purgers := []struct {
+		name  string
+		purge func(ctx context.Context, deletedBefore int64) (int64, error)
+	}{
+		{"products", s.productRepo.PurgeDeleted},
+		{"categories", s.ctgryRepo.PurgeDeleted},
+		{"brands", s.brandRepo.PurgeDeleted},
+		{"suppliers", s.spplrRepo.PurgeDeleted},
+	}
+
+	for _, purger := range purgers {
+		count, err := purger.purge(ctx, deletedBefore)
+		if err != nil {
+			return purged, err
+		}
+
+		purged[purger.name] = count
+	}
+
+	return purged, nil
 }
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// maxSlugSuffix is the highest number appended to a slug made from a name before
+// falling back to a random suffix
+const maxSlugSuffix = 100
+
+// SEO is the metadata a storefront renders into the page of a brand, category or product
+type SEO struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	CanonicalURL string `json:"canonical_url"`
+}
+
+// SEOPatch is a partial update of the seo metadata, nil fields are left untouched
+// and empty ones are removed
+type SEOPatch struct {
+	Title        *string
+	Description  *string
+	CanonicalURL *string
+}
+
+// PathResolution is the category or product a storefront path leads to
+type PathResolution struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// Path is the canonical path of the entity, its category slugs from the root down
+	Path string `json:"path"`
+	// Redirect tells that the path asked for is an old one, the storefront redirects to Path
+	Redirect bool      `json:"redirect"`
+	Category *Category `json:"category,omitempty"`
+	Product  *Product  `json:"product,omitempty"`
+}
+
+// ResolveSlug returns the id of the brand, category or product with the slug, or which
+// had it before. the slugs of deleted entities are found too. empty when there is none
+func (s *service) ResolveSlug(ctx context.Context, entity, slug string) (string, error) {
+	entityID, _, err := s.slugRepo.GetEntityID(ctx, entity, slug)
+	if err != nil {
+		return "", err
+	}
+
+	return entityID, nil
+}
+
+// ResolvePath finds the entity of a storefront path such as /laptops/gaming, the slugs
+// of a category and its ancestors, optionally followed by the slug of a product of the
+// category. every slug must lead to the category or product at its place in the
+// hierarchy, ErrPathNotFound when there is no such entity or the storefront does not
+// show the product at now
+func (s *service) ResolvePath(ctx context.Context, path string, now int64) (*PathResolution, error) {
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	if len(segments) == 0 || len(segments) > MaxCategoryDepth+1 {
+		return nil, ErrPathNotFound
+	}
+
+	redirect := false
+	entityIDs := make([]string, len(segments))
+	isProduct := false
+
+	for i, segment := range segments {
+		slug := strings.ToLower(segment)
+		redirect = redirect || slug != segment
+
+		entityID, redirected, err := s.slugRepo.GetEntityID(ctx, AuditEntityCategory, slug)
+		if err != nil {
+			return nil, err
+		}
+
+		// a product comes last, after the slug of its category at least
+		if len(entityID) == 0 && i > 0 && i == len(segments)-1 {
+			isProduct = true
+			entityID, redirected, err = s.slugRepo.GetEntityID(ctx, AuditEntityProduct, slug)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(entityID) == 0 {
+			return nil, ErrPathNotFound
+		}
+
+		entityIDs[i] = entityID
+		redirect = redirect || redirected
+	}
+
+	resolution := &PathResolution{
+		Type:     AuditEntityCategory,
+		ID:       entityIDs[len(entityIDs)-1],
+		Redirect: redirect,
+	}
+
+	ctgryID := resolution.ID
+	if isProduct {
+		product, err := s.productRepo.GetItemByID(ctx, resolution.ID)
+		if errors.Is(err, ErrProductNotFound) {
+			return nil, ErrPathNotFound
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !product.Live(now) {
+			return nil, ErrPathNotFound
+		}
+
+		resolution.Type = AuditEntityProduct
+		resolution.Product = product
+		ctgryID = product.Category.ID
+	}
+
+	ctgry, err := s.ctgryRepo.GetItemByID(ctx, ctgryID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return nil, ErrPathNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	ancestors, err := s.ctgryRepo.GetAncestors(ctx, ctgryID, categoryWalkLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// the canonical chain of slugs, which the path must follow
+	chain := append(ancestors, *ctgry)
+	slugs := make([]string, 0, len(chain)+1)
+	ids := make([]string, 0, len(chain)+1)
+	for _, c := range chain {
+		slugs = append(slugs, c.Slug)
+		ids = append(ids, c.ID)
+	}
+
+	if isProduct {
+		slugs = append(slugs, resolution.Product.Slug)
+		ids = append(ids, resolution.Product.ID)
+	} else {
+		resolution.Category = ctgry
+	}
+
+	if len(ids) != len(entityIDs) {
+		return nil, ErrPathNotFound
+	}
+
+	for i := range ids {
+		if ids[i] != entityIDs[i] {
+			return nil, ErrPathNotFound
+		}
+	}
+
+	resolution.Path = "/" + strings.Join(slugs, "/")
+	return resolution, nil
+}
+
+// newSlug returns the slug a new entity, or an existing one when entityID is set, is
+// saved with. a requested slug is used as it is and must be free. without one the slug
+// is made from the name, with a number appended until it is free
+func (s *service) newSlug(ctx context.Context, entity, entityID, requested, name string) (string, error) {
+	if len(requested) > 0 {
+		if !util.IsSupportedSlug(requested) {
+			return "", ErrInvalidSlug
+		}
+
+		taken, err := s.slugRepo.IsTaken(ctx, entity, requested, entityID)
+		if err != nil {
+			return "", err
+		}
+
+		if taken {
+			return "", NewConflictError(entity, "slug", ErrSlugTaken)
+		}
+
+		return requested, nil
+	}
+
+	base := util.Slugify(name)
+	if len(base) == 0 {
+		base = entity
+	}
+
+	slug := base
+	for n := 2; n <= maxSlugSuffix+1; n++ {
+		taken, err := s.slugRepo.IsTaken(ctx, entity, slug, entityID)
+		if err != nil {
+			return "", err
+		}
+
+		if !taken {
+			return slug, nil
+		}
+
+		slug = withSlugSuffix(base, fmt.Sprint(n))
+	}
+
+	// that many entities with the same name is unlikely, a random suffix settles it
+	return withSlugSuffix(base, strings.SplitN(uuid.NewString(), "-", 2)[0]), nil
+}
+
+// updatedSlug returns the slug an updated entity is saved with, its current one when
+// no other is requested
+func (s *service) updatedSlug(ctx context.Context, entity, entityID, current, requested string) (string, error) {
+	if len(requested) == 0 || requested == current {
+		return current, nil
+	}
+
+	return s.newSlug(ctx, entity, entityID, requested, "")
+}
+
+// patchedSlug returns the slug a patched entity is saved with. an empty slug in the
+// patch makes it again from the name
+func (s *service) patchedSlug(ctx context.Context, entity, entityID, current string, requested *string, name string) (string, error) {
+	if len(*requested) == 0 {
+		slug := util.Slugify(name)
+		if slug == current {
+			return current, nil
+		}
+
+		return s.newSlug(ctx, entity, entityID, "", name)
+	}
+
+	return s.updatedSlug(ctx, entity, entityID, current, *requested)
+}
+
+// moveSlug keeps the old slug of an entity as a redirect to it. the new slug stops being
+// a redirect, to this entity or to another which had it before
+func (s *service) moveSlug(ctx context.Context, entity, entityID, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+
+	if len(oldSlug) > 0 {
+		if err := s.slugRepo.AddRedirect(ctx, entity, oldSlug, entityID); err != nil {
+			return err
+		}
+	}
+
+	return s.slugRepo.DeleteRedirect(ctx, entity, newSlug)
+}
+
+func withSlugSuffix(base, suffix string) string {
+	if maxLen := util.MaxSlugLength - len(suffix) - 1; len(base) > maxLen {
+		base = strings.TrimRight(base[:maxLen], "-")
+	}
+
+	return base + "-" + suffix
+}
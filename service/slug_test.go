@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// memorySlugs finds the slugs of the categories and products in memory, and keeps their
+// redirects. like the repo, the redirect of an entity which is gone leads nowhere
+type memorySlugs struct {
+	SlugRepo
+	ctgries   *memoryCategories
+	products  *memoryScheduled
+	redirects map[string]map[string]string
+}
+
+func (m *memorySlugs) GetEntityID(ctx context.Context, entity, slug string) (string, bool, error) {
+	exists := func(entityID string) bool {
+		if entity == AuditEntityCategory {
+			_, ok := m.ctgries.ctgries[entityID]
+			return ok
+		}
+
+		_, ok := m.products.products[entityID]
+		return ok
+	}
+
+	if entity == AuditEntityCategory {
+		for _, ctgry := range m.ctgries.ctgries {
+			if ctgry.Slug == slug {
+				return ctgry.ID, false, nil
+			}
+		}
+	} else {
+		for _, product := range m.products.products {
+			if product.Slug == slug {
+				return product.ID, false, nil
+			}
+		}
+	}
+
+	if entityID, ok := m.redirects[entity][slug]; ok && exists(entityID) {
+		return entityID, true, nil
+	}
+
+	return "", false, nil
+}
+
+func (m *memorySlugs) AddRedirect(ctx context.Context, entity, slug, entityID string) error {
+	if m.redirects[entity] == nil {
+		m.redirects[entity] = make(map[string]string)
+	}
+
+	m.redirects[entity][slug] = entityID
+	return nil
+}
+
+func (m *memorySlugs) DeleteRedirect(ctx context.Context, entity, slug string) error {
+	delete(m.redirects[entity], slug)
+	return nil
+}
+
+// purgingProducts purges the products deleted before a time along with their redirects,
+// the way the repo does
+type purgingProducts struct {
+	*memoryScheduled
+	slugs *memorySlugs
+}
+
+func (p purgingProducts) PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error) {
+	var purged int64
+	for productID, product := range p.products {
+		if product.DeletedAt == 0 || product.DeletedAt >= deletedBefore {
+			continue
+		}
+
+		delete(p.products, productID)
+		for slug, entityID := range p.slugs.redirects[AuditEntityProduct] {
+			if entityID == productID {
+				delete(p.slugs.redirects[AuditEntityProduct], slug)
+			}
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (m *memoryCategories) PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryBrands) PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error) {
+	return 0, nil
+}
+
+func (m *memorySuppliers) PurgeDeleted(ctx context.Context, deletedBefore int64) (int64, error) {
+	return 0, nil
+}
+
+// newSlugService returns a service of the electronics > phones tree, with a live product
+// and a draft in phones
+func newSlugService() (*service, *memorySlugs) {
+	ctgries := newMemoryCategories(
+		Category{ID: "electronics", Slug: "electronics", Status: StatusActive},
+		Category{ID: "phones", Slug: "phones", ParentID: "electronics", Status: StatusActive},
+	)
+
+	products := &memoryScheduled{products: map[string]Product{
+		"phone-x": {ID: "phone-x", Slug: "phone-x", Category: Category{ID: "phones"}, Status: ProductStatusActive},
+		"phone-y": {ID: "phone-y", Slug: "phone-y", Category: Category{ID: "phones"}, Status: ProductStatusDraft},
+	}}
+
+	slugs := &memorySlugs{ctgries: ctgries, products: products, redirects: make(map[string]map[string]string)}
+
+	return &service{
+		slugRepo:    slugs,
+		ctgryRepo:   ctgries,
+		productRepo: purgingProducts{memoryScheduled: products, slugs: slugs},
+		brandRepo:   &memoryBrands{},
+		spplrRepo:   &memorySuppliers{},
+	}, slugs
+}
+
+func TestResolvePath(t *testing.T) {
+	svc, _ := newSlugService()
+
+	tests := []struct {
+		path      string
+		typ       string
+		id        string
+		canonical string
+		redirect  bool
+	}{
+		{"/electronics", AuditEntityCategory, "electronics", "/electronics", false},
+		{"/electronics/phones/", AuditEntityCategory, "phones", "/electronics/phones", false},
+		{"/electronics/phones/phone-x", AuditEntityProduct, "phone-x", "/electronics/phones/phone-x", false},
+		{"/Electronics/Phones", AuditEntityCategory, "phones", "/electronics/phones", true},
+		// not at their place in the tree
+		{"/phones", "", "", "", false},
+		{"/electronics/phone-x", "", "", "", false},
+		{"/phone-x", "", "", "", false},
+		// not shown by the storefront, or not there at all
+		{"/electronics/phones/phone-y", "", "", "", false},
+		{"/electronics/phones/phone-z", "", "", "", false},
+		{"/", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resolution, err := svc.ResolvePath(context.Background(), tt.path, 100)
+			if len(tt.id) == 0 {
+				if !errors.Is(err, ErrPathNotFound) {
+					t.Errorf("ResolvePath = %+v, %v, want %v", resolution, err, ErrPathNotFound)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ResolvePath: %v", err)
+			}
+
+			if resolution.Type != tt.typ || resolution.ID != tt.id || resolution.Path != tt.canonical || resolution.Redirect != tt.redirect {
+				t.Errorf("ResolvePath = %+v, want %s %s at %s, redirect %v", resolution, tt.typ, tt.id, tt.canonical, tt.redirect)
+			}
+
+			if (tt.typ == AuditEntityCategory) != (resolution.Category != nil) || (tt.typ == AuditEntityProduct) != (resolution.Product != nil) {
+				t.Errorf("ResolvePath = %+v, want the %s along", resolution, tt.typ)
+			}
+		})
+	}
+}
+
+func TestResolvePathFollowsOldSlugs(t *testing.T) {
+	svc, slugs := newSlugService()
+	ctx := context.Background()
+
+	// phones is renamed mobiles
+	phones := slugs.ctgries.ctgries["phones"]
+	phones.Slug = "mobiles"
+	slugs.ctgries.ctgries["phones"] = phones
+
+	if err := svc.moveSlug(ctx, AuditEntityCategory, "phones", "phones", "mobiles"); err != nil {
+		t.Fatalf("moveSlug: %v", err)
+	}
+
+	resolution, err := svc.ResolvePath(ctx, "/electronics/phones/phone-x", 100)
+	if err != nil {
+		t.Fatalf("ResolvePath: %v", err)
+	}
+
+	if resolution.ID != "phone-x" || !resolution.Redirect || resolution.Path != "/electronics/mobiles/phone-x" {
+		t.Errorf("ResolvePath = %+v, want a redirect to /electronics/mobiles/phone-x", resolution)
+	}
+
+	// and back again, the old slug is the category's own once more
+	phones.Slug = "phones"
+	slugs.ctgries.ctgries["phones"] = phones
+
+	if err := svc.moveSlug(ctx, AuditEntityCategory, "phones", "mobiles", "phones"); err != nil {
+		t.Fatalf("moveSlug: %v", err)
+	}
+
+	if _, ok := slugs.redirects[AuditEntityCategory]["phones"]; ok {
+		t.Errorf("phones still redirects, want it to be the slug of the category")
+	}
+
+	for path, redirect := range map[string]bool{"/electronics/phones": false, "/electronics/mobiles": true} {
+		resolution, err := svc.ResolvePath(ctx, path, 100)
+		if err != nil {
+			t.Fatalf("ResolvePath(%s): %v", path, err)
+		}
+
+		if resolution.ID != "phones" || resolution.Redirect != redirect || resolution.Path != "/electronics/phones" {
+			t.Errorf("ResolvePath(%s) = %+v, want phones at /electronics/phones, redirect %v", path, resolution, redirect)
+		}
+	}
+}
+
+func TestPurgeDropsRedirects(t *testing.T) {
+	svc, slugs := newSlugService()
+	ctx := context.Background()
+
+	// phone-x was called phone-x-pro before it was deleted
+	if err := svc.moveSlug(ctx, AuditEntityProduct, "phone-x", "phone-x-pro", "phone-x"); err != nil {
+		t.Fatalf("moveSlug: %v", err)
+	}
+
+	phoneX := slugs.products.products["phone-x"]
+	phoneX.DeletedAt = 10
+	slugs.products.products["phone-x"] = phoneX
+
+	// a deleted product is still found by its old slug, so it can be restored and resolved
+	if productID, err := svc.ResolveSlug(ctx, AuditEntityProduct, "phone-x-pro"); productID != "phone-x" || err != nil {
+		t.Fatalf("ResolveSlug = %q, %v, want phone-x", productID, err)
+	}
+
+	purged, err := svc.PurgeDeleted(ctx, 20)
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+
+	if purged["products"] != 1 {
+		t.Errorf("purged %v, want one product", purged)
+	}
+
+	if len(slugs.redirects[AuditEntityProduct]) != 0 {
+		t.Errorf("redirects = %v, want the ones of the purged product dropped", slugs.redirects[AuditEntityProduct])
+	}
+
+	for _, slug := range []string{"phone-x", "phone-x-pro"} {
+		if productID, err := svc.ResolveSlug(ctx, AuditEntityProduct, slug); productID != "" || err != nil {
+			t.Errorf("ResolveSlug(%s) = %q, %v, want nothing", slug, productID, err)
+		}
+	}
+
+	if _, err := svc.ResolvePath(ctx, "/electronics/phones/phone-x-pro", 100); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("ResolvePath = %v, want %v", err, ErrPathNotFound)
+	}
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// product lifecycle statuses
+const (
+	ProductStatusDraft         = "draft"
+	ProductStatusPendingReview = "pending_review"
+	ProductStatusActive        = "active"
+	ProductStatusOutOfStock    = "out_of_stock"
+	ProductStatusDiscontinued  = "discontinued"
+	ProductStatusArchived      = "archived"
+)
+
+// statuses of brands, categories and suppliers
+const (
+	StatusActive   = "active"
+	StatusInactive = "inactive"
+	StatusArchived = "archived"
+)
+
+// moderation statuses of the content customers post, only approved content is shown
+const (
+	ModerationStatusPending  = "pending"
+	ModerationStatusApproved = "approved"
+	ModerationStatusRejected = "rejected"
+)
+
+// productTransitions are the statuses a product may go to from each status, the empty
+// status being the one of a product yet to be created
+var productTransitions = map[string][]string{
+	"":                         {ProductStatusDraft, ProductStatusPendingReview, ProductStatusActive},
+	ProductStatusDraft:         {ProductStatusPendingReview, ProductStatusArchived},
+	ProductStatusPendingReview: {ProductStatusActive, ProductStatusDraft, ProductStatusArchived},
+	ProductStatusActive:        {ProductStatusOutOfStock, ProductStatusDiscontinued, ProductStatusArchived},
+	ProductStatusOutOfStock:    {ProductStatusActive, ProductStatusDiscontinued, ProductStatusArchived},
+	ProductStatusDiscontinued:  {ProductStatusActive, ProductStatusArchived},
+	ProductStatusArchived:      {ProductStatusDraft},
+}
+
+// statusTransitions are the statuses a brand, category or supplier may go to from each
+// status, the empty status being the one of a record yet to be created
+var statusTransitions = map[string][]string{
+	"":             {StatusActive, StatusInactive},
+	StatusActive:   {StatusInactive, StatusArchived},
+	StatusInactive: {StatusActive, StatusArchived},
+	StatusArchived: {StatusInactive},
+}
+
+// moderationTransitions are the statuses moderated content may go to from each status, the
+// empty status being the one of content yet to be created
+var moderationTransitions = map[string][]string{
+	"":                       {ModerationStatusPending},
+	ModerationStatusPending:  {ModerationStatusApproved, ModerationStatusRejected},
+	ModerationStatusApproved: {ModerationStatusRejected},
+	ModerationStatusRejected: {ModerationStatusApproved},
+}
+
+var entityTransitions = map[string]map[string][]string{
+	AuditEntityBrand:    statusTransitions,
+	AuditEntityCategory: statusTransitions,
+	AuditEntitySupplier: statusTransitions,
+	AuditEntityProduct:  productTransitions,
+	AuditEntityReview:   moderationTransitions,
+	AuditEntityQuestion: moderationTransitions,
+}
+
+// LiveProductStatuses are the statuses of the products the storefront shows
+var LiveProductStatuses = []string{ProductStatusActive, ProductStatusOutOfStock}
+
+// ProductStatuses returns every product status, in lifecycle order
+func ProductStatuses() []string {
+	return []string{
+		ProductStatusDraft,
+		ProductStatusPendingReview,
+		ProductStatusActive,
+		ProductStatusOutOfStock,
+		ProductStatusDiscontinued,
+		ProductStatusArchived,
+	}
+}
+
+// IsProductStatus reports whether status is a product status
+func IsProductStatus(status string) bool {
+	_, ok := productTransitions[status]
+	return ok && len(status) > 0
+}
+
+// IsLiveProductStatus reports whether the storefront shows the products of the status
+func IsLiveProductStatus(status string) bool {
+	for _, live := range LiveProductStatuses {
+		if status == live {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsStatus reports whether status is a status of brands, categories and suppliers
+func IsStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok && len(status) > 0
+}
+
+// IsModerationStatus reports whether status is a moderation status
+func IsModerationStatus(status string) bool {
+	_, ok := moderationTransitions[status]
+	return ok && len(status) > 0
+}
+
+// Transition is a change of the status of a brand, category, supplier, product, review or
+// question
+type Transition struct {
+	ID         string `json:"id"`
+	EntityType string `json:"entity_type"`
+	EntityID   string `json:"entity_id"`
+	// FromStatus is empty for the status a record was created with
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason"`
+	Actor      string `json:"actor"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type TransitionResult struct {
+	Transitions []Transition `json:"transitions"`
+	Total       int64        `json:"total"`
+	Page        int64        `json:"page"`
+	Limit       int64        `json:"limit"`
+}
+
+// checkTransition returns a TransitionError unless a record of the entity type may go
+// from the status to the other, staying in a status being no transition
+func checkTransition(entityType, from, to string) error {
+	allowed := entityTransitions[entityType][from]
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+
+	return &TransitionError{Entity: entityType, From: from, To: to, Allowed: allowed}
+}
+
+// checkProductStatus checks that a product may go from the status to the other. it can
+// only become active while its brand, category and supplier are
+func (s *service) checkProductStatus(ctx context.Context, from, to string, product *Product) error {
+	if err := checkTransition(AuditEntityProduct, from, to); err != nil {
+		return err
+	}
+
+	if to != ProductStatusActive {
+		return nil
+	}
+
+	brand, err := s.brandRepo.GetItemByID(ctx, product.Brand.ID)
+	if errors.Is(err, ErrBrandNotFound) {
+		return NewReferenceError("brand_id", err)
+	} else if err != nil {
+		return err
+	}
+
+	ctgry, err := s.ctgryRepo.GetItemByID(ctx, product.Category.ID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return NewReferenceError("category_id", err)
+	} else if err != nil {
+		return err
+	}
+
+	spplr, err := s.spplrRepo.GetItemByID(ctx, product.Supplier.ID)
+	if errors.Is(err, ErrSupplierNotFound) {
+		return NewReferenceError("supplier_id", err)
+	} else if err != nil {
+		return err
+	}
+
+	if brand.Status != StatusActive || ctgry.Status != StatusActive || spplr.Status != StatusActive {
+		return ErrParentInactive
+	}
+
+	return nil
+}
+
+// checkCategoryStatus checks that a category may go from the status to the other. it can
+// only become active while its parent is. it locks the tree for the transaction of ctx,
+// so no category is moved under one going inactive
+func (s *service) checkCategoryStatus(ctx context.Context, from, to, parentID string) error {
+	if err := checkTransition(AuditEntityCategory, from, to); err != nil {
+		return err
+	}
+
+	if err := s.ctgryRepo.LockTree(ctx); err != nil {
+		return err
+	}
+
+	if to != StatusActive || len(parentID) == 0 {
+		return nil
+	}
+
+	parent, err := s.ctgryRepo.GetItemByID(ctx, parentID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return ErrParentCategoryNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if parent.Status != StatusActive {
+		return ErrParentInactive
+	}
+
+	return nil
+}
+
+// statusChanged records the transition of a record and carries it over to the records
+// which belong to it: archiving a brand, category or supplier archives its products, and
+// archiving or deactivating a category does the same to the categories underneath it.
+// it must be called with the ctx of the transaction making the change
+func (s *service) statusChanged(ctx context.Context, entityType, entityID, from, to, reason string) error {
+	if from == to {
+		return nil
+	}
+
+	err := s.transitionRepo.Add(ctx, &Transition{
+		EntityType: entityType,
+		EntityID:   entityID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		Actor:      GetActor(ctx),
+		CreatedAt:  util.GetCurrentTimestamp(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// only brands, categories and suppliers have records belonging to them
+	if len(from) == 0 || (entityType != AuditEntityBrand && entityType != AuditEntityCategory && entityType != AuditEntitySupplier) {
+		return nil
+	}
+
+	cascadeReason := fmt.Sprintf("%s %s is %s", entityType, entityID, to)
+
+	if to == StatusArchived {
+		productIDs, err := s.productRepo.GetUnarchivedIDs(ctx, entityType, entityID)
+		if err != nil {
+			return err
+		}
+
+		for _, productID := range productIDs {
+			product, err := s.productRepo.GetItemByID(ctx, productID)
+			if err != nil {
+				return err
+			}
+
+			if _, err := s.transitionProduct(ctx, product, ProductStatusArchived, cascadeReason, product.Version); err != nil {
+				return err
+			}
+		}
+	}
+
+	if entityType != AuditEntityCategory || to == StatusActive {
+		return nil
+	}
+
+	children, err := s.ctgryRepo.GetItemsByParentID(ctx, entityID)
+	if err != nil {
+		return err
+	}
+
+	for i := range children {
+		child := &children[i]
+
+		// an archived category is out of sight already
+		if child.Status == to || child.Status == StatusArchived {
+			continue
+		}
+
+		if _, err := s.transitionCategory(ctx, child, to, cascadeReason, child.Version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) GetTransitions(ctx context.Context, entityType, entityID string, page, limit int64) (*TransitionResult, error) {
+	result, err := s.transitionRepo.GetItems(ctx, entityType, entityID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TransitionBrand moves the brand to the status, version being the one the change is based on
+func (s *service) TransitionBrand(ctx context.Context, brandID, status, reason string, version int64) (*Brand, error) {
+	var after *Brand
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkTransition(AuditEntityBrand, before.Status, status); err != nil {
+			return err
+		}
+
+		affected, err := s.brandRepo.PatchItemByID(ctx, brandID, &BrandPatch{Status: &status, Version: version})
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the transition was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err = s.brandRepo.GetItemByID(ctx, brandID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntityBrand, brandID, before.Status, after.Status, reason); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntityBrand, brandID, AuditActionUpdate, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// TransitionCategory moves the category to the status, version being the one the change is based on
+func (s *service) TransitionCategory(ctx context.Context, ctgryID, status, reason string, version int64) (*Category, error) {
+	var after *Category
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.ctgryRepo.GetItemByID(ctx, ctgryID)
+		if err != nil {
+			return err
+		}
+
+		after, err = s.transitionCategory(ctx, before, status, reason, version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// transitionCategory moves the category to the status within the transaction of ctx
+func (s *service) transitionCategory(ctx context.Context, before *Category, status, reason string, version int64) (*Category, error) {
+	if err := s.checkCategoryStatus(ctx, before.Status, status, before.ParentID); err != nil {
+		return nil, err
+	}
+
+	affected, err := s.ctgryRepo.PatchItemByID(ctx, before.ID, &CategoryPatch{Status: &status, Version: version})
+	if err != nil {
+		return nil, err
+	}
+
+	// nothing matched the id and version the transition was based on
+	if affected == 0 {
+		return nil, ErrVersionConflict
+	}
+
+	after, err := s.ctgryRepo.GetItemByID(ctx, before.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.statusChanged(ctx, AuditEntityCategory, before.ID, before.Status, after.Status, reason); err != nil {
+		return nil, err
+	}
+
+	return after, s.audit(ctx, AuditEntityCategory, before.ID, AuditActionUpdate, before, after)
+}
+
+// TransitionSupplier moves the supplier to the status, version being the one the change is based on
+func (s *service) TransitionSupplier(ctx context.Context, spplrID, status, reason string, version int64) (*Supplier, error) {
+	var after *Supplier
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkTransition(AuditEntitySupplier, before.Status, status); err != nil {
+			return err
+		}
+
+		affected, err := s.spplrRepo.PatchItemByID(ctx, spplrID, &SupplierPatch{Status: &status, Version: version})
+		if err != nil {
+			return err
+		}
+
+		// nothing matched the id and version the transition was based on
+		if affected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err = s.spplrRepo.GetItemByID(ctx, spplrID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.statusChanged(ctx, AuditEntitySupplier, spplrID, before.Status, after.Status, reason); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, AuditEntitySupplier, spplrID, AuditActionUpdate, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// TransitionProduct moves the product to the status, version being the one the change is based on
+func (s *service) TransitionProduct(ctx context.Context, productID, status, reason string, version int64) (*Product, error) {
+	var after *Product
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.productRepo.GetItemByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		after, err = s.transitionProduct(ctx, before, status, reason, version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// transitionProduct moves the product to the status within the transaction of ctx
+func (s *service) transitionProduct(ctx context.Context, before *Product, status, reason string, version int64) (*Product, error) {
+	if err := s.checkProductStatus(ctx, before.Status, status, before); err != nil {
+		return nil, err
+	}
+
+	affected, err := s.productRepo.PatchItemByID(ctx, before.ID, &ProductPatch{Status: &status, Version: version})
+	if err != nil {
+		return nil, err
+	}
+
+	// nothing matched the id and version the transition was based on
+	if affected == 0 {
+		return nil, ErrVersionConflict
+	}
+
+	after, err := s.productRepo.GetItemByID(ctx, before.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.statusChanged(ctx, AuditEntityProduct, before.ID, before.Status, after.Status, reason); err != nil {
+		return nil, err
+	}
+
+	return after, s.audit(ctx, AuditEntityProduct, before.ID, AuditActionUpdate, before, after)
+}
+
+// patchedProduct returns the product with the brand, category and supplier the patch
+// gives it, for the checks of its new status
+func patchedProduct(before *Product, patch *ProductPatch) *Product {
+	product := *before
+
+	if patch.BrandID != nil {
+		product.Brand = Brand{ID: *patch.BrandID}
+	}
+
+	if patch.CategoryID != nil {
+		product.Category = Category{ID: *patch.CategoryID}
+	}
+
+	if patch.SupplierID != nil {
+		product.Supplier = Supplier{ID: *patch.SupplierID}
+	}
+
+	return &product
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// memoryBrands keeps the brands in memory, checking versions the way the repo does
+type memoryBrands struct {
+	BrandRepo
+	brands map[string]Brand
+}
+
+func (m *memoryBrands) GetItemByID(ctx context.Context, brandID string) (*Brand, error) {
+	brand, ok := m.brands[brandID]
+	if !ok {
+		return nil, ErrBrandNotFound
+	}
+
+	return &brand, nil
+}
+
+func (m *memoryBrands) PatchItemByID(ctx context.Context, brandID string, patch *BrandPatch) (int64, error) {
+	brand, ok := m.brands[brandID]
+	if !ok || brand.Version != patch.Version {
+		return 0, nil
+	}
+
+	if patch.Status != nil {
+		brand.Status = *patch.Status
+	}
+
+	brand.Version++
+	m.brands[brandID] = brand
+
+	return 1, nil
+}
+
+// memoryTransitions keeps the transitions in memory, newest first
+type memoryTransitions struct {
+	transitions []Transition
+}
+
+func (m *memoryTransitions) Add(ctx context.Context, transition *Transition) error {
+	m.transitions = append([]Transition{*transition}, m.transitions...)
+	return nil
+}
+
+func (m *memoryTransitions) GetItems(ctx context.Context, entityType, entityID string, page, limit int64) (*TransitionResult, error) {
+	result := &TransitionResult{Page: page, Limit: limit}
+	for _, transition := range m.transitions {
+		if transition.EntityType == entityType && transition.EntityID == entityID {
+			result.Transitions = append(result.Transitions, transition)
+			result.Total++
+		}
+	}
+
+	return result, nil
+}
+
+// memoryAudit keeps the audit entries and the events raised along with them in memory
+type memoryAudit struct {
+	entries []AuditEntry
+	events  []Event
+}
+
+func (m *memoryAudit) Add(ctx context.Context, entry *AuditEntry) error {
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+func (m *memoryAudit) GetItems(ctx context.Context, filterParams FilterAuditParams) (*AuditResult, error) {
+	return &AuditResult{}, nil
+}
+
+type memoryOutbox struct {
+	OutboxRepo
+	audit *memoryAudit
+}
+
+func (m memoryOutbox) Add(ctx context.Context, event *Event) error {
+	m.audit.events = append(m.audit.events, *event)
+	return nil
+}
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		entity string
+		from   string
+		to     string
+		ok     bool
+	}{
+		{AuditEntityProduct, "", ProductStatusDraft, true},
+		{AuditEntityProduct, "", ProductStatusArchived, false},
+		{AuditEntityProduct, ProductStatusDraft, ProductStatusPendingReview, true},
+		{AuditEntityProduct, ProductStatusDraft, ProductStatusActive, false},
+		{AuditEntityProduct, ProductStatusPendingReview, ProductStatusActive, true},
+		{AuditEntityProduct, ProductStatusActive, ProductStatusOutOfStock, true},
+		{AuditEntityProduct, ProductStatusActive, ProductStatusDraft, false},
+		{AuditEntityProduct, ProductStatusOutOfStock, ProductStatusActive, true},
+		{AuditEntityProduct, ProductStatusDiscontinued, ProductStatusOutOfStock, false},
+		{AuditEntityProduct, ProductStatusArchived, ProductStatusDraft, true},
+		{AuditEntityProduct, ProductStatusArchived, ProductStatusActive, false},
+		{AuditEntityProduct, ProductStatusActive, ProductStatusActive, false},
+		{AuditEntityBrand, "", StatusActive, true},
+		{AuditEntityBrand, "", StatusArchived, false},
+		{AuditEntityCategory, StatusActive, StatusArchived, true},
+		{AuditEntitySupplier, StatusArchived, StatusInactive, true},
+		{AuditEntitySupplier, StatusArchived, StatusActive, false},
+		{AuditEntityReview, "", ModerationStatusPending, true},
+		{AuditEntityReview, ModerationStatusPending, ModerationStatusApproved, true},
+		{AuditEntityQuestion, ModerationStatusApproved, ModerationStatusPending, false},
+		{AuditEntityQuestion, ModerationStatusRejected, ModerationStatusApproved, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %q to %s", tt.entity, tt.from, tt.to), func(t *testing.T) {
+			err := checkTransition(tt.entity, tt.from, tt.to)
+			if tt.ok {
+				if err != nil {
+					t.Errorf("checkTransition = %v, want nil", err)
+				}
+
+				return
+			}
+
+			var transitionErr *TransitionError
+			if !errors.As(err, &transitionErr) {
+				t.Fatalf("checkTransition = %v, want a TransitionError", err)
+			}
+
+			if fmt.Sprint(transitionErr.Allowed) != fmt.Sprint(entityTransitions[tt.entity][tt.from]) {
+				t.Errorf("allowed = %q, want %q", transitionErr.Allowed, entityTransitions[tt.entity][tt.from])
+			}
+		})
+	}
+}
+
+func TestTransitionBrand(t *testing.T) {
+	brands := &memoryBrands{brands: map[string]Brand{
+		"acme": {ID: "acme", Name: "Acme", Status: StatusActive, Version: 1},
+	}}
+	transitions := &memoryTransitions{}
+	audit := &memoryAudit{}
+	svc := &service{
+		brandRepo:      brands,
+		transitionRepo: transitions,
+		auditRepo:      audit,
+		outboxRepo:     memoryOutbox{audit: audit},
+		tx:             noTx{},
+	}
+
+	ctx := WithActor(context.Background(), "catalog-team")
+
+	brand, err := svc.TransitionBrand(ctx, "acme", StatusInactive, "rebranding", 1)
+	if err != nil {
+		t.Fatalf("TransitionBrand: %v", err)
+	}
+
+	if brand.Status != StatusInactive || brand.Version != 2 {
+		t.Errorf("brand = %+v, want inactive at version 2", brand)
+	}
+
+	result, err := svc.GetTransitions(ctx, AuditEntityBrand, "acme", 1, 10)
+	if err != nil {
+		t.Fatalf("GetTransitions: %v", err)
+	}
+
+	want := Transition{EntityType: AuditEntityBrand, EntityID: "acme", FromStatus: StatusActive, ToStatus: StatusInactive, Reason: "rebranding", Actor: "catalog-team"}
+	if result.Total != 1 || result.Transitions[0].CreatedAt == 0 {
+		t.Fatalf("transitions = %+v, want %+v", result.Transitions, want)
+	}
+
+	if got := result.Transitions[0]; got.FromStatus != want.FromStatus || got.ToStatus != want.ToStatus || got.Reason != want.Reason || got.Actor != want.Actor {
+		t.Errorf("transition = %+v, want %+v", got, want)
+	}
+
+	if len(audit.entries) != 1 || !hasEvent(audit.events, EventStatusChanged) {
+		t.Errorf("audited %d changes and raised %+v, want the change audited and its status change raised", len(audit.entries), audit.events)
+	}
+
+	// a stale version changes nothing
+	if _, err := svc.TransitionBrand(ctx, "acme", StatusActive, "", 1); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("TransitionBrand of a stale version = %v, want %v", err, ErrVersionConflict)
+	}
+
+	// an archived brand is made inactive before it is active again
+	brands.brands["acme"] = Brand{ID: "acme", Status: StatusArchived, Version: 2}
+
+	var transitionErr *TransitionError
+	if _, err := svc.TransitionBrand(ctx, "acme", StatusActive, "", 2); !errors.As(err, &transitionErr) {
+		t.Errorf("TransitionBrand = %v, want a TransitionError", err)
+	}
+
+	if brands.brands["acme"].Version != 2 || len(transitions.transitions) != 1 {
+		t.Errorf("a refused transition changed the brand or was recorded")
+	}
+}
+
+func hasEvent(events []Event, eventType string) bool {
+	for _, event := range events {
+		if event.Type == eventType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -5,9 +5,21 @@ type Supplier struct {
 	Name               string `json:"name"`
 	Email              string `json:"email"`
 	Phone              string `json:"phone"`
-	StatusID           int    `json:"status_id"`
+	Status             string `json:"status"`
 	IsVerifiedSupplier bool   `json:"is_verified_supplier"`
 	CreatedAt          int64  `json:"created_at"`
+	DeletedAt          int64  `json:"deleted_at,omitempty"`
+	Version            int64  `json:"version"`
+}
+
+// SupplierPatch is a partial update of a supplier, nil fields are left untouched
+type SupplierPatch struct {
+	Name               *string
+	Email              *string
+	Phone              *string
+	Status             *string
+	IsVerifiedSupplier *bool
+	Version            int64
 }
 
 type SupplierResult struct {
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// supplierPortal scopes the product operations of Service to a single supplier
+type supplierPortal struct {
+	svc Service
+}
+
+func NewSupplierPortal(svc Service) SupplierPortal {
+	return &supplierPortal{
+		svc: svc,
+	}
+}
+
+// ownedProduct is the single place where supplier ownership of a product is enforced.
+// a product owned by another supplier is reported as not found so its existence is not leaked
+func (p *supplierPortal) ownedProduct(ctx context.Context, supplierID, productID string) (*Product, error) {
+	product, err := p.svc.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Supplier.ID != supplierID {
+		return nil, ErrProductNotFound
+	}
+
+	return product, nil
+}
+
+func (p *supplierPortal) GetProducts(ctx context.Context, supplierID string, filterParams FilterProductsParams) (*ProductResult, error) {
+	filterParams.SupplierID = supplierID
+
+	// suppliers see their products in every status, not only the live ones
+	if len(filterParams.Statuses) == 0 {
+		filterParams.Statuses = ProductStatuses()
+	}
+
+	return p.svc.GetProducts(ctx, filterParams)
+}
+
+func (p *supplierPortal) GetProduct(ctx context.Context, supplierID, productID string) (*Product, error) {
+	return p.ownedProduct(ctx, supplierID, productID)
+}
+
+func (p *supplierPortal) AddProduct(ctx context.Context, supplierID string, product *Product) (*Product, error) {
+	spplr, err := p.svc.GetSupplier(ctx, supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	brand, err := p.svc.GetBrand(ctx, product.Brand.ID)
+	if errors.Is(err, ErrBrandNotFound) {
+		return nil, NewReferenceError("brand_id", err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	ctgry, err := p.svc.GetCategory(ctx, product.Category.ID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return nil, NewReferenceError("category_id", err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// a product of a supplier goes live once the catalog team approves it
+	if product.Status == ProductStatusActive {
+		return nil, ErrApprovalRequired
+	}
+
+	product.Brand = *brand
+	product.Category = *ctgry
+	product.Supplier = *spplr
+
+	return p.svc.AddProduct(ctx, product)
+}
+
+func (p *supplierPortal) UpdateProduct(ctx context.Context, supplierID, productID string, product *Product) error {
+	owned, err := p.ownedProduct(ctx, supplierID, productID)
+	if err != nil {
+		return err
+	}
+
+	// suppliers can never hand a product over to another supplier, and move it to another
+	// status only through a transition. the schedule is up to the catalog team
+	product.Supplier = Supplier{ID: supplierID}
+	product.Status = ""
+	product.PublishAt = owned.PublishAt
+	product.UnpublishAt = owned.UnpublishAt
+
+	return p.svc.UpdateProduct(ctx, productID, product)
+}
+
+func (p *supplierPortal) ArchiveProduct(ctx context.Context, supplierID, productID string) error {
+	product, err := p.ownedProduct(ctx, supplierID, productID)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.svc.TransitionProduct(ctx, productID, ProductStatusArchived, "archived by the supplier", product.Version)
+	return err
+}
+
+// TransitionProduct moves a product of the supplier to the status, all but approving a
+// product under review or a draft, which is up to the catalog team
+func (p *supplierPortal) TransitionProduct(ctx context.Context, supplierID, productID, status, reason string, version int64) (*Product, error) {
+	product, err := p.ownedProduct(ctx, supplierID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == ProductStatusActive && (product.Status == ProductStatusPendingReview || product.Status == ProductStatusDraft) {
+		return nil, ErrApprovalRequired
+	}
+
+	return p.svc.TransitionProduct(ctx, productID, status, reason, version)
+}
+
+func (p *supplierPortal) AdjustStock(ctx context.Context, supplierID string, movement *StockMovement) (*ProductStock, error) {
+	product, err := p.ownedProduct(ctx, supplierID, movement.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	movement.UnitPrice = product.UnitPrice
+
+	return p.svc.AdjustStock(ctx, movement)
+}
+
+func (p *supplierPortal) GetSalesReport(ctx context.Context, supplierID string, from, to int64) ([]SalesReportItem, error) {
+	return p.svc.GetSalesReport(ctx, supplierID, from, to)
+}
+
+func (p *supplierPortal) GetLowStockReport(ctx context.Context, supplierID string, threshold int64) ([]LowStockItem, error) {
+	return p.svc.GetLowStockReport(ctx, supplierID, threshold)
+}
+
+// GetQuestions lists the approved questions on a product of the supplier, the ones it can answer
+func (p *supplierPortal) GetQuestions(ctx context.Context, supplierID, productID string, filterParams FilterQuestionsParams) (*QuestionResult, error) {
+	if _, err := p.ownedProduct(ctx, supplierID, productID); err != nil {
+		return nil, err
+	}
+
+	filterParams.ProductID = productID
+	filterParams.Statuses = []string{ModerationStatusApproved}
+
+	return p.svc.GetQuestions(ctx, filterParams)
+}
+
+// AnswerQuestion answers a question on a product of the supplier in the name of the supplier
+func (p *supplierPortal) AnswerQuestion(ctx context.Context, supplierID, productID string, answer *Answer) (*Answer, error) {
+	if _, err := p.ownedProduct(ctx, supplierID, productID); err != nil {
+		return nil, err
+	}
+
+	spplr, err := p.svc.GetSupplier(ctx, supplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	answer.AuthorType = AnswerAuthorSupplier
+	answer.AuthorID = supplierID
+	answer.AuthorName = spplr.Name
+
+	return p.svc.AnswerQuestion(ctx, productID, answer)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"net/mail"
+
+	"github.com/jsiqbal/ecommerce/apperr"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// ValidationError reports a field of a record breaking the rules the rest api
+// binds its bodies with, for the apis which have no binding of their own
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+func (e *ValidationError) Kind() apperr.Kind {
+	return apperr.KindValidation
+}
+
+func (e *ValidationError) Code() string {
+	return "invalid_field"
+}
+
+func (e *ValidationError) Fields() []apperr.FieldError {
+	return []apperr.FieldError{{Field: e.Field, Message: e.Message}}
+}
+
+func ValidateBrand(brand *Brand) error {
+	if err := validateLength("name", brand.Name, 2, 50); err != nil {
+		return err
+	}
+
+	if err := validateLength("description", brand.Description, 0, 2000); err != nil {
+		return err
+	}
+
+	if len(brand.Website) > 0 && (len(brand.Website) > 2048 || !util.IsSupportedWebsite(brand.Website)) {
+		return &ValidationError{Field: "website", Message: "must be an http or https url"}
+	}
+
+	if len(brand.CountryOfOrigin) > 0 && !util.IsSupportedCountryCode(brand.CountryOfOrigin) {
+		return &ValidationError{Field: "country_of_origin", Message: "must be an ISO 3166-1 alpha-2 country code"}
+	}
+
+	return validateStatus(brand.Status, IsStatus)
+}
+
+func ValidateCategory(ctgry *Category) error {
+	if err := validateLength("name", ctgry.Name, 2, 50); err != nil {
+		return err
+	}
+
+	return validateStatus(ctgry.Status, IsStatus)
+}
+
+func ValidateSupplier(spplr *Supplier) error {
+	if err := validateLength("name", spplr.Name, 2, 50); err != nil {
+		return err
+	}
+
+	if _, err := mail.ParseAddress(spplr.Email); err != nil {
+		return &ValidationError{Field: "email", Message: "must be a valid email address"}
+	}
+
+	if !util.IsSupportedPhone(spplr.Phone) {
+		return &ValidationError{Field: "phone", Message: "must be a supported phone number"}
+	}
+
+	return validateStatus(spplr.Status, IsStatus)
+}
+
+func ValidateProduct(product *Product) error {
+	if err := validateLength("name", product.Name, 2, 50); err != nil {
+		return err
+	}
+
+	if err := validateLength("description", product.Description, 2, 500); err != nil {
+		return err
+	}
+
+	if err := validateLength("specifications", product.Specifications, 0, 500); err != nil {
+		return err
+	}
+
+	if product.UnitPrice < 0 {
+		return &ValidationError{Field: "unit_price", Message: "must not be negative"}
+	}
+
+	if product.DiscountPrice < 0 {
+		return &ValidationError{Field: "discount_price", Message: "must not be negative"}
+	}
+
+	if product.ProductStock.StockQuantity < 1 {
+		return &ValidationError{Field: "stock_quantity", Message: "must be at least 1"}
+	}
+
+	if err := validateSchedule(product.PublishAt, product.UnpublishAt); err != nil {
+		return err
+	}
+
+	return validateStatus(product.Status, IsProductStatus)
+}
+
+func validateLength(field, value string, min, max int) error {
+	if len(value) < min || len(value) > max {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("must be between %d and %d characters", min, max)}
+	}
+
+	return nil
+}
+
+// validateStatus accepts the statuses isStatus knows and none, which leaves the status
+// to its default or as it is
+func validateStatus(status string, isStatus func(string) bool) error {
+	if len(status) > 0 && !isStatus(status) {
+		return &ValidationError{Field: "status", Message: "is not supported"}
+	}
+
+	return nil
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/util"
+	"github.com/jsiqbal/ecommerce/webhook"
+)
+
+// webhook delivery statuses, a failed delivery used up its attempts
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryRetrying  = "retrying"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// JobTypeWebhookDelivery delivers an event to a webhook subscription
+const JobTypeWebhookDelivery = "webhook_delivery"
+
+// webhookMaxAttempts is how many times a delivery is tried, the job queue backs off between them
+const webhookMaxAttempts = 8
+
+type WebhookSubscription struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	IsActive   bool     `json:"is_active"`
+	CreatedAt  int64    `json:"created_at"`
+	UpdatedAt  int64    `json:"updated_at"`
+}
+
+type WebhookSubscriptionResult struct {
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+	Total         int64                 `json:"total"`
+	Page          int64                 `json:"page"`
+	Limit         int64                 `json:"limit"`
+}
+
+// WebhookDelivery is an event sent, or to be sent, to a subscription
+type WebhookDelivery struct {
+	ID             string           `json:"id"`
+	SubscriptionID string           `json:"subscription_id"`
+	EventID        string           `json:"event_id"`
+	EventType      string           `json:"event_type"`
+	IdempotencyKey string           `json:"idempotency_key"`
+	Payload        json.RawMessage  `json:"payload"`
+	Status         string           `json:"status"`
+	Attempts       int              `json:"attempts"`
+	ResponseCode   int              `json:"response_code,omitempty"`
+	LastError      string           `json:"last_error,omitempty"`
+	AttemptLog     []WebhookAttempt `json:"attempt_log"`
+	CreatedAt      int64            `json:"created_at"`
+	UpdatedAt      int64            `json:"updated_at"`
+	DeliveredAt    int64            `json:"delivered_at,omitempty"`
+}
+
+// WebhookAttempt is the log of a single try of a delivery
+type WebhookAttempt struct {
+	Attempt      int    `json:"attempt"`
+	ResponseCode int    `json:"response_code,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+	Error        string `json:"error,omitempty"`
+	DurationMs   int64  `json:"duration_ms"`
+	AttemptedAt  int64  `json:"attempted_at"`
+}
+
+type WebhookDeliveryResult struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int64             `json:"total"`
+	Page       int64             `json:"page"`
+	Limit      int64             `json:"limit"`
+}
+
+// WebhookDeliveryPayload is the payload of a webhook delivery job
+type WebhookDeliveryPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// webhookClient only reaches public addresses and does not follow redirects, so a
+// subscription can not make the server call into its own network
+var webhookClient = webhook.NewClient(webhook.Timeout)
+
+func (s *service) AddWebhookSubscription(ctx context.Context, sub *WebhookSubscription) (*WebhookSubscription, error) {
+	if err := validateEventTypes(sub.EventTypes); err != nil {
+		return nil, err
+	}
+
+	if err := webhook.CheckURL(ctx, sub.URL); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookURLNotAllowed, err)
+	}
+
+	if len(sub.Secret) == 0 {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			return nil, err
+		}
+
+		sub.Secret = secret
+	}
+
+	now := util.GetCurrentTimestamp()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+
+	return s.webhookSubRepo.Add(ctx, sub)
+}
+
+func (s *service) GetWebhookSubscription(ctx context.Context, subID string) (*WebhookSubscription, error) {
+	return s.webhookSubRepo.GetItemByID(ctx, subID)
+}
+
+func (s *service) GetWebhookSubscriptions(ctx context.Context, page, limit int64) (*WebhookSubscriptionResult, error) {
+	return s.webhookSubRepo.GetItems(ctx, page, limit)
+}
+
+// UpdateWebhookSubscription replaces the url, event types and state of a
+// subscription, the secret is only replaced when a new one is given
+func (s *service) UpdateWebhookSubscription(ctx context.Context, subID string, sub *WebhookSubscription) (*WebhookSubscription, error) {
+	if err := validateEventTypes(sub.EventTypes); err != nil {
+		return nil, err
+	}
+
+	if err := webhook.CheckURL(ctx, sub.URL); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookURLNotAllowed, err)
+	}
+
+	existSub, err := s.webhookSubRepo.GetItemByID(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	existSub.URL = sub.URL
+	existSub.EventTypes = sub.EventTypes
+	existSub.IsActive = sub.IsActive
+	existSub.UpdatedAt = util.GetCurrentTimestamp()
+
+	if len(sub.Secret) > 0 {
+		existSub.Secret = sub.Secret
+	}
+
+	if err := s.webhookSubRepo.UpdateItemByID(ctx, subID, existSub); err != nil {
+		return nil, err
+	}
+
+	return existSub, nil
+}
+
+// DeleteWebhookSubscription removes a subscription along with its deliveries
+func (s *service) DeleteWebhookSubscription(ctx context.Context, subID string) error {
+	affected, err := s.webhookSubRepo.DeleteItemByID(ctx, subID)
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (s *service) GetWebhookDeliveries(ctx context.Context, subID string, page, limit int64) (*WebhookDeliveryResult, error) {
+	return s.webhookDeliveryRepo.GetItems(ctx, subID, page, limit)
+}
+
+// RedeliverWebhook sends a delivery of the subscription again with a fresh set
+// of attempts, whatever the outcome of the previous ones
+func (s *service) RedeliverWebhook(ctx context.Context, subID, deliveryID string) (*WebhookDelivery, error) {
+	var delivery *WebhookDelivery
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		delivery, err = s.webhookDeliveryRepo.GetItemByID(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+
+		if delivery.SubscriptionID != subID {
+			return ErrWebhookDeliveryNotFound
+		}
+
+		delivery.Status = WebhookDeliveryPending
+		delivery.UpdatedAt = util.GetCurrentTimestamp()
+
+		if err := s.webhookDeliveryRepo.UpdateItemByID(ctx, deliveryID, delivery); err != nil {
+			return err
+		}
+
+		_, err = s.EnqueueJob(ctx, JobTypeWebhookDelivery, WebhookDeliveryPayload{DeliveryID: deliveryID}, JobOptions{MaxAttempts: webhookMaxAttempts})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// DispatchWebhooks records a delivery of the event for every active subscription
+// to its type and queues them. the outbox relays an event at least once, a
+// subscription already holding a delivery of the event is skipped
+func (s *service) DispatchWebhooks(ctx context.Context, event *Event) error {
+	subs, err := s.webhookSubRepo.GetActiveItems(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+
+	if len(subs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for _, sub := range subs {
+			now := util.GetCurrentTimestamp()
+
+			delivery, err := s.webhookDeliveryRepo.Add(ctx, &WebhookDelivery{
+				SubscriptionID: sub.ID,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				IdempotencyKey: event.IdempotencyKey,
+				Payload:        payload,
+				Status:         WebhookDeliveryPending,
+				AttemptLog:     []WebhookAttempt{},
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			})
+			if err != nil {
+				return err
+			}
+
+			// delivered to this subscription before
+			if delivery == nil {
+				continue
+			}
+
+			_, err = s.EnqueueJob(ctx, JobTypeWebhookDelivery, WebhookDeliveryPayload{DeliveryID: delivery.ID}, JobOptions{MaxAttempts: webhookMaxAttempts})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeliverWebhook makes an attempt of a delivery and logs it. it returns an error
+// when the receiver did not accept the delivery, for the job queue to retry it,
+// final marks the last attempt the queue will make
+func (s *service) DeliverWebhook(ctx context.Context, deliveryID string, final bool) error {
+	delivery, err := s.webhookDeliveryRepo.GetItemByID(ctx, deliveryID)
+	if errors.Is(err, ErrWebhookDeliveryNotFound) {
+		// the subscription was deleted along with its deliveries
+		logger.Warn(ctx, "webhook delivery not found", deliveryID)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	sub, err := s.webhookSubRepo.GetItemByID(ctx, delivery.SubscriptionID)
+	if errors.Is(err, ErrWebhookNotFound) {
+		logger.Warn(ctx, "webhook subscription not found", delivery.SubscriptionID)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	delivery.Attempts++
+
+	attempt := WebhookAttempt{
+		Attempt:     delivery.Attempts,
+		AttemptedAt: util.GetCurrentTimestamp(),
+	}
+
+	var deliveryErr error
+
+	if sub.IsActive {
+		res, err := webhook.Send(ctx, webhookClient, &webhook.Request{
+			URL:    sub.URL,
+			Secret: sub.Secret,
+			Body:   delivery.Payload,
+			Headers: map[string]string{
+				webhook.HeaderDeliveryID:     delivery.ID,
+				webhook.HeaderEventType:      delivery.EventType,
+				webhook.HeaderIdempotencyKey: delivery.IdempotencyKey,
+			},
+		})
+
+		switch {
+		case err != nil:
+			deliveryErr = err
+		case !res.OK():
+			deliveryErr = fmt.Errorf("receiver answered %d", res.StatusCode)
+		}
+
+		if res != nil {
+			attempt.ResponseCode = res.StatusCode
+			attempt.ResponseBody = res.Body
+			attempt.DurationMs = res.Duration.Milliseconds()
+		}
+	} else {
+		// a deactivated subscription gets nothing more, retrying would not change that
+		deliveryErr = ErrWebhookInactive
+		final = true
+	}
+
+	now := util.GetCurrentTimestamp()
+
+	delivery.ResponseCode = attempt.ResponseCode
+	delivery.LastError = ""
+	delivery.UpdatedAt = now
+
+	switch {
+	case deliveryErr == nil:
+		delivery.Status = WebhookDeliverySucceeded
+		delivery.DeliveredAt = now
+	case final:
+		delivery.Status = WebhookDeliveryFailed
+	default:
+		delivery.Status = WebhookDeliveryRetrying
+	}
+
+	if deliveryErr != nil {
+		attempt.Error = deliveryErr.Error()
+		delivery.LastError = deliveryErr.Error()
+	}
+
+	delivery.AttemptLog = append(delivery.AttemptLog, attempt)
+
+	if err := s.webhookDeliveryRepo.UpdateItemByID(ctx, deliveryID, delivery); err != nil {
+		logger.Error(ctx, "cannot save webhook delivery", err)
+		return err
+	}
+
+	if errors.Is(deliveryErr, ErrWebhookInactive) {
+		return nil
+	}
+
+	return deliveryErr
+}
+
+// FailWebhookDelivery marks a delivery failed whose job is dead without the delivery knowing,
+// e.g. as the worker of its last attempt went away. a delivery which finished keeps its outcome
+func (s *service) FailWebhookDelivery(ctx context.Context, deliveryID string, cause error) error {
+	delivery, err := s.webhookDeliveryRepo.GetItemByID(ctx, deliveryID)
+	if errors.Is(err, ErrWebhookDeliveryNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if delivery.Status == WebhookDeliverySucceeded || delivery.Status == WebhookDeliveryFailed {
+		return nil
+	}
+
+	delivery.Status = WebhookDeliveryFailed
+	delivery.LastError = cause.Error()
+	delivery.UpdatedAt = util.GetCurrentTimestamp()
+
+	if err := s.webhookDeliveryRepo.UpdateItemByID(ctx, deliveryID, delivery); err != nil {
+		logger.Error(ctx, "cannot save webhook delivery", err)
+		return err
+	}
+
+	return nil
+}
+
+// validateEventTypes checks a subscription lists known event types only
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !IsEventType(eventType) {
+			return fmt.Errorf("%w: %s", ErrInvalidEventType, eventType)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/webhook"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// noTx runs the function without a transaction
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// memoryWebhooks keeps the subscriptions and deliveries in memory
+type memoryWebhooks struct {
+	mu         sync.Mutex
+	subs       map[string]*WebhookSubscription
+	deliveries map[string]*WebhookDelivery
+}
+
+func newMemoryWebhooks(subs ...WebhookSubscription) *memoryWebhooks {
+	m := &memoryWebhooks{
+		subs:       make(map[string]*WebhookSubscription),
+		deliveries: make(map[string]*WebhookDelivery),
+	}
+
+	for i := range subs {
+		sub := subs[i]
+		m.subs[sub.ID] = &sub
+	}
+
+	return m
+}
+
+func (m *memoryWebhooks) Add(ctx context.Context, sub *WebhookSubscription) (*WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub.ID = fmt.Sprint("sub-", len(m.subs)+1)
+	m.subs[sub.ID] = sub
+
+	return sub, nil
+}
+
+func (m *memoryWebhooks) GetItemByID(ctx context.Context, subID string) (*WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subs[subID]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+
+	copied := *sub
+	return &copied, nil
+}
+
+func (m *memoryWebhooks) GetItems(ctx context.Context, page int64, limit int64) (*WebhookSubscriptionResult, error) {
+	return nil, nil
+}
+
+func (m *memoryWebhooks) GetActiveItems(ctx context.Context, eventType string) ([]WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var subs []WebhookSubscription
+	for _, sub := range m.subs {
+		for _, subType := range sub.EventTypes {
+			if sub.IsActive && subType == eventType {
+				subs = append(subs, *sub)
+			}
+		}
+	}
+
+	return subs, nil
+}
+
+func (m *memoryWebhooks) UpdateItemByID(ctx context.Context, subID string, sub *WebhookSubscription) error {
+	return nil
+}
+
+func (m *memoryWebhooks) DeleteItemByID(ctx context.Context, subID string) (int64, error) {
+	return 0, nil
+}
+
+// memoryDeliveries is the delivery repo of memoryWebhooks
+type memoryDeliveries struct {
+	*memoryWebhooks
+}
+
+func (m memoryDeliveries) Add(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.deliveries {
+		if existing.SubscriptionID == delivery.SubscriptionID && existing.EventID == delivery.EventID {
+			return nil, nil
+		}
+	}
+
+	delivery.ID = fmt.Sprint("delivery-", len(m.deliveries)+1)
+	copied := *delivery
+	m.deliveries[delivery.ID] = &copied
+
+	return delivery, nil
+}
+
+func (m memoryDeliveries) GetItemByID(ctx context.Context, deliveryID string) (*WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery, ok := m.deliveries[deliveryID]
+	if !ok {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	copied := *delivery
+	copied.AttemptLog = append([]WebhookAttempt(nil), delivery.AttemptLog...)
+
+	return &copied, nil
+}
+
+func (m memoryDeliveries) GetItems(ctx context.Context, subID string, page int64, limit int64) (*WebhookDeliveryResult, error) {
+	return nil, nil
+}
+
+func (m memoryDeliveries) UpdateItemByID(ctx context.Context, deliveryID string, delivery *WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *delivery
+	m.deliveries[deliveryID] = &copied
+
+	return nil
+}
+
+// memoryJobs records the enqueued jobs
+type memoryJobs struct {
+	JobRepo
+	jobs []*Job
+}
+
+func (m *memoryJobs) Add(ctx context.Context, job *Job) (*Job, error) {
+	job.ID = fmt.Sprint("job-", len(m.jobs)+1)
+	m.jobs = append(m.jobs, job)
+
+	return job, nil
+}
+
+// receiver is a webhook receiver answering the statuses in turn, checking the
+// signature of every request
+type receiver struct {
+	t        *testing.T
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	if err := webhook.Verify(testWebhookSecret, r.Header.Get(webhook.HeaderSignature), body, time.Minute); err != nil {
+		rc.t.Errorf("delivery signature: %v", err)
+	}
+
+	rc.mu.Lock()
+	status := rc.statuses[len(rc.requests)%len(rc.statuses)]
+	rc.requests = append(rc.requests, r)
+	rc.mu.Unlock()
+
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "answered %d", status)
+}
+
+// newWebhookService returns a service delivering the event types to a subscription
+// of the receiver, with a delivery of an event recorded
+func newWebhookService(t *testing.T, rc *receiver) (*service, memoryDeliveries, *memoryJobs) {
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	// the receiver listens on loopback, which the delivery client refuses
+	client := webhookClient
+	webhookClient = srv.Client()
+	t.Cleanup(func() { webhookClient = client })
+
+	webhooks := newMemoryWebhooks(WebhookSubscription{
+		ID:         "sub-1",
+		URL:        srv.URL,
+		Secret:     testWebhookSecret,
+		EventTypes: []string{EventProductCreated},
+		IsActive:   true,
+	})
+	deliveries := memoryDeliveries{webhooks}
+	jobs := &memoryJobs{}
+
+	svc := &service{
+		webhookSubRepo:      webhooks,
+		webhookDeliveryRepo: deliveries,
+		jobRepo:             jobs,
+		tx:                  noTx{},
+	}
+
+	event := &Event{
+		ID:             "event-1",
+		Type:           EventProductCreated,
+		SchemaVersion:  1,
+		AggregateType:  AuditEntityProduct,
+		AggregateID:    "product-1",
+		IdempotencyKey: "key-1",
+		Data:           json.RawMessage(`{"name":"phone"}`),
+	}
+
+	if err := svc.DispatchWebhooks(context.Background(), event); err != nil {
+		t.Fatalf("DispatchWebhooks: %v", err)
+	}
+
+	return svc, deliveries, jobs
+}
+
+func TestDispatchWebhooksQueuesADeliveryOnce(t *testing.T) {
+	svc, deliveries, jobs := newWebhookService(t, &receiver{t: t, statuses: []int{http.StatusOK}})
+
+	delivery, err := deliveries.GetItemByID(context.Background(), "delivery-1")
+	if err != nil {
+		t.Fatalf("no delivery recorded: %v", err)
+	}
+
+	if delivery.Status != WebhookDeliveryPending || delivery.EventID != "event-1" || delivery.SubscriptionID != "sub-1" {
+		t.Errorf("delivery = %+v", delivery)
+	}
+
+	if len(jobs.jobs) != 1 {
+		t.Fatalf("%d jobs queued, want 1", len(jobs.jobs))
+	}
+
+	job := jobs.jobs[0]
+	if job.Type != JobTypeWebhookDelivery || job.MaxAttempts != webhookMaxAttempts {
+		t.Errorf("job = %+v", job)
+	}
+
+	var payload WebhookDeliveryPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil || payload.DeliveryID != delivery.ID {
+		t.Errorf("job payload = %s", job.Payload)
+	}
+
+	// the outbox relays an event at least once
+	event := &Event{ID: "event-1", Type: EventProductCreated}
+	if err := svc.DispatchWebhooks(context.Background(), event); err != nil {
+		t.Fatalf("DispatchWebhooks again: %v", err)
+	}
+
+	if len(jobs.jobs) != 1 {
+		t.Errorf("a relayed event was queued again")
+	}
+}
+
+func TestDeliverWebhookRetriesUntilAccepted(t *testing.T) {
+	rc := &receiver{t: t, statuses: []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK}}
+	svc, deliveries, _ := newWebhookService(t, rc)
+	ctx := context.Background()
+
+	for attempt, status := range []int{http.StatusServiceUnavailable, http.StatusInternalServerError} {
+		if err := svc.DeliverWebhook(ctx, "delivery-1", false); err == nil {
+			t.Fatalf("attempt %d: a %d answer did not fail the attempt", attempt+1, status)
+		}
+
+		delivery, _ := deliveries.GetItemByID(ctx, "delivery-1")
+		if delivery.Status != WebhookDeliveryRetrying {
+			t.Errorf("attempt %d: status = %s, want %s", attempt+1, delivery.Status, WebhookDeliveryRetrying)
+		}
+
+		if delivery.Attempts != attempt+1 || delivery.ResponseCode != status {
+			t.Errorf("attempt %d: attempts = %d, response code = %d", attempt+1, delivery.Attempts, delivery.ResponseCode)
+		}
+
+		if want := fmt.Sprintf("receiver answered %d", status); delivery.LastError != want {
+			t.Errorf("attempt %d: last error = %q, want %q", attempt+1, delivery.LastError, want)
+		}
+	}
+
+	if err := svc.DeliverWebhook(ctx, "delivery-1", false); err != nil {
+		t.Fatalf("accepted delivery: %v", err)
+	}
+
+	delivery, _ := deliveries.GetItemByID(ctx, "delivery-1")
+	if delivery.Status != WebhookDeliverySucceeded || delivery.DeliveredAt == 0 || len(delivery.LastError) > 0 {
+		t.Errorf("delivery = %+v", delivery)
+	}
+
+	if len(delivery.AttemptLog) != 3 {
+		t.Fatalf("attempt log has %d entries, want 3", len(delivery.AttemptLog))
+	}
+
+	for i, status := range rc.statuses {
+		logged := delivery.AttemptLog[i]
+		if logged.Attempt != i+1 || logged.ResponseCode != status || logged.ResponseBody != fmt.Sprintf("answered %d", status) {
+			t.Errorf("attempt log %d = %+v", i, logged)
+		}
+	}
+
+	for _, r := range rc.requests {
+		if r.Header.Get(webhook.HeaderDeliveryID) != "delivery-1" ||
+			r.Header.Get(webhook.HeaderEventType) != EventProductCreated ||
+			r.Header.Get(webhook.HeaderIdempotencyKey) != "key-1" {
+			t.Errorf("delivery headers = %v", r.Header)
+		}
+	}
+}
+
+func TestDeliverWebhookFailsOnLastAttempt(t *testing.T) {
+	svc, deliveries, _ := newWebhookService(t, &receiver{t: t, statuses: []int{http.StatusBadGateway}})
+	ctx := context.Background()
+
+	if err := svc.DeliverWebhook(ctx, "delivery-1", true); err == nil {
+		t.Fatal("a 502 answer did not fail the attempt")
+	}
+
+	delivery, _ := deliveries.GetItemByID(ctx, "delivery-1")
+	if delivery.Status != WebhookDeliveryFailed || delivery.ResponseCode != http.StatusBadGateway {
+		t.Errorf("delivery = %+v", delivery)
+	}
+}
+
+func TestDeliverWebhookToInactiveSubscription(t *testing.T) {
+	rc := &receiver{t: t, statuses: []int{http.StatusOK}}
+	svc, deliveries, _ := newWebhookService(t, rc)
+	ctx := context.Background()
+
+	svc.webhookSubRepo.(*memoryWebhooks).subs["sub-1"].IsActive = false
+
+	// nothing to retry
+	if err := svc.DeliverWebhook(ctx, "delivery-1", false); err != nil {
+		t.Fatalf("DeliverWebhook: %v", err)
+	}
+
+	delivery, _ := deliveries.GetItemByID(ctx, "delivery-1")
+	if delivery.Status != WebhookDeliveryFailed || delivery.LastError != ErrWebhookInactive.Error() {
+		t.Errorf("delivery = %+v", delivery)
+	}
+
+	if len(rc.requests) > 0 {
+		t.Errorf("an inactive subscription was posted to")
+	}
+}
+
+func TestAddWebhookSubscriptionRefusesPrivateURLs(t *testing.T) {
+	webhooks := newMemoryWebhooks()
+	svc := &service{webhookSubRepo: webhooks}
+
+	for _, url := range []string{"http://127.0.0.1:5000/api/products", "http://169.254.169.254/latest/meta-data", "file:///etc/passwd"} {
+		_, err := svc.AddWebhookSubscription(context.Background(), &WebhookSubscription{URL: url, EventTypes: []string{EventProductCreated}})
+		if !errors.Is(err, ErrWebhookURLNotAllowed) {
+			t.Errorf("%s: AddWebhookSubscription = %v, want %v", url, err, ErrWebhookURLNotAllowed)
+		}
+	}
+
+	if len(webhooks.subs) > 0 {
+		t.Errorf("a subscription was added")
+	}
+}
+
+func TestFailWebhookDelivery(t *testing.T) {
+	rc := &receiver{t: t, statuses: []int{http.StatusServiceUnavailable}}
+	svc, deliveries, _ := newWebhookService(t, rc)
+
+	if err := svc.DeliverWebhook(context.Background(), "delivery-1", false); err == nil {
+		t.Fatal("DeliverWebhook succeeded, want the receiver's 503")
+	}
+
+	// the worker of the last attempt went away, the job is buried
+	if err := svc.FailWebhookDelivery(context.Background(), "delivery-1", ErrJobAbandoned); err != nil {
+		t.Fatalf("FailWebhookDelivery: %v", err)
+	}
+
+	delivery, _ := deliveries.GetItemByID(context.Background(), "delivery-1")
+	if delivery.Status != WebhookDeliveryFailed || delivery.LastError != ErrJobAbandoned.Error() || len(delivery.AttemptLog) != 1 {
+		t.Errorf("delivery = %+v, want failed as abandoned with its attempt kept", delivery)
+	}
+
+	// a finished delivery keeps its outcome
+	delivery.Status = WebhookDeliverySucceeded
+	deliveries.UpdateItemByID(context.Background(), "delivery-1", delivery)
+
+	if err := svc.FailWebhookDelivery(context.Background(), "delivery-1", ErrJobAbandoned); err != nil {
+		t.Fatalf("FailWebhookDelivery: %v", err)
+	}
+
+	if delivery, _ := deliveries.GetItemByID(context.Background(), "delivery-1"); delivery.Status != WebhookDeliverySucceeded {
+		t.Errorf("status = %s, want %s", delivery.Status, WebhookDeliverySucceeded)
+	}
+}
@@ -0,0 +1,320 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// supported formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	// FormatJSONL writes every row as a json object keyed by the header, it can not be read
+	FormatJSONL = "jsonl"
+)
+
+// formulaPrefixes are the first characters of the cells spreadsheet programs run as formulas
+const formulaPrefixes = "=+-@\t\r"
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported spreadsheet format, use csv or xlsx")
+	ErrNoHeader          = errors.New("spreadsheet has no header row")
+)
+
+// FormatOf returns the format of a file by its extension, one that can be written
+func FormatOf(fileName string) (string, error) {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), "."))
+	if format != FormatCSV && format != FormatXLSX && format != FormatJSONL {
+		return "", ErrUnsupportedFormat
+	}
+
+	return format, nil
+}
+
+// ReadFormatOf returns the format of a file by its extension, one that can be read
+func ReadFormatOf(fileName string) (string, error) {
+	format, err := FormatOf(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatJSONL {
+		return "", ErrUnsupportedFormat
+	}
+
+	return format, nil
+}
+
+// Read returns the header and the data rows of a csv file or of the first sheet of a xlsx file
+func Read(r io.Reader, format string) ([]string, [][]string, error) {
+	var (
+		records [][]string
+		err     error
+	)
+
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err = reader.ReadAll()
+	case FormatXLSX:
+		records, err = readXLSX(r)
+	default:
+		return nil, nil, ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, ErrNoHeader
+	}
+
+	for _, record := range records {
+		for i, value := range record {
+			record[i] = unescapeFormula(value)
+		}
+	}
+
+	return records[0], records[1:], nil
+}
+
+// Write writes the header and rows as a csv file, a single sheet xlsx file or json lines.
+// text cells starting like a formula are written with a quote before them in csv and xlsx,
+// which Read drops again
+func Write(w io.Writer, format string, header []string, rows [][]string) error {
+	writer, err := NewWriter(w, format, header)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		values := make([]interface{}, len(row))
+		for i, value := range row {
+			values[i] = value
+		}
+
+		if err := writer.WriteRow(values); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// RowWriter writes rows one at a time so large files never have to be held in memory
+type RowWriter interface {
+	WriteRow(values []interface{}) error
+	// Close flushes the rows written, it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter returns a RowWriter of the format, writing the header first where the format has one
+func NewWriter(w io.Writer, format string, header []string) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+
+		return &csvWriter{writer: writer}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, header)
+	case FormatJSONL:
+		return &jsonlWriter{writer: w, header: header}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns the mime type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	default:
+		return "text/csv"
+	}
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrNoHeader
+	}
+
+	return file.GetRows(sheets[0])
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if text, ok := value.(string); ok {
+			record[i] = escapeFormula(text)
+		} else if value != nil {
+			record[i] = fmt.Sprint(value)
+		}
+	}
+
+	return c.writer.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	writer io.Writer
+	header []string
+}
+
+// WriteRow writes the row as a json object keeping the order of the header
+func (j *jsonlWriter) WriteRow(values []interface{}) error {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, name := range j.header {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		var value interface{}
+		if i < len(values) {
+			value = values[i]
+		}
+
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+
+	buf.WriteString("}\n")
+
+	_, err := j.writer.Write(buf.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+// xlsxWriter streams the rows into a single sheet, excelize keeps large sheets
+// in a temporary file until the workbook is written out on close
+type xlsxWriter struct {
+	writer io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, header []string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	writer := &xlsxWriter{
+		writer: w,
+		file:   file,
+		stream: stream,
+	}
+
+	values := make([]interface{}, len(header))
+	for i, name := range header {
+		values[i] = name
+	}
+
+	if err := writer.WriteRow(values); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (x *xlsxWriter) WriteRow(values []interface{}) error {
+	x.row++
+
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+
+	escaped := make([]interface{}, len(values))
+	for i, value := range values {
+		if text, ok := value.(string); ok {
+			value = escapeFormula(text)
+		}
+
+		escaped[i] = value
+	}
+
+	return x.stream.SetRow(cell, escaped)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+
+	_, err := x.file.WriteTo(x.writer)
+	return err
+}
+
+// escapeFormula puts a quote before a text cell starting like a formula, so spreadsheet
+// programs show it as text instead of running it
+func escapeFormula(value string) string {
+	if len(value) > 0 && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// unescapeFormula drops the quote escapeFormula put before a cell
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+
+	return value
+}
@@ -0,0 +1,93 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestWriteEscapesFormulas(t *testing.T) {
+	header := []string{"name", "description"}
+	rows := [][]string{
+		{"=HYPERLINK(\"http://evil\")", "+1"},
+		{"-2", "@SUM(A1:A2)"},
+		{"\tcell", "\rcell"},
+		{"Phone X", "it's = good"},
+	}
+
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, header, rows); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			written := writtenCells(t, format, buf.Bytes())
+			want := [][]string{
+				header,
+				{"'=HYPERLINK(\"http://evil\")", "'+1"},
+				{"'-2", "'@SUM(A1:A2)"},
+				{"'\tcell", "'\rcell"},
+				{"Phone X", "it's = good"},
+			}
+
+			if !reflect.DeepEqual(written, want) {
+				t.Errorf("written cells = %q, want %q", written, want)
+			}
+
+			// the import reads back what was exported
+			readHeader, readRows, err := Read(bytes.NewReader(buf.Bytes()), format)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			if !reflect.DeepEqual(readHeader, header) || !reflect.DeepEqual(readRows, rows) {
+				t.Errorf("read %q %q, want %q %q", readHeader, readRows, header, rows)
+			}
+		})
+	}
+}
+
+func TestWriteKeepsNumbers(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer, err := NewWriter(&buf, FormatCSV, []string{"discount_price"})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	if err := writer.WriteRow([]interface{}{-1.5}); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got, want := buf.String(), "discount_price\n-1.5\n"; got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
+
+// writtenCells returns the cells of a file as stored, before Read unescapes them
+func writtenCells(t *testing.T, format string, data []byte) [][]string {
+	t.Helper()
+
+	var (
+		cells [][]string
+		err   error
+	)
+
+	if format == FormatCSV {
+		cells, err = csv.NewReader(bytes.NewReader(data)).ReadAll()
+	} else {
+		cells, err = readXLSX(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		t.Fatalf("cannot read the written %s: %v", format, err)
+	}
+
+	return cells
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"net/url"
+
+	"golang.org/x/text/language"
+)
+
+// IsSupportedCountryCode reports whether the code is an ISO 3166-1 alpha-2 country code, e.g. "DE"
+func IsSupportedCountryCode(code string) bool {
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return false
+	}
+
+	region, err := language.ParseRegion(code)
+	return err == nil && region.IsCountry()
+}
+
+// IsSupportedWebsite reports whether the url is an absolute http or https url
+func IsSupportedWebsite(website string) bool {
+	u, err := url.Parse(website)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
@@ -0,0 +1,19 @@
+package util
+
+import (
+	"golang.org/x/text/language"
+)
+
+// maxLocaleLength is the longest locale kept, enough for a language, script and region
+// such as "zh-Hant-TW"
+const maxLocaleLength = 35
+
+// IsSupportedLocale reports whether the locale is a BCP 47 language tag, e.g. "bn" or "pt-BR"
+func IsSupportedLocale(locale string) bool {
+	if len(locale) == 0 || len(locale) > maxLocaleLength {
+		return false
+	}
+
+	tag, err := language.Parse(locale)
+	return err == nil && tag != language.Und
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength is the longest slug kept, longer ones are cut at a word boundary
+const MaxSlugLength = 100
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// IsSupportedSlug reports whether the slug is lowercase letters and digits
+// in words joined by single hyphens
+func IsSupportedSlug(slug string) bool {
+	return len(slug) <= MaxSlugLength && slugPattern.MatchString(slug)
+}
+
+// Slugify turns a name into a slug, e.g. "Gaming Laptops & PCs" into "gaming-laptops-pcs".
+// accents are dropped and whatever is not a letter or a digit separates the words
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// the accent of a decomposed letter
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+
+			b.WriteRune(unicode.ToLower(r))
+			hyphen = false
+		default:
+			hyphen = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		cut := slug[:MaxSlugLength]
+		if slug[MaxSlugLength] != '-' {
+			if i := strings.LastIndexByte(cut, '-'); i > 0 {
+				cut = cut[:i]
+			}
+		}
+
+		slug = cut
+	}
+
+	return strings.Trim(slug, "-")
+}
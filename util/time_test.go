@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 10 * time.Second},
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{9, 42*time.Minute + 40*time.Second},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts, 10*time.Second, time.Hour); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
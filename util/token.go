@@ -0,0 +1,53 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// GenerateSupplierToken signs the supplier id and the time the token expires at, a
+// timestamp in milliseconds, with the given secret
+func GenerateSupplierToken(secret, supplierID string, expiresAt int64) string {
+	claims := supplierID + "." + strconv.FormatInt(expiresAt, 10)
+	return claims + "." + sign(secret, claims)
+}
+
+// ParseSupplierToken verifies the token signature and that it has not expired at now, a
+// timestamp in milliseconds, and returns the supplier id
+func ParseSupplierToken(secret, token string, now int64) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || len(parts[0]) == 0 {
+		return "", ErrInvalidToken
+	}
+
+	claims := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(sign(secret, claims)), []byte(parts[2])) {
+		return "", ErrInvalidToken
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	if now >= expiresAt {
+		return "", ErrTokenExpired
+	}
+
+	return parts[0], nil
+}
+
+func sign(secret, value string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
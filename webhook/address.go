@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrUnresolvableHost = errors.New("webhook url host does not resolve")
+	ErrNonPublicAddress = errors.New("webhook url must resolve to public addresses only")
+)
+
+// reservedPrefixes are the ranges which are not reachable on the internet, or lead back
+// into a private network, beyond the private, loopback and link local ones
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// IsPublic reports whether the address is a unicast address on the internet, so not
+// loopback, private, link local (such as cloud metadata endpoints) or otherwise reserved
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckURL checks that the url is an http or https one whose host resolves to public
+// addresses only, so a subscription can not make the server call into its own network
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Hostname()) == 0 {
+		return ErrInvalidURL
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return ErrUnresolvableHost
+	}
+
+	for _, addr := range addrs {
+		if !IsPublic(addr) {
+			return ErrNonPublicAddress
+		}
+	}
+
+	return nil
+}
+
+// NewClient returns the client to deliver with. it only connects to public addresses,
+// checked again when dialing as the host may resolve differently than when the url was
+// checked, and hands back a redirect as the response instead of following it
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, IsPublic)
+}
+
+func newClient(timeout time.Duration, allow func(addr netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !allow(addrPort.Addr()) {
+				return ErrNonPublicAddress
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// a proxy would be dialed instead of the receiver
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://8.8.8.8/hooks", nil},
+		{"http://[2606:4700:4700::1111]:8080/hooks", nil},
+		{"ftp://8.8.8.8/hooks", ErrInvalidURL},
+		{"/hooks", ErrInvalidURL},
+		{"http://127.0.0.1/hooks", ErrNonPublicAddress},
+		{"http://localhost:5000/api/products", ErrNonPublicAddress},
+		{"http://10.0.0.8/hooks", ErrNonPublicAddress},
+		{"http://192.168.1.1/hooks", ErrNonPublicAddress},
+		{"http://169.254.169.254/latest/meta-data", ErrNonPublicAddress},
+		{"http://100.64.0.1/hooks", ErrNonPublicAddress},
+		{"http://0.0.0.0/hooks", ErrNonPublicAddress},
+		{"http://[::1]/hooks", ErrNonPublicAddress},
+		{"http://[fd00::1]/hooks", ErrNonPublicAddress},
+		{"http://[::ffff:127.0.0.1]/hooks", ErrNonPublicAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := CheckURL(context.Background(), tt.url); !errors.Is(err, tt.want) {
+				t.Errorf("CheckURL = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	_, err := Send(context.Background(), NewClient(time.Second), &Request{URL: srv.URL, Secret: "secret", Body: []byte(`{}`)})
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("Send = %v, want %v", err, ErrNonPublicAddress)
+	}
+
+	if called {
+		t.Error("the client connected to a loopback address")
+	}
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer srv.Close()
+
+	// the test servers listen on loopback
+	client := newClient(time.Second, func(addr netip.Addr) bool { return true })
+
+	res, err := Send(context.Background(), client, &Request{URL: srv.URL, Secret: "secret", Body: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if res.StatusCode != http.StatusFound || res.OK() {
+		t.Errorf("response = %+v, want the redirect as a failed delivery", res)
+	}
+
+	if followed {
+		t.Error("the client followed the redirect")
+	}
+}
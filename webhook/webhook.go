@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers of a delivery
+const (
+	HeaderSignature      = "X-Webhook-Signature"
+	HeaderDeliveryID     = "X-Webhook-Delivery"
+	HeaderEventType      = "X-Event-Type"
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// Timeout bounds a single delivery
+const Timeout = 10 * time.Second
+
+// maxResponseBody is how much of a response body is kept for the delivery log
+const maxResponseBody = 1 << 10
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrSignatureExpired = errors.New("webhook signature expired")
+)
+
+// NewSecret returns a random secret to sign deliveries with
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header of a body sent at timestamp (unix seconds):
+// "t=<timestamp>,v1=<hex hmac-sha256 of "<timestamp>.<body>" keyed by the secret>"
+func Sign(secret string, timestamp int64, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, signature(secret, timestamp, body))
+}
+
+// Verify checks a signature header against the body, rejecting signatures older than tolerance
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var (
+		timestamp int64
+		signed    string
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+
+			timestamp = t
+		case "v1":
+			signed = value
+		}
+	}
+
+	if timestamp == 0 || len(signed) == 0 {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signed), []byte(signature(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 && time.Since(time.Unix(timestamp, 0)) > tolerance {
+		return ErrSignatureExpired
+	}
+
+	return nil
+}
+
+func signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Request is a delivery to make
+type Request struct {
+	URL     string
+	Secret  string
+	Body    []byte
+	Headers map[string]string
+}
+
+// Response is what a receiver answered
+type Response struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+// OK reports whether the receiver accepted the delivery
+func (r *Response) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode <= 299
+}
+
+// Send posts the signed body to the url. an error means no response was
+// received, a response of any status is returned as is
+func Send(ctx context.Context, client *http.Client, req *Request) (*Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, time.Now().Unix(), req.Body))
+
+	start := time.Now()
+
+	httpRes, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(httpRes.Body, maxResponseBody))
+	if err != nil {
+		return nil, err
+	}
+
+	// drain the rest so the connection can be reused
+	io.Copy(io.Discard, httpRes.Body)
+
+	return &Response{
+		StatusCode: httpRes.StatusCode,
+		Body:       string(body),
+		Duration:   time.Since(start),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		want   error
+	}{
+		{"valid", "secret", Sign("secret", now, body), body, nil},
+		{"other secret", "other", Sign("secret", now, body), body, ErrInvalidSignature},
+		{"tampered body", "secret", Sign("secret", now, body), []byte(`{"id":"2"}`), ErrInvalidSignature},
+		{"expired", "secret", Sign("secret", now-600, body), body, ErrSignatureExpired},
+		{"malformed", "secret", "v1=abc", body, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		if err := Verify(tt.secret, tt.header, tt.body, 5*time.Minute); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ := io.ReadAll(r.Body)
+
+		if err := Verify("secret", r.Header.Get(HeaderSignature), received, time.Minute); err != nil {
+			t.Errorf("signature: %v", err)
+		}
+
+		if r.Header.Get(HeaderEventType) != "ProductCreated" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("headers = %v", r.Header)
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "try later")
+	}))
+	defer srv.Close()
+
+	res, err := Send(context.Background(), srv.Client(), &Request{
+		URL:     srv.URL,
+		Secret:  "secret",
+		Body:    body,
+		Headers: map[string]string{HeaderEventType: "ProductCreated"},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if res.OK() || res.StatusCode != http.StatusServiceUnavailable || res.Body != "try later" {
+		t.Errorf("response = %+v", res)
+	}
+}
@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/logger"
+)
+
+// Every runs fn right away and then every interval until ctx is done. an error of fn is
+// logged and fn runs again at the next tick
+func Every(ctx context.Context, interval time.Duration, name string, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil {
+			logger.Error(ctx, "cannot run "+name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
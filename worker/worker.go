@@ -0,0 +1,214 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/logger"
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/util"
+)
+
+// defaults of a pool
+const (
+	DefaultPollInterval = time.Second
+	DefaultLockTimeout  = 30 * time.Minute
+)
+
+// retry backoff, doubling from the base delay up to the max delay
+const (
+	backoffBase = 30 * time.Second
+	backoffMax  = time.Hour
+)
+
+// Handler runs a claimed job, an error fails the attempt
+type Handler func(ctx context.Context, job *service.Job) error
+
+// DeadHandler is told about a job that is dead, having failed its last attempt or been
+// abandoned by the worker of its last attempt, e.g. to fail the record the job works on
+type DeadHandler func(ctx context.Context, job *service.Job)
+
+// Pool runs jobs from the jobs table on a number of concurrent workers
+type Pool struct {
+	repo         service.JobRepo
+	handlers     map[string]Handler
+	deadHandlers map[string]DeadHandler
+	concurrency  int
+
+	// PollInterval is how long an idle worker waits before looking for a due job again
+	PollInterval time.Duration
+	// LockTimeout is how long a job may run before other workers consider its worker dead
+	LockTimeout time.Duration
+}
+
+func NewPool(repo service.JobRepo, concurrency int) *Pool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Pool{
+		repo:         repo,
+		handlers:     make(map[string]Handler),
+		deadHandlers: make(map[string]DeadHandler),
+		concurrency:  concurrency,
+		PollInterval: DefaultPollInterval,
+		LockTimeout:  DefaultLockTimeout,
+	}
+}
+
+// Handle registers the handler of a job type
+func (p *Pool) Handle(jobType string, handler Handler) {
+	p.handlers[jobType] = handler
+}
+
+// OnDead registers the handler of the jobs of a type that are dead
+func (p *Pool) OnDead(jobType string, handler DeadHandler) {
+	p.deadHandlers[jobType] = handler
+}
+
+// Register registers a handler of a job type that receives the payload decoded into T
+func Register[T any](p *Pool, jobType string, handle func(ctx context.Context, payload T) error) {
+	p.Handle(jobType, func(ctx context.Context, job *service.Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return fmt.Errorf("cannot decode %s payload: %w", job.Type, err)
+		}
+
+		return handle(ctx, payload)
+	})
+}
+
+// Run starts the workers and blocks until ctx is done. workers stop claiming
+// jobs once ctx is done, Run returns when the jobs they are running finished
+func (p *Pool) Run(ctx context.Context) {
+	types := make([]string, 0, len(p.handlers))
+	for jobType := range p.handlers {
+		types = append(types, jobType)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx, types)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (p *Pool) work(ctx context.Context, types []string) {
+	for ctx.Err() == nil {
+		now := util.GetCurrentTimestamp()
+
+		buried, err := p.repo.Bury(ctx, types, now)
+		if err != nil {
+			logger.Error(ctx, "cannot bury abandoned jobs", err)
+		} else if len(buried) > 0 {
+			logger.Warn(ctx, "abandoned jobs without attempts left are dead", len(buried))
+		}
+
+		for i := range buried {
+			p.dead(&buried[i])
+		}
+
+		job, err := p.repo.Claim(ctx, types, now, now+p.LockTimeout.Milliseconds())
+		if err != nil {
+			logger.Error(ctx, "cannot claim job", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(p.PollInterval):
+			}
+
+			continue
+		}
+
+		p.run(job)
+	}
+}
+
+// run runs a job to the end, even while the pool shuts down, and records the outcome
+func (p *Pool) run(job *service.Job) {
+	ctx := service.WithActor(logger.CreateContext(job.TraceID), job.Actor)
+
+	logger.Info(ctx, "job started", fmt.Sprintf("id: %s, type: %s, attempt: %d of %d", job.ID, job.Type, job.Attempts, job.MaxAttempts))
+
+	err := p.handle(ctx, job)
+
+	now := util.GetCurrentTimestamp()
+	job.UpdatedAt = now
+	job.LastError = ""
+
+	switch {
+	case err == nil:
+		job.Status = service.JobStatusSucceeded
+		job.FinishedAt = now
+		logger.Info(ctx, "job succeeded", job.ID)
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = service.JobStatusDead
+		job.LastError = err.Error()
+		job.FinishedAt = now
+		logger.Error(ctx, "job is dead", fmt.Sprintf("id: %s, error: %v", job.ID, err))
+	default:
+		job.Status = service.JobStatusQueued
+		job.LastError = err.Error()
+		job.RunAt = now + util.Backoff(job.Attempts, backoffBase, backoffMax).Milliseconds()
+		logger.Warn(ctx, "job failed, retrying", fmt.Sprintf("id: %s, error: %v, run at: %d", job.ID, err, job.RunAt))
+	}
+
+	affected, err := p.repo.Finish(ctx, job)
+	if err != nil {
+		logger.Error(ctx, "cannot save job outcome", err)
+		return
+	}
+
+	if affected == 0 {
+		logger.Warn(ctx, "job was claimed again before it finished", job.ID)
+		return
+	}
+
+	if job.Status == service.JobStatusDead {
+		p.dead(job)
+	}
+}
+
+// dead runs the dead handler of the job's type, if any
+func (p *Pool) dead(job *service.Job) {
+	handler, ok := p.deadHandlers[job.Type]
+	if !ok {
+		return
+	}
+
+	ctx := service.WithActor(logger.CreateContext(job.TraceID), job.Actor)
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error(ctx, "dead job handler panicked", fmt.Sprintf("id: %s, panic: %v", job.ID, r))
+		}
+	}()
+
+	handler(ctx, job)
+}
+
+// handle turns a panic of the handler into a failed attempt
+func (p *Pool) handle(ctx context.Context, job *service.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for job type %s", job.Type)
+	}
+
+	return handler(ctx, job)
+}
@@ -0,0 +1,278 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jsiqbal/ecommerce/service"
+	"github.com/jsiqbal/ecommerce/webhook"
+)
+
+// memoryJobs is a job repo keeping the jobs in memory, claiming the due ones the way
+// the jobs table does
+type memoryJobs struct {
+	mu   sync.Mutex
+	jobs []*service.Job
+}
+
+func (m *memoryJobs) Add(ctx context.Context, job *service.Job) (*service.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job.ID = fmt.Sprint("job-", len(m.jobs)+1)
+	m.jobs = append(m.jobs, job)
+
+	return job, nil
+}
+
+func (m *memoryJobs) GetItemByID(ctx context.Context, jobID string) (*service.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.ID == jobID {
+			copied := *job
+			return &copied, nil
+		}
+	}
+
+	return nil, service.ErrJobNotFound
+}
+
+func (m *memoryJobs) Claim(ctx context.Context, types []string, now, lockedUntil int64) (*service.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		due := job.Status == service.JobStatusQueued && job.RunAt <= now
+		abandoned := job.Status == service.JobStatusRunning && job.LockedUntil < now && job.Attempts < job.MaxAttempts
+		if !due && !abandoned {
+			continue
+		}
+
+		job.Status = service.JobStatusRunning
+		job.Attempts++
+		job.LockedUntil = lockedUntil
+
+		copied := *job
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+func (m *memoryJobs) Bury(ctx context.Context, types []string, now int64) ([]service.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var buried []service.Job
+	for _, job := range m.jobs {
+		if job.Status == service.JobStatusRunning && job.LockedUntil < now && job.Attempts >= job.MaxAttempts {
+			job.Status = service.JobStatusDead
+			job.LastError = service.ErrJobAbandoned.Error()
+			buried = append(buried, *job)
+		}
+	}
+
+	return buried, nil
+}
+
+func (m *memoryJobs) Finish(ctx context.Context, job *service.Job) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.jobs {
+		if existing.ID == job.ID && existing.Status == service.JobStatusRunning && existing.Attempts == job.Attempts {
+			copied := *job
+			m.jobs[i] = &copied
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func (m *memoryJobs) Requeue(ctx context.Context, jobID string, now int64) (int64, error) {
+	return 0, nil
+}
+
+// postHandler posts every job to the url, an answer but 2xx fails the attempt
+func postHandler(url string) Handler {
+	return func(ctx context.Context, job *service.Job) error {
+		res, err := webhook.Send(ctx, http.DefaultClient, &webhook.Request{URL: url, Secret: "secret", Body: job.Payload})
+		if err != nil {
+			return err
+		}
+
+		if !res.OK() {
+			return fmt.Errorf("receiver answered %d", res.StatusCode)
+		}
+
+		return nil
+	}
+}
+
+func TestRunRetriesWithBackoff(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		statuses = []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK}
+		requests int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.WriteHeader(statuses[requests])
+		requests++
+	}))
+	defer srv.Close()
+
+	repo := &memoryJobs{}
+	pool := NewPool(repo, 1)
+	pool.Handle("post", postHandler(srv.URL))
+
+	repo.Add(context.Background(), &service.Job{Type: "post", Payload: []byte(`{}`), Status: service.JobStatusQueued, MaxAttempts: 5})
+
+	// every failed attempt doubles the delay before the next one
+	for attempt, delay := range []time.Duration{30 * time.Second, time.Minute} {
+		before := time.Now().UnixMilli()
+
+		job, _ := repo.Claim(context.Background(), []string{"post"}, before, before+pool.LockTimeout.Milliseconds())
+		if job == nil {
+			t.Fatalf("attempt %d: the job is not due", attempt+1)
+		}
+
+		pool.run(job)
+
+		job, _ = repo.GetItemByID(context.Background(), job.ID)
+		if job.Status != service.JobStatusQueued || job.Attempts != attempt+1 {
+			t.Fatalf("attempt %d: job = %+v", attempt+1, job)
+		}
+
+		if wait := job.RunAt - before; wait < delay.Milliseconds() || wait > delay.Milliseconds()+1000 {
+			t.Errorf("attempt %d: next attempt in %dms, want %dms", attempt+1, wait, delay.Milliseconds())
+		}
+
+		if want := fmt.Sprintf("receiver answered %d", statuses[attempt]); job.LastError != want {
+			t.Errorf("attempt %d: last error = %q, want %q", attempt+1, job.LastError, want)
+		}
+
+		// not due before its backoff passed
+		if claimed, _ := repo.Claim(context.Background(), []string{"post"}, before, before); claimed != nil {
+			t.Fatalf("attempt %d: the job was claimed again before its next attempt", attempt+1)
+		}
+
+		repo.mu.Lock()
+		repo.jobs[0].RunAt = 0
+		repo.mu.Unlock()
+	}
+
+	pool.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := repo.GetItemByID(context.Background(), "job-1"); job.Status == service.JobStatusSucceeded {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	job, _ := repo.GetItemByID(context.Background(), "job-1")
+	if job.Status != service.JobStatusSucceeded || job.Attempts != 3 || job.FinishedAt == 0 || len(job.LastError) > 0 {
+		t.Errorf("job = %+v", job)
+	}
+}
+
+func TestRunDiesAfterMaxAttempts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	repo := &memoryJobs{}
+	pool := NewPool(repo, 1)
+	pool.Handle("post", postHandler(srv.URL))
+
+	repo.Add(context.Background(), &service.Job{Type: "post", Payload: []byte(`{}`), Status: service.JobStatusQueued, MaxAttempts: 1})
+
+	now := time.Now().UnixMilli()
+	job, _ := repo.Claim(context.Background(), []string{"post"}, now, now+pool.LockTimeout.Milliseconds())
+	pool.run(job)
+
+	job, _ = repo.GetItemByID(context.Background(), job.ID)
+	if job.Status != service.JobStatusDead || job.FinishedAt == 0 || job.LastError != "receiver answered 502" {
+		t.Errorf("job = %+v", job)
+	}
+}
+
+func TestDeadJobsAreReported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	var (
+		mu   sync.Mutex
+		dead = make(map[string]string)
+	)
+
+	repo := &memoryJobs{}
+	pool := NewPool(repo, 1)
+	pool.PollInterval = 10 * time.Millisecond
+	pool.Handle("post", postHandler(srv.URL))
+	pool.OnDead("post", func(ctx context.Context, job *service.Job) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		dead[job.ID] = job.LastError
+	})
+
+	// one fails its last attempt, the worker of the other's last attempt went away
+	repo.Add(context.Background(), &service.Job{Type: "post", Payload: []byte(`{}`), Status: service.JobStatusQueued, MaxAttempts: 1})
+	repo.Add(context.Background(), &service.Job{Type: "post", Payload: []byte(`{}`), Status: service.JobStatusRunning, Attempts: 3, MaxAttempts: 3, LockedUntil: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		reported := len(dead)
+		mu.Unlock()
+
+		if reported == 2 {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	want := map[string]string{"job-1": "receiver answered 502", "job-2": service.ErrJobAbandoned.Error()}
+	if fmt.Sprint(dead) != fmt.Sprint(want) {
+		t.Errorf("dead jobs = %v, want %v", dead, want)
+	}
+}